@@ -0,0 +1,87 @@
+package petsounds
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// AliasesHCL is the aliases block, which names the types of pet in the
+// vocabulary of the configuration, such as:
+//   aliases {
+//     kitten = "cat"
+//     puppy  = "dog"
+//   }
+// A pet whose type is an alias is decoded as the type the alias names.
+type AliasesHCL struct {
+	HCL hcl.Body `hcl:",remain"`
+}
+
+// resolveAliases sets the type of each of the pets of petsHCL whose type is
+// one of its aliases to the type the alias names. An alias must name one of
+// petKinds, and must not be one itself.
+func resolveAliases(petsHCL *PetsHCL, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	if petsHCL.AliasesHCL == nil {
+		return nil
+	}
+	attrs, diags := petsHCL.AliasesHCL.HCL.JustAttributes()
+	if diags.HasErrors() {
+		return diags
+	}
+
+	aliases := map[string]string{}
+	for name, attr := range attrs {
+		val, valDiags := attr.Expr.Value(evalContext)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			continue
+		}
+		val, err := convert.Convert(val, cty.String)
+		if err != nil || val.IsNull() || !val.IsKnown() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid alias",
+				Detail:   fmt.Sprintf("The alias `%s` must be the name of a pet type.", name),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+
+		petType := val.AsString()
+		if _, ok := petKinds[name]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid alias",
+				Detail:   fmt.Sprintf("`%s` is already a pet type, so it can't be an alias.", name),
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+		if _, ok := petKinds[petType]; !ok {
+			detail := fmt.Sprintf("The alias `%s` is for `%s`, which is not a pet type.", name, petType)
+			if suggestion := suggest(petType, petKindNames()); suggestion != "" {
+				detail += fmt.Sprintf(" Did you mean `%s`?", suggestion)
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown pet type",
+				Detail:   detail,
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+		aliases[name] = petType
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, p := range petsHCL.PetHCLBodies {
+		if petType, ok := aliases[p.Type]; ok {
+			p.Type = petType
+		}
+	}
+	return diags
+}
@@ -0,0 +1,80 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliases(t *testing.T) {
+	t.Parallel()
+
+	pets := mustParse(t, `
+aliases {
+  kitten = "cat"
+  puppy  = "dog"
+}
+
+pet "Ink" {
+  type = "kitten"
+}
+
+pet "Swinney" {
+  type = "puppy"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+`)
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "meow"},
+		&Dog{Name: "Swinney", Breed: "Dachshund"},
+	}, clearDeclRanges(pets))
+}
+
+func TestAliasErrors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		aliases string
+		want    string
+	}{
+		{
+			name:    "unknown type",
+			aliases: `kitten = "caat"`,
+			want: "test.hcl:3,12-18: Unknown pet type; " +
+				"The alias `kitten` is for `caat`, which is not a pet type. Did you mean `cat`?",
+		},
+		{
+			name:    "shadows a type",
+			aliases: `dog = "cat"`,
+			want:    "test.hcl:3,3-6: Invalid alias; `dog` is already a pet type, so it can't be an alias.",
+		},
+		{
+			name:    "not a string",
+			aliases: `kitten = ["cat"]`,
+			want:    "test.hcl:3,12-19: Invalid alias; The alias `kitten` must be the name of a pet type.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeConfig([]byte(`
+aliases {
+  `+tc.aliases+`
+}
+
+pet "Ink" {
+  type = "kitten"
+}
+`), "test.hcl", LoadOptions{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
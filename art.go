@@ -0,0 +1,87 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// catArt and dogArt are the ASCII art templates pets are drawn with in
+// --art mode, by name. A pet picks one with its art characteristic, and is
+// drawn with the template named after its type if it doesn't. Each template
+// starts with the tail of the speech bubble above it.
+var (
+	catArt = map[string]string{
+		"cat": `
+    \
+     \   /\_/\
+        ( o.o )
+         > ^ <
+`,
+		"kitten": `
+    \
+     \   /\_/\
+        ( ^.^ )
+        (")_(")
+`,
+		"loaf": `
+    \
+     \    /\_/\
+         ( -.- )
+        (_______)
+`,
+	}
+	dogArt = map[string]string{
+		"dog": `
+    \
+     \   / \__
+        (    @\___
+        /         O
+       /   (_____/
+      /_____/   U
+`,
+		"puppy": `
+    \
+     \   __      _
+        o'')}____//
+         \_/      )
+         (_(_/-(_/
+`,
+	}
+)
+
+// validateArt checks that art, the art characteristic of a pet of petType
+// decoded from body, names one of templates.
+func validateArt(body hcl.Body, petType, art string, templates map[string]string) hcl.Diagnostics {
+	if _, ok := templates[art]; ok || art == "" {
+		return nil
+	}
+	names := []string{}
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid art",
+		Detail: fmt.Sprintf(
+			"The %s has the unknown art `%s`, expected one of %s.", petType, art, strings.Join(names, ", "),
+		),
+		Subject: attributeRange(body, "art"),
+	}}
+}
+
+// writeArt writes line in a speech bubble, above the art template named art
+// of petType, or the template named after petType if art is empty.
+func writeArt(w io.Writer, petType, art, line string) {
+	if art == "" {
+		art = petType
+	}
+	width := utf8.RuneCountInString(line)
+	fmt.Fprintf(w, " %s\n< %s >\n %s", strings.Repeat("_", width+2), line, strings.Repeat("-", width+2))
+	fmt.Fprint(w, display(petType).art[art])
+}
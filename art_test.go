@@ -0,0 +1,42 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteArt(t *testing.T) {
+	out := &bytes.Buffer{}
+	writeArt(out, "cat", "", "Ink meow")
+	assert.Equal(t, ` __________
+< Ink meow >
+ ----------
+    \
+     \   /\_/\
+        ( o.o )
+         > ^ <
+`, out.String())
+
+	out.Reset()
+	writeArt(out, "dog", "puppy", "Biscuit yips")
+	assert.Contains(t, out.String(), "< Biscuit yips >\n")
+	assert.Contains(t, out.String(), dogArt["puppy"])
+}
+
+func TestArtConfig(t *testing.T) {
+	config, err := LoadConfig("testdata/art.hcl")
+	if assert.Nil(t, err) {
+		SetStyle(config.Pets, &Style{Art: true})
+		out := &bytes.Buffer{}
+		config.Pets[0].Say(out)
+		assert.Contains(t, out.String(), catArt["loaf"])
+	}
+
+	src := []byte("pet \"Ink\" {\n  type = \"cat\"\n  characteristics {\n    art = \"puppy\"\n  }\n}\n")
+	_, err = DecodeConfig(src, "art_invalid.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "art_invalid.hcl:4,11-18: Invalid art; The cat has the unknown art `puppy`, expected one of cat, kitten, loaf.")
+	}
+}
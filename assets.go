@@ -0,0 +1,61 @@
+package petsounds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// assetAttrs are the characteristics that are paths of files, such as
+//   photo = "images/ink.jpg"
+// which are resolved relative to the file the pet is declared in, and
+// checked to exist when the pet is decoded. The older audio_file is left
+// relative to the working directory, as it always has been.
+var assetAttrs = []string{"photo"}
+
+// resolveAssets resolves the asset characteristics of pet, a pet of kind,
+// relative to the directory of filename. Unless noCheck is set, assets
+// whose files don't exist are returned as diagnostics pointing at their
+// attribute in body, the pet's characteristics block. Pets declared in
+// configurations fetched from URLs have no directory to resolve them in, so
+// their assets are left as they are.
+func resolveAssets(kind *petKind, pet Pet, body hcl.Body, filename string, noCheck bool) hcl.Diagnostics {
+	if isURL(filename) {
+		return nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(pet))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	diags := hcl.Diagnostics{}
+	for _, f := range kind.fields {
+		if !contains(assetAttrs, f.name) {
+			continue
+		}
+		field := v.Field(f.index)
+		path := field.String()
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) && filename != "" {
+			path = filepath.Join(filepath.Dir(filename), path)
+			field.SetString(path)
+		}
+		if noCheck {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing asset",
+				Detail:   fmt.Sprintf("The %s file %s can't be found: %s.", f.name, path, err),
+				Subject:  attributeRange(body, f.name),
+			})
+		}
+	}
+	return diags
+}
@@ -0,0 +1,69 @@
+package petsounds
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssets(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		photo   string
+		noCheck bool
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "relative to the file",
+			photo: "../ink.jpg",
+			want:  "ink.jpg",
+		},
+		{
+			name:  "absolute",
+			photo: mustAbs(t, "ink.jpg"),
+			want:  mustAbs(t, "ink.jpg"),
+		},
+		{
+			name:  "missing",
+			photo: "ink.jpg",
+			wantErr: "error in DecodeConfig validating cat `Ink`: testdata/pets.hcl:4,13-22: Missing asset; " +
+				"The photo file testdata/ink.jpg can't be found: stat testdata/ink.jpg: no such file or directory.",
+		},
+		{
+			name:    "missing without checks",
+			photo:   "ink.jpg",
+			noCheck: true,
+			want:    "testdata/ink.jpg",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := "pet \"Ink\" {\n  type = \"cat\"\n  characteristics {\n    photo = \"" + tc.photo + "\"\n  }\n}\n"
+			config, err := DecodeConfig([]byte(src), "testdata/pets.hcl", LoadOptions{NoAssetCheck: tc.noCheck})
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, filepath.Clean(config.Pets[0].(*Cat).Photo))
+			}
+		})
+	}
+}
+
+// mustAbs returns the absolute path of path.
+func mustAbs(t *testing.T, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
@@ -0,0 +1,207 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+const (
+	// Bundled sounds are synthesized as 16-bit mono PCM WAV files.
+	audioSampleRate = 22050
+)
+
+// tone is a segment of a synthesized sound: a sine wave gliding from one
+// frequency to another over its duration. A tone with no frequency is
+// silence.
+type tone struct {
+	from, to float64
+	seconds  float64
+}
+
+// bundledSounds are the sounds played for each pet type that does not name
+// its own audio_file. They are synthesized rather than recorded, which keeps
+// binary audio files out of the repository.
+var bundledSounds = map[string][]tone{
+	// A meow rises, then falls away.
+	"cat": {
+		{from: 450, to: 800, seconds: 0.15},
+		{from: 800, to: 500, seconds: 0.35},
+	},
+	// A bark is two short, low bursts.
+	"dog": {
+		{from: 320, to: 260, seconds: 0.12},
+		{seconds: 0.08},
+		{from: 320, to: 260, seconds: 0.12},
+	},
+}
+
+// AudioPlayer plays the sound of a pet through the speakers by handing an
+// audio file to a command line audio player. Each pet plays its audio_file
+// characteristic if it has one, and otherwise a sound bundled for its type.
+type AudioPlayer struct {
+	// Command is the audio player and its arguments; the audio file is
+	// appended as the final argument. If empty, a player is chosen for the
+	// current operating system.
+	Command []string
+
+	bundleOnce sync.Once
+	bundleDir  string
+	bundleErr  error
+}
+
+// Play plays the sound of p, returning once playback has finished.
+func (a *AudioPlayer) Play(p Pet) error {
+	file, err := a.audioFile(p)
+	if err != nil {
+		return fmt.Errorf("error in AudioPlayer.Play finding audio: %w", err)
+	}
+	return a.PlayFile(file)
+}
+
+// PlayFile plays the audio file at path, returning once playback has
+// finished.
+func (a *AudioPlayer) PlayFile(path string) error {
+	command := a.Command
+	if len(command) == 0 {
+		var err error
+		command, err = defaultAudioCommand()
+		if err != nil {
+			return fmt.Errorf("error in AudioPlayer.PlayFile: %w", err)
+		}
+	}
+
+	args := append(append([]string{}, command[1:]...), path)
+	if out, err := exec.Command(command[0], args...).CombinedOutput(); err != nil {
+		return fmt.Errorf(
+			"error in AudioPlayer.PlayFile playing `%s`: %w: %s", path, err, bytes.TrimSpace(out),
+		)
+	}
+	return nil
+}
+
+// Close removes any bundled sounds written out by the AudioPlayer.
+func (a *AudioPlayer) Close() error {
+	if a.bundleDir == "" {
+		return nil
+	}
+	return os.RemoveAll(a.bundleDir)
+}
+
+// audioFile returns the path of the audio file to play for p.
+func (a *AudioPlayer) audioFile(p Pet) (string, error) {
+	if file := petAudioFile(p); file != "" {
+		return file, nil
+	}
+
+	// The bundled sounds are written out to a temporary directory the first
+	// time they are needed, so that they can be handed to the player.
+	a.bundleOnce.Do(func() {
+		a.bundleDir, a.bundleErr = writeBundledSounds()
+	})
+	if a.bundleErr != nil {
+		return "", a.bundleErr
+	}
+
+	_, petType := petIdentity(p)
+	if _, ok := bundledSounds[petType]; !ok {
+		return "", fmt.Errorf("no bundled sound for pet type `%s`", petType)
+	}
+	return filepath.Join(a.bundleDir, petType+".wav"), nil
+}
+
+// petAudioFile returns the audio_file characteristic of p, if it has one.
+func petAudioFile(p Pet) string {
+	return fieldsOf(p).audioFile
+}
+
+// defaultAudioCommand returns a command line audio player for the current
+// operating system.
+func defaultAudioCommand() ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"afplay"}, nil
+	case "windows":
+		// PowerShell passes the file name, appended after the script block, to
+		// the script block as its first argument.
+		return []string{
+			"powershell", "-NoProfile", "-Command",
+			"& { (New-Object Media.SoundPlayer $args[0]).PlaySync() }",
+		}, nil
+	}
+
+	candidates := [][]string{
+		{"paplay"},
+		{"aplay", "-q"},
+		{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no audio player found, install paplay, aplay or ffplay")
+}
+
+// writeBundledSounds synthesizes every bundled sound into a new temporary
+// directory, returning the directory.
+func writeBundledSounds() (string, error) {
+	dir, err := ioutil.TempDir("", "pet-sounds")
+	if err != nil {
+		return "", fmt.Errorf("error in writeBundledSounds creating directory: %w", err)
+	}
+	for petType, tones := range bundledSounds {
+		path := filepath.Join(dir, petType+".wav")
+		if err := ioutil.WriteFile(path, synthesizeWAV(tones), 0644); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("error in writeBundledSounds writing `%s`: %w", path, err)
+		}
+	}
+	return dir, nil
+}
+
+// synthesizeWAV renders tones, one after another, as a WAV file.
+func synthesizeWAV(tones []tone) []byte {
+	samples := []int16{}
+	for _, t := range tones {
+		n := int(t.seconds * audioSampleRate)
+		phase := 0.0
+		for i := 0; i < n; i++ {
+			progress := float64(i) / float64(n)
+			freq := t.from + (t.to-t.from)*progress
+			phase += 2 * math.Pi * freq / audioSampleRate
+			// Fade each tone in and out to avoid clicks at the edges.
+			envelope := math.Min(1, math.Min(progress, 1-progress)*10)
+			samples = append(samples, int16(math.Sin(phase)*envelope*math.MaxInt16*0.8))
+		}
+	}
+
+	// A WAV file is a RIFF header, a format chunk, and a data chunk.
+	dataSize := uint32(len(samples) * 2)
+	buf := &bytes.Buffer{}
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVEfmt ")
+	for _, field := range []interface{}{
+		uint32(16),                  // format chunk size
+		uint16(1),                   // PCM
+		uint16(1),                   // mono
+		uint32(audioSampleRate),     // sample rate
+		uint32(audioSampleRate * 2), // byte rate
+		uint16(2),                   // block align
+		uint16(16),                  // bits per sample
+	} {
+		binary.Write(buf, binary.LittleEndian, field)
+	}
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, dataSize)
+	binary.Write(buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
@@ -0,0 +1,75 @@
+package petsounds
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSynthesizeWAV(t *testing.T) {
+	wav := synthesizeWAV([]tone{{from: 440, to: 440, seconds: 0.5}})
+
+	samples := audioSampleRate / 2
+	if assert.Len(t, wav, 44+samples*2) {
+		assert.Equal(t, "RIFF", string(wav[0:4]))
+		assert.Equal(t, "WAVE", string(wav[8:12]))
+		assert.Equal(t, uint32(audioSampleRate), binary.LittleEndian.Uint32(wav[24:28]))
+		assert.Equal(t, "data", string(wav[36:40]))
+		assert.Equal(t, uint32(samples*2), binary.LittleEndian.Uint32(wav[40:44]))
+	}
+}
+
+func TestAudioPlayer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake audio player is a shell script")
+	}
+
+	tcs := []struct {
+		name     string
+		pet      Pet
+		wantFile string
+	}{
+		{
+			name:     "bundled",
+			pet:      &Cat{Name: "Ink"},
+			wantFile: "cat.wav",
+		},
+		{
+			name:     "audio_file",
+			pet:      &Dog{Name: "Swinney", AudioFile: "testdata/woof.wav"},
+			wantFile: "woof.wav",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The fake player records the file it was asked to play.
+			dir, err := ioutil.TempDir("", "pet-sounds-test")
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer os.RemoveAll(dir)
+			record := filepath.Join(dir, "played")
+			player := &AudioPlayer{
+				Command: []string{"sh", "-c", `printf %s "$1" > "$0"`, record},
+			}
+
+			defer player.Close()
+
+			if assert.Nil(t, player.Play(tc.pet)) {
+				played, err := ioutil.ReadFile(record)
+				if assert.Nil(t, err) {
+					assert.Equal(t, tc.wantFile, filepath.Base(string(played)))
+				}
+			}
+		})
+	}
+}
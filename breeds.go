@@ -0,0 +1,80 @@
+package petsounds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// dogBreeds is the registry of known dog breeds that -validate-breeds checks
+// dog breeds against. Matching ignores case.
+var dogBreeds = []string{
+	"Affenpinscher", "Afghan Hound", "Airedale Terrier", "Akita",
+	"Alaskan Malamute", "American Bulldog", "American Eskimo Dog",
+	"American Foxhound", "American Staffordshire Terrier", "Anatolian Shepherd",
+	"Australian Cattle Dog", "Australian Shepherd", "Australian Terrier",
+	"Basenji", "Basset Hound", "Beagle", "Bearded Collie", "Bedlington Terrier",
+	"Belgian Malinois", "Bernese Mountain Dog", "Bichon Frise",
+	"Black and Tan Coonhound", "Bloodhound", "Border Collie", "Border Terrier",
+	"Borzoi", "Boston Terrier", "Boxer", "Boykin Spaniel", "Briard",
+	"Brittany", "Brussels Griffon", "Bull Terrier", "Bulldog", "Bullmastiff",
+	"Cairn Terrier", "Cane Corso", "Cavalier King Charles Spaniel",
+	"Chesapeake Bay Retriever", "Chihuahua", "Chinese Crested", "Chow Chow",
+	"Cocker Spaniel", "Collie", "Corgi", "Dachshund", "Dalmatian",
+	"Doberman Pinscher", "English Setter", "English Springer Spaniel",
+	"French Bulldog", "German Shepherd", "German Shorthaired Pointer",
+	"Giant Schnauzer", "Golden Retriever", "Goldendoodle", "Great Dane",
+	"Great Pyrenees", "Greyhound", "Havanese", "Irish Setter",
+	"Irish Wolfhound", "Italian Greyhound", "Jack Russell Terrier",
+	"Japanese Chin", "Keeshond", "Labradoodle", "Labrador Retriever",
+	"Lhasa Apso", "Maltese", "Mastiff", "Miniature Pinscher",
+	"Miniature Schnauzer", "Mutt", "Newfoundland", "Norwegian Elkhound",
+	"Old English Sheepdog", "Papillon", "Pekingese", "Pembroke Welsh Corgi",
+	"Pit Bull", "Pointer", "Pomeranian", "Poodle", "Portuguese Water Dog",
+	"Pug", "Rhodesian Ridgeback", "Rottweiler", "Saint Bernard", "Saluki",
+	"Samoyed", "Schipperke", "Scottish Terrier", "Shar Pei",
+	"Shetland Sheepdog", "Shiba Inu", "Shih Tzu", "Siberian Husky",
+	"Soft Coated Wheaten Terrier", "Staffordshire Bull Terrier",
+	"Standard Schnauzer", "Vizsla", "Weimaraner", "Welsh Terrier",
+	"West Highland White Terrier", "Whippet", "Wire Fox Terrier",
+	"Yorkshire Terrier",
+}
+
+// ValidateBreeds checks the breed of every dog in config against the breed
+// registry. Unknown breeds are errors, unless the configuration sets
+// allow_unknown_breeds, in which case they are only warnings. Each diagnostic
+// suggests the closest known breed, if there is one.
+func ValidateBreeds(config *Config) hcl.Diagnostics {
+	severity := hcl.DiagError
+	if config.AllowUnknownBreeds {
+		severity = hcl.DiagWarning
+	}
+
+	diags := hcl.Diagnostics{}
+	Walk(config.Pets, VisitorFuncs{Dog: func(dog *Dog) {
+		if knownBreed(dog.Breed) {
+			return
+		}
+
+		detail := fmt.Sprintf("The dog `%s` has the unknown breed `%s`.", dog.Name, redacted(dog.Breed, dog.sensitive["breed"]))
+		if suggestion := suggest(dog.Breed, dogBreeds); suggestion != "" && !dog.sensitive["breed"] {
+			detail += fmt.Sprintf(" Did you mean `%s`?", suggestion)
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: severity,
+			Summary:  "Unknown dog breed",
+			Detail:   detail,
+		})
+	}})
+	return diags
+}
+
+func knownBreed(breed string) bool {
+	for _, b := range dogBreeds {
+		if strings.EqualFold(b, breed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBreeds(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		allow bool
+		pets  []Pet
+		want  hcl.Diagnostics
+	}{
+		{
+			name: "known breeds",
+			pets: []Pet{
+				&Dog{Name: "Swinney", Breed: "Dachshund"},
+				&Dog{Name: "Rex", Breed: "mutt"},
+				&Cat{Name: "Ink", Sound: "meow"},
+			},
+			want: hcl.Diagnostics{},
+		},
+		{
+			name: "unknown breed",
+			pets: []Pet{&Dog{Name: "Swinney", Breed: "Dachsund"}},
+			want: hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown dog breed",
+				Detail:   "The dog `Swinney` has the unknown breed `Dachsund`. Did you mean `Dachshund`?",
+			}},
+		},
+		{
+			name:  "allowed unknown breed",
+			allow: true,
+			pets:  []Pet{&Dog{Name: "Spot", Breed: "Space Dog"}},
+			want: hcl.Diagnostics{{
+				Severity: hcl.DiagWarning,
+				Summary:  "Unknown dog breed",
+				Detail:   "The dog `Spot` has the unknown breed `Space Dog`.",
+			}},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := &Config{Pets: tc.pets, AllowUnknownBreeds: tc.allow}
+			assert.Equal(t, tc.want, ValidateBreeds(config))
+		})
+	}
+}
+
+func TestAllowUnknownBreeds(t *testing.T) {
+	config, err := LoadConfig("testdata/breeds.hcl")
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.True(t, config.AllowUnknownBreeds)
+	}
+}
@@ -0,0 +1,152 @@
+package petsounds
+
+import (
+	"crypto/sha256"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ParseCache caches parsed configuration files by the SHA-256 of their
+// contents, so that a long running process reloading its configuration only
+// parses the files that have changed. Only the latest contents of each file
+// are kept. A ParseCache is safe for concurrent use, and a nil *ParseCache
+// parses every file.
+type ParseCache struct {
+	mu    sync.Mutex
+	files map[string]cachedFile
+
+	hits, misses uint64
+}
+
+// cachedFile is a parsed file and the SHA-256 of its contents.
+type cachedFile struct {
+	sum  [sha256.Size]byte
+	body *hclsyntax.Body
+	// src is the contents, for fingerprinting the pet blocks of the file,
+	// and rest the SHA-256 of the contents outside them.
+	src  []byte
+	rest [sha256.Size]byte
+}
+
+// ParseCacheStats counts the files a ParseCache has been asked for that it
+// had already parsed, and those it had to parse.
+type ParseCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// NewParseCache returns an empty ParseCache.
+func NewParseCache() *ParseCache {
+	return &ParseCache{files: map[string]cachedFile{}}
+}
+
+// Stats returns the number of hits and misses of the cache so far.
+func (c *ParseCache) Stats() ParseCacheStats {
+	return ParseCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// parse parses src, the native syntax HCL contents of filename, or returns
+// the body it was parsed into before. Decoding changes the bodies it is
+// given, by migrating and merging them, so each caller gets its own copy of
+// a cached body. Files with errors are not cached.
+func (c *ParseCache) parse(src []byte, filename string) (*hclsyntax.Body, hcl.Diagnostics) {
+	if c == nil {
+		file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		return file.Body.(*hclsyntax.Body), diags
+	}
+
+	sum := sha256.Sum256(src)
+	c.mu.Lock()
+	cached, ok := c.files[filename]
+	c.mu.Unlock()
+	if ok && cached.sum == sum {
+		atomic.AddUint64(&c.hits, 1)
+		return copyBody(cached.body), nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body := file.Body.(*hclsyntax.Body)
+	c.mu.Lock()
+	c.files[filename] = cachedFile{
+		sum: sum, body: copyBody(body), src: src, rest: sha256.Sum256(outsidePetBlocks(src, filename, body)),
+	}
+	c.mu.Unlock()
+	return body, diags
+}
+
+// source returns the contents of the file filename was last parsed from, or
+// nil if the cache has not parsed it.
+func (c *ParseCache) source(filename string) []byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.files[filename].src
+}
+
+// restSum returns a SHA-256 of the names of the files in the cache and
+// their contents outside their pet blocks, which changes whenever anything
+// but a pet block does.
+func (c *ParseCache) restSum() [sha256.Size]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := []string{}
+	for name := range c.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		rest := c.files[name].rest
+		h.Write([]byte(name))
+		h.Write(rest[:])
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// outsidePetBlocks returns src, the contents of filename parsed into body,
+// without its pet blocks, and those of its household blocks. Override files
+// change the pets of other files, so they are returned whole.
+func outsidePetBlocks(src []byte, filename string, body *hclsyntax.Body) []byte {
+	if isOverrideFile(filename) {
+		return src
+	}
+	pets := []hcl.Range{}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "pet":
+			pets = append(pets, block.Range())
+		case "household":
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "pet" {
+					pets = append(pets, nested.Range())
+				}
+			}
+		}
+	}
+	sort.Slice(pets, func(i, j int) bool { return pets[i].Start.Byte < pets[j].Start.Byte })
+
+	rest, start := []byte{}, 0
+	for _, r := range pets {
+		rest = append(rest, src[start:r.Start.Byte]...)
+		start = r.End.Byte
+	}
+	return append(rest, src[start:]...)
+}
@@ -0,0 +1,71 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCache(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		input string
+		files uint64
+	}{
+		{
+			name:  "includes",
+			input: "testdata/include.hcl",
+			files: 3,
+		},
+		{
+			name:  "migrated schema",
+			input: "testdata/schema_v1.hcl",
+			files: 1,
+		},
+		{
+			name:  "templates",
+			input: "testdata/template.hcl",
+			files: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := ioutil.ReadFile(tc.input)
+			if !assert.Nil(t, err) {
+				return
+			}
+			cache := NewParseCache()
+			opts := LoadOptions{ParseCache: cache}
+
+			// Decoding changes the bodies it is given, which must not
+			// change the cached ones.
+			first, err := DecodeConfig(src, tc.input, opts)
+			if !assert.Nil(t, err, "error while parsing input") {
+				return
+			}
+			assert.Equal(t, ParseCacheStats{Misses: tc.files}, cache.Stats())
+
+			second, err := DecodeConfig(src, tc.input, opts)
+			if assert.Nil(t, err, "error while parsing input") {
+				assert.Equal(t, first.Pets, second.Pets)
+				assert.Equal(t, len(first.Warnings), len(second.Warnings))
+			}
+			assert.Equal(t, ParseCacheStats{Hits: tc.files, Misses: tc.files}, cache.Stats())
+		})
+	}
+
+	// Changed contents are parsed again.
+	cache := NewParseCache()
+	cache.parse([]byte(`pet "Ink" { type = "cat" }`), "pets.hcl")
+	body, diags := cache.parse([]byte(`pet "Tom" { type = "cat" }`), "pets.hcl")
+	if assert.False(t, diags.HasErrors()) {
+		assert.Equal(t, []string{"Tom"}, body.Blocks[0].Labels)
+	}
+	assert.Equal(t, ParseCacheStats{Misses: 2}, cache.Stats())
+}
@@ -0,0 +1,50 @@
+package petsounds
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Strategies for choosing one of several configured values.
+const (
+	strategyRandom = "random"
+	strategyCycle  = "cycle"
+
+	defaultStrategy = strategyRandom
+)
+
+// chooser picks one value at a time from a list, either at random or by
+// cycling through the list in order. The zero value is ready to use, and
+// chooses at random from the random source of the program until rand is
+// set.
+type chooser struct {
+	mu   sync.Mutex
+	next int
+	rand *lockedRand
+}
+
+// choose returns one of values according to strategy, which must already
+// have been checked with validateStrategy. values must not be empty.
+func (c *chooser) choose(values []string, strategy string) string {
+	if strategy != strategyCycle {
+		return values[c.rand.Intn(len(values))]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value := values[c.next%len(values)]
+	c.next++
+	return value
+}
+
+// validateStrategy returns an error if strategy, the value of the attribute
+// named attr, is not a known strategy. An empty strategy is the default.
+func validateStrategy(attr, strategy string) error {
+	switch strategy {
+	case "", strategyRandom, strategyCycle:
+		return nil
+	}
+	return fmt.Errorf(
+		"unknown %s `%s`, expected `%s` or `%s`", attr, strategy, strategyRandom, strategyCycle,
+	)
+}
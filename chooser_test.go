@@ -0,0 +1,84 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooser(t *testing.T) {
+	values := []string{"a", "b", "c"}
+
+	c := &chooser{}
+	got := []string{}
+	for i := 0; i < 5; i++ {
+		got = append(got, c.choose(values, strategyCycle))
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a", "b"}, got)
+
+	for i := 0; i < 10; i++ {
+		assert.Contains(t, values, c.choose(values, strategyRandom))
+	}
+}
+
+func TestValidateStrategy(t *testing.T) {
+	for _, strategy := range []string{"", strategyRandom, strategyCycle} {
+		assert.Nil(t, validateStrategy("action_strategy", strategy))
+	}
+	assert.EqualError(t,
+		validateStrategy("action_strategy", "shuffle"),
+		"unknown action_strategy `shuffle`, expected `random` or `cycle`",
+	)
+}
+
+func TestActions(t *testing.T) {
+	dog := &Dog{
+		Name:           "Swinney",
+		Breed:          "Dachshund",
+		Actions:        []string{"fetches the ball", "digs a hole"},
+		ActionStrategy: strategyCycle,
+	}
+	cat := &Cat{Name: "Ink"}
+
+	out := &bytes.Buffer{}
+	dog.Act(out)
+	dog.Act(out)
+	dog.Act(out)
+	cat.Act(out)
+
+	assert.Equal(t, ""+
+		"Swinney the Dachshund fetches the ball\n"+
+		"Swinney the Dachshund digs a hole\n"+
+		"Swinney the Dachshund fetches the ball\n"+
+		"Ink snoozes\n",
+		out.String(),
+	)
+}
+
+func TestSounds(t *testing.T) {
+	cat := &Cat{
+		Name:          "Ink",
+		Sound:         "meow",
+		Sounds:        []string{"mrrp", "hiss"},
+		SoundStrategy: strategyCycle,
+	}
+	dog := &Dog{Name: "Swinney", Breed: "Dachshund"}
+
+	out := &bytes.Buffer{}
+	cat.Say(out)
+	cat.Say(out)
+	cat.Say(out)
+	dog.Say(out)
+	dog.Sound = "woofs"
+	dog.Say(out)
+
+	assert.Equal(t, ""+
+		"Ink mrrp\n"+
+		"Ink hiss\n"+
+		"Ink mrrp\n"+
+		"Swinney the Dachshund barks\n"+
+		"Swinney the Dachshund woofs\n",
+		out.String(),
+	)
+}
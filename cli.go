@@ -0,0 +1,266 @@
+package petsounds
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// parseFlags parses args into flags. The flag package has already printed
+// the usage for any error it returns.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	return withExitCode(exitUsage, flags.Parse(args))
+}
+
+// command is a command pet-sounds can run. Its setup function registers the
+// command's flags, and returns the function that runs it with the remaining
+// arguments once they have been parsed. args and synopsis are for its help.
+type command struct {
+	name     string
+	args     string
+	synopsis string
+	setup    func(flags *flag.FlagSet) func(args []string) error
+}
+
+// commands returns the commands pet-sounds runs, selected by its first
+// argument. The first has no name, and makes a single pass over the pets
+// when no other command is given.
+func commands() []command {
+	return []command{
+		{name: "", synopsis: "Have each pet say and act once", setup: defaultCommand},
+		{name: "run", synopsis: "Run a simulation of the pets until interrupted", setup: runCommand},
+		{name: "tui", synopsis: "Show a dashboard of the pets in the terminal", setup: tuiCommand},
+		{name: "shell", synopsis: "Have the pets say, act and be fed, a command at a time", setup: shellCommand},
+		{name: "serve", synopsis: "Serve a web dashboard of the pets", setup: serveCommand},
+		{name: "graph", synopsis: "Write the pets and their relationships as Graphviz DOT", setup: graphCommand},
+		{name: "explain", args: "<pet>.<characteristic>", synopsis: "Explain where the value of a characteristic came from", setup: explainCommand},
+		{name: "stats", synopsis: "Summarize the pets of the configuration", setup: statsCommand},
+		{name: "feed", synopsis: "Write the feeding schedule of each pet", setup: feedCommand},
+		{name: "reminders", synopsis: "Write the vet visits and birthdays coming up", setup: remindersCommand},
+		{name: "plan", synopsis: "Show the pets that apply would add, change or remove", setup: planCommand},
+		{name: "apply", synopsis: "Run the pets that have changed, and record them in the state file", setup: applyCommand},
+		{name: "convert", args: "[file]", synopsis: "Convert a configuration file between HCL and JSON", setup: convertCommand},
+		{name: "import", args: "<file.csv>", synopsis: "Convert a CSV list of pets into a configuration file", setup: importCommand},
+		{name: "generate", synopsis: "Write a configuration of random pets", setup: generateCommand},
+		{name: "init", synopsis: "Write a commented starter configuration", setup: initCommand},
+		{name: "completion", args: "<bash|zsh|fish>", synopsis: "Write a shell completion script", setup: completionCommand},
+		{name: "functions", synopsis: "List the functions and variables configurations can use", setup: functionsCommand},
+		{name: "eval", args: "<expression>", synopsis: "Write the value of an expression", setup: evalCommand},
+		{name: "schema", synopsis: "Write the JSON Schema of configuration files", setup: schemaCommand},
+		{name: "lint", synopsis: "Check a configuration file for problems", setup: lintCommand},
+		{name: "lsp", synopsis: "Run a language server for configuration files", setup: lspCommand},
+		{name: "version", synopsis: "Print the version of pet-sounds", setup: versionCommand},
+		{name: "help", args: "[command]", synopsis: "Show the help of a command", setup: helpCommand},
+	}
+}
+
+// findCommand returns the command named name, which must be one of
+// commands.
+func findCommand(name string) (command, error) {
+	names := []string{}
+	for _, c := range commands()[1:] {
+		if c.name == name {
+			return c, nil
+		}
+		names = append(names, c.name)
+	}
+	if suggestion := suggest(name, names); suggestion != "" {
+		return command{}, withExitCode(exitUsage, fmt.Errorf("unknown command `%s`, did you mean `%s`?", name, suggestion))
+	}
+	return command{}, withExitCode(exitUsage, fmt.Errorf("unknown command `%s`, see `pet-sounds help`", name))
+}
+
+func inner(args []string) error {
+	cmd := commands()[0]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		c, err := findCommand(args[0])
+		if err != nil {
+			return err
+		}
+		cmd, args = c, args[1:]
+	}
+	flags, instrument, run := commandFlags(cmd)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	stop, err := instrument.start()
+	if err != nil {
+		return err
+	}
+	err = run(flags.Args())
+	if stopErr := stop(); err == nil {
+		err = stopErr
+	}
+	// The pets skipped with -keep-going are reported once the others have
+	// run.
+	if err == nil {
+		err = flagKeepGoing(flags).err()
+	}
+	return err
+}
+
+// commandFlags returns the flags of cmd, with the global flags and the
+// instrumentation flags every command takes registered before its own, and
+// the function that runs it.
+func commandFlags(cmd command) (*flag.FlagSet, *instrumentation, func(args []string) error) {
+	flags := flag.NewFlagSet(strings.TrimSpace("pet-sounds "+cmd.name), flag.ContinueOnError)
+	globalFlags(flags)
+	instrument := instrumentFlags(flags)
+	global := map[string]bool{}
+	flags.VisitAll(func(f *flag.Flag) {
+		global[f.Name] = true
+	})
+	run := cmd.setup(flags)
+	flags.Usage = func() {
+		writeUsage(flags.Output(), cmd, flags, global)
+	}
+	return flags, instrument, run
+}
+
+// globalFlags registers the flags every command takes. Commands read them
+// with flagString and logWriter once the flags have been parsed:
+//   -f, -file    the file or URL of the configuration
+//   -o           the output format of the commands that run pets, stats
+//                and lint, or the file import and generate write
+//   -log-level   the messages written to stderr
+func globalFlags(flags *flag.FlagSet) {
+	var file, output string
+	level := logLevel(logInfo)
+	flags.StringVar(&file, "file", defaultFileName, "the file, directory or URL to read pet configuration from")
+	flags.StringVar(&file, "f", defaultFileName, "the file or URL to read pet configuration from (shorthand)")
+	flags.StringVar(&output, "o", "", "the output format, text or ndjson for the commands that run pets, table or json for stats and text or sarif for lint; or the file import and generate write, defaulting to stdout")
+	flags.Var(&level, "log-level", "the `level` of the messages to write to stderr: error, warn, info, or debug to also log each time a pet says or acts")
+}
+
+// flagString returns the value of the flag name of flags, or an empty
+// string if it has none.
+func flagString(flags *flag.FlagSet, name string) string {
+	f := flags.Lookup(name)
+	if f == nil {
+		return ""
+	}
+	return f.Value.String()
+}
+
+// The log levels of the -log-level flag, from the fewest messages to the
+// most.
+const (
+	logError = "error"
+	logWarn  = "warn"
+	logInfo  = "info"
+	logDebug = "debug"
+)
+
+// logLevels are the log levels, in order.
+var logLevels = []string{logError, logWarn, logInfo, logDebug}
+
+// logLevel is the -log-level flag.
+type logLevel string
+
+func (l *logLevel) String() string {
+	return string(*l)
+}
+
+func (l *logLevel) Set(value string) error {
+	if !contains(logLevels, value) {
+		return fmt.Errorf("unknown log level `%s`, expected %s", value, strings.Join(logLevels, ", "))
+	}
+	*l = logLevel(value)
+	return nil
+}
+
+// logEnabled reports whether messages of level are written at the
+// -log-level of flags.
+func logEnabled(flags *flag.FlagSet, level string) bool {
+	l := logLevel(logInfo)
+	if f := flags.Lookup("log-level"); f != nil {
+		if v, ok := f.Value.(*logLevel); ok {
+			l = *v
+		}
+	}
+	return indexOf(logLevels, level) <= indexOf(logLevels, string(l))
+}
+
+// logWriter returns stderr if messages of level are written at the
+// -log-level of flags, and otherwise a writer that discards them.
+func logWriter(flags *flag.FlagSet, level string) io.Writer {
+	if !logEnabled(flags, level) {
+		return ioutil.Discard
+	}
+	return os.Stderr
+}
+
+// helpCommand writes the help of the command given as an argument, or of
+// pet-sounds and its commands, to stdout.
+func helpCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 1 {
+			return withExitCode(exitUsage, fmt.Errorf("help takes a single command, such as `help run`"))
+		}
+		cmd := commands()[0]
+		if len(args) == 1 {
+			var err error
+			if cmd, err = findCommand(args[0]); err != nil {
+				return err
+			}
+		}
+		flags, _, _ := commandFlags(cmd)
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		return nil
+	}
+}
+
+// writeUsage writes the help of cmd to w: how to run it, its flags and the
+// global flags, and for the default command the other commands.
+func writeUsage(w io.Writer, cmd command, flags *flag.FlagSet, global map[string]bool) {
+	if cmd.name == "" {
+		fmt.Fprintf(w, "Usage: pet-sounds [command] [flags]\n\n%s, or run one of the commands.\n\nCommands:\n", cmd.synopsis)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, c := range commands()[1:] {
+			fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.synopsis)
+		}
+		tw.Flush()
+		fmt.Fprint(w, "\nRun `pet-sounds help <command>` for the flags of a command.\n")
+	} else {
+		fmt.Fprintf(w, "Usage: pet-sounds %s [flags]", cmd.name)
+		if cmd.args != "" {
+			fmt.Fprintf(w, " %s", cmd.args)
+		}
+		fmt.Fprintf(w, "\n\n%s.\n", cmd.synopsis)
+	}
+
+	own, globals := flag.NewFlagSet("", flag.ContinueOnError), flag.NewFlagSet("", flag.ContinueOnError)
+	flags.VisitAll(func(f *flag.Flag) {
+		to := own
+		if global[f.Name] {
+			to = globals
+		}
+		to.Var(f.Value, f.Name, f.Usage)
+		to.Lookup(f.Name).DefValue = f.DefValue
+	})
+	for _, section := range []struct {
+		title string
+		flags *flag.FlagSet
+	}{{"Flags", own}, {"Global flags", globals}} {
+		if !hasFlags(section.flags) {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		section.flags.SetOutput(w)
+		section.flags.PrintDefaults()
+	}
+}
+
+// hasFlags reports whether any flags are registered on flags.
+func hasFlags(flags *flag.FlagSet) bool {
+	has := false
+	flags.VisitAll(func(*flag.Flag) {
+		has = true
+	})
+	return has
+}
@@ -0,0 +1,87 @@
+package petsounds
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := findCommand("lint")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "lint", cmd.name)
+	}
+
+	_, err = findCommand("lnt")
+	if assert.Error(t, err) {
+		assert.Equal(t, "unknown command `lnt`, did you mean `lint`?", err.Error())
+		assert.Equal(t, exitUsage, exitCode(err))
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := findCommand("explain")
+	if !assert.NoError(t, err) {
+		return
+	}
+	flags, _, _ := commandFlags(cmd)
+	out := &bytes.Buffer{}
+	flags.SetOutput(out)
+	flags.Usage()
+
+	usage := out.String()
+	assert.Contains(t, usage, "Usage: pet-sounds explain [flags] <pet>.<characteristic>\n")
+	flagsAt, globalAt := bytes.Index(out.Bytes(), []byte("\nFlags:\n")), bytes.Index(out.Bytes(), []byte("\nGlobal flags:\n"))
+	if assert.True(t, flagsAt >= 0 && globalAt > flagsAt, usage) {
+		// The command's own flags come first, and the global flags after.
+		assert.Contains(t, usage[flagsAt:globalAt], "-merge-strategy")
+		assert.NotContains(t, usage[flagsAt:globalAt], "-log-level")
+		assert.Contains(t, usage[globalAt:], "-log-level")
+		assert.Contains(t, usage[globalAt:], "-file")
+	}
+
+	// The default command lists the others.
+	flags, _, _ = commandFlags(commands()[0])
+	out.Reset()
+	flags.SetOutput(out)
+	flags.Usage()
+	assert.Contains(t, out.String(), "\n  explain     Explain where the value of a characteristic came from\n")
+}
+
+func TestLogEnabled(t *testing.T) {
+	tcs := []struct {
+		level string
+		want  []string
+	}{
+		{level: "", want: []string{logError, logWarn, logInfo}},
+		{level: logError, want: []string{logError}},
+		{level: logWarn, want: []string{logError, logWarn}},
+		{level: logDebug, want: []string{logError, logWarn, logInfo, logDebug}},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.level, func(t *testing.T) {
+			t.Parallel()
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalFlags(flags)
+			if tc.level != "" {
+				assert.NoError(t, flags.Set("log-level", tc.level))
+			}
+			enabled := []string{}
+			for _, level := range logLevels {
+				if logEnabled(flags, level) {
+					enabled = append(enabled, level)
+				}
+			}
+			assert.Equal(t, tc.want, enabled)
+		})
+	}
+}
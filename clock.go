@@ -0,0 +1,84 @@
+package petsounds
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Clock tells the time. Moods, schedules and recorded events read the time
+// from a Clock, so that tests and replays can set it rather than sleep.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock of the machine pet-sounds runs on.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockOrSystem returns c, or the system clock if c is nil.
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return systemClock{}
+	}
+	return c
+}
+
+// newTimestampFunc returns a function that returns the time of c, or of the
+// system clock, as an RFC 3339 timestamp, for timeadd and formatdate:
+//   formatdate("DD MMM YYYY", timestamp())
+func newTimestampFunc(c Clock) function.Function {
+	return function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(clockOrSystem(c).Now().UTC().Format(time.RFC3339)), nil
+		},
+	})
+}
+
+// lockedRand reads from a rand.Source for many goroutines at once, as pets
+// choose their sounds and actions concurrently and a rand.Source is not
+// safe for concurrent use. A nil *lockedRand reads from timeRand.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// timeRand is the random source of pet-sounds when none is given, seeded
+// with the time the program started.
+var timeRand = &lockedRand{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// newLockedRand returns a lockedRand reading from src, or nil if src is
+// nil.
+func newLockedRand(src rand.Source) *lockedRand {
+	if src == nil {
+		return nil
+	}
+	return &lockedRand{r: rand.New(src)}
+}
+
+// Intn is rand.Intn from l.
+func (l *lockedRand) Intn(n int) int {
+	if l == nil {
+		return timeRand.Intn(n)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+// Int63n is rand.Int63n from l.
+func (l *lockedRand) Int63n(n int64) int64 {
+	if l == nil {
+		return timeRand.Int63n(n)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
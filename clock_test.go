@@ -0,0 +1,72 @@
+package petsounds
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock is a Clock that is always at the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestSeededConfigIsReproducible(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound  = random("meow", "mrrp", "purr", "hiss", "chirp")
+    sounds = ["a", "b", "c", "d", "e", "f"]
+  }
+}
+`)
+	run := func() string {
+		config, err := DecodeConfig(src, "test.hcl", LoadOptions{Rand: rand.NewSource(42)})
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		out := &bytes.Buffer{}
+		for i := 0; i < 10; i++ {
+			config.Pets[0].Say(out)
+		}
+		return config.Pets[0].(*Cat).Sound + "\n" + out.String()
+	}
+	assert.Equal(t, run(), run())
+}
+
+func TestMoodMachineClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 8, 1, 8, 0, 0, 0, time.UTC)
+	src := []byte(`
+pet "Ink" {
+  type = "cat"
+  moods {
+    initial = "playful"
+    transition {
+      from  = "playful"
+      to    = "sleepy"
+      after = "1h"
+    }
+  }
+}
+`)
+	clock := fixedClock(start)
+	config, err := DecodeConfig(src, "test.hcl", LoadOptions{Clock: &clock})
+	if !assert.NoError(t, err) {
+		return
+	}
+	moods := config.Pets[0].(*Cat).Moods
+	assert.Equal(t, MoodPlayful, moods.Mood())
+
+	clock = fixedClock(start.Add(time.Hour))
+	assert.Equal(t, MoodSleepy, moods.Mood())
+}
@@ -0,0 +1,13 @@
+// Command pet-sounds decodes a configuration of pets and has them say and
+// act. See the petsounds package for the library it is built on.
+package main
+
+import (
+	"os"
+
+	petsounds "github.com/russellrollins/pet-sounds"
+)
+
+func main() {
+	os.Exit(petsounds.Main(os.Args[1:]))
+}
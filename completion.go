@@ -0,0 +1,194 @@
+package petsounds
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completionCommand writes a completion script for the shell given as an
+// argument, bash, zsh or fish, to stdout. The scripts complete the commands
+// and their flags, and the values of flags like -type and -sort. The names
+// of pets given to -name and -target are read from the default
+// configuration file when completing, with -pets.
+func completionCommand(flags *flag.FlagSet) func(args []string) error {
+	pets := flags.Bool("pets", false, "print the names of the pets in the configuration file, for completion scripts")
+
+	return func(args []string) error {
+		if *pets {
+			return printPetNames(os.Stdout, defaultFileName)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("completion needs a shell, bash, zsh or fish")
+		}
+		switch args[0] {
+		case "bash":
+			return writeBashCompletion(os.Stdout, completions())
+		case "zsh":
+			// zsh can use the bash script through its bash compatibility.
+			fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+			return writeBashCompletion(os.Stdout, completions())
+		case "fish":
+			return writeFishCompletion(os.Stdout, completions())
+		}
+		return fmt.Errorf("unknown shell `%s`, expected bash, zsh or fish", args[0])
+	}
+}
+
+// printPetNames writes the names of the pets in filename to w, one per line.
+// Completion is best effort, so a file that can't be loaded has no pets.
+func printPetNames(w io.Writer, filename string) error {
+	headers, err := LoadPetHeaders(context.Background(), filename)
+	if err != nil {
+		return nil
+	}
+	for _, h := range headers {
+		fmt.Fprintln(w, h.Name)
+	}
+	return nil
+}
+
+// completion is what a completion script completes for one command.
+type completion struct {
+	name  string
+	flags []*flag.Flag
+}
+
+// completions returns the completion of every command, built from the flags
+// they register, so that completion scripts stay in step with them.
+func completions() []completion {
+	all := []completion{}
+	for _, c := range commands() {
+		flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		globalFlags(flags)
+		c.setup(flags)
+		comp := completion{name: c.name}
+		flags.VisitAll(func(f *flag.Flag) {
+			comp.flags = append(comp.flags, f)
+		})
+		all = append(all, comp)
+	}
+	return all
+}
+
+// fileFlags are the flags whose values are files or directories.
+var fileFlags = map[string]bool{"file": true, "f": true, "env-file": true, "o": true, "out": true, "cache-dir": true, "state": true, "record": true, "cpuprofile": true, "memprofile": true}
+
+// flagValues returns the values of flags that take one of a few values.
+func flagValues() map[string][]string {
+	types := petKindNames()
+	return map[string][]string{
+		"type":       types,
+		"types":      types,
+		"sort":       {sortName, sortType, sortFile},
+		"catch-up":   {catchUpOnce, catchUpAll, catchUpSkip},
+		"format":     {FormatHCL, FormatJSON, FormatYAML, FormatTOML},
+		"to":         {"json", "hcl"},
+		"log-level":  logLevels,
+		"out-format": {eventLogText, eventLogJSON},
+	}
+}
+
+// isBoolFlag reports whether f is a flag that takes no value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// writeBashCompletion writes a bash completion script for comps to w.
+func writeBashCompletion(w io.Writer, comps []completion) error {
+	b := &strings.Builder{}
+	b.WriteString("# bash completion for pet-sounds, generated by `pet-sounds completion bash`.\n")
+	b.WriteString("_pet_sounds() {\n")
+	b.WriteString("  local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" cmd=\"\"\n")
+
+	commands := []string{}
+	for _, c := range comps[1:] {
+		commands = append(commands, c.name)
+	}
+	fmt.Fprintf(b, "  case \"${COMP_WORDS[1]}\" in\n    %s) cmd=\"${COMP_WORDS[1]}\" ;;\n  esac\n", strings.Join(commands, "|"))
+
+	// Values are completed for the flag before the word being completed.
+	b.WriteString("  case \"${prev#-}\" in\n")
+	b.WriteString("    -name|name|-target|target)\n      COMPREPLY=($(compgen -W \"$(pet-sounds completion -pets 2>/dev/null)\" -- \"${cur}\")); return ;;\n")
+	values := flagValues()
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "    -%s|%s)\n      COMPREPLY=($(compgen -W \"%s\" -- \"${cur}\")); return ;;\n",
+			name, name, strings.Join(values[name], " "))
+	}
+	files := []string{}
+	for name := range fileFlags {
+		files = append(files, "-"+name, name)
+	}
+	sort.Strings(files)
+	fmt.Fprintf(b, "    %s)\n      COMPREPLY=($(compgen -f -- \"${cur}\")); return ;;\n", strings.Join(files, "|"))
+	b.WriteString("  esac\n")
+
+	b.WriteString("  case \"${cmd}\" in\n")
+	for _, c := range comps {
+		words := []string{}
+		for _, f := range c.flags {
+			words = append(words, "-"+f.Name)
+		}
+		pattern := c.name
+		if c.name == "" {
+			pattern = "\"\""
+			words = append(words, commands...)
+		}
+		fmt.Fprintf(b, "    %s)\n      COMPREPLY=($(compgen -W \"%s\" -- \"${cur}\")) ;;\n", pattern, strings.Join(words, " "))
+	}
+	b.WriteString("  esac\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _pet_sounds pet-sounds\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFishCompletion writes a fish completion script for comps to w.
+func writeFishCompletion(w io.Writer, comps []completion) error {
+	b := &strings.Builder{}
+	b.WriteString("# fish completion for pet-sounds, generated by `pet-sounds completion fish`.\n")
+	b.WriteString("complete -c pet-sounds -f\n")
+
+	commands := []string{}
+	for _, c := range comps[1:] {
+		commands = append(commands, c.name)
+	}
+	fmt.Fprintf(b, "complete -c pet-sounds -n '__fish_use_subcommand' -a '%s'\n", strings.Join(commands, " "))
+
+	values := flagValues()
+	for _, c := range comps {
+		condition := "__fish_seen_subcommand_from " + c.name
+		if c.name == "" {
+			condition = "not __fish_seen_subcommand_from " + strings.Join(commands, " ")
+		}
+		for _, f := range c.flags {
+			fmt.Fprintf(b, "complete -c pet-sounds -n '%s' -o %s", condition, f.Name)
+			switch {
+			case isBoolFlag(f):
+			case f.Name == "name" || f.Name == "target":
+				b.WriteString(" -x -a '(pet-sounds completion -pets 2>/dev/null)'")
+			case values[f.Name] != nil:
+				fmt.Fprintf(b, " -x -a '%s'", strings.Join(values[f.Name], " "))
+			case fileFlags[f.Name]:
+				b.WriteString(" -r -F")
+			default:
+				b.WriteString(" -x")
+			}
+			fmt.Fprintf(b, " -d '%s'\n", strings.ReplaceAll(f.Usage, "'", `\'`))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,82 @@
+package petsounds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "pet-sounds-completion")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	script := &bytes.Buffer{}
+	if !assert.Nil(t, writeBashCompletion(script, completions())) {
+		return
+	}
+	if !assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "pet-sounds.bash"), script.Bytes(), 0644)) {
+		return
+	}
+
+	tcs := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{
+			name:  "commands",
+			words: []string{"pet-sounds", "gr"},
+			want:  []string{"graph"},
+		},
+		{
+			name:  "command flags",
+			words: []string{"pet-sounds", "run", "-ti"},
+			want:  []string{"-ticks"},
+		},
+		{
+			name:  "flag values",
+			words: []string{"pet-sounds", "--type", ""},
+			want:  []string{"cat", "dog"},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command("bash", "-c", `source pet-sounds.bash
+COMP_WORDS=("$@"); COMP_CWORD=$(($# - 1))
+_pet_sounds
+printf '%s\n' "${COMPREPLY[@]}"`, "bash")
+			cmd.Args = append(cmd.Args, tc.words...)
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+			if assert.Nil(t, err, string(out)) {
+				assert.Equal(t, tc.want, strings.Fields(string(out)))
+			}
+		})
+	}
+}
+
+func TestPrintPetNames(t *testing.T) {
+	out := &bytes.Buffer{}
+	if assert.Nil(t, printPetNames(out, "testdata/basic.hcl")) {
+		assert.Equal(t, "Ink\nSwinney\n", out.String())
+	}
+
+	out.Reset()
+	if assert.Nil(t, printPetNames(out, "testdata/missing.hcl")) {
+		assert.Empty(t, out.String())
+	}
+}
@@ -0,0 +1,125 @@
+package petsounds
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// The phases in which a pet's conditions are checked.
+const (
+	phasePrecondition  = "precondition"
+	phasePostcondition = "postcondition"
+)
+
+// ConditionHCL is a precondition or postcondition of a pet, checked by the
+// Runner before or after the pet Says or Acts. Each condition is represented
+// in hcl, inside a pet block, as:
+//   precondition {
+//     condition     = <boolean expression>
+//     error_message = "<what is wrong>"
+//     on            = "<say | act>"
+//     severity      = "<error | warning>"
+//   }
+// with postcondition blocks taking the same form. A condition without an "on"
+// event applies to both. When an error condition is false the pet is stopped
+// and the run fails, while a false warning condition is only reported. Like
+// validations, conditions can refer to the pet as `self`.
+type ConditionHCL struct {
+	Condition    hcl.Expression `hcl:"condition"`
+	ErrorMessage hcl.Expression `hcl:"error_message"`
+	On           string         `hcl:"on,optional"`
+	Severity     string         `hcl:"severity,optional"`
+}
+
+// Conditions are the checked preconditions and postconditions of a single
+// pet. All methods are safe to call on a nil *Conditions, which represents a
+// pet without conditions.
+type Conditions struct {
+	pre         []*ConditionHCL
+	post        []*ConditionHCL
+	evalContext *hcl.EvalContext
+}
+
+// NewConditions checks the precondition and postcondition blocks of a pet,
+// returning nil if there are none. The conditions will be evaluated in a
+// child of evalContext.
+func NewConditions(pre, post []*ConditionHCL, evalContext *hcl.EvalContext) (*Conditions, error) {
+	if len(pre) == 0 && len(post) == 0 {
+		return nil, nil
+	}
+
+	for _, c := range append(append([]*ConditionHCL{}, pre...), post...) {
+		switch c.On {
+		case "", eventSay, eventAct:
+		default:
+			return nil, fmt.Errorf("error in NewConditions: unknown condition event `%s`", c.On)
+		}
+		if _, err := conditionSeverity(c.Severity); err != nil {
+			return nil, fmt.Errorf("error in NewConditions: %w", err)
+		}
+	}
+	return &Conditions{pre: pre, post: post, evalContext: evalContext}, nil
+}
+
+// Check evaluates the conditions of p for phase that apply to event,
+// returning a diagnostic for each one that is false.
+func (c *Conditions) Check(p Pet, phase, event string) hcl.Diagnostics {
+	if c == nil {
+		return nil
+	}
+	conditions := c.pre
+	summary := "Precondition failed"
+	if phase == phasePostcondition {
+		conditions = c.post
+		summary = "Postcondition failed"
+	}
+
+	var ctx *hcl.EvalContext
+	diags := hcl.Diagnostics{}
+	for _, cond := range conditions {
+		if cond.On != "" && cond.On != event {
+			continue
+		}
+		// self is only built once a condition applies, so pets whose
+		// conditions are all for other events pay nothing.
+		if ctx == nil {
+			var selfDiags hcl.Diagnostics
+			ctx, selfDiags = selfContext(p, c.evalContext)
+			if selfDiags.HasErrors() {
+				return selfDiags
+			}
+		}
+		// The severity was checked by NewConditions.
+		severity, _ := conditionSeverity(cond.Severity)
+		diags = append(diags, evalCondition(
+			phase, cond.Condition, cond.ErrorMessage, severity, summary, ctx,
+		)...)
+	}
+	return diags
+}
+
+// conditionSeverity returns the diagnostic severity named by the severity
+// attribute of a condition, which defaults to error.
+func conditionSeverity(severity string) (hcl.DiagnosticSeverity, error) {
+	switch severity {
+	case "", "error":
+		return hcl.DiagError, nil
+	case "warning":
+		return hcl.DiagWarning, nil
+	}
+	return hcl.DiagInvalid, fmt.Errorf("unknown condition severity `%s`, expected `error` or `warning`", severity)
+}
+
+// petConditions returns the conditions of p, if it has any.
+func petConditions(p Pet) *Conditions {
+	switch pet := unwrapPet(p).(type) {
+	case *Cat:
+		return pet.Conditions
+	case *Dog:
+		return pet.Conditions
+	case *CustomPet:
+		return pet.Conditions
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package petsounds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditions(t *testing.T) {
+	config, err := LoadConfig("testdata/conditions.hcl")
+	if !assert.Nil(t, err, "error while parsing input") {
+		return
+	}
+
+	out, warnings := &bytes.Buffer{}, &bytes.Buffer{}
+	runner := &Runner{Out: out, Warnings: warnings}
+	err = runner.Run(config.Pets)
+
+	// Swinney's precondition stops it from acting, but Ink still runs.
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"conditions.hcl:9,21-44: Precondition failed; Swinney must be vaccinated before going to the park",
+		)
+	}
+	assert.Equal(t, "Swinney the Dachshund barks\nInk meow\nInk snoozes\n", out.String())
+
+	// Ink's postcondition is checked after both Say and Act.
+	lines := strings.Split(strings.TrimSpace(warnings.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], "Postcondition failed; Ink should have an age")
+	}
+}
+
+func TestNewConditions(t *testing.T) {
+	conditions, err := NewConditions(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, conditions)
+
+	_, err = NewConditions([]*ConditionHCL{{On: "eat"}}, nil, nil)
+	assert.EqualError(t, err, "error in NewConditions: unknown condition event `eat`")
+
+	_, err = NewConditions(nil, []*ConditionHCL{{Severity: "fatal"}}, nil)
+	assert.EqualError(t, err,
+		"error in NewConditions: unknown condition severity `fatal`, expected `error` or `warning`",
+	)
+}
@@ -0,0 +1,545 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ConvertToJSON converts the native syntax HCL document src, read from
+// filename, into the equivalent HCL JSON document and writes it to w.
+//
+// Expressions that are plain values become JSON values. Expressions that
+// need evaluating, like variables and function calls, become HCL JSON
+// template strings holding the original expression, so they are still
+// evaluated when the JSON document is decoded. Comments are not kept.
+func ConvertToJSON(w io.Writer, src []byte, filename string) error {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("error in ConvertToJSON parsing HCL: %w", diags)
+	}
+
+	out, err := marshalJSON(bodyJSON(file.Body.(*hclsyntax.Body), src))
+	if err != nil {
+		return fmt.Errorf("error in ConvertToJSON encoding JSON: %w", err)
+	}
+	indented := &bytes.Buffer{}
+	if err := json.Indent(indented, out, "", "  "); err != nil {
+		return fmt.Errorf("error in ConvertToJSON indenting JSON: %w", err)
+	}
+	indented.WriteByte('\n')
+	if _, err := indented.WriteTo(w); err != nil {
+		return fmt.Errorf("error in ConvertToJSON writing JSON: %w", err)
+	}
+	return nil
+}
+
+// jsonObject is a JSON object that keeps its members in order, so converted
+// documents read in the same order as the original.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSON(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSON(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal without HTML escaping, which would make
+// expressions like `a > b` hard to read.
+func marshalJSON(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// bodyJSON converts a body into a JSON object. Attributes and block types
+// appear in the order they first appear in the source.
+func bodyJSON(body *hclsyntax.Body, src []byte) jsonObject {
+	type item struct {
+		pos    int
+		member jsonMember
+	}
+	items := []item{}
+
+	for _, attr := range body.Attributes {
+		items = append(items, item{
+			pos:    attr.SrcRange.Start.Byte,
+			member: jsonMember{key: attr.Name, value: exprJSON(attr.Expr, src)},
+		})
+	}
+
+	blockTypes := map[string][]*hclsyntax.Block{}
+	for _, block := range body.Blocks {
+		blockTypes[block.Type] = append(blockTypes[block.Type], block)
+	}
+	for blockType, blocks := range blockTypes {
+		items = append(items, item{
+			pos:    blocks[0].TypeRange.Start.Byte,
+			member: jsonMember{key: blockType, value: blocksJSON(blocks, src)},
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].pos < items[j].pos })
+	obj := jsonObject{}
+	for _, i := range items {
+		obj = append(obj, i.member)
+	}
+	return obj
+}
+
+// blocksJSON converts every block of a single type. Blocks with a single
+// label and distinct labels share one object keyed by label, other repeated
+// blocks become an array.
+func blocksJSON(blocks []*hclsyntax.Block, src []byte) interface{} {
+	if len(blocks) == 1 {
+		return blockJSON(blocks[0], src)
+	}
+
+	byLabel := jsonObject{}
+	seen := map[string]bool{}
+	for _, block := range blocks {
+		if len(block.Labels) != 1 || seen[block.Labels[0]] {
+			byLabel = nil
+			break
+		}
+		seen[block.Labels[0]] = true
+		byLabel = append(byLabel, jsonMember{key: block.Labels[0], value: bodyJSON(block.Body, src)})
+	}
+	if byLabel != nil {
+		return byLabel
+	}
+
+	all := []interface{}{}
+	for _, block := range blocks {
+		all = append(all, blockJSON(block, src))
+	}
+	return all
+}
+
+// blockJSON converts a block into its body, nested in an object for each of
+// its labels.
+func blockJSON(block *hclsyntax.Block, src []byte) jsonObject {
+	obj := bodyJSON(block.Body, src)
+	for i := len(block.Labels) - 1; i >= 0; i-- {
+		obj = jsonObject{{key: block.Labels[i], value: obj}}
+	}
+	return obj
+}
+
+// exprJSON converts an expression into a JSON value if it can be evaluated
+// without a context, and into a template string wrapping its source if not.
+func exprJSON(expr hclsyntax.Expression, src []byte) interface{} {
+	if val, diags := expr.Value(nil); !diags.HasErrors() && val.IsWhollyKnown() {
+		return valueJSON(val)
+	}
+	return "${" + string(expr.Range().SliceBytes(src)) + "}"
+}
+
+// valueJSON converts a value into a JSON value. Strings are escaped so they
+// are not mistaken for templates when decoded from HCL JSON.
+func valueJSON(val cty.Value) interface{} {
+	if val.IsNull() {
+		return nil
+	}
+
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		s := strings.ReplaceAll(val.AsString(), "${", "$${")
+		return strings.ReplaceAll(s, "%{", "%%{")
+	case ty == cty.Number:
+		return json.Number(val.AsBigFloat().Text('f', -1))
+	case ty == cty.Bool:
+		return val.True()
+	case ty.IsObjectType() || ty.IsMapType():
+		obj := jsonObject{}
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			obj = append(obj, jsonMember{key: k.AsString(), value: valueJSON(v)})
+		}
+		return obj
+	}
+
+	all := []interface{}{}
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		all = append(all, valueJSON(v))
+	}
+	return all
+}
+
+// ConvertToHCL converts the JSON document src, read from filename, into
+// native syntax HCL and writes it to w. src may be an HCL JSON document, as
+// written by ConvertToJSON, or plain JSON where labelled blocks are arrays
+// of objects that hold their labels as attributes, such as:
+//   {"pet": [{"name": "Ink", "type": "cat"}]}
+// Which JSON properties are blocks is decided by the pets schema, since JSON
+// on its own cannot tell blocks and object attributes apart.
+func ConvertToHCL(w io.Writer, src []byte, filename string) error {
+	doc, err := parseOrderedJSON(src)
+	if err != nil {
+		return fmt.Errorf("error in ConvertToHCL parsing `%s`: %w", filename, err)
+	}
+	obj, ok := doc.(jsonObject)
+	if !ok {
+		return fmt.Errorf("error in ConvertToHCL: `%s` is not a JSON object", filename)
+	}
+
+	out, err := documentHCL(obj, filename)
+	if err != nil {
+		return fmt.Errorf("error in ConvertToHCL: %w", err)
+	}
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("error in ConvertToHCL writing HCL: %w", err)
+	}
+	return nil
+}
+
+// jsonToHCL translates a configuration written in HCL's JSON syntax into
+// native syntax HCL, so that it is decoded exactly like an HCL file. It is
+// parsed with HCL's JSON parser first, so that syntax errors point at where
+// they are in the JSON file rather than in the translation.
+func jsonToHCL(src []byte, filename string) ([]byte, error) {
+	if _, diags := hcljson.Parse(src, filename); diags.HasErrors() {
+		return nil, diags
+	}
+	out := &bytes.Buffer{}
+	if err := ConvertToHCL(out, src, filename); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// documentHCL returns the formatted native syntax HCL for a JSON document
+// decoded by parseOrderedJSON, or an equivalent structure.
+func documentHCL(obj jsonObject, filename string) ([]byte, error) {
+	file := hclwrite.NewFile()
+	if err := writeBodyHCL(file.Body(), obj, blockShapes(reflect.TypeOf(PetsHCL{}))); err != nil {
+		return nil, err
+	}
+
+	// Expressions are written as raw source, so the result is parsed again
+	// to make sure it is valid before formatting it.
+	out := file.Bytes()
+	if _, diags := hclsyntax.ParseConfig(out, filename, hcl.InitialPos); diags.HasErrors() {
+		return nil, fmt.Errorf("converting expressions: %w", diags)
+	}
+	return hclwrite.Format(out), nil
+}
+
+// blockShape describes a block type of the pets schema: the names of its
+// labels, and the block types that can be nested inside it.
+type blockShape struct {
+	labels []string
+	blocks map[string]blockShape
+}
+
+// blockShapes returns the block types that can appear in a body decoded
+// into the struct type t, found from the struct's hcl tags.
+func blockShapes(t reflect.Type) map[string]blockShape {
+	shapes := map[string]blockShape{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("hcl"), ",")
+		if len(tag) < 2 || tag[1] != "block" {
+			continue
+		}
+
+		blockType := t.Field(i).Type
+		for blockType.Kind() == reflect.Ptr || blockType.Kind() == reflect.Slice {
+			blockType = blockType.Elem()
+		}
+		shape := blockShape{blocks: blockShapes(blockType)}
+		for j := 0; j < blockType.NumField(); j++ {
+			labelTag := strings.Split(blockType.Field(j).Tag.Get("hcl"), ",")
+			if len(labelTag) == 2 && labelTag[1] == "label" {
+				shape.labels = append(shape.labels, labelTag[0])
+			}
+		}
+		shapes[tag[0]] = shape
+	}
+	return shapes
+}
+
+// writeBodyHCL writes the members of obj into body, as blocks if shapes
+// says they are and as attributes if not.
+func writeBodyHCL(body *hclwrite.Body, obj jsonObject, shapes map[string]blockShape) error {
+	for _, m := range obj {
+		// "//" properties are comments in HCL JSON.
+		if m.key == "//" {
+			continue
+		}
+
+		shape, ok := shapes[m.key]
+		if !ok {
+			src, err := exprHCL(m.value)
+			if err != nil {
+				return fmt.Errorf("attribute `%s`: %w", m.key, err)
+			}
+			body.SetAttributeRaw(m.key, hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(src)}})
+			continue
+		}
+
+		values := []interface{}{m.value}
+		if all, ok := m.value.([]interface{}); ok {
+			values = all
+		}
+		for _, v := range values {
+			if err := writeBlocksHCL(body, m.key, shape, nil, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeBlocksHCL writes the blocks of type blockType found in v, given the
+// labels already found for them.
+func writeBlocksHCL(body *hclwrite.Body, blockType string, shape blockShape, labels []string, v interface{}) error {
+	obj, ok := v.(jsonObject)
+	if !ok {
+		return fmt.Errorf("block `%s` is not a JSON object", blockType)
+	}
+
+	// A plain JSON block holds its labels as attributes.
+	if len(labels) == 0 && len(shape.labels) > 0 && obj.has(shape.labels[0]) {
+		rest := jsonObject{}
+		for _, m := range obj {
+			if s, ok := m.value.(string); ok && len(labels) < len(shape.labels) && m.key == shape.labels[len(labels)] {
+				labels = append(labels, s)
+				continue
+			}
+			rest = append(rest, m)
+		}
+		if len(labels) < len(shape.labels) {
+			return fmt.Errorf("block `%s` needs the labels %s", blockType, strings.Join(shape.labels, ", "))
+		}
+		obj = rest
+	}
+
+	// An HCL JSON block nests its body in an object for each label.
+	if len(labels) < len(shape.labels) {
+		for _, m := range obj {
+			if err := writeBlocksHCL(body, blockType, shape, append(labels, m.key), m.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(body.Blocks()) > 0 || len(body.Attributes()) > 0 {
+		body.AppendNewline()
+	}
+	block := body.AppendNewBlock(blockType, labels)
+	return writeBodyHCL(block.Body(), obj, shape.blocks)
+}
+
+// has reports whether o has a member named key.
+func (o jsonObject) has(key string) bool {
+	for _, m := range o {
+		if m.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// exprHCL returns the native syntax source of a JSON value. Strings are HCL
+// JSON templates, and a string that is a single interpolation becomes the
+// interpolated expression.
+func exprHCL(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return templateHCL(v)
+	case []interface{}:
+		elems := []string{}
+		for _, e := range v {
+			src, err := exprHCL(e)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, src)
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case jsonObject:
+		items := []string{}
+		for _, m := range v {
+			src, err := exprHCL(m.value)
+			if err != nil {
+				return "", err
+			}
+			key := m.key
+			if !hclsyntax.ValidIdentifier(key) {
+				key = string(hclwrite.TokensForValue(cty.StringVal(key)).Bytes())
+			}
+			items = append(items, key+" = "+src)
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported JSON value %v", v)
+}
+
+// templateHCL returns the native syntax source of an HCL JSON template.
+// Literal text is escaped for a quoted string, while interpolations and
+// directives are copied as they are.
+func templateHCL(s string) (string, error) {
+	if !strings.Contains(s, "${") && !strings.Contains(s, "%{") {
+		return string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes()), nil
+	}
+
+	expr, diags := hclsyntax.ParseTemplate([]byte(s), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if wrap, ok := expr.(*hclsyntax.TemplateWrapExpr); ok {
+		return string(wrap.Wrapped.Range().SliceBytes([]byte(s))), nil
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	literal := 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"), strings.HasPrefix(s[i:], "%%{"):
+			// Escaped introducers are written the same way in both syntaxes.
+			b.WriteString(quotedStringHCL(s[literal:i]))
+			b.WriteString(s[i : i+3])
+			i += 3
+			literal = i
+		case strings.HasPrefix(s[i:], "${"), strings.HasPrefix(s[i:], "%{"):
+			b.WriteString(quotedStringHCL(s[literal:i]))
+			end := templateSequenceEnd(s, i+2)
+			b.WriteString(s[i:end])
+			i = end
+			literal = i
+		default:
+			i++
+		}
+	}
+	b.WriteString(quotedStringHCL(s[literal:]))
+	b.WriteByte('"')
+	return b.String(), nil
+}
+
+// quotedStringHCL escapes s to appear inside a native syntax quoted string.
+func quotedStringHCL(s string) string {
+	quoted := string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes())
+	return quoted[1 : len(quoted)-1]
+}
+
+// templateSequenceEnd returns the index just after the closing brace of the
+// template interpolation or directive whose contents start at start.
+func templateSequenceEnd(s string, start int) int {
+	depth := 1
+	inString := false
+	for i := start; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && c == '{':
+			depth++
+		case !inString && c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// parseOrderedJSON parses a JSON document, keeping the order of object
+// members and the text of numbers.
+func parseOrderedJSON(src []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	v, err := decodeOrderedJSON(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return v, nil
+}
+
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := jsonObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, jsonMember{key: key.(string), value: value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		all := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, value)
+		}
+		_, err := dec.Token()
+		return all, err
+	}
+	return tok, nil
+}
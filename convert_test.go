@@ -0,0 +1,344 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertToJSON(t *testing.T) {
+
+	tcs := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "blocks",
+			src: `pet "Ink" {
+  type = "cat"
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+    age   = 4
+  }
+}`,
+			want: `{
+  "pet": {
+    "Ink": {
+      "type": "cat"
+    },
+    "Swinney": {
+      "type": "dog",
+      "characteristics": {
+        "breed": "Dachshund",
+        "age": 4
+      }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "expressions",
+			src: `pet "Spot" {
+  type = "dog"
+  characteristics {
+    breed  = random("Lab", "Pug")
+    sounds = ["woof", "$${not a template}"]
+  }
+}`,
+			want: `{
+  "pet": {
+    "Spot": {
+      "type": "dog",
+      "characteristics": {
+        "breed": "${random(\"Lab\", \"Pug\")}",
+        "sounds": [
+          "woof",
+          "$${not a template}"
+        ]
+      }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "repeated blocks",
+			src: `interaction {
+  from = "Swinney"
+}
+interaction {
+  from = "Ink"
+}`,
+			want: `{
+  "interaction": [
+    {
+      "from": "Swinney"
+    },
+    {
+      "from": "Ink"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			err := ConvertToJSON(out, []byte(tc.src), "test.hcl")
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, out.String())
+			}
+		})
+	}
+}
+
+// TestConvertToJSONDecodes checks converted testdata decodes to the same pets
+// as the original.
+func TestConvertToJSONDecodes(t *testing.T) {
+	for _, filename := range []string{
+		"testdata/basic.hcl",
+		"testdata/moods.hcl",
+		"testdata/interactions.hcl",
+		"testdata/validation.hcl",
+	} {
+		filename := filename // capture range variable
+		t.Run(filename, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := ioutil.ReadFile(filename)
+			if !assert.Nil(t, err) {
+				return
+			}
+			out := &bytes.Buffer{}
+			if !assert.Nil(t, ConvertToJSON(out, src, filename)) {
+				return
+			}
+
+			parser := hclparse.NewParser()
+			want := decodePetsHCL(t, parser.ParseHCL, src, filename)
+			got := decodePetsHCL(t, parser.ParseJSON, out.Bytes(), filename+".json")
+			if assert.Len(t, got.PetHCLBodies, len(want.PetHCLBodies)) {
+				for i := range want.PetHCLBodies {
+					assert.Equal(t, want.PetHCLBodies[i].Name, got.PetHCLBodies[i].Name)
+					assert.Equal(t, want.PetHCLBodies[i].Type, got.PetHCLBodies[i].Type)
+					assert.Equal(t, want.PetHCLBodies[i].MoodsHCL, got.PetHCLBodies[i].MoodsHCL)
+					assert.Len(t, got.PetHCLBodies[i].ValidationsHCL, len(want.PetHCLBodies[i].ValidationsHCL))
+				}
+			}
+			assert.Equal(t, want.InteractionsHCL, got.InteractionsHCL)
+		})
+	}
+}
+
+func TestDecodeConfigJSON(t *testing.T) {
+	t.Parallel()
+
+	// A file written by ConvertToJSON loads like the file it was converted
+	// from.
+	src, err := ioutil.ReadFile("testdata/basic.hcl")
+	if !assert.Nil(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	if !assert.Nil(t, ConvertToJSON(out, src, "testdata/basic.hcl")) {
+		return
+	}
+	want, err := DecodeConfig(src, "testdata/basic.hcl", LoadOptions{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	got, err := DecodeConfig(out.Bytes(), "pets.json", LoadOptions{})
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, clearDeclRanges(want.Pets), clearDeclRanges(got.Pets))
+	}
+
+	// Syntax errors are reported where they are in the JSON file.
+	_, err = DecodeConfig([]byte("{\n  \"pet\": [\n}\n"), "pets.json", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "pets.json:3,1-2")
+	}
+}
+
+func decodePetsHCL(
+	t *testing.T, parse func([]byte, string) (*hcl.File, hcl.Diagnostics), src []byte, filename string,
+) *PetsHCL {
+	petsHCL := &PetsHCL{}
+	file, diags := parse(src, filename)
+	if assert.False(t, diags.HasErrors(), diags.Error()) {
+		evalContext, err := createContext(context.Background(), LoadOptions{})
+		if assert.Nil(t, err) {
+			diags = gohcl.DecodeBody(file.Body, evalContext, petsHCL)
+			assert.False(t, diags.HasErrors(), diags.Error())
+		}
+	}
+	return petsHCL
+}
+
+func TestConvertToHCL(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "hcl json",
+			src: `{
+  "pet": {
+    "Spot": {
+      "type": "dog",
+      "characteristics": {
+        "breed": "${random(\"Lab\", \"Pug\")}",
+        "sounds": ["woof", "$${not a template}"],
+        "age": 4
+      },
+      "moods": {"initial": "sleepy"}
+    }
+  }
+}`,
+			want: `pet "Spot" {
+  type = "dog"
+
+  characteristics {
+    breed  = random("Lab", "Pug")
+    sounds = ["woof", "$${not a template}"]
+    age    = 4
+  }
+
+  moods {
+    initial = "sleepy"
+  }
+}
+`,
+		},
+		{
+			name: "plain json",
+			src: `{
+  "pet": [
+    {"name": "Ink", "type": "cat"},
+    {"name": "Swinney", "type": "dog", "validation": [{"condition": "${true}"}]}
+  ]
+}`,
+			want: `pet "Ink" {
+  type = "cat"
+}
+
+pet "Swinney" {
+  type = "dog"
+
+  validation {
+    condition = true
+  }
+}
+`,
+		},
+		{
+			name: "templates",
+			src: `{
+  "pet": {
+    "Ink": {
+      "validation": {
+        "error_message": "${self.name} says \"${upper(\"hi\")}\"\n"
+      }
+    }
+  }
+}`,
+			want: `pet "Ink" {
+  validation {
+    error_message = "${self.name} says \"${upper("hi")}\"\n"
+  }
+}
+`,
+		},
+		{
+			name:    "invalid expression",
+			src:     `{"pet": {"Ink": {"type": "${1 +}"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			src:     `["Ink"]`,
+			wantErr: true,
+		},
+		{
+			name:    "missing label",
+			src:     `{"pet": [{"type": "cat"}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			err := ConvertToHCL(out, []byte(tc.src), "test.json")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, out.String())
+			}
+		})
+	}
+}
+
+// TestConvertRoundTrip checks testdata converted to JSON and back decodes to
+// the same pets as the original.
+func TestConvertRoundTrip(t *testing.T) {
+	for _, filename := range []string{
+		"testdata/basic.hcl",
+		"testdata/moods.hcl",
+		"testdata/interactions.hcl",
+		"testdata/conditions.hcl",
+	} {
+		filename := filename // capture range variable
+		t.Run(filename, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := ioutil.ReadFile(filename)
+			if !assert.Nil(t, err) {
+				return
+			}
+			jsonOut, hclOut := &bytes.Buffer{}, &bytes.Buffer{}
+			if !assert.Nil(t, ConvertToJSON(jsonOut, src, filename)) {
+				return
+			}
+			if !assert.Nil(t, ConvertToHCL(hclOut, jsonOut.Bytes(), filename+".json")) {
+				return
+			}
+
+			parser := hclparse.NewParser()
+			want := decodePetsHCL(t, parser.ParseHCL, src, filename)
+			got := decodePetsHCL(t, parser.ParseHCL, hclOut.Bytes(), filename+".json.hcl")
+			if assert.Len(t, got.PetHCLBodies, len(want.PetHCLBodies)) {
+				for i := range want.PetHCLBodies {
+					assert.Equal(t, want.PetHCLBodies[i].Name, got.PetHCLBodies[i].Name)
+					assert.Equal(t, want.PetHCLBodies[i].MoodsHCL, got.PetHCLBodies[i].MoodsHCL)
+					assert.Len(t, got.PetHCLBodies[i].PreconditionsHCL, len(want.PetHCLBodies[i].PreconditionsHCL))
+				}
+			}
+			assert.Equal(t, want.InteractionsHCL, got.InteractionsHCL)
+		})
+	}
+}
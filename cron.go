@@ -0,0 +1,168 @@
+package petsounds
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a schedule written as a cron expression, with five fields
+// for the minute, hour, day of the month, month and day of the week:
+//   0 8,18 * * *
+// Fields are a *, a number, a range like 1-5, or a comma separated list of
+// them, and any of them can be stepped, as in */15 or 8-18/2. Months and
+// days of the week can also be named, as in jan or mon-fri. As in cron, a
+// day matches when either of the day fields matches, unless one of them is
+// a *.
+type CronSchedule struct {
+	expr   string
+	fields [5]uint64
+	// domAny and dowAny are whether the day of the month and day of the
+	// week fields are *.
+	domAny bool
+	dowAny bool
+}
+
+// cronField is the range of values, and the names, of a cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of the month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+	}},
+	// Sunday is both 0 and 7.
+	{name: "day of the week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseCronSchedule parses the cron expression expr.
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression `%s` has %d fields, expected 5", expr, len(parts))
+	}
+
+	s := &CronSchedule{expr: expr, domAny: parts[2] == "*", dowAny: parts[4] == "*"}
+	for i, part := range parts {
+		bits, err := cronFields[i].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression `%s`: %w", expr, err)
+		}
+		s.fields[i] = bits
+	}
+	// Sunday is matched as 0.
+	if s.fields[4]&(1<<7) != 0 {
+		s.fields[4] |= 1
+	}
+	return s, nil
+}
+
+// parse returns the values of the field written as part, as a bit set.
+func (f cronField) parse(part string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rng = item[:i]
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step `%s` in %s field", item[i+1:], f.name)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// A stepped single value, like 5/10, runs to the end of
+				// the field.
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range `%s` in %s field", rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value returns the value written as s in the field, a number or a name.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value `%s` in %s field, expected %d to %d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the cron expression of s.
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// has reports whether field i of s includes v.
+func (s *CronSchedule) has(i, v int) bool {
+	return s.fields[i]&(1<<uint(v)) != 0
+}
+
+// dayMatches reports whether the day of t is a day of s.
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := s.has(2, t.Day()), s.has(4, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Matches reports whether the minute of t is one of the times of s.
+func (s *CronSchedule) Matches(t time.Time) bool {
+	return s.has(0, t.Minute()) && s.has(1, t.Hour()) && s.has(3, int(t.Month())) && s.dayMatches(t)
+}
+
+// Next returns the first time of s after t, or the zero time if s has no
+// times in the next five years, as for the 30th of February.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		y, m, d := t.Date()
+		switch {
+		case !s.has(3, int(m)):
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case !s.has(1, t.Hour()):
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		case !s.has(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
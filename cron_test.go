@@ -0,0 +1,150 @@
+package petsounds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	tcs := []struct {
+		name    string
+		expr    string
+		matches []string
+		misses  []string
+		err     string
+	}{
+		{
+			name:    "list",
+			expr:    "0 8,18 * * *",
+			matches: []string{"2020-08-01 08:00", "2020-08-01 18:00"},
+			misses:  []string{"2020-08-01 08:01", "2020-08-01 12:00"},
+		},
+		{
+			name:    "step",
+			expr:    "*/15 9-17/4 * * *",
+			matches: []string{"2020-08-01 09:45", "2020-08-01 13:00", "2020-08-01 17:30"},
+			misses:  []string{"2020-08-01 09:10", "2020-08-01 10:00"},
+		},
+		{
+			name:    "names",
+			expr:    "0 9 * jan-mar mon-fri",
+			matches: []string{"2020-02-03 09:00"},
+			misses:  []string{"2020-02-01 09:00", "2020-08-03 09:00"},
+		},
+		{
+			name:    "sunday is seven",
+			expr:    "0 9 * * 7",
+			matches: []string{"2020-08-02 09:00"},
+			misses:  []string{"2020-08-01 09:00"},
+		},
+		{
+			name:    "either day",
+			expr:    "0 9 1 * mon",
+			matches: []string{"2020-08-01 09:00", "2020-08-03 09:00"},
+			misses:  []string{"2020-08-02 09:00"},
+		},
+		{
+			name: "too few fields",
+			expr: "0 8 * *",
+			err:  "cron expression `0 8 * *` has 4 fields, expected 5",
+		},
+		{
+			name: "out of range",
+			expr: "0 25 * * *",
+			err:  "cron expression `0 25 * * *`: invalid value `25` in hour field, expected 0 to 23",
+		},
+		{
+			name: "backwards range",
+			expr: "0 18-8 * * *",
+			err:  "cron expression `0 18-8 * * *`: invalid range `18-8` in hour field",
+		},
+		{
+			name: "bad step",
+			expr: "*/0 * * * *",
+			err:  "cron expression `*/0 * * * *`: invalid step `0` in minute field",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := ParseCronSchedule(tc.expr)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expr, s.String())
+			for _, m := range tc.matches {
+				assert.True(t, s.Matches(cronTime(t, m)), m)
+			}
+			for _, m := range tc.misses {
+				assert.False(t, s.Matches(cronTime(t, m)), m)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	tcs := []struct {
+		name string
+		expr string
+		from string
+		want string
+	}{
+		{
+			name: "later today",
+			expr: "0 8,18 * * *",
+			from: "2020-08-01 08:00",
+			want: "2020-08-01 18:00",
+		},
+		{
+			name: "tomorrow",
+			expr: "0 8,18 * * *",
+			from: "2020-08-01 18:30",
+			want: "2020-08-02 08:00",
+		},
+		{
+			name: "next year",
+			expr: "0 0 1 jan *",
+			from: "2020-08-01 12:00",
+			want: "2021-01-01 00:00",
+		},
+		{
+			name: "never",
+			expr: "0 0 30 feb *",
+			from: "2020-08-01 12:00",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := ParseCronSchedule(tc.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+			next := s.Next(cronTime(t, tc.from))
+			if tc.want == "" {
+				assert.True(t, next.IsZero())
+				return
+			}
+			assert.Equal(t, cronTime(t, tc.want), next)
+		})
+	}
+}
+
+// cronTime parses a time written as 2006-01-02 15:04, in UTC.
+func cronTime(t *testing.T, s string) time.Time {
+	tm, err := time.Parse("2006-01-02 15:04", s)
+	assert.NoError(t, err)
+	return tm
+}
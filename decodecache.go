@@ -0,0 +1,114 @@
+package petsounds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DecodeCache keeps the pets of the configuration last decoded with it by
+// the fingerprint of the blocks they were decoded from, so that a long
+// running process reloading its configuration only decodes the pets whose
+// blocks have changed, however many pets there are. A pet's fingerprint
+// covers its own block, the blocks of the pets it refers to, the rest of
+// every file in the ParseCache, and the options that change how pets are
+// decoded, so anything but a pet block changing decodes every pet again.
+// Values that don't come from the files, such as the time and random
+// numbers, are those of the decode that last decoded the pet.
+//
+// Pets are kept as they were decoded, and returned as they are, so a
+// DecodeCache is for the reloads of a single configuration. It is safe for
+// concurrent use.
+type DecodeCache struct {
+	mu   sync.Mutex
+	pets map[string]Pet
+
+	hits, misses uint64
+}
+
+// DecodeCacheStats counts the pets a DecodeCache has been asked for that it
+// had already decoded, and those that had to be decoded.
+type DecodeCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// NewDecodeCache returns an empty DecodeCache.
+func NewDecodeCache() *DecodeCache {
+	return &DecodeCache{pets: map[string]Pet{}}
+}
+
+// Stats returns the number of hits and misses of the cache so far.
+func (c *DecodeCache) Stats() DecodeCacheStats {
+	return DecodeCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// get returns the pet decoded with fingerprint, or nil if there is none.
+// Pets without a fingerprint are never cached.
+func (c *DecodeCache) get(fingerprint string) Pet {
+	if c == nil || fingerprint == "" {
+		return nil
+	}
+	c.mu.Lock()
+	pet := c.pets[fingerprint]
+	c.mu.Unlock()
+	if pet == nil {
+		atomic.AddUint64(&c.misses, 1)
+		return nil
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return pet
+}
+
+// replace replaces the pets of the cache with pets, by fingerprint.
+func (c *DecodeCache) replace(pets map[string]Pet) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pets = pets
+}
+
+// decodeBase returns what the fingerprints of the pets decoded with opts
+// start from: the rest of the files in the ParseCache, and the options
+// that change how the pets are decoded.
+func decodeBase(opts LoadOptions) []byte {
+	rest := opts.ParseCache.restSum()
+	env := []string{}
+	for k, v := range opts.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	h := sha256.New()
+	h.Write(rest[:])
+	fmt.Fprintf(h, "%q %q %q %t %q %q", opts.Format, opts.Profile, opts.Packs, opts.NoAssetCheck, opts.MergeStrategy, env)
+	return h.Sum(nil)
+}
+
+// petBlockFingerprint returns the fingerprint of p, from base, the
+// contents of its block and refs, the fingerprints of the pets it refers
+// to. It is empty when the contents of the block are not in cache.
+func petBlockFingerprint(base []byte, cache *ParseCache, p *PetHCL, refs []string) string {
+	src, r := cache.source(p.blockRange.Filename), p.blockRange
+	if src == nil || r.End.Byte > len(src) || r.Start.Byte > r.End.Byte {
+		return ""
+	}
+	h := sha256.New()
+	h.Write(base)
+	fmt.Fprintf(h, "%q %q ", p.Name, r.Filename)
+	h.Write(src[r.Start.Byte:r.End.Byte])
+	for _, ref := range refs {
+		if ref == "" {
+			return ""
+		}
+		h.Write([]byte(ref))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
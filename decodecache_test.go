@@ -0,0 +1,78 @@
+package petsounds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCache(t *testing.T) {
+	t.Parallel()
+
+	src := `
+variable "sound" {
+  default = "meow"
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = var.sound
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = pet.Ink.sound
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+`
+	cache := NewDecodeCache()
+	opts := LoadOptions{ParseCache: NewParseCache(), DecodeCache: cache}
+	decode := func(src string) []Pet {
+		config, err := DecodeConfig([]byte(src), "pets.hcl", opts)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		return config.Pets
+	}
+
+	first := decode(src)
+	assert.Equal(t, DecodeCacheStats{Misses: 3}, cache.Stats())
+
+	// Nothing has changed, so every pet is kept.
+	second := decode(src)
+	assert.Equal(t, first, second)
+	assert.Equal(t, DecodeCacheStats{Hits: 3, Misses: 3}, cache.Stats())
+	assert.Empty(t, reloadChanges(first, second))
+
+	// Ink has changed, and Neko refers to Ink, so both are decoded again.
+	third := decode(strings.Replace(src, "sound = var.sound", `sound = "${var.sound}!"`, 1))
+	if assert.Len(t, third, 3) {
+		assert.Equal(t, "meow!", third[1].(*Cat).Sound)
+		assert.True(t, second[2] == third[2], "Swinney is kept")
+	}
+	assert.Equal(t, DecodeCacheStats{Hits: 4, Misses: 5}, cache.Stats())
+	changes := reloadChanges(second, third)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, changeUpdate, changes[0].Action)
+		assert.Equal(t, "Ink", changes[0].Name)
+		assert.Equal(t, "Neko", changes[1].Name)
+	}
+
+	// Changing anything but a pet block decodes every pet again.
+	fourth := decode(strings.Replace(src, `default = "meow"`, `default = "mrrp"`, 1))
+	assert.Equal(t, DecodeCacheStats{Hits: 4, Misses: 8}, cache.Stats())
+	if assert.Len(t, fourth, 3) {
+		assert.Equal(t, "mrrp", fourth[0].(*Cat).Sound)
+	}
+}
@@ -0,0 +1,52 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+)
+
+// Decoder decodes many configurations with the same options, sharing what
+// can be shared between them: the ParseCache, so that files that many
+// configurations include are parsed once, the random source and the
+// functions. A Decoder is safe for concurrent use, so a server can keep one
+// and decode the configuration of each request with it.
+type Decoder struct {
+	opts LoadOptions
+}
+
+// NewDecoder returns a Decoder that decodes with opts. A ParseCache is
+// created for it when opts has none.
+func NewDecoder(opts LoadOptions) *Decoder {
+	if opts.ParseCache == nil {
+		opts.ParseCache = NewParseCache()
+	}
+	return &Decoder{opts: opts.withDefaults()}
+}
+
+// Decode is DecodeConfigContext with the options of d.
+func (d *Decoder) Decode(ctx context.Context, src []byte, filename string) (*Config, error) {
+	return DecodeConfigContext(ctx, src, filename, d.opts)
+}
+
+// Load is LoadConfigContext with the options of d. The format of the file
+// is the one d was given, or else the one of its source.
+func (d *Decoder) Load(ctx context.Context, filename string) (*Config, error) {
+	source, err := NewConfigSource(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error in Decoder.Load: %w", err)
+	}
+	src, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in Decoder.Load: %w", err)
+	}
+	opts := d.opts
+	if opts.Format == "" {
+		opts.Format = source.Format()
+	}
+	return DecodeConfigContext(ctx, src, filename, opts)
+}
+
+// Stats returns the hits and misses of the ParseCache of d.
+func (d *Decoder) Stats() ParseCacheStats {
+	return d.opts.ParseCache.Stats()
+}
@@ -0,0 +1,63 @@
+package petsounds
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	d := NewDecoder(LoadOptions{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config, err := d.Load(context.Background(), "testdata/basic.hcl")
+			if assert.NoError(t, err) {
+				assert.Equal(t, []Pet{
+					&Cat{Name: "Ink", Sound: "meow"},
+					&Dog{Name: "Swinney", Breed: "Dachshund"},
+				}, clearDeclRanges(config.Pets))
+			}
+		}()
+	}
+	wg.Wait()
+	stats := d.Stats()
+	assert.Equal(t, uint64(8), stats.Hits+stats.Misses)
+
+	// The file has been parsed by now, however the loads raced.
+	_, err := d.Load(context.Background(), "testdata/basic.hcl")
+	assert.NoError(t, err)
+	assert.Equal(t, stats.Hits+1, d.Stats().Hits)
+}
+
+func TestDecoderFunctions(t *testing.T) {
+	t.Parallel()
+
+	shout := function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "sound", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(args[0].AsString() + "!"), nil
+		},
+	})
+	d := NewDecoder(LoadOptions{Functions: map[string]function.Function{"shout": shout}})
+	config, err := d.Decode(context.Background(), []byte(`
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = shout("meow")
+  }
+}
+`), "test.hcl")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Pet{&Cat{Name: "Ink", Sound: "meow!"}}, clearDeclRanges(config.Pets))
+	}
+}
@@ -0,0 +1,6 @@
+// Package petsounds decodes configurations of pets written in HCL, and has
+// the pets say and act. It is the library the pet-sounds command, in
+// cmd/pet-sounds, is built on: LoadConfig and DecodeConfig decode a
+// configuration, and a Runner runs its pets. The petsoundstest package
+// helps test code built on it.
+package petsounds
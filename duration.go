@@ -0,0 +1,39 @@
+package petsounds
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// durationType is the type of characteristics that are durations. In
+// configuration they are strings in Go's duration syntax, such as "2h15m".
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parseDuration returns the duration the string v holds.
+func parseDuration(v cty.Value) (time.Duration, error) {
+	if v.Type() != cty.String {
+		return 0, fmt.Errorf("a duration must be a string, such as \"2h15m\"")
+	}
+	d, err := time.ParseDuration(v.AsString())
+	if err != nil {
+		return 0, fmt.Errorf("`%s` is not a duration, such as \"2h15m\"", v.AsString())
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("`%s` is negative", v.AsString())
+	}
+	return d, nil
+}
+
+// durationValue returns d as it is written in configuration.
+func durationValue(d time.Duration) cty.Value {
+	return cty.StringVal(d.String())
+}
+
+// petNapDuration returns how long a pet naps for after it Acts, which is
+// zero for pets that don't nap.
+func petNapDuration(p Pet) time.Duration {
+	return fieldsOf(p).napDuration
+}
@@ -0,0 +1,86 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationCharacteristics(t *testing.T) {
+	t.Parallel()
+
+	pets := mustParse(t, `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    nap_duration = "2h15m"
+  }
+}
+`)
+	assert.Equal(t, 2*time.Hour+15*time.Minute, pets[0].(*Cat).NapDuration)
+
+	tcs := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "bad syntax",
+			input: `"a while"`,
+			want: "test.hcl:5,20-29: Invalid nap_duration; Unsuitable value for nap_duration: " +
+				"`a while` is not a duration, such as \"2h15m\".",
+		},
+		{
+			name:  "negative",
+			input: `"-1h"`,
+			want:  "test.hcl:5,20-25: Invalid nap_duration; Unsuitable value for nap_duration: `-1h` is negative.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeConfig([]byte(`
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    nap_duration = `+tc.input+`
+  }
+}
+`), "test.hcl", LoadOptions{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
+
+func TestSimulationNaps(t *testing.T) {
+	t.Parallel()
+
+	clock := fixedClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+	out := &bytes.Buffer{}
+	sim := &Simulation{
+		Runner:   Runner{Out: out, Clock: clock, Rand: rand.NewSource(1)},
+		Ticks:    20,
+		Interval: time.Millisecond,
+	}
+	pets := []Pet{&Cat{Name: "Ink", Sound: "meow", NapDuration: time.Hour}}
+
+	// The clock never moves on, so once Ink snoozes it naps for the rest of
+	// the simulation.
+	err := sim.Run(context.Background(), pets)
+	if assert.NoError(t, err) {
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		assert.Less(t, len(lines), 20)
+		assert.Equal(t, "Ink snoozes", lines[len(lines)-1])
+		assert.Equal(t, 1, strings.Count(out.String(), "snoozes"))
+	}
+}
@@ -0,0 +1,180 @@
+package petsounds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// ReadEnvFile reads variables for the env namespace from a .env file. Each
+// line of the file is a KEY=VALUE pair, optionally preceded by `export`.
+// Values can be double quoted, with Go escapes, or single quoted, taken as
+// they are. Blank lines and lines starting with # are ignored, as is the
+// rest of a line after an unquoted value's " #".
+func ReadEnvFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error in ReadEnvFile: %w", err)
+	}
+	defer f.Close()
+
+	env, err := parseEnv(f)
+	if err != nil {
+		return nil, fmt.Errorf("error in ReadEnvFile reading `%s`: %w", filename, err)
+	}
+	return env, nil
+}
+
+// parseEnv parses the contents of a .env file.
+func parseEnv(r io.Reader) (map[string]string, error) {
+	env := map[string]string{}
+	lines := bufio.NewScanner(r)
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+
+		value, err := envValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		env[key] = value
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// envValue unquotes the value of a .env line, dropping any comment after it.
+func envValue(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, "'") {
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = strings.TrimSpace(s[:i])
+		}
+		return s, nil
+	}
+
+	// Find the closing quote, skipping escaped characters in double quotes.
+	end := -1
+	for i := 1; i < len(s); i++ {
+		if s[0] == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == s[0] {
+			end = i
+			break
+		}
+	}
+	if rest := strings.TrimSpace(s[end+1:]); end < 0 || (rest != "" && !strings.HasPrefix(rest, "#")) {
+		return "", fmt.Errorf("invalid quoted value %s", s)
+	}
+	if s[0] == '\'' {
+		return s[1:end], nil
+	}
+	value, err := strconv.Unquote(s[:end+1])
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted value %s", s)
+	}
+	return value, nil
+}
+
+// requiredEnvKey is the attribute that lists the environment variables a
+// configuration needs, which are checked before it is decoded.
+const requiredEnvKey = "required_env"
+
+// lookupEnv returns the variable name of the env namespace: the process
+// environment's, or else the one opts.Env sets.
+func (opts LoadOptions) lookupEnv(name string) (string, bool) {
+	if v := opts.getenv(name); v != "" {
+		return v, true
+	}
+	v, ok := opts.Env[name]
+	return v, ok
+}
+
+// getenv returns the variable name of the process environment, or of the
+// environment opts.Getenv stands in for it.
+func (opts LoadOptions) getenv(name string) string {
+	if opts.Getenv != nil {
+		return opts.Getenv(name)
+	}
+	return os.Getenv(name)
+}
+
+// withRequiredEnv returns opts with the variables names in its Env, with
+// their values from the process environment or Env itself, so that the
+// variables a configuration requires are in the env namespace wherever they
+// are set.
+func (opts LoadOptions) withRequiredEnv(names []string) LoadOptions {
+	env := map[string]string{}
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+	for _, name := range names {
+		env[name], _ = opts.lookupEnv(name)
+	}
+	opts.Env = env
+	return opts
+}
+
+// checkRequiredEnv returns the variables the required_env attribute of body
+// names, with an error diagnostic listing every one of them that is not
+// set, in the process environment or in opts.Env.
+func checkRequiredEnv(body *hclsyntax.Body, opts LoadOptions) ([]string, hcl.Diagnostics) {
+	attr, ok := body.Attributes[requiredEnvKey]
+	if !ok {
+		return nil, nil
+	}
+
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	var names []string
+	val, err := convert.Convert(val, cty.List(cty.String))
+	if err == nil {
+		err = gocty.FromCtyValue(val, &names)
+	}
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid required_env",
+			Detail:   "The required_env must be a list of the names of environment variables.",
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	missing := []string{}
+	for _, name := range names {
+		if _, ok := opts.lookupEnv(name); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return names, nil
+	}
+	return names, hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Missing environment variables",
+		Detail:   fmt.Sprintf("The configuration requires environment variables that are not set: %s.", strings.Join(missing, ", ")),
+		Subject:  attr.Expr.Range().Ptr(),
+	}}
+}
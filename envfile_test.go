@@ -0,0 +1,153 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnv(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "values",
+			input: `
+# a comment
+PLAIN=woof
+export EXPORTED = bark  # trailing comment
+DOUBLE="a \"quoted\" value\n"
+SINGLE='no \n escapes'
+EMPTY=
+`,
+			want: map[string]string{
+				"PLAIN":    "woof",
+				"EXPORTED": "bark",
+				"DOUBLE":   "a \"quoted\" value\n",
+				"SINGLE":   `no \n escapes`,
+				"EMPTY":    "",
+			},
+		},
+		{
+			name:    "missing equals",
+			input:   "WOOF\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			input:   "WOOF='bark\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseEnv(strings.NewReader(tc.input))
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEnvFile(t *testing.T) {
+	env, err := ReadEnvFile("testdata/pets.env")
+	if !assert.Nil(t, err) {
+		return
+	}
+	src, err := ioutil.ReadFile("testdata/env.hcl")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	config, err := DecodeConfig(src, "testdata/env.hcl", LoadOptions{Env: env})
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{&Dog{Name: "Biscuit", Breed: "Corgi", Sound: "yips\tloudly"}}, clearDeclRanges(config.Pets))
+	}
+
+	// The process environment takes precedence over the env file, and is
+	// never changed by it.
+	defer os.Unsetenv("DOG_BREED")
+	os.Setenv("DOG_BREED", "Beagle")
+	config, err = DecodeConfig(src, "testdata/env.hcl", LoadOptions{Env: env})
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, "Beagle", config.Pets[0].(*Dog).Breed)
+	}
+	assert.Equal(t, "", os.Getenv("DOG_SOUND"))
+}
+
+func TestRequiredEnv(t *testing.T) {
+	t.Parallel()
+
+	os.Setenv("REQUIRED_SOUND", "mew")
+	defer os.Unsetenv("REQUIRED_SOUND")
+	tcs := []struct {
+		name string
+		src  string
+		env  map[string]string
+		want string
+		err  string
+	}{
+		{
+			name: "set",
+			src: `required_env = ["REQUIRED_SOUND", "REQUIRED_TOKEN"]
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "${env.REQUIRED_SOUND} ${env.REQUIRED_TOKEN}"
+  }
+}
+`,
+			env:  map[string]string{"REQUIRED_TOKEN": "secret"},
+			want: "mew secret",
+		},
+		{
+			name: "missing",
+			src: `required_env = ["REQUIRED_SOUND", "REQUIRED_TOKEN", "REQUIRED_HOST"]
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = env.REQUIRED_TOKEN
+  }
+}
+`,
+			err: "error in DecodeConfig checking environment: pets.hcl:1,16-69: Missing environment variables; " +
+				"The configuration requires environment variables that are not set: REQUIRED_TOKEN, REQUIRED_HOST.",
+		},
+		{
+			name: "invalid",
+			src:  `required_env = "REQUIRED_SOUND"`,
+			err: "error in DecodeConfig checking environment: pets.hcl:1,16-32: Invalid required_env; " +
+				"The required_env must be a list of the names of environment variables.",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := DecodeConfig([]byte(tc.src), "pets.hcl", LoadOptions{Env: tc.env})
+			if tc.err == "" {
+				if assert.NoError(t, err) {
+					assert.Equal(t, tc.want, config.Pets[0].(*Cat).Sound)
+				}
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
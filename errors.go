@@ -0,0 +1,79 @@
+package petsounds
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// ErrConfigNotFound is wrapped by the errors of configuration sources when
+// the configuration they fetch does not exist, so callers can check for it
+// with errors.Is whichever source they use.
+var ErrConfigNotFound = errors.New("configuration not found")
+
+// ErrParse is wrapped by the errors of configurations that could not be
+// parsed, as opposed to configurations that were parsed but could not be
+// decoded or failed validation.
+var ErrParse = errors.New("configuration could not be parsed")
+
+// sentinelError marks err as one of the sentinel errors above, for
+// errors.Is, while still wrapping err and keeping its message.
+type sentinelError struct {
+	err      error
+	sentinel error
+}
+
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.err
+}
+
+func (e *sentinelError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// ErrUnknownPetType is the error for a pet whose type is not one of
+// petKinds. Range is the pet's type attribute, which is empty for pets that
+// were not declared in a configuration file. The error suggests the closest
+// type there is, as it is most likely a typo.
+type ErrUnknownPetType struct {
+	Type  string
+	Range hcl.Range
+}
+
+func (e *ErrUnknownPetType) Error() string {
+	msg := fmt.Sprintf("unknown pet type `%s`", e.Type)
+	if suggestion := suggest(e.Type, petKindNames()); suggestion != "" {
+		msg = fmt.Sprintf("unknown pet type `%s`, did you mean `%s`?", e.Type, suggestion)
+	}
+	if e.Range.Filename != "" {
+		msg = fmt.Sprintf("%s: %s", e.Range, msg)
+	}
+	return msg
+}
+
+// errorDiagnostics returns the diagnostics of err, an error of decoding a
+// configuration, so tools can point at where in the file each problem is.
+// Errors that are not diagnostics become a single diagnostic without a
+// range.
+func errorDiagnostics(err error) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	var unknown *ErrUnknownPetType
+	switch {
+	case errors.As(err, &diags):
+		return diags
+	case errors.As(err, &unknown):
+		subject := unknown.Range
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown pet type",
+			Detail:   unknown.Error(),
+			Subject:  &subject,
+		}}
+	}
+	return hcl.Diagnostics{{Severity: hcl.DiagError, Summary: err.Error()}}
+}
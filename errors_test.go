@@ -0,0 +1,53 @@
+package petsounds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrConfigNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := LoadConfig("testdata/missing.hcl")
+	assert.True(t, errors.Is(err, ErrConfigNotFound), "want not found, got %v", err)
+	for _, name := range []string{"testdata/missing.hcl", server.URL + "/missing.hcl"} {
+		_, err := LoadConfigContext(context.Background(), name)
+		assert.True(t, errors.Is(err, ErrConfigNotFound), "want not found for %s, got %v", name, err)
+	}
+
+	// Missing files are still reported like any other missing file.
+	_, err = LoadConfig("testdata/missing.hcl")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	_, err = LoadConfig("testdata/basic.hcl")
+	assert.False(t, errors.Is(err, ErrConfigNotFound))
+}
+
+func TestErrUnknownPetType(t *testing.T) {
+	src := []byte("pet \"Nemo\" {\n  type = \"fish\"\n}\n")
+	_, err := DecodeConfig(src, "fish.hcl", LoadOptions{})
+	var unknown *ErrUnknownPetType
+	if assert.True(t, errors.As(err, &unknown), "want unknown pet type, got %v", err) {
+		assert.Equal(t, "fish", unknown.Type)
+		assert.Equal(t, "fish.hcl", unknown.Range.Filename)
+		assert.Equal(t, 2, unknown.Range.Start.Line)
+	}
+	assert.EqualError(t, err, "error in DecodeConfig: fish.hcl:2,10-16: unknown pet type `fish`")
+
+	src = []byte("pet \"Ink\" {\n  type = \"caat\"\n}\n")
+	_, err = DecodeConfig(src, "caat.hcl", LoadOptions{})
+	assert.EqualError(t, err, "error in DecodeConfig: caat.hcl:2,10-16: unknown pet type `caat`, did you mean `cat`?")
+
+	_, err = ImportCSV(strings.NewReader("name,type\nNemo,fish\n"), nil)
+	if assert.True(t, errors.As(err, &unknown), "want unknown pet type, got %v", err) {
+		assert.Equal(t, "fish", unknown.Type)
+	}
+}
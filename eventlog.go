@@ -0,0 +1,68 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The formats of an EventLog.
+const (
+	// eventLogText writes each event as its time and what the pet wrote.
+	eventLogText = "text"
+	// eventLogJSON writes each event as a JSON object on a line of its own,
+	// as they are streamed from /events.
+	eventLogJSON = "json"
+)
+
+// EventLog writes what pets Say and do to a writer of its own, usually a
+// RotatingFile, alongside the Runner's output, so that long running
+// simulations and schedules keep a record of it without redirecting their
+// output. It is safe for concurrent use.
+type EventLog struct {
+	w      io.WriteCloser
+	format string
+
+	mu sync.Mutex
+}
+
+// NewEventLog returns an EventLog that writes to w in format, text or json.
+func NewEventLog(w io.WriteCloser, format string) (*EventLog, error) {
+	if format != eventLogText && format != eventLogJSON {
+		return nil, fmt.Errorf("error in NewEventLog: unknown format `%s`, expected text or json", format)
+	}
+	return &EventLog{w: w, format: format}, nil
+}
+
+// Write writes that p carried out event at the time at, writing line.
+func (l *EventLog) Write(p Pet, event, line string, at time.Time) error {
+	var entry []byte
+	if l.format == eventLogJSON {
+		name, petType := petIdentity(p)
+		var err error
+		entry, err = json.Marshal(streamEvent{Pet: name, Type: petType, Event: event, Line: line, TS: at})
+		if err != nil {
+			return fmt.Errorf("error in EventLog: %w", err)
+		}
+		entry = append(entry, '\n')
+	} else {
+		// Lines of more than one line, like art, keep the time on each.
+		stamp := at.Format(time.RFC3339) + " "
+		entry = []byte(stamp + strings.ReplaceAll(line, "\n", "\n"+stamp) + "\n")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(entry); err != nil {
+		return fmt.Errorf("error in EventLog: %w", err)
+	}
+	return nil
+}
+
+// Close closes the writer of the log.
+func (l *EventLog) Close() error {
+	return l.w.Close()
+}
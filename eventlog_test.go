@@ -0,0 +1,68 @@
+package petsounds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nopCloser is a bytes.Buffer with a Close method.
+type nopCloser struct {
+	bytes.Buffer
+}
+
+func (*nopCloser) Close() error {
+	return nil
+}
+
+func TestEventLog(t *testing.T) {
+	tcs := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: eventLogText,
+			want:   "2020-06-01T12:00:00Z Ink meow\n2020-06-01T12:00:00Z Ink snoozes\n",
+		},
+		{
+			format: eventLogJSON,
+			want: `{"pet":"Ink","type":"cat","event":"say","line":"Ink meow","ts":"2020-06-01T12:00:00Z"}` + "\n" +
+				`{"pet":"Ink","type":"cat","event":"act","line":"Ink snoozes","ts":"2020-06-01T12:00:00Z"}` + "\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.format, func(t *testing.T) {
+			t.Parallel()
+
+			out := &nopCloser{}
+			log, err := NewEventLog(out, tc.format)
+			if !assert.NoError(t, err) {
+				return
+			}
+			runner := &Runner{Out: ioutil.Discard, Log: log, Clock: fixedClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))}
+			assert.NoError(t, runner.Run([]Pet{&Cat{Name: "Ink", Sound: "meow"}}))
+			assert.NoError(t, runner.Close())
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestEventLogMultiline(t *testing.T) {
+	t.Parallel()
+
+	out := &nopCloser{}
+	log, err := NewEventLog(out, eventLogText)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, log.Write(&Cat{Name: "Ink"}, eventSay, " /\\_/\\\n( o.o )", time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2020-06-01T12:00:00Z  /\\_/\\\n2020-06-01T12:00:00Z ( o.o )\n", out.String())
+
+	_, err = NewEventLog(out, "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,335 @@
+package petsounds
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamEvent is a pet Saying or Acting, as it is streamed from /events.
+type streamEvent struct {
+	Pet   string    `json:"pet"`
+	Type  string    `json:"type,omitempty"`
+	Event string    `json:"event"`
+	Line  string    `json:"line"`
+	TS    time.Time `json:"ts"`
+}
+
+// eventBroker streams the events of the pets a Runner runs to the clients
+// of /events, over a WebSocket when the client asks to upgrade to one, and
+// as server-sent events otherwise. Clients that fall behind miss events
+// rather than hold up the pets.
+type eventBroker struct {
+	clock Clock
+
+	// origins are the origins, such as https://pets.example.com, whose pages
+	// may open a WebSocket to the broker besides those of the same host.
+	origins []string
+
+	mu          sync.Mutex
+	subscribers map[chan []byte]bool
+
+	// done is closed once the broker is closed, ending every stream.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// eventBufferSize is the number of events a client can fall behind by
+// before it misses events.
+const eventBufferSize = 64
+
+// newEventBroker returns an eventBroker that stamps events with the time
+// on clock, or the system clock if it is nil.
+func newEventBroker(clock Clock) *eventBroker {
+	return &eventBroker{clock: clockOrSystem(clock), subscribers: map[chan []byte]bool{}, done: make(chan struct{})}
+}
+
+// close ends the streams of every client, and any that start later, so
+// that a server shutting down isn't held up by them.
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// hooks returns h, which may be nil, with an Event hook that publishes each
+// event to the broker after calling the Event hook of h.
+func (b *eventBroker) hooks(h *Hooks) *Hooks {
+	hooks := &Hooks{}
+	if h != nil {
+		*hooks = *h
+	}
+	next := hooks.Event
+	hooks.Event = func(p Pet, event, line string) {
+		if next != nil {
+			next(p, event, line)
+		}
+		name, petType := petIdentity(p)
+		b.publish(streamEvent{Pet: name, Type: petType, Event: event, Line: line, TS: b.clock.Now()})
+	}
+	return hooks
+}
+
+// publish sends e to every subscriber with room for it.
+func (b *eventBroker) publish(e streamEvent) {
+	msg, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// subscribe returns a channel of the events published from now on, and a
+// function to stop them.
+func (b *eventBroker) subscribe() (<-chan []byte, func()) {
+	ch := make(chan []byte, eventBufferSize)
+	b.mu.Lock()
+	b.subscribers[ch] = true
+	b.mu.Unlock()
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+func (b *eventBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		b.serveWebSocket(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	events, stop := b.subscribe()
+	defer stop()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-b.done:
+			return
+		case msg := <-events:
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		}
+	}
+}
+
+// webSocketGUID is the key suffix of the WebSocket opening handshake, from
+// RFC 6455.
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// The WebSocket frame opcodes the broker uses.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+// webSocketWriteTimeout is the time a client has to take each frame sent
+// to it, after which its connection is closed, so that a client that stops
+// reading can't hold up the broker.
+const webSocketWriteTimeout = 10 * time.Second
+
+// serveWebSocket upgrades the connection of r to a WebSocket and sends
+// each event as a text message, until the client closes it. Pings from the
+// client are answered with pongs.
+func (b *eventBroker) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "unsupported WebSocket handshake", http.StatusBadRequest)
+		return
+	}
+	if !b.allowOrigin(r) {
+		http.Error(w, "cross-origin WebSocket handshakes are not allowed", http.StatusForbidden)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSockets are not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	accept := sha1.Sum([]byte(key + webSocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	var mu sync.Mutex
+	send := func(op byte, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout)); err != nil {
+			return err
+		}
+		if err := writeWebSocketFrame(rw.Writer, op, payload); err != nil {
+			return err
+		}
+		return rw.Flush()
+	}
+
+	events, stop := b.subscribe()
+	defer stop()
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		readWebSocketUntilClose(rw.Reader, func(payload []byte) error {
+			return send(opPong, payload)
+		})
+	}()
+	for {
+		select {
+		case <-closed:
+			send(opClose, nil)
+			return
+		case <-b.done:
+			send(opClose, nil)
+			return
+		case msg := <-events:
+			if err := send(opText, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// allowOrigin reports whether the page r came from may open a WebSocket:
+// requests without an Origin, which aren't sent by browsers, those from a
+// page of the host r was sent to, and those from one of the broker's
+// origins.
+func (b *eventBroker) allowOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range b.origins {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeWebSocketFrame writes payload to w as a single unmasked frame, as
+// servers send them.
+func writeWebSocketFrame(w io.Writer, op byte, payload []byte) error {
+	header := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readWebSocketUntilClose reads the frames the client sends, calling pong
+// with the payload of each ping and discarding the others, and returns once
+// it sends a close frame or the connection fails.
+func readWebSocketUntilClose(r *bufio.Reader, pong func(payload []byte) error) {
+	for {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return
+		}
+		n := uint64(header[1] & 0x7f)
+		switch n {
+		case 126:
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(r, ext); err != nil {
+				return
+			}
+			n = uint64(binary.BigEndian.Uint16(ext))
+		case 127:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(r, ext); err != nil {
+				return
+			}
+			n = binary.BigEndian.Uint64(ext)
+		}
+		// Frames from clients are masked with a four byte key.
+		var mask []byte
+		if header[1]&0x80 != 0 {
+			mask = make([]byte, 4)
+			if _, err := io.ReadFull(r, mask); err != nil {
+				return
+			}
+		}
+
+		op := header[0] & 0x0f
+		if op != opPing {
+			if _, err := io.CopyN(ioutil.Discard, r, int64(n)); err != nil {
+				return
+			}
+			if op == opClose {
+				return
+			}
+			continue
+		}
+		// Control frames, pings among them, carry at most 125 bytes.
+		if n > 125 {
+			return
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return
+		}
+		if mask != nil {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+		if err := pong(payload); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package petsounds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBrokerSSE(t *testing.T) {
+	t.Parallel()
+
+	broker := newEventBroker(fixedClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)))
+	server := httptest.NewServer(broker)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The client is subscribed once the headers arrive.
+	runner := &Runner{Out: ioutil.Discard, Hooks: broker.hooks(nil)}
+	assert.NoError(t, runner.do(&Cat{Name: "Ink", Sound: "meow"}, runner.Out, eventSay))
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if assert.NoError(t, err) {
+		assert.Equal(t,
+			`data: {"pet":"Ink","type":"cat","event":"say","line":"Ink meow","ts":"2020-06-01T12:00:00Z"}`+"\n", line)
+	}
+}
+
+func TestEventBrokerWebSocket(t *testing.T) {
+	t.Parallel()
+
+	broker := newEventBroker(fixedClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)))
+	server := httptest.NewServer(broker)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	// The key and accept are the example of RFC 6455.
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: pets\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	// The client is subscribed once the handshake is done.
+	runner := &Runner{Out: ioutil.Discard, Hooks: broker.hooks(nil)}
+	assert.NoError(t, runner.do(&Dog{Name: "Swinney", Breed: "Dachshund"}, runner.Out, eventAct))
+
+	header := make([]byte, 2)
+	if !assert.NoError(t, readFull(r, header)) {
+		return
+	}
+	assert.Equal(t, byte(0x80|opText), header[0])
+	payload := make([]byte, header[1])
+	if assert.NoError(t, readFull(r, payload)) {
+		assert.Equal(t,
+			`{"pet":"Swinney","type":"dog","event":"act","line":"Swinney the Dachshund plays","ts":"2020-06-01T12:00:00Z"}`,
+			string(payload))
+	}
+
+	// A masked ping from the client is answered with a pong of the same
+	// payload.
+	mask := []byte{1, 2, 3, 4}
+	ping := []byte("purr")
+	for i := range ping {
+		ping[i] ^= mask[i%4]
+	}
+	conn.Write(append(append([]byte{0x80 | opPing, 0x80 | 4}, mask...), ping...))
+	if assert.NoError(t, readFull(r, header)) {
+		assert.Equal(t, []byte{0x80 | opPong, 4}, header)
+	}
+	payload = make([]byte, 4)
+	if assert.NoError(t, readFull(r, payload)) {
+		assert.Equal(t, "purr", string(payload))
+	}
+
+	// A masked close frame from the client is answered with one of the
+	// server's own.
+	conn.Write(append([]byte{0x80 | opClose, 0x80}, mask...))
+	if assert.NoError(t, readFull(r, header)) {
+		assert.Equal(t, []byte{0x80 | opClose, 0}, header)
+	}
+}
+
+func TestEventBrokerWebSocketOrigin(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{name: "none", origin: "", want: http.StatusSwitchingProtocols},
+		{name: "same host", origin: "http://pets", want: http.StatusSwitchingProtocols},
+		{name: "allowed", origin: "https://pets.example.com", want: http.StatusSwitchingProtocols},
+		{name: "cross-origin", origin: "https://evil.example.com", want: http.StatusForbidden},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			broker := newEventBroker(nil)
+			broker.origins = []string{"https://pets.example.com/"}
+			defer broker.close()
+			server := httptest.NewServer(broker)
+			defer server.Close()
+
+			conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: pets\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+				"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n")
+			if tc.origin != "" {
+				fmt.Fprintf(conn, "Origin: %s\r\n", tc.origin)
+			}
+			fmt.Fprint(conn, "\r\n")
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func readFull(r io.Reader, buf []byte) error {
+	_, err := io.ReadFull(r, buf)
+	return err
+}
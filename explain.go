@@ -0,0 +1,138 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Origin is where the value of one of a pet's characteristics came from,
+// recorded while the pet is decoded. Characteristics that were never set
+// have no Origin, and keep the built in default of the pet's type.
+type Origin struct {
+	// Block is the block the characteristic was set in, characteristics or
+	// the defaults of the file or of the pet's household.
+	Block string
+	// References are the variables the value was computed from, such as
+	// env.CAT_SOUND or var.sound.
+	References []string
+	// Literal is whether the value was written as a constant.
+	Literal bool
+	// Range is the range of the expression of the value.
+	Range hcl.Range
+}
+
+// The blocks an Origin can be in.
+const (
+	originCharacteristics   = "characteristics"
+	originDefaults          = "defaults"
+	originHouseholdDefaults = "household defaults"
+)
+
+func (o *Origin) String() string {
+	if o == nil {
+		return "the built in default"
+	}
+	var s string
+	switch {
+	case len(o.References) > 0:
+		s = fmt.Sprintf("%s at %s", strings.Join(o.References, ", "), o.Range)
+	case o.Literal:
+		s = fmt.Sprintf("a literal at %s", o.Range)
+	default:
+		s = fmt.Sprintf("an expression at %s", o.Range)
+	}
+	if o.Block != originCharacteristics {
+		s += " in the " + o.Block
+	}
+	return s
+}
+
+// origins returns the Origins of the characteristics body sets, which is
+// a block of type block.
+func (k *petKind) origins(body hcl.Body, block string) map[string]*Origin {
+	content, _, _ := body.PartialContent(hcldec.ImpliedSchema(k.spec))
+	origins := map[string]*Origin{}
+	for name, attr := range content.Attributes {
+		o := &Origin{Block: block, Range: attr.Expr.Range(), References: []string{}}
+		for _, traversal := range attr.Expr.Variables() {
+			o.References = append(o.References, traversalName(traversal))
+		}
+		if len(o.References) == 0 {
+			_, diags := attr.Expr.Value(nil)
+			o.Literal = !diags.HasErrors()
+		}
+		origins[name] = o
+	}
+	return origins
+}
+
+// traversalName returns the variable traversal refers to, up to its first
+// attribute or key, as in env.CAT_SOUND or pet.Ink.
+func traversalName(traversal hcl.Traversal) string {
+	name := traversal.RootName()
+	if len(traversal) < 2 {
+		return name
+	}
+	switch step := traversal[1].(type) {
+	case hcl.TraverseAttr:
+		name += "." + step.Name
+	case hcl.TraverseIndex:
+		if step.Key.Type() == cty.String {
+			name += "." + step.Key.AsString()
+		}
+	}
+	return name
+}
+
+// petOrigin returns where the characteristic attr of p came from, or nil if
+// it has the built in default.
+func petOrigin(p Pet, attr string) *Origin {
+	return fieldsOf(p).origins[attr]
+}
+
+// Explain writes the value of the characteristic ref of a pet in config,
+// written as <pet name>.<characteristic>, and where the value came from.
+func Explain(w io.Writer, config *Config, ref string) error {
+	i := strings.LastIndex(ref, ".")
+	if i < 0 {
+		return fmt.Errorf("error in Explain: `%s` is not a characteristic, such as Ink.sound", ref)
+	}
+	name, attr := ref[:i], ref[i+1:]
+
+	names := []string{}
+	for _, p := range config.Pets {
+		petName, petType := petIdentity(p)
+		names = append(names, petName)
+		if petName != name {
+			continue
+		}
+
+		kind := petKinds[petType]
+		if _, ok := kind.spec[attr]; !ok {
+			attrs := []string{}
+			for a := range kind.spec {
+				attrs = append(attrs, a)
+			}
+			if suggestion := suggest(attr, attrs); suggestion != "" {
+				return fmt.Errorf("error in Explain: %s `%s` has no characteristic `%s`, did you mean `%s`?", petType, name, attr, suggestion)
+			}
+			return fmt.Errorf("error in Explain: %s `%s` has no characteristic `%s`", petType, name, attr)
+		}
+		self, err := selfValue(p)
+		if err != nil {
+			return fmt.Errorf("error in Explain: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s = %s\n  from %s\n", ref, valueString(self.GetAttr(attr)), petOrigin(p, attr))
+		return err
+	}
+
+	if suggestion := suggest(name, names); suggestion != "" {
+		return fmt.Errorf("error in Explain: no pet `%s`, did you mean `%s`?", name, suggestion)
+	}
+	return fmt.Errorf("error in Explain: no pet `%s`", name)
+}
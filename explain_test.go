@@ -0,0 +1,125 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const explainTestConfig = `variable "breed" {
+  default = "Corgi"
+}
+
+defaults {
+  cat {
+    sound = "purr"
+  }
+}
+
+household "smiths" {
+  defaults {
+    cat {
+      age = 3
+    }
+  }
+
+  pet "Neko" {
+    type = "cat"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = env.INK_SOUND
+    age   = 2
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = var.breed
+  }
+}
+`
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	config, err := DecodeConfig([]byte(explainTestConfig), "pets.hcl", LoadOptions{Env: map[string]string{"INK_SOUND": "meow"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tcs := []struct {
+		name string
+		ref  string
+		want string
+		err  string
+	}{
+		{
+			name: "environment variable",
+			ref:  "Ink.sound",
+			want: "Ink.sound = \"meow\"\n  from env.INK_SOUND at pets.hcl:26,13-26\n",
+		},
+		{
+			name: "literal",
+			ref:  "Ink.age",
+			want: "Ink.age = 2\n  from a literal at pets.hcl:27,13-14\n",
+		},
+		{
+			name: "variable",
+			ref:  "Swinney.breed",
+			want: "Swinney.breed = \"Corgi\"\n  from var.breed at pets.hcl:34,13-22\n",
+		},
+		{
+			name: "built in default",
+			ref:  "Swinney.sound",
+			want: "Swinney.sound = \"\"\n  from the built in default\n",
+		},
+		{
+			name: "defaults",
+			ref:  "smiths.Neko.sound",
+			want: "smiths.Neko.sound = \"purr\"\n  from a literal at pets.hcl:7,13-19 in the defaults\n",
+		},
+		{
+			name: "household defaults",
+			ref:  "smiths.Neko.age",
+			want: "smiths.Neko.age = 3\n  from a literal at pets.hcl:14,13-14 in the household defaults\n",
+		},
+		{
+			name: "unknown characteristic",
+			ref:  "Ink.snd",
+			err:  "error in Explain: cat `Ink` has no characteristic `snd`, did you mean `sound`?",
+		},
+		{
+			name: "unknown pet",
+			ref:  "Inky.sound",
+			err:  "error in Explain: no pet `Inky`, did you mean `Ink`?",
+		},
+		{
+			name: "not a characteristic",
+			ref:  "Ink",
+			err:  "error in Explain: `Ink` is not a characteristic, such as Ink.sound",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			err := Explain(out, config, tc.ref)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, out.String())
+			}
+		})
+	}
+}
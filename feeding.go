@@ -0,0 +1,102 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// FeedingHCL is a feeding block of a pet, one of its mealtimes. It is
+// represented in hcl, inside a pet block, as:
+//   feeding {
+//     schedule = "<cron expression, e.g. 0 8,18 * * *>"
+//     food     = "<what the pet is fed>"
+//   }
+// A pet with several kinds of meal has a feeding block for each.
+type FeedingHCL struct {
+	Schedule string `hcl:"schedule"`
+	Food     string `hcl:"food,optional"`
+}
+
+// Feeding is a validated feeding block.
+type Feeding struct {
+	Schedule *CronSchedule
+	Food     string
+}
+
+// NewFeedings checks the feeding blocks of a pet, returning nil if there are
+// none.
+func NewFeedings(feedings []*FeedingHCL) ([]*Feeding, error) {
+	if len(feedings) == 0 {
+		return nil, nil
+	}
+
+	all := []*Feeding{}
+	for _, f := range feedings {
+		schedule, err := ParseCronSchedule(f.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("error in NewFeedings: invalid schedule: %w", err)
+		}
+		all = append(all, &Feeding{Schedule: schedule, Food: f.Food})
+	}
+	return all, nil
+}
+
+// hcl returns the feeding block f was decoded from.
+func (f *Feeding) hcl() *FeedingHCL {
+	return &FeedingHCL{Schedule: f.Schedule.String(), Food: f.Food}
+}
+
+// Due returns the latest time of f that has come in the window before now,
+// and whether there is one. The minute of now is part of the window.
+func (f *Feeding) Due(now time.Time, window time.Duration) (time.Time, bool) {
+	for t := now.Truncate(time.Minute); now.Sub(t) < window; t = t.Add(-time.Minute) {
+		if f.Schedule.Matches(t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// food returns what f feeds a pet.
+func (f *Feeding) food() string {
+	if f.Food == "" {
+		return "food"
+	}
+	return f.Food
+}
+
+// petFeedings returns the feedings of p, if it has any.
+func petFeedings(p Pet) []*Feeding {
+	switch pet := unwrapPet(p).(type) {
+	case *Cat:
+		return pet.Feedings
+	case *Dog:
+		return pet.Feedings
+	case *CustomPet:
+		return pet.Feedings
+	}
+	return nil
+}
+
+// writeFeedings writes the feedings of pets to w as of now, with the next
+// time of each. With due, only the feedings that have come in the window
+// before now are written, with the time they were due.
+func writeFeedings(w io.Writer, pets []Pet, now time.Time, due bool, window time.Duration) {
+	for _, p := range pets {
+		name, _ := petIdentity(p)
+		for _, f := range petFeedings(p) {
+			if !due {
+				fmt.Fprintf(w, "%s gets %s at `%s`, next at %s\n",
+					name, f.food(), f.Schedule, f.Schedule.Next(now).Format(feedingTimeFormat))
+				continue
+			}
+			if at, ok := f.Due(now, window); ok {
+				fmt.Fprintf(w, "%s needs %s, due at %s\n", name, f.food(), at.Format(feedingTimeFormat))
+			}
+		}
+	}
+}
+
+// feedingTimeFormat is how feeding times are written.
+const feedingTimeFormat = "Mon Jan 2 15:04"
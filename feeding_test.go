@@ -0,0 +1,65 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeding(t *testing.T) {
+	pets, err := ReadConfig("testdata/feeding.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tcs := []struct {
+		name string
+		now  string
+		due  bool
+		want string
+	}{
+		{
+			name: "schedule",
+			now:  "2020-08-01 10:00",
+			want: "Ink gets kibble at `0 8,18 * * *`, next at Sat Aug 1 18:00\n" +
+				"Ink gets tuna at `30 12 * * sat,sun`, next at Sat Aug 1 12:30\n" +
+				"Swinney gets food at `0 7 * * *`, next at Sun Aug 2 07:00\n",
+		},
+		{
+			name: "due",
+			now:  "2020-08-01 08:20",
+			due:  true,
+			want: "Ink needs kibble, due at Sat Aug 1 08:00\nSwinney needs food, due at Sat Aug 1 07:00\n",
+		},
+		{
+			name: "due on the minute",
+			now:  "2020-08-01 12:30",
+			due:  true,
+			want: "Ink needs tuna, due at Sat Aug 1 12:30\n",
+		},
+		{
+			name: "nothing due",
+			now:  "2020-08-01 10:00",
+			due:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			writeFeedings(out, pets, cronTime(t, tc.now), tc.due, time.Hour+30*time.Minute)
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestFeedingInvalid(t *testing.T) {
+	_, err := ReadConfig("testdata/feeding_invalid.hcl")
+	assert.EqualError(t, err, "error in DecodeConfig decoding feeding of pet `Ink`: error in NewFeedings: "+
+		"invalid schedule: cron expression `0 25 * * *`: invalid value `25` in hour field, expected 0 to 23")
+}
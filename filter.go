@@ -0,0 +1,158 @@
+package petsounds
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PetFilter selects some of the pets of a configuration, so that a single
+// pet, a single type of pet, the pets of a household or the pets with a tag
+// can be run from a large shared file. An empty Type, Household, Names or
+// Tags selects pets of every type, household, name or tag. Pets are
+// selected by any one of Names, and by any one of Tags.
+type PetFilter struct {
+	Type      string
+	Household string
+	Names     []string
+	Tags      []string
+}
+
+// Match reports whether f selects p.
+func (f PetFilter) Match(p Pet) bool {
+	name, petType := petIdentity(p)
+	if f.Type != "" && f.Type != petType {
+		return false
+	}
+	if f.Household != "" && f.Household != petHousehold(p) {
+		return false
+	}
+	if len(f.Names) > 0 && !contains(f.Names, name) {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range petTags(p) {
+		if contains(f.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresPetType reports whether any of pets is of petType, as the pets of
+// the pet types a configuration declares are.
+func declaresPetType(pets []Pet, petType string) bool {
+	for _, p := range pets {
+		if _, t := petIdentity(p); t == petType {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether values contains s.
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// petTags returns the tags of a pet.
+func petTags(p Pet) []string {
+	switch pet := unwrapPet(p).(type) {
+	case *Cat:
+		return pet.Tags
+	case *Dog:
+		return pet.Tags
+	case *CustomPet:
+		return pet.Tags
+	}
+	return nil
+}
+
+// Apply removes the pets f does not select from config, along with their
+// interactions. It is an error for f to name a type, a household, a pet or
+// a tag that is not in the configuration, as that is most likely a typo.
+func (f PetFilter) Apply(config *Config) error {
+	if f.Type != "" {
+		if _, ok := petKinds[f.Type]; !ok && !declaresPetType(config.Pets, f.Type) {
+			return &ErrUnknownPetType{Type: f.Type}
+		}
+	}
+	names, declared := []string{}, map[string]bool{}
+	tags, tagged := []string{}, map[string]bool{}
+	households, housed := []string{}, map[string]bool{}
+	for _, p := range config.Pets {
+		name, _ := petIdentity(p)
+		names = append(names, name)
+		declared[name] = true
+		if h := petHousehold(p); h != "" && !housed[h] {
+			households = append(households, h)
+			housed[h] = true
+		}
+		for _, tag := range petTags(p) {
+			if !tagged[tag] {
+				tags = append(tags, tag)
+				tagged[tag] = true
+			}
+		}
+	}
+	if f.Household != "" && !housed[f.Household] {
+		if suggestion := suggest(f.Household, households); suggestion != "" {
+			return fmt.Errorf("no household named `%s`, did you mean `%s`?", f.Household, suggestion)
+		}
+		return fmt.Errorf("no household named `%s`", f.Household)
+	}
+	for _, n := range f.Names {
+		if declared[n] {
+			continue
+		}
+		if suggestion := suggest(n, names); suggestion != "" {
+			return fmt.Errorf("no pet named `%s`, did you mean `%s`?", n, suggestion)
+		}
+		return fmt.Errorf("no pet named `%s`", n)
+	}
+	for _, tag := range f.Tags {
+		if tagged[tag] {
+			continue
+		}
+		if suggestion := suggest(tag, tags); suggestion != "" {
+			return fmt.Errorf("no pet is tagged `%s`, did you mean `%s`?", tag, suggestion)
+		}
+		return fmt.Errorf("no pet is tagged `%s`", tag)
+	}
+
+	pets, kept := []Pet{}, map[string]bool{}
+	for _, p := range config.Pets {
+		if f.Match(p) {
+			pets = append(pets, p)
+			name, _ := petIdentity(p)
+			kept[name] = true
+		}
+	}
+	interactions := []*Interaction{}
+	for _, i := range config.Interactions {
+		if kept[i.From] && kept[i.To] {
+			interactions = append(interactions, i)
+		}
+	}
+	config.Pets, config.Interactions = pets, interactions
+	return nil
+}
+
+// stringsFlag is a flag that can be given more than once, collecting each
+// value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,134 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetFilter(t *testing.T) {
+	tcs := []struct {
+		name             string
+		filter           PetFilter
+		want             []string
+		wantInteractions int
+		wantErr          string
+	}{
+		{
+			name:             "everything",
+			want:             []string{"Ink", "Swinney"},
+			wantInteractions: 2,
+		},
+		{
+			name:   "type",
+			filter: PetFilter{Type: "dog"},
+			want:   []string{"Swinney"},
+		},
+		{
+			name:   "names",
+			filter: PetFilter{Names: []string{"Ink"}},
+			want:   []string{"Ink"},
+		},
+		{
+			name:   "type and names",
+			filter: PetFilter{Type: "cat", Names: []string{"Ink", "Swinney"}},
+			want:   []string{"Ink"},
+		},
+		{
+			name:    "unknown type",
+			filter:  PetFilter{Type: "fish"},
+			wantErr: "unknown pet type `fish`",
+		},
+		{
+			name:    "misspelled type",
+			filter:  PetFilter{Type: "dgo"},
+			wantErr: "unknown pet type `dgo`, did you mean `dog`?",
+		},
+		{
+			name:    "unknown name",
+			filter:  PetFilter{Names: []string{"Swiney"}},
+			wantErr: "no pet named `Swiney`, did you mean `Swinney`?",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := LoadConfig("testdata/interactions.hcl")
+			if !assert.Nil(t, err) {
+				return
+			}
+			err = tc.filter.Apply(config)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if assert.Nil(t, err) {
+				got := []string{}
+				for _, p := range config.Pets {
+					name, _ := petIdentity(p)
+					got = append(got, name)
+				}
+				assert.Equal(t, tc.want, got)
+				assert.Len(t, config.Interactions, tc.wantInteractions)
+			}
+		})
+	}
+}
+
+func TestPetFilterTags(t *testing.T) {
+	tcs := []struct {
+		name    string
+		filter  PetFilter
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "tag",
+			filter: PetFilter{Tags: []string{"indoor"}},
+			want:   []string{"Ink", "Neko"},
+		},
+		{
+			name:   "any tag",
+			filter: PetFilter{Tags: []string{"senior", "outdoor"}},
+			want:   []string{"Ink", "Swinney"},
+		},
+		{
+			name:   "tag and name",
+			filter: PetFilter{Names: []string{"Neko", "Swinney"}, Tags: []string{"indoor"}},
+			want:   []string{"Neko"},
+		},
+		{
+			name:    "unknown tag",
+			filter:  PetFilter{Tags: []string{"indoors"}},
+			wantErr: "no pet is tagged `indoors`, did you mean `indoor`?",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := LoadConfig("testdata/tags.hcl")
+			if !assert.Nil(t, err) {
+				return
+			}
+			err = tc.filter.Apply(config)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if assert.Nil(t, err) {
+				got := []string{}
+				for _, p := range config.Pets {
+					name, _ := petIdentity(p)
+					got = append(got, name)
+				}
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
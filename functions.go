@@ -0,0 +1,246 @@
+package petsounds
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// contextFunction is a function that configurations can call. The
+// evaluation context is built from contextFunctions, and the functions
+// command documents them, so the two can't disagree.
+type contextFunction struct {
+	name        string
+	description string
+	// new returns the function for decoding a configuration with opts
+	// within ctx.
+	new func(ctx context.Context, opts LoadOptions) function.Function
+}
+
+// contextFunctions are the functions of the evaluation context.
+var contextFunctions = []contextFunction{
+	{
+		name:        "random",
+		description: "Returns one of its arguments, chosen at random. Lists of strings contribute each of their elements. Given a count and a separator first, as in random(2, \", \", var.breeds), it returns that many different values, joined by the separator.",
+		new: func(_ context.Context, opts LoadOptions) function.Function {
+			opts = opts.withDefaults()
+			return newRandomFunc(opts.random, opts.tracer)
+		},
+	},
+	{
+		name:        "shuffle",
+		description: "Returns a list with its elements in an order chosen at random.",
+		new: func(_ context.Context, opts LoadOptions) function.Function {
+			return newShuffleFunc(opts.withDefaults().random)
+		},
+	},
+	{
+		name:        "env",
+		description: "Returns the environment variable name, like the env namespace, or default when it is not set.",
+		new: func(_ context.Context, opts LoadOptions) function.Function {
+			return newEnvFunc(opts)
+		},
+	},
+	{
+		name:        "sensitive",
+		description: "Returns its argument, marking it as sensitive so that the characteristics of pets set with it are written as (sensitive) in output, traces and errors.",
+		new: func(_ context.Context, opts LoadOptions) function.Function {
+			return newSensitiveFunc(false)
+		},
+	},
+	{
+		name:        "timestamp",
+		description: "Returns the current time, as an RFC 3339 timestamp.",
+		new: func(_ context.Context, opts LoadOptions) function.Function {
+			return newTimestampFunc(opts.Clock)
+		},
+	},
+	{
+		name:        "timeadd",
+		description: "Returns the RFC 3339 timestamp timestamp plus the duration, such as \"24h\" or \"-1h30m\".",
+		new:         func(context.Context, LoadOptions) function.Function { return stdlib.TimeAddFunc },
+	},
+	{
+		name:        "formatdate",
+		description: "Returns the RFC 3339 timestamp time written with format, such as \"DD MMM YYYY\".",
+		new:         func(context.Context, LoadOptions) function.Function { return stdlib.FormatDateFunc },
+	},
+	{
+		name:        "length",
+		description: "Returns the number of characters in a string, or the number of elements in a collection.",
+		new:         func(context.Context, LoadOptions) function.Function { return lengthFunc },
+	},
+	{
+		name:        "vault",
+		description: "Returns the field key of the Vault secret at path. Reading secrets has to be enabled with -vault.",
+		new: func(ctx context.Context, opts LoadOptions) function.Function {
+			return vaultFunc(ctx, opts.Vault)
+		},
+	},
+}
+
+// contextVariable is a variable that configurations can refer to, for the
+// functions command to document.
+type contextVariable struct {
+	name        string
+	description string
+}
+
+// contextVariables are the variables of the evaluation context, and of the
+// blocks that add their own.
+var contextVariables = []contextVariable{
+	{
+		name:        environmentKey + "." + catSoundKey,
+		description: fmt.Sprintf("The sound cats make, from the environment, defaulting to %q.", defaultCatSound),
+	},
+	{
+		name:        environmentKey + ".<KEY>",
+		description: "Each variable of the -env-file, unless the environment sets it.",
+	},
+	{
+		name:        "var.<name>",
+		description: "The value of each variable block, or the module input that sets it.",
+	},
+	{
+		name:        "count.index",
+		description: "The index of a module instance, in modules that set count.",
+	},
+	{
+		name:        petsKey + ".<name>",
+		description: "Another pet, by name, in characteristics. Pets are decoded after the pets they refer to.",
+	},
+	{
+		name:        soundPackKey + ".<type>.<key>",
+		description: "Each sound of the sound_pack file, by the type of pet it is for and its key.",
+	},
+	{
+		name:        "self",
+		description: "The name and type of the pet, in its characteristics, and the decoded pet in validation, precondition and postcondition blocks.",
+	},
+}
+
+// functionSignature returns the signature of fn, called name, as in
+// "vault(path string, key string) string".
+func functionSignature(name string, fn function.Function) string {
+	params, types := []string{}, []cty.Type{}
+	for _, p := range fn.Params() {
+		params = append(params, p.Name+" "+typeexpr.TypeString(p.Type))
+		// cty gives up on the return type of a call with arguments of any
+		// type, so strings stand in for them.
+		ty := p.Type
+		if ty == cty.DynamicPseudoType {
+			ty = cty.String
+		}
+		types = append(types, ty)
+	}
+	if p := fn.VarParam(); p != nil {
+		params = append(params, p.Name+"... "+typeexpr.TypeString(p.Type))
+	}
+
+	ret := "any"
+	if ty, err := fn.ReturnType(types); err == nil {
+		ret = typeexpr.TypeString(ty)
+	}
+	return fmt.Sprintf("%s(%s) %s", name, strings.Join(params, ", "), ret)
+}
+
+// functionsCommand prints the reference of the functions and variables
+// configurations can use.
+func functionsCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		return writeFunctions(os.Stdout)
+	}
+}
+
+// writeFunctions writes the reference of contextFunctions and
+// contextVariables to w.
+func writeFunctions(w io.Writer) error {
+	b := &strings.Builder{}
+	b.WriteString("Functions:\n")
+	for _, f := range contextFunctions {
+		fn := f.new(context.Background(), LoadOptions{})
+		fmt.Fprintf(b, "  %s\n      %s\n", functionSignature(f.name, fn), f.description)
+	}
+	b.WriteString("\nVariables:\n")
+	for _, v := range contextVariables {
+		fmt.Fprintf(b, "  %s\n      %s\n", v.name, v.description)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// EvalExpression evaluates src, a single expression, in the context that
+// configurations are decoded in with opts, for trying out functions and
+// variables without writing a configuration.
+func EvalExpression(ctx context.Context, src string, opts LoadOptions) (cty.Value, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "<eval>", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("error in EvalExpression parsing `%s`: %w", src, diags)
+	}
+	evalContext, err := createContext(ctx, opts.withDefaults())
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("error in EvalExpression: %w", err)
+	}
+	evalContext = evalContext.NewChild()
+	evalContext.Functions = sensitiveFunctions
+	value, diags := expr.Value(evalContext)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("error in EvalExpression evaluating `%s`: %w", src, diags)
+	}
+	return value, nil
+}
+
+// evalCommand writes the value of the expression given as its argument.
+// Strings are written as they are, so they can be used in scripts, and
+// other values as they would be written in HCL.
+func evalCommand(flags *flag.FlagSet) func(args []string) error {
+	var envFile string
+	var vault bool
+	var seed int64
+	flags.StringVar(&envFile, "env-file", "", "a file of KEY=VALUE lines to add to the env namespace")
+	flags.BoolVar(&vault, "vault", false, "enable the vault function, reading secrets from the Vault at VAULT_ADDR")
+	flags.Int64Var(&seed, "seed", 0, "seed the random function, for reproducible values; 0 seeds from the time")
+
+	return func(args []string) error {
+		if len(args) != 1 {
+			return withExitCode(exitUsage, fmt.Errorf("eval takes a single expression, such as 'random(\"meow\", \"purr\")'"))
+		}
+		opts := LoadOptions{}
+		if seed != 0 {
+			opts.Rand = rand.NewSource(seed)
+		}
+		var err error
+		if envFile != "" {
+			if opts.Env, err = ReadEnvFile(envFile); err != nil {
+				return withExitCode(exitParse, err)
+			}
+		}
+		if vault {
+			if opts.Vault, err = NewVaultClient(); err != nil {
+				return err
+			}
+		}
+
+		value, err := EvalExpression(context.Background(), args[0], opts)
+		if err != nil {
+			return withExitCode(exitDecode, err)
+		}
+		if value.Type() == cty.String && value.IsKnown() && !value.IsNull() && !isSensitive(value) {
+			_, err = fmt.Println(value.AsString())
+			return err
+		}
+		_, err = fmt.Println(valueString(value))
+		return err
+	}
+}
@@ -0,0 +1,293 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFunctionSignature(t *testing.T) {
+	tcs := []struct {
+		name string
+		want string
+	}{
+		{name: "random", want: "random(values... any) string"},
+		{name: "shuffle", want: "shuffle(list list(any)) list(any)"},
+		{name: "env", want: "env(name string, default string) string"},
+		{name: "sensitive", want: "sensitive(value any) string"},
+		{name: "length", want: "length(value any) number"},
+		{name: "vault", want: "vault(path string, key string) string"},
+	}
+
+	evalContext, err := createContext(context.Background(), LoadOptions{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fn, ok := evalContext.Functions[tc.name]
+			if assert.True(t, ok, "no function %s in the context", tc.name) {
+				assert.Equal(t, tc.want, functionSignature(tc.name, fn))
+			}
+		})
+	}
+	assert.Len(t, evalContext.Functions, len(contextFunctions))
+}
+
+func TestWriteFunctions(t *testing.T) {
+	out := &bytes.Buffer{}
+	if assert.Nil(t, writeFunctions(out)) {
+		for _, f := range contextFunctions {
+			assert.Contains(t, out.String(), "  "+f.name+"(")
+		}
+		for _, v := range contextVariables {
+			assert.Contains(t, out.String(), "  "+v.name+"\n")
+		}
+	}
+}
+
+func TestRandomFuncNoValues(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = random()
+  }
+}
+`)
+	_, err := DecodeConfig(src, "random.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "random needs at least one value to choose from")
+	}
+}
+
+func TestRandomFuncLists(t *testing.T) {
+	t.Parallel()
+
+	breeds := cty.ListVal([]cty.Value{cty.StringVal("Corgi"), cty.StringVal("Pug")})
+	tcs := []struct {
+		name string
+		args []cty.Value
+		want []string
+		err  string
+	}{
+		{
+			name: "list",
+			args: []cty.Value{breeds},
+			want: []string{"Corgi", "Pug"},
+		},
+		{
+			name: "count and separator",
+			args: []cty.Value{cty.NumberIntVal(2), cty.StringVal(", "), breeds},
+			want: []string{"Corgi, Pug", "Pug, Corgi"},
+		},
+		{
+			name: "count and separator varargs",
+			args: []cty.Value{cty.NumberIntVal(1), cty.StringVal("-"), cty.StringVal("a"), cty.StringVal("b")},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "numbers",
+			args: []cty.Value{cty.NumberIntVal(2), cty.NumberIntVal(3)},
+			want: []string{"2", "3"},
+		},
+		{
+			name: "count too large",
+			args: []cty.Value{cty.NumberIntVal(3), cty.StringVal(", "), breeds},
+			err:  "random can't choose 3 different values from 2",
+		},
+		{
+			name: "count not whole",
+			args: []cty.Value{cty.NumberFloatVal(1.5), cty.StringVal(", "), breeds},
+			err:  "random needs a whole number of values to choose, of at least 1",
+		},
+		{
+			name: "count without values",
+			args: []cty.Value{cty.NumberIntVal(2), cty.StringVal(", ")},
+			err:  "random needs at least one value to choose from",
+		},
+		{
+			name: "tuple",
+			args: []cty.Value{cty.TupleVal([]cty.Value{cty.StringVal("mew"), cty.True})},
+			want: []string{"mew", "true"},
+		},
+		{
+			name: "empty",
+			args: []cty.Value{cty.ListValEmpty(cty.String)},
+			err:  "random needs at least one value to choose from",
+		},
+		{
+			name: "not strings",
+			args: []cty.Value{cty.StringVal("mew"), cty.ListVal([]cty.Value{cty.EmptyObjectVal})},
+			err:  "random only chooses from strings and lists of strings",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fn := newRandomFunc(newLockedRand(rand.NewSource(1)), nil)
+			seen := map[string]bool{}
+			for i := 0; i < 50; i++ {
+				val, err := fn.Call(tc.args)
+				if tc.err != "" {
+					assert.EqualError(t, err, tc.err)
+					return
+				}
+				if !assert.NoError(t, err) {
+					return
+				}
+				seen[val.AsString()] = true
+			}
+			for _, want := range tc.want {
+				assert.True(t, seen[want], "never chose %q", want)
+			}
+			assert.Len(t, seen, len(tc.want))
+		})
+	}
+}
+
+func TestShuffleFunc(t *testing.T) {
+	t.Parallel()
+
+	fn := newShuffleFunc(newLockedRand(rand.NewSource(1)))
+	list := cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("c"), cty.StringVal("d")})
+	orders := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		val, err := fn.Call([]cty.Value{list})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, cty.List(cty.String), val.Type())
+		assert.ElementsMatch(t, list.AsValueSlice(), val.AsValueSlice())
+		orders[valueString(val)] = true
+	}
+	assert.True(t, len(orders) > 1, "always shuffled into %v", orders)
+
+	empty := cty.ListValEmpty(cty.String)
+	val, err := fn.Call([]cty.Value{empty})
+	if assert.NoError(t, err) {
+		assert.True(t, val.RawEquals(empty))
+	}
+	_, err = fn.Call([]cty.Value{cty.StringVal("a")})
+	assert.Error(t, err)
+}
+
+func TestEnvFunc(t *testing.T) {
+	t.Parallel()
+
+	os.Setenv("ENV_FUNC_BREED", "Corgi")
+	defer os.Unsetenv("ENV_FUNC_BREED")
+	fn := newEnvFunc(LoadOptions{Env: map[string]string{"ENV_FUNC_BREED": "Pug", "ENV_FUNC_SOUND": "yip"}})
+	tcs := []struct {
+		name string
+		want string
+	}{
+		{name: "ENV_FUNC_BREED", want: "Corgi"},
+		{name: "ENV_FUNC_SOUND", want: "yip"},
+		{name: "ENV_FUNC_UNSET", want: "mutt"},
+	}
+	for _, tc := range tcs {
+		val, err := fn.Call([]cty.Value{cty.StringVal(tc.name), cty.StringVal("mutt")})
+		if assert.NoError(t, err) {
+			assert.Equal(t, tc.want, val.AsString(), tc.name)
+		}
+	}
+}
+
+func TestEnvVariables(t *testing.T) {
+	t.Parallel()
+
+	pets := mustParseEnv(t, `pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = env.INK_SOUND
+  }
+}
+`, map[string]string{"INK_SOUND": "mrrp"})
+	assert.Equal(t, "mrrp", pets[0].(*Cat).Sound)
+}
+
+func TestEvalExpression(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		src  string
+		want cty.Value
+		err  string
+	}{
+		{
+			name: "random",
+			src:  `random("mew", "mew")`,
+			want: cty.StringVal("mew"),
+		},
+		{
+			name: "env",
+			src:  `"${env.EVAL_SOUND}s"`,
+			want: cty.StringVal("purrs"),
+		},
+		{
+			name: "length",
+			src:  `length(["a", "b"])`,
+			want: cty.NumberIntVal(2),
+		},
+		{
+			name: "invalid",
+			src:  `random(`,
+			err:  "error in EvalExpression parsing `random(`",
+		},
+		{
+			name: "unknown function",
+			src:  `bark()`,
+			err:  "Call to unknown function",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := EvalExpression(context.Background(), tc.src, LoadOptions{Env: map[string]string{"EVAL_SOUND": "purr"}})
+			if tc.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.err)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.True(t, tc.want.RawEquals(value), "got %#v", value)
+			}
+		})
+	}
+}
+
+func TestDateFuncs(t *testing.T) {
+	t.Parallel()
+
+	now := fixedClock(time.Date(2021, 5, 31, 12, 0, 0, 0, time.UTC))
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = formatdate("DD MMM YYYY", timeadd(timestamp(), "48h"))
+  }
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Clock: now})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "02 Jun 2021", config.Pets[0].(*Cat).Sound)
+	}
+}
@@ -0,0 +1,31 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// FuzzReadConfig checks that no configuration, however malformed, makes
+// decoding panic. The testdata configurations are the seed corpus. Run it
+// with:
+//   go test -fuzz FuzzReadConfig
+func FuzzReadConfig(f *testing.F) {
+	filenames, err := filepath.Glob("testdata/*.hcl")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, filename := range filenames {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(src)
+	}
+	f.Add([]byte(`pet "Ink" { type = "cat" characteristics { sound = random() } }`))
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		// Errors are expected, panics are not.
+		DecodeConfig(src, "fuzz.hcl", LoadOptions{})
+	})
+}
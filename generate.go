@@ -0,0 +1,50 @@
+package petsounds
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// generateNames are the names given to generated pets. Once they have all
+// been used, they are used again with a number, as Ink 2.
+var generateNames = []string{
+	"Ink", "Swinney", "Ada", "Biscuit", "Pepper", "Mochi", "Olive", "Juniper",
+	"Waffles", "Luna", "Milo", "Nala", "Otis", "Pickles", "Rosie", "Scout",
+	"Tofu", "Winston", "Ziggy", "Bean", "Clover", "Dash", "Ember", "Fig",
+	"Gus", "Hazel", "Iggy", "Jasper", "Kiwi", "Lola", "Maple", "Noodle",
+}
+
+// GeneratePets returns n random pets, of the types in types, or of every
+// type if types is empty. The same r gives the same pets.
+func GeneratePets(r *rand.Rand, n int, types []string) ([]Pet, error) {
+	if len(types) == 0 {
+		types = petKindNames()
+	}
+	for _, t := range types {
+		if _, ok := petKinds[t]; !ok {
+			return nil, fmt.Errorf("error in GeneratePets: %w", &ErrUnknownPetType{Type: t})
+		}
+	}
+
+	pets := []Pet{}
+	for i := 0; i < n; i++ {
+		name := generateNames[i%len(generateNames)]
+		if round := i / len(generateNames); round > 0 {
+			name = fmt.Sprintf("%s %d", name, round+1)
+		}
+		pets = append(pets, petKinds[types[r.Intn(len(types))]].generate(r, name))
+	}
+	return pets, nil
+}
+
+// splitTypes splits a comma separated list of pet types.
+func splitTypes(s string) []string {
+	types := []string{}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
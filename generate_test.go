@@ -0,0 +1,76 @@
+package petsounds
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePets(t *testing.T) {
+	tcs := []struct {
+		name  string
+		n     int
+		types []string
+	}{
+		{
+			name: "every type",
+			n:    50,
+		},
+		{
+			name:  "cats",
+			n:     5,
+			types: []string{"cat"},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pets, err := GeneratePets(rand.New(rand.NewSource(1)), tc.n, tc.types)
+			if !assert.NoError(t, err) || !assert.Len(t, pets, tc.n) {
+				return
+			}
+			names := map[string]bool{}
+			for _, p := range pets {
+				name, petType := petIdentity(p)
+				assert.False(t, names[name], "duplicate name %s", name)
+				names[name] = true
+				if len(tc.types) > 0 {
+					assert.Contains(t, tc.types, petType)
+				}
+			}
+
+			// The same seed gives the same pets.
+			again, err := GeneratePets(rand.New(rand.NewSource(1)), tc.n, tc.types)
+			if assert.NoError(t, err) {
+				assert.Equal(t, pets, again)
+			}
+		})
+	}
+
+	_, err := GeneratePets(rand.New(rand.NewSource(1)), 1, []string{"fish"})
+	var unknown *ErrUnknownPetType
+	assert.True(t, errors.As(err, &unknown))
+}
+
+// TestGeneratePetsValid checks that generated pets are written as a
+// configuration that loads.
+func TestGeneratePetsValid(t *testing.T) {
+	pets, err := GeneratePets(rand.New(rand.NewSource(2)), 40, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, WriteConfig(out, pets)) {
+		return
+	}
+
+	loaded := mustParse(t, out.String())
+	assert.Len(t, loaded, 40)
+	assert.False(t, ValidateBreeds(&Config{Pets: loaded}).HasErrors())
+}
@@ -0,0 +1,89 @@
+package petsounds
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// DecodePets decodes the pet blocks of body whose type is the type of T,
+// with the same two passes configurations are decoded with: the type of
+// each pet is read first, and then the characteristics of the pets of T's
+// type are decoded with a specification built from the hcl tags of T's
+// struct. Pets of other types are skipped, so programs that embed
+// pet-sounds can decode each type of pet of their own in turn:
+//   fish, diags := DecodePets[*Fish](body, ctx)
+// T must be a pointer to a struct, whose Name field, if it has one, is set
+// to the pet's name. The built in types, *Cat and *Dog, start out with
+// their defaults.
+func DecodePets[T Pet](body hcl.Body, ctx *hcl.EvalContext) ([]T, hcl.Diagnostics) {
+	petType := PetTypeName[T]()
+	structType := reflect.TypeOf((*T)(nil)).Elem().Elem()
+	kind, builtin := petKinds[petType]
+	if !builtin {
+		kind = newPetKind(reflect.Zero(structType).Interface(), &petKind{})
+	}
+
+	petsHCL := &struct {
+		Pets   []*PetHCL `hcl:"pet,block"`
+		Remain hcl.Body  `hcl:",remain"`
+	}{}
+	if diags := gohcl.DecodeBody(body, ctx, petsHCL); diags.HasErrors() {
+		return nil, diags
+	}
+
+	pets, diags := []T{}, hcl.Diagnostics{}
+	for _, p := range petsHCL.Pets {
+		if p.Type != petType {
+			continue
+		}
+		var pet T
+		if builtin {
+			pet = kind.new(p, nil, nil, nil, nil).(T)
+		} else {
+			pet = reflect.New(structType).Interface().(T)
+			if name := reflect.ValueOf(pet).Elem().FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String {
+				name.SetString(p.Name)
+			}
+		}
+		if p.CharacteristicsHCL != nil {
+			decodeDiags := kind.decode(p.CharacteristicsHCL.HCL, headerContext(p.Name, petType, ctx), pet)
+			diags = append(diags, decodeDiags...)
+			if decodeDiags.HasErrors() {
+				continue
+			}
+		}
+		pets = append(pets, pet)
+	}
+	return pets, diags
+}
+
+// PetTypeName returns the name of the type of pet T is in configurations,
+// as in the type attribute of its pet blocks. It is what the Type method of
+// T returns if it has one, which is called on a nil T, or else the name of
+// T's struct in lower case.
+func PetTypeName[T Pet]() string {
+	var pet T
+	if typed, ok := Pet(pet).(interface{ Type() string }); ok {
+		return typed.Type()
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.ToLower(t.Name())
+}
+
+// PetsOfType returns the pets of type T among pets, unwrapping the pets
+// middleware has wrapped, in the order they come in.
+func PetsOfType[T Pet](pets []Pet) []T {
+	typed := []T{}
+	for _, p := range pets {
+		if pet, ok := unwrapPet(p).(T); ok {
+			typed = append(typed, pet)
+		}
+	}
+	return typed
+}
@@ -0,0 +1,120 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fish is a pet of a program that embeds pet-sounds.
+type Fish struct {
+	Name   string
+	Bubble string `hcl:"bubble"`
+	Fins   *int   `hcl:"fins,optional"`
+}
+
+func (f *Fish) Say(w io.Writer) { fmt.Fprintf(w, "%s %s\n", f.Name, f.Bubble) }
+func (f *Fish) Act(w io.Writer) { fmt.Fprintf(w, "%s swims\n", f.Name) }
+
+// Goldfish is a Fish with its own type name.
+type Goldfish struct{ Fish }
+
+func (*Goldfish) Type() string { return "goldfish" }
+
+const genericsTestConfig = `pet "Wanda" {
+  type = "fish"
+  characteristics {
+    bubble = "blub"
+    fins   = 3
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "purr"
+  }
+}
+
+pet "Bubbles" {
+  type = "fish"
+  characteristics {
+    bubble = "${self.name} blubs"
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+}
+`
+
+func TestDecodePets(t *testing.T) {
+	t.Parallel()
+
+	file, diags := hclsyntax.ParseConfig([]byte(genericsTestConfig), "pets.hcl", hcl.InitialPos)
+	if !assert.False(t, diags.HasErrors(), diags.Error()) {
+		return
+	}
+
+	fish, diags := DecodePets[*Fish](file.Body, nil)
+	if assert.False(t, diags.HasErrors(), diags.Error()) {
+		assert.Equal(t, []*Fish{
+			{Name: "Wanda", Bubble: "blub", Fins: intPtr(3)},
+			{Name: "Bubbles", Bubble: "Bubbles blubs"},
+		}, fish)
+	}
+
+	cats, diags := DecodePets[*Cat](file.Body, nil)
+	if assert.False(t, diags.HasErrors(), diags.Error()) && assert.Len(t, cats, 2) {
+		assert.Equal(t, "purr", cats[0].Sound)
+		// Cats start out with the default sound.
+		assert.Equal(t, defaultCatSound, cats[1].Sound)
+	}
+
+	goldfish, diags := DecodePets[*Goldfish](file.Body, nil)
+	assert.False(t, diags.HasErrors(), diags.Error())
+	assert.Empty(t, goldfish)
+}
+
+func TestDecodePetsInvalid(t *testing.T) {
+	t.Parallel()
+
+	file, diags := hclsyntax.ParseConfig([]byte(`pet "Wanda" {
+  type = "fish"
+  characteristics {
+    fins = 3
+  }
+}
+`), "pets.hcl", hcl.InitialPos)
+	if !assert.False(t, diags.HasErrors(), diags.Error()) {
+		return
+	}
+	fish, diags := DecodePets[*Fish](file.Body, nil)
+	assert.Empty(t, fish)
+	assert.Contains(t, diags.Error(), `The argument "bubble" is required`)
+}
+
+func TestPetTypeName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "cat", PetTypeName[*Cat]())
+	assert.Equal(t, "fish", PetTypeName[*Fish]())
+	assert.Equal(t, "goldfish", PetTypeName[*Goldfish]())
+}
+
+func TestPetsOfType(t *testing.T) {
+	t.Parallel()
+
+	ink, swinney := &Cat{Name: "Ink"}, &Dog{Name: "Swinney"}
+	pets := []Pet{ink, swinney, chain(&Cat{Name: "Neko"}, nil)}
+	cats := PetsOfType[*Cat](pets)
+	if assert.Len(t, cats, 2) {
+		assert.Equal(t, ink, cats[0])
+		assert.Equal(t, "Neko", cats[1].Name)
+	}
+	assert.Equal(t, []*Dog{swinney}, PetsOfType[*Dog](pets))
+}
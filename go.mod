@@ -1,9 +1,33 @@
 module github.com/russellrollins/pet-sounds
 
-go 1.14
+go 1.18
 
 require (
-	github.com/hashicorp/hcl/v2 v2.6.0
+	github.com/BurntSushi/toml v0.3.1
+	github.com/aws/aws-sdk-go v1.34.0
+	github.com/hashicorp/hcl/v2 v2.8.2
 	github.com/stretchr/testify v1.6.1
 	github.com/zclconf/go-cty v1.5.1
+	go.etcd.io/bbolt v1.3.6
+	go.starlark.net v0.0.0-20230525235612-a134d8f9ddca
+	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
+	golang.org/x/term v0.5.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
+)
+
+require (
+	cloud.google.com/go v0.65.0 // indirect
+	github.com/agext/levenshtein v1.2.1 // indirect
+	github.com/apparentlymart/go-textseg/v12 v12.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.4.2 // indirect
+	github.com/google/go-cmp v0.5.1 // indirect
+	github.com/jmespath/go-jmespath v0.3.0 // indirect
+	github.com/mitchellh/go-wordwrap v0.0.0-20150314170334-ad45545899c7 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.0.0-20200822124328-c89045814202 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.3.3 // indirect
+	google.golang.org/appengine v1.6.6 // indirect
+	google.golang.org/protobuf v1.25.0 // indirect
 )
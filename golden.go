@@ -0,0 +1,56 @@
+package petsounds
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// goldenPath returns the golden file in dir for the output of the
+// configuration file configFile, named after it as <name>.golden.
+func goldenPath(dir, configFile string) string {
+	base := filepath.Base(configFile)
+	return filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+".golden")
+}
+
+// checkGolden compares got, the output of a run of a configuration, with
+// the golden file at filename. A golden file that does not exist yet is
+// recorded from got, as is one that differs when update is set.
+func checkGolden(filename string, got []byte, update bool) error {
+	want, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) || (err == nil && update) {
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return fmt.Errorf("error in checkGolden: %w", err)
+		}
+		if err := ioutil.WriteFile(filename, got, 0644); err != nil {
+			return fmt.Errorf("error in checkGolden: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error in checkGolden: %w", err)
+	}
+	if bytes.Equal(want, got) {
+		return nil
+	}
+
+	// Point at the first line that differs.
+	wantLines, gotLines := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return fmt.Errorf(
+				"output differs from golden file `%s` at line %d:\n  want: %q\n  got:  %q", filename, i+1, w, g,
+			)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package petsounds
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "record the golden files in testdata/golden again")
+
+// assertGolden checks got against the golden file testdata/golden/<name>.golden,
+// recording it when it does not exist yet or when the tests are run with
+// -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	assert.NoError(t, checkGolden(goldenPath(filepath.Join("testdata", "golden"), name), got, *updateGolden))
+}
+
+func TestGoldenOutput(t *testing.T) {
+	tcs := []struct {
+		filename string
+		style    *Style
+	}{
+		{filename: "testdata/basic.hcl"},
+		{filename: "testdata/moods.hcl"},
+		{filename: "testdata/art.hcl", style: &Style{Art: true}},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.filename, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := LoadConfig(tc.filename)
+			if !assert.NoError(t, err) {
+				return
+			}
+			SetStyle(config.Pets, tc.style)
+			out := &bytes.Buffer{}
+			runner := &Runner{Out: out}
+			if assert.NoError(t, runner.Run(config.Pets)) {
+				runner.Interact(config.Interactions)
+				assertGolden(t, tc.filename, out.Bytes())
+			}
+		})
+	}
+}
+
+func TestCheckGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := goldenPath(filepath.Join(dir, "golden"), "pets.hcl")
+	assert.Equal(t, filepath.Join(dir, "golden", "pets.golden"), filename)
+
+	// The first run is recorded, and later runs compared with it.
+	assert.NoError(t, checkGolden(filename, []byte("Ink meow\nInk snoozes\n"), false))
+	assert.NoError(t, checkGolden(filename, []byte("Ink meow\nInk snoozes\n"), false))
+	err = checkGolden(filename, []byte("Ink meow\nInk purrs\n"), false)
+	assert.EqualError(t, err, "output differs from golden file `"+filename+"` at line 2:\n"+
+		"  want: \"Ink snoozes\"\n  got:  \"Ink purrs\"")
+	err = checkGolden(filename, []byte("Ink meow\n"), false)
+	assert.EqualError(t, err, "output differs from golden file `"+filename+"` at line 2:\n"+
+		"  want: \"Ink snoozes\"\n  got:  \"\"")
+
+	// Updating records the run again.
+	assert.NoError(t, checkGolden(filename, []byte("Ink meow\n"), true))
+	assert.NoError(t, checkGolden(filename, []byte("Ink meow\n"), false))
+}
@@ -0,0 +1,91 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT renders config as a Graphviz DOT digraph. Every pet is a node,
+// labelled with its type, and the pets of each household are drawn
+// together in a cluster labelled with its name. Owners are box nodes, with
+// an edge to each of their pets. Every interaction is an edge from the
+// acting pet to the pet it is acting on, labelled with the verb. A pet
+// whose characteristics refer to another has a dashed edge to it.
+func WriteDOT(w io.Writer, config *Config) error {
+	var b strings.Builder
+	b.WriteString("digraph pets {\n")
+	names, households, members := []string{}, []string{}, map[string][]Pet{}
+	for _, p := range config.Pets {
+		name, _ := petIdentity(p)
+		names = append(names, name)
+		household := petHousehold(p)
+		if household == "" {
+			writeDOTPet(&b, "  ", p, "")
+			continue
+		}
+		if _, ok := members[household]; !ok {
+			households = append(households, household)
+		}
+		members[household] = append(members[household], p)
+	}
+	for _, household := range households {
+		fmt.Fprintf(&b, "  subgraph %s {\n    label=%s;\n", dotQuote("cluster_"+household), dotQuote(household))
+		for _, p := range members[household] {
+			writeDOTPet(&b, "    ", p, household)
+		}
+		b.WriteString("  }\n")
+	}
+	// Owners are named apart from pets, which can have the same names.
+	for _, o := range config.Owners {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=box];\n", dotQuote("owner:"+o.Name), dotQuote(o.Name))
+	}
+	for _, p := range config.Pets {
+		if owner := petOwner(p); owner != "" {
+			name, _ := petIdentity(p)
+			fmt.Fprintf(&b, "  %s -> %s [label=\"owns\"];\n", dotQuote("owner:"+owner), dotQuote(name))
+		}
+	}
+	for _, i := range config.Interactions {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(i.From), dotQuote(i.To), dotQuote(i.Verb))
+	}
+	// Pets that were filtered out or skipped are left out of the graph,
+	// with their references.
+	for _, r := range config.References {
+		if contains(names, r.From) && contains(names, r.To) {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed, label=\"refers to\"];\n", dotQuote(r.From), dotQuote(r.To))
+		}
+	}
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("error in WriteDOT writing graph: %w", err)
+	}
+	return nil
+}
+
+// writeDOTPet writes the node of p, indented by indent. Pets of household
+// are labelled without the household their name starts with, as their
+// cluster has it.
+func writeDOTPet(b *strings.Builder, indent string, p Pet, household string) {
+	name, petType := petIdentity(p)
+	label := strings.TrimPrefix(name, household+".")
+	fmt.Fprintf(b, "%s%s [label=%s];\n", indent, dotQuote(name), dotQuote(label+"\n("+petType+")"))
+}
+
+// petIdentity returns the name and type of a pet, unwrapping any middleware
+// around it. Pets that are not PetInfos are of type "unknown".
+func petIdentity(p Pet) (string, string) {
+	if info, ok := unwrapPet(p).(PetInfo); ok {
+		return info.PetName(), info.Type()
+	}
+	return "", "unknown"
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,102 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDOT(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "pets",
+			input: "testdata/basic.hcl",
+			want: `digraph pets {
+  "Ink" [label="Ink\n(cat)"];
+  "Swinney" [label="Swinney\n(dog)"];
+}
+`,
+		},
+		{
+			name:  "interactions",
+			input: "testdata/interactions.hcl",
+			want: `digraph pets {
+  "Ink" [label="Ink\n(cat)"];
+  "Swinney" [label="Swinney\n(dog)"];
+  "Swinney" -> "Ink" [label="chases"];
+  "Ink" -> "Swinney" [label="ignores"];
+}
+`,
+		},
+		{
+			name:  "references",
+			input: "testdata/references.hcl",
+			want: `digraph pets {
+  "Swinney" [label="Swinney\n(dog)"];
+  "Ink" [label="Ink\n(cat)"];
+  "Swinney" -> "Ink" [style=dashed, label="refers to"];
+}
+`,
+		},
+		{
+			name:  "households",
+			input: "testdata/household.hcl",
+			want: `digraph pets {
+  "Ink" [label="Ink\n(cat)"];
+  subgraph "cluster_smith-family" {
+    label="smith-family";
+    "smith-family.Ink" [label="Ink\n(cat)"];
+    "smith-family.Swinney" [label="Swinney\n(dog)"];
+  }
+  subgraph "cluster_jones" {
+    label="jones";
+    "jones.Neko" [label="Neko\n(cat)"];
+  }
+}
+`,
+		},
+		{
+			name:  "owners",
+			input: "testdata/graph.hcl",
+			want: `digraph pets {
+  "Swinney" [label="Swinney\n(dog)"];
+  subgraph "cluster_home" {
+    label="home";
+    "home.Ink" [label="Ink\n(cat)"];
+  }
+  "owner:russell" [label="russell", shape=box];
+  "owner:russell" -> "Swinney" [label="owns"];
+  "owner:russell" -> "home.Ink" [label="owns"];
+  "Swinney" -> "home.Ink" [style=dashed, label="refers to"];
+}
+`,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := LoadConfig(tc.input)
+			if !assert.Nil(t, err, "error while parsing input") {
+				return
+			}
+
+			out := &bytes.Buffer{}
+			if assert.Nil(t, WriteDOT(out, config)) {
+				assert.Equal(t, tc.want, out.String())
+			}
+		})
+	}
+}
+
+func TestDotQuote(t *testing.T) {
+	assert.Equal(t, `"Mr. \"Paws\" \\ Jr"`, dotQuote(`Mr. "Paws" \ Jr`))
+}
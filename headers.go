@@ -0,0 +1,93 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PetHeader is a pet that has been declared in a configuration file, but not
+// yet decoded. Listing pets only needs their headers, while the rest of each
+// pet is decoded the first time Pet is called, so tools that only list the
+// pets in a large configuration don't have to decode all of it.
+type PetHeader struct {
+	Name      string
+	Type      string
+	DeclRange hcl.Range
+
+	once   sync.Once
+	decode func() (Pet, error)
+	pet    Pet
+	err    error
+}
+
+// Pet decodes the pet the first time it is called, and returns the same pet,
+// or error, every time after. It is safe to call concurrently.
+func (h *PetHeader) Pet() (Pet, error) {
+	h.once.Do(func() {
+		h.pet, h.err = h.decode()
+	})
+	return h.pet, h.err
+}
+
+// LoadPetHeaders is LoadConfigContext for only the headers of the pets in a
+// file or URL.
+func LoadPetHeaders(ctx context.Context, filename string) ([]*PetHeader, error) {
+	source, err := NewConfigSource(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error in LoadPetHeaders: %w", err)
+	}
+	src, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in LoadPetHeaders: %w", err)
+	}
+	return DecodePetHeaders(ctx, src, filename, LoadOptions{Format: source.Format()})
+}
+
+// DecodePetHeaders is DecodeConfigContext for only the headers of the pets
+// in src. The characteristics, moods, conditions and validations of each
+// pet are only decoded and checked when PetHeader.Pet is called, and
+// interactions are not decoded at all.
+func DecodePetHeaders(ctx context.Context, src []byte, filename string, opts LoadOptions) ([]*PetHeader, error) {
+	opts = opts.withDefaults()
+	petsHCL, evalContext, _, err := decodeGenericPets(ctx, src, filename, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cycles are found up front, as the pets in one would wait on each
+	// other to be decoded.
+	if _, diags := decodeOrder(petsHCL.PetHCLBodies); diags.HasErrors() {
+		return nil, fmt.Errorf("error in DecodePetHeaders resolving pet references: %w", diags)
+	}
+
+	headers, byName := []*PetHeader{}, map[string]*PetHeader{}
+	for _, p := range petsHCL.PetHCLBodies {
+		p := p // capture range variable
+		h := &PetHeader{
+			Name:      p.Name,
+			Type:      p.Type,
+			DeclRange: p.declRange,
+		}
+		// The pets a pet refers to are decoded before it.
+		h.decode = func() (Pet, error) {
+			others := map[string]cty.Value{}
+			for _, ref := range petReferences(p) {
+				other, err := byName[ref.name].Pet()
+				if err != nil {
+					return nil, err
+				}
+				if others[ref.name], err = selfValue(other); err != nil {
+					return nil, fmt.Errorf("error in DecodeConfig referring to pet `%s`: %w", ref.name, err)
+				}
+			}
+			return decodePet(p, petsHCL.DefaultsHCL, evalContext, others, opts)
+		}
+		headers = append(headers, h)
+		byName[p.Name] = h
+	}
+	return headers, nil
+}
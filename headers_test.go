@@ -0,0 +1,45 @@
+package petsounds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPetHeaders(t *testing.T) {
+	headers, err := LoadPetHeaders(context.Background(), "testdata/basic.hcl")
+	if !assert.Nil(t, err, "error while parsing input") || !assert.Len(t, headers, 2) {
+		return
+	}
+
+	assert.Equal(t, "Ink", headers[0].Name)
+	assert.Equal(t, "cat", headers[0].Type)
+	assert.Equal(t, hcl.Pos{Line: 1, Column: 1, Byte: 0}, headers[0].DeclRange.Start)
+	assert.Equal(t, "Swinney", headers[1].Name)
+	assert.Equal(t, "dog", headers[1].Type)
+	assert.Equal(t, 5, headers[1].DeclRange.Start.Line)
+
+	pet, err := headers[1].Pet()
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Dog{Name: "Swinney", Breed: "Dachshund"}, clearDeclRanges([]Pet{pet})[0])
+	}
+	again, _ := headers[1].Pet()
+	assert.True(t, pet == again, "a pet is only decoded once")
+}
+
+func TestLoadPetHeadersLazy(t *testing.T) {
+	// Headers can be listed for pets whose characteristics are invalid,
+	// which are only reported once the pet is decoded.
+	headers, err := LoadPetHeaders(context.Background(), "testdata/vitals_invalid.hcl")
+	if !assert.Nil(t, err, "error while parsing input") || !assert.Len(t, headers, 1) {
+		return
+	}
+	assert.Equal(t, "Swinney", headers[0].Name)
+
+	_, err = headers[0].Pet()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "vitals_invalid.hcl:5,14-16: Invalid age")
+	}
+}
@@ -0,0 +1,58 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// health answers the probes of the orchestrator running the serve command.
+// /healthz answers ok for as long as the server is up, and /readyz only
+// once the configuration has loaded, and no longer once the server has
+// begun shutting down, so that no new traffic is sent its way.
+type health struct {
+	ready int32
+}
+
+// setReady sets whether /readyz answers ok.
+func (h *health) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&h.ready, v)
+}
+
+// register adds /healthz and /readyz to mux.
+func (h *health) register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&h.ready) == 0 {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// shutdown stops server gracefully: it stops being ready, and goes on
+// serving for grace, so that the orchestrator sees /readyz fail and stops
+// sending traffic its way before it stops listening. Then the event streams
+// of events end, and the requests in flight have until timeout to finish
+// before their connections are closed.
+func (h *health) shutdown(server *http.Server, events *eventBroker, grace, timeout time.Duration) error {
+	h.setReady(false)
+	time.Sleep(grace)
+	events.close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("error shutting down the server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthProbes(t *testing.T) {
+	t.Parallel()
+
+	probes := &health{}
+	mux := http.NewServeMux()
+	probes.register(mux)
+	listener, err := net.Listen("tcp", "localhost:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+	url := "http://" + listener.Addr().String()
+
+	status := func(path string) int {
+		resp, err := http.Get(url + path)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, status("/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, status("/readyz"))
+	probes.setReady(true)
+	assert.Equal(t, http.StatusOK, status("/readyz"))
+
+	// Shutting down waits for the request in flight, and ends the event
+	// streams, which would otherwise never finish.
+	events := newEventBroker(nil)
+	started, finish := make(chan struct{}), make(chan struct{})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.Write([]byte("done"))
+	})
+	mux.Handle("/events", events)
+	stream, err := http.Get(url + "/events")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stream.Body.Close()
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- probes.shutdown(server, events, 0, time.Minute)
+	}()
+	_, err = ioutil.ReadAll(stream.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&probes.ready))
+	close(finish)
+	assert.Equal(t, "done", <-slow)
+	assert.NoError(t, <-shutdown)
+}
+
+func TestHealthShutdownTimeout(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	started, finish := make(chan struct{}), make(chan struct{})
+	defer close(finish)
+	mux.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+	})
+	listener, err := net.Listen("tcp", "localhost:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+	<-started
+
+	err = (&health{}).shutdown(server, newEventBroker(nil), 0, 10*time.Millisecond)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "error shutting down the server")
+	}
+}
+
+func TestHealthShutdownGrace(t *testing.T) {
+	t.Parallel()
+
+	probes := &health{}
+	mux := http.NewServeMux()
+	probes.register(mux)
+	listener, err := net.Listen("tcp", "localhost:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+	url := "http://" + listener.Addr().String()
+	probes.setReady(true)
+
+	// During the grace period, /readyz fails while requests are still
+	// served.
+	const grace = 500 * time.Millisecond
+	start := time.Now()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- probes.shutdown(server, newEventBroker(nil), grace, time.Minute)
+	}()
+	for atomic.LoadInt32(&probes.ready) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for path, want := range map[string]int{"/readyz": http.StatusServiceUnavailable, "/healthz": http.StatusOK} {
+		resp, err := http.Get(url + path)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, want, resp.StatusCode, path)
+		}
+	}
+
+	assert.NoError(t, <-shutdown)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(grace))
+	_, err = http.Get(url + "/healthz")
+	assert.Error(t, err)
+}
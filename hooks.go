@@ -0,0 +1,53 @@
+package petsounds
+
+// Hooks are callbacks for tools that use pet-sounds as a library, to record
+// metrics, persist pets or add side effects of their own as pets are decoded
+// and run. Any of them can be nil. A Runner with more than one worker may
+// call them from several goroutines at once.
+type Hooks struct {
+	// Decoded is called with each pet once it has been decoded from a
+	// configuration, before it is validated against the known breeds.
+	Decoded func(p Pet)
+	// BeforeSay is called before a pet Says something, once its
+	// preconditions have passed.
+	BeforeSay func(p Pet)
+	// AfterAct is called after a pet Acts, before its postconditions are
+	// checked.
+	AfterAct func(p Pet)
+	// Event is called with what a pet wrote each time it Says or Acts,
+	// after any other hooks for the event.
+	Event func(p Pet, event, line string)
+	// Error is called with the error of a pet that fails, such as one with
+	// a failed condition or whose sound could not be played.
+	Error func(p Pet, err error)
+}
+
+func (h *Hooks) decoded(p Pet) {
+	if h != nil && h.Decoded != nil {
+		h.Decoded(p)
+	}
+}
+
+func (h *Hooks) beforeSay(p Pet) {
+	if h != nil && h.BeforeSay != nil {
+		h.BeforeSay(p)
+	}
+}
+
+func (h *Hooks) afterAct(p Pet) {
+	if h != nil && h.AfterAct != nil {
+		h.AfterAct(p)
+	}
+}
+
+func (h *Hooks) event(p Pet, event, line string) {
+	if h != nil && h.Event != nil {
+		h.Event(p, event, line)
+	}
+}
+
+func (h *Hooks) error(p Pet, err error) {
+	if h != nil && h.Error != nil {
+		h.Error(p, err)
+	}
+}
@@ -0,0 +1,57 @@
+package petsounds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	events := []string{}
+	record := func(event string) func(p Pet) {
+		return func(p Pet) {
+			name, _ := petIdentity(p)
+			events = append(events, event+" "+name)
+		}
+	}
+	hooks := &Hooks{
+		Decoded:   record("decoded"),
+		BeforeSay: record("before say"),
+		AfterAct:  record("after act"),
+		Event: func(p Pet, event, line string) {
+			events = append(events, event+" "+line)
+		},
+		Error: func(p Pet, err error) {
+			name, _ := petIdentity(p)
+			events = append(events, "error "+name)
+		},
+	}
+
+	src, err := ioutil.ReadFile("testdata/conditions.hcl")
+	assert.NoError(t, err)
+	config, err := DecodeConfig(src, "testdata/conditions.hcl", LoadOptions{Hooks: hooks})
+	assert.NoError(t, err)
+
+	runner := &Runner{Out: &bytes.Buffer{}, Hooks: hooks}
+	assert.Error(t, runner.Run(config.Pets))
+
+	// Swinney fails the precondition on act, so never gets past saying.
+	assert.Equal(t, []string{
+		"decoded Swinney",
+		"decoded Ink",
+		"before say Swinney",
+		"say Swinney the Dachshund barks",
+		"error Swinney",
+		"before say Ink",
+		"say Ink meow",
+		"after act Ink",
+		"act Ink snoozes",
+	}, events)
+
+	// Pets without hooks run as they always have.
+	assert.NoError(t, (&Runner{Out: &bytes.Buffer{}}).Run([]Pet{&Cat{Name: "Ink"}}))
+}
@@ -0,0 +1,63 @@
+package petsounds
+
+import (
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// HouseholdHCL is a household block, which groups the pets that live
+// together, such as:
+//   household "smith-family" {
+//     defaults {
+//       cat {
+//         sound = "purr"
+//       }
+//     }
+//     pet "Ink" {
+//       type = "cat"
+//     }
+//   }
+// The pets of a household are named after it, as smith-family.Ink, so that
+// pets of different households can have the same name. The household's
+// defaults block sets the characteristics its pets leave out, over the
+// file's defaults block.
+type HouseholdHCL struct {
+	Name         string       `hcl:"name,label"`
+	DefaultsHCL  *DefaultsHCL `hcl:"defaults,block"`
+	PetHCLBodies []*PetHCL    `hcl:"pet,block"`
+}
+
+// addHouseholds adds the pets of the household blocks of petsHCL, which was
+// decoded from body, to its own pets.
+func addHouseholds(petsHCL *PetsHCL, body *hclsyntax.Body) {
+	blocks := []*hclsyntax.Block{}
+	for _, block := range body.Blocks {
+		if block.Type == "household" {
+			blocks = append(blocks, block)
+		}
+	}
+
+	for i, h := range petsHCL.HouseholdsHCL {
+		if i < len(blocks) {
+			setDeclRanges(&PetsHCL{PetHCLBodies: h.PetHCLBodies}, blocks[i].Body)
+		}
+		for _, p := range h.PetHCLBodies {
+			p.Name = h.Name + "." + p.Name
+			p.household, p.householdDefaults = h.Name, h.DefaultsHCL
+			petsHCL.PetHCLBodies = append(petsHCL.PetHCLBodies, p)
+		}
+	}
+}
+
+// petHousehold returns the name of the household of a pet, which is empty
+// for pets that are not in one.
+func petHousehold(p Pet) string {
+	switch pet := unwrapPet(p).(type) {
+	case *Cat:
+		return pet.Household
+	case *Dog:
+		return pet.Household
+	case *CustomPet:
+		return pet.Household
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHouseholds(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfig("testdata/household.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 22, declRange(config.Pets[2]).Start.Line)
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "purr"},
+		&Cat{Name: "smith-family.Ink", Sound: "mrrp", Household: "smith-family"},
+		&Dog{Name: "smith-family.Swinney", Breed: "Dachshund", Household: "smith-family"},
+		&Cat{Name: "jones.Neko", Sound: "purr", Household: "jones"},
+	}, clearDeclRanges(config.Pets))
+
+	out := &bytes.Buffer{}
+	if assert.NoError(t, WriteConfig(out, config.Pets)) {
+		assert.Equal(t, `pet "Ink" {
+  type = "cat"
+
+  characteristics {
+    sound = "purr"
+  }
+}
+
+household "smith-family" {
+  pet "Ink" {
+    type = "cat"
+
+    characteristics {
+      sound = "mrrp"
+    }
+  }
+
+  pet "Swinney" {
+    type = "dog"
+
+    characteristics {
+      breed = "Dachshund"
+    }
+  }
+}
+
+household "jones" {
+  pet "Neko" {
+    type = "cat"
+
+    characteristics {
+      sound = "purr"
+    }
+  }
+}
+`, out.String())
+	}
+}
+
+func TestPetFilterHousehold(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfig("testdata/household.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.EqualError(t, PetFilter{Household: "smith-famly"}.Apply(config), "no household named `smith-famly`, did you mean `smith-family`?")
+	if assert.NoError(t, PetFilter{Household: "smith-family"}.Apply(config)) {
+		names := []string{}
+		for _, p := range config.Pets {
+			name, _ := petIdentity(p)
+			names = append(names, name)
+		}
+		assert.Equal(t, []string{"smith-family.Ink", "smith-family.Swinney"}, names)
+	}
+}
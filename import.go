@@ -0,0 +1,106 @@
+package petsounds
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// importColumns are the characteristics ImportCSV reads from a CSV file.
+var importColumns = []string{"name", "type", "breed", "sound"}
+
+// ImportCSV reads pets from a CSV file with a header row. The name, type,
+// breed and sound columns are found by their headers, which can be renamed
+// with columns, a map from a header in the file to the column it holds. The
+// name and type of every pet are required, while empty breeds and sounds are
+// left to their defaults.
+func ImportCSV(r io.Reader, columns map[string]string) ([]Pet, error) {
+	records := csv.NewReader(r)
+	records.TrimLeadingSpace = true
+
+	header, err := records.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error in ImportCSV reading header: %w", err)
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		column := strings.ToLower(strings.TrimSpace(h))
+		for from, to := range columns {
+			if strings.EqualFold(strings.TrimSpace(from), column) {
+				column = to
+			}
+		}
+		index[column] = i
+	}
+	for _, required := range importColumns[:2] {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("error in ImportCSV: no `%s` column in header", required)
+		}
+	}
+
+	pets := []Pet{}
+	for n := 1; ; n++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error in ImportCSV reading record %d: %w", n, err)
+		}
+
+		fields := map[string]string{}
+		for _, column := range importColumns {
+			if i, ok := index[column]; ok && i < len(record) {
+				fields[column] = strings.TrimSpace(record[i])
+			}
+		}
+		if fields["name"] == "" {
+			return nil, fmt.Errorf("error in ImportCSV: record %d has no name", n)
+		}
+
+		switch strings.ToLower(fields["type"]) {
+		case "cat":
+			if fields["breed"] != "" {
+				return nil, fmt.Errorf(
+					"error in ImportCSV: record %d is the cat `%s`, which cannot have a breed", n, fields["name"],
+				)
+			}
+			pets = append(pets, &Cat{Name: fields["name"], Sound: fields["sound"]})
+		case "dog":
+			pets = append(pets, &Dog{Name: fields["name"], Breed: fields["breed"], Sound: fields["sound"]})
+		default:
+			return nil, fmt.Errorf(
+				"error in ImportCSV: record %d: %w", n, &ErrUnknownPetType{Type: fields["type"]},
+			)
+		}
+	}
+	return pets, nil
+}
+
+// parseColumns parses a column mapping given as comma separated
+// `header=column` pairs, such as "Animal Name=name,Species=type".
+func parseColumns(s string) (map[string]string, error) {
+	columns := map[string]string{}
+	if s == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid column mapping `%s`, expected header=column", pair)
+		}
+		column := strings.ToLower(strings.TrimSpace(parts[1]))
+		known := false
+		for _, c := range importColumns {
+			known = known || c == column
+		}
+		if !known {
+			return nil, fmt.Errorf(
+				"unknown column `%s` in mapping, expected one of %s", column, strings.Join(importColumns, ", "),
+			)
+		}
+		columns[strings.TrimSpace(parts[0])] = column
+	}
+	return columns, nil
+}
@@ -0,0 +1,87 @@
+package petsounds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportCSV(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		csv     string
+		columns map[string]string
+		want    []Pet
+		wantErr bool
+	}{
+		{
+			name: "standard headers",
+			csv: `name,type,breed,sound
+Ink,cat,,purrs
+Swinney,Dog,Dachshund,
+`,
+			want: []Pet{
+				&Cat{Name: "Ink", Sound: "purrs"},
+				&Dog{Name: "Swinney", Breed: "Dachshund"},
+			},
+		},
+		{
+			name: "mapped headers",
+			csv: `Animal Name, Species, Intake Date
+Ink, Cat, 2020-01-02
+`,
+			columns: map[string]string{"Animal Name": "name", "species": "type"},
+			want:    []Pet{&Cat{Name: "Ink"}},
+		},
+		{
+			name:    "missing column",
+			csv:     "name,breed\nSwinney,Dachshund\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			csv:     "name,type\nBubbles,fish\n",
+			wantErr: true,
+		},
+		{
+			name:    "cat with breed",
+			csv:     "name,type,breed\nInk,cat,Siamese\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			csv:     "name,type\n,dog\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pets, err := ImportCSV(strings.NewReader(tc.csv), tc.columns)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, pets)
+			}
+		})
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := parseColumns("Animal Name=name, Species = Type")
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"Animal Name": "name", "Species": "type"}, columns)
+	}
+
+	_, err = parseColumns("Animal Name")
+	assert.Error(t, err)
+	_, err = parseColumns("Color=coat")
+	assert.Error(t, err)
+}
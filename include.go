@@ -0,0 +1,141 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// includeBlockType is the block that adds the contents of another
+// configuration file to the one it is in, so a file can be composed of
+// fragments.
+const includeBlockType = "include"
+
+// IncludeHCL is an include block, such as:
+//   include {
+//     path = "./cats.hcl"
+//   }
+type IncludeHCL struct {
+	Path string `hcl:"path"`
+}
+
+// resolveIncludes replaces every include block in body with the blocks and
+// attributes of the file it includes, merged by strategy. chain is the
+// files that led to body, from the root configuration file to body's own;
+// it stops files including themselves, and is reported in diagnostics about
+// included files.
+func resolveIncludes(ctx context.Context, cache *ParseCache, body *hclsyntax.Body, chain []string, strategy string) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	blocks := hclsyntax.Blocks{}
+	// Body's own blocks are merged first, then each included file's in
+	// turn.
+	ranks, rank := []int{}, 0
+	for _, block := range body.Blocks {
+		if block.Type != includeBlockType {
+			blocks = append(blocks, block)
+			ranks = append(ranks, 0)
+			continue
+		}
+
+		included, includeDiags := include(ctx, cache, block, chain, strategy)
+		diags = append(diags, includeDiags...)
+		if included == nil {
+			continue
+		}
+		rank++
+		for _, b := range included.Blocks {
+			blocks = append(blocks, b)
+			ranks = append(ranks, rank)
+		}
+		diags = append(diags, mergeAttributes(body, included, strategy)...)
+	}
+	var mergeDiags hcl.Diagnostics
+	body.Blocks, mergeDiags = mergeBlocks(blocks, ranks, strategy)
+	return append(diags, mergeDiags...)
+}
+
+// include reads, parses and migrates the file included by block, which is
+// in the last file of chain, and resolves the includes in it in turn.
+// Relative paths are relative to the directory of the including file.
+func include(ctx context.Context, cache *ParseCache, block *hclsyntax.Block, chain []string, strategy string) (*hclsyntax.Body, hcl.Diagnostics) {
+	includeHCL := &IncludeHCL{}
+	if diags := gohcl.DecodeBody(block.Body, nil, includeHCL); diags.HasErrors() {
+		return nil, diags
+	}
+	path := includeHCL.Path
+	pathRange := block.Body.Attributes["path"].Expr.Range()
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(chain[len(chain)-1]), path)
+	}
+	chain = append(append([]string{}, chain...), path)
+
+	for _, f := range chain[:len(chain)-1] {
+		if samePath(f, path) {
+			return nil, hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  "Include cycle",
+				Detail:   fmt.Sprintf("`%s` includes itself. %s", path, includeChain(chain)),
+				Subject:  pathRange.Ptr(),
+			}}
+		}
+	}
+
+	body, diags := parseFragment(ctx, cache, path, chain, strategy)
+	for _, d := range diags {
+		if d.Subject == nil {
+			d.Subject = pathRange.Ptr()
+		}
+	}
+	return body, diags
+}
+
+// parseFragment reads, parses and migrates the file at path, the last file
+// of chain, and resolves the includes in it in turn, merging them by
+// strategy. Diagnostics about the file report chain.
+func parseFragment(ctx context.Context, cache *ParseCache, path string, chain []string, strategy string) (*hclsyntax.Body, hcl.Diagnostics) {
+	src, err := ioutil.ReadFile(path)
+	if err == nil {
+		src, err = translateConfig(ctx, src, path, formatOf(path))
+	}
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unreadable configuration file",
+			Detail:   fmt.Sprintf("%s. %s", err, includeChain(chain)),
+		}}
+	}
+
+	body, diags := cache.parse(src, path)
+	if !diags.HasErrors() {
+		diags = append(diags, migrateSchema(body)...)
+	}
+	for _, d := range diags {
+		d.Detail = strings.TrimSpace(d.Detail + " " + includeChain(chain))
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return body, append(diags, resolveIncludes(ctx, cache, body, chain, strategy)...)
+}
+
+// includeChain describes a chain of included files for diagnostics.
+func includeChain(chain []string) string {
+	return fmt.Sprintf("Include chain: %s.", strings.Join(chain, " -> "))
+}
+
+// samePath reports whether the paths a and b name the same file.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
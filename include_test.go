@@ -0,0 +1,58 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInclude(t *testing.T) {
+	config, err := LoadConfig("testdata/include.hcl")
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{
+			&Cat{Name: "Ink", Sound: "meow"},
+			&Dog{Name: "Swinney", Breed: "Dachshund"},
+			&Dog{Name: "Biscuit", Breed: "Corgi"},
+		}, clearDeclRanges(config.Pets))
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "cycle",
+			input: "testdata/include/cycle.hcl",
+			want: []string{
+				"cycle_other.hcl:2,10-21: Include cycle",
+				"Include chain: testdata/include/cycle.hcl -> testdata/include/cycle_other.hcl -> testdata/include/cycle.hcl.",
+			},
+		},
+		{
+			name:  "invalid fragment",
+			input: "testdata/include/invalid.hcl",
+			want: []string{
+				"invalid_fragment.hcl:",
+				"Include chain: testdata/include/invalid.hcl -> testdata/include/invalid_fragment.hcl.",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadConfig(tc.input)
+			if assert.Error(t, err) {
+				for _, want := range tc.want {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package petsounds
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// starterFiles are the files starter configurations are made of: header.hcl,
+// which starts every one with the variables its pets use, and a file for
+// each type of pet a starter configuration can scaffold, such as cat.hcl,
+// with a pet of the type. The types of pet packs need the pack enabled,
+// which WriteStarterConfig does for them.
+//
+//go:embed starter/*.hcl
+var starterFiles embed.FS
+
+// starterHeader is the file of starterFiles that starts every starter
+// configuration.
+const starterHeader = "header.hcl"
+
+// starterTypes returns the types of pet a starter configuration can
+// scaffold, sorted.
+func starterTypes() []string {
+	entries, _ := starterFiles.ReadDir("starter")
+	types := []string{}
+	for _, e := range entries {
+		if e.Name() != starterHeader {
+			types = append(types, strings.TrimSuffix(e.Name(), ".hcl"))
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// starterFile returns the file of starterFiles called name.
+func starterFile(name string) ([]byte, error) {
+	return starterFiles.ReadFile(path.Join("starter", name))
+}
+
+// WriteStarterConfig writes a commented configuration to w with a pet of
+// each of types, for a new user to start from. Without types, it has a
+// pet of each of the built in types.
+func WriteStarterConfig(w io.Writer, types []string) error {
+	if len(types) == 0 {
+		types = petKindNames()
+	}
+	packs := []string{}
+	for _, t := range types {
+		if !contains(starterTypes(), t) {
+			if suggestion := suggest(t, starterTypes()); suggestion != "" {
+				return fmt.Errorf("error in WriteStarterConfig: unknown pet type `%s`, did you mean `%s`?", t, suggestion)
+			}
+			return fmt.Errorf(
+				"error in WriteStarterConfig: unknown pet type `%s`, expected one of %s", t, strings.Join(starterTypes(), ", "),
+			)
+		}
+		if pack := petTypePack(t); pack != "" && !contains(packs, pack) {
+			packs = append(packs, pack)
+		}
+	}
+
+	files := []string{starterHeader}
+	for _, t := range types {
+		if !contains(files, t+".hcl") {
+			files = append(files, t+".hcl")
+		}
+	}
+	for i, name := range files {
+		src, err := starterFile(name)
+		if err != nil {
+			return fmt.Errorf("error in WriteStarterConfig: %w", err)
+		}
+		if i > 0 {
+			src = append([]byte("\n"), src...)
+		}
+		if _, err := w.Write(src); err != nil {
+			return err
+		}
+		if name == starterHeader && len(packs) > 0 {
+			if _, err := fmt.Fprintf(w, "\n# Pet packs add more types of pet.\npacks = [\"%s\"]\n", strings.Join(packs, `", "`)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// petTypePack returns the pet pack that declares the pet type named t, or
+// an empty string if none does.
+func petTypePack(t string) string {
+	for _, pack := range petPackNames() {
+		types, diags := petPackTypes(pack)
+		if diags.HasErrors() {
+			continue
+		}
+		for _, pt := range types {
+			if pt.Name == t {
+				return pack
+			}
+		}
+	}
+	return ""
+}
+
+// initCommand writes a starter configuration to the file given with -f,
+// unless it exists already.
+func initCommand(flags *flag.FlagSet) func(args []string) error {
+	types := flags.String("types", "", "the types of pet to scaffold, as a comma separated list, such as cat,dog,goldfish; defaults to every built in type")
+	force := flags.Bool("force", false, "overwrite the file if it exists")
+
+	return func(args []string) error {
+		outputFile := flagString(flags, "file")
+		if _, err := os.Stat(outputFile); err == nil && !*force {
+			return fmt.Errorf("`%s` already exists, use -force to overwrite it", outputFile)
+		}
+
+		b := &strings.Builder{}
+		if err := WriteStarterConfig(b, splitTypes(*types)); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(outputFile, []byte(b.String()), 0644); err != nil {
+			return fmt.Errorf("error writing `%s`: %w", outputFile, err)
+		}
+		fmt.Printf("pet-sounds: wrote %s, run it with pet-sounds -f %s\n", outputFile, outputFile)
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package petsounds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteStarterConfig(t *testing.T) {
+	tcs := []struct {
+		name  string
+		types []string
+		want  []string
+	}{
+		{name: "default", want: []string{"Ink", "Swinney"}},
+		{name: "packs", types: []string{"cat", "goldfish", "horse"}, want: []string{"Ink", "Bubbles", "Clover"}},
+		{name: "every type", types: starterTypes(), want: []string{"Ink", "Nugget", "Swinney", "Billy", "Bubbles", "Clover", "Pinch"}},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := &bytes.Buffer{}
+			if !assert.NoError(t, WriteStarterConfig(b, tc.types)) {
+				return
+			}
+			config, err := DecodeConfig(b.Bytes(), "pets.hcl", LoadOptions{})
+			if !assert.NoError(t, err, b.String()) {
+				return
+			}
+			assert.Empty(t, config.Warnings)
+			names := []string{}
+			for _, p := range config.Pets {
+				name, _ := petIdentity(p)
+				names = append(names, name)
+			}
+			assert.Equal(t, tc.want, names)
+		})
+	}
+}
+
+func TestWriteStarterConfigUnknownType(t *testing.T) {
+	t.Parallel()
+
+	err := WriteStarterConfig(ioutil.Discard, []string{"cat", "goat", "shrmp"})
+	if assert.Error(t, err) {
+		assert.Equal(t, "error in WriteStarterConfig: unknown pet type `shrmp`, did you mean `shrimp`?", err.Error())
+	}
+}
+
+func TestInitCommand(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pet-sounds-init")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pets.hcl")
+
+	if !assert.NoError(t, inner([]string{"init", "-f", path, "-types", "dog"})) {
+		return
+	}
+	src, err := ioutil.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(src), `pet "Swinney"`)
+		assert.NotContains(t, string(src), `pet "Ink"`)
+	}
+
+	// An existing file is only overwritten with -force.
+	assert.Error(t, inner([]string{"init", "-f", path}))
+	assert.NoError(t, inner([]string{"init", "-f", path, "-force"}))
+	src, err = ioutil.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(src), `pet "Ink"`)
+	}
+}
@@ -0,0 +1,54 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+)
+
+// InteractionHCL is a relationship between two declared pets. Each
+// Interaction is represented in hcl as:
+//   interaction {
+//     from = "<PET NAME>"
+//     to   = "<PET NAME>"
+//     verb = "<what the first pet does to the second>"
+//   }
+type InteractionHCL struct {
+	From string `hcl:"from"`
+	To   string `hcl:"to"`
+	Verb string `hcl:"verb"`
+}
+
+// Interaction is a validated InteractionHCL, between two pets known to exist.
+type Interaction struct {
+	From string
+	To   string
+	Verb string
+}
+
+// Do writes the interaction to w, e.g. "Swinney chases Ink".
+func (i *Interaction) Do(w io.Writer) {
+	fmt.Fprintf(w, "%s %s %s\n", i.From, i.Verb, i.To)
+}
+
+// newInteractions validates the interaction blocks of petsHCL against the
+// pets it declares.
+func newInteractions(petsHCL *PetsHCL) ([]*Interaction, error) {
+	declared := map[string]bool{}
+	for _, p := range petsHCL.PetHCLBodies {
+		declared[p.Name] = true
+	}
+
+	interactions := []*Interaction{}
+	for _, i := range petsHCL.InteractionsHCL {
+		for _, name := range []string{i.From, i.To} {
+			if !declared[name] {
+				return nil, fmt.Errorf(
+					"error in newInteractions: interaction `%s %s %s` refers to undeclared pet `%s`",
+					i.From, i.Verb, i.To, name,
+				)
+			}
+		}
+		interactions = append(interactions, &Interaction{From: i.From, To: i.To, Verb: i.Verb})
+	}
+	return interactions, nil
+}
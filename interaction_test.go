@@ -0,0 +1,63 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteractions(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		input   string
+		want    []*Interaction
+		wantErr bool
+	}{
+		{
+			name:  "declared pets",
+			input: "testdata/interactions.hcl",
+			want: []*Interaction{
+				{From: "Swinney", To: "Ink", Verb: "chases"},
+				{From: "Ink", To: "Swinney", Verb: "ignores"},
+			},
+		},
+		{
+			name:  "no interactions",
+			input: "testdata/basic.hcl",
+			want:  []*Interaction{},
+		},
+		{
+			name:    "undeclared pet",
+			input:   "testdata/interactions_undeclared.hcl",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := LoadConfig(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err, "error while parsing input") {
+				assert.Equal(t, tc.want, got.Interactions)
+			}
+		})
+	}
+}
+
+func TestRunnerInteract(t *testing.T) {
+	out := &bytes.Buffer{}
+	runner := &Runner{Out: out}
+	runner.Interact([]*Interaction{
+		{From: "Swinney", To: "Ink", Verb: "chases"},
+		{From: "Ink", To: "Swinney", Verb: "ignores"},
+	})
+	assert.Equal(t, "Swinney chases Ink\nInk ignores Swinney\n", out.String())
+}
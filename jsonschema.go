@@ -0,0 +1,212 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// jsonSchemaDraft is the version of JSON Schema that configJSONSchema is
+// written in.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// jsonSchema is a JSON Schema, or part of one.
+type jsonSchema map[string]interface{}
+
+// expressionType is the type of attributes that are kept as expressions to
+// evaluate later, which can be any value.
+var expressionType = reflect.TypeOf((*hcl.Expression)(nil)).Elem()
+
+// WriteJSONSchema writes the JSON Schema of configuration files written in
+// HCL's JSON syntax to w, so editors and validators can check them without
+// pet-sounds.
+func WriteJSONSchema(w io.Writer) error {
+	out, err := json.MarshalIndent(configJSONSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+// configJSONSchema returns the JSON Schema of configuration files, built
+// from the hcl tags of PetsHCL and the blocks it holds, and from the
+// specifications of petKinds for characteristics.
+func configJSONSchema() jsonSchema {
+	schema := bodyJSONSchema(reflect.TypeOf(PetsHCL{}))
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = "pet-sounds configuration"
+	return schema
+}
+
+// bodyJSONSchema returns the schema of a body decoded into the struct t.
+// Bodies that keep the rest of their content for later allow any other
+// properties.
+func bodyJSONSchema(t reflect.Type) jsonSchema {
+	properties, required := jsonSchema{}, []string{}
+	additional := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("hcl"), ",")
+		kind := ""
+		if len(tag) > 1 {
+			kind = tag[1]
+		}
+		switch {
+		case kind == "remain":
+			additional = true
+		case tag[0] == "" || kind == "label":
+		case kind == "block":
+			properties[tag[0]] = blockJSONSchema(tag[0], field.Type)
+		default:
+			properties[tag[0]] = attrJSONSchema(field.Type)
+			if kind == "" {
+				required = append(required, tag[0])
+			}
+		}
+	}
+
+	schema := jsonSchema{"type": "object", "properties": properties, "additionalProperties": additional}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// blockJSONSchema returns the schema of the blocks of type name, decoded
+// into t. In HCL's JSON syntax, a block is an object nested in an object
+// for each of its labels, and blocks that can be repeated can be an array.
+func blockJSONSchema(name string, t reflect.Type) jsonSchema {
+	repeated := t.Kind() == reflect.Slice
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	body := bodyJSONSchema(t)
+	switch {
+	case name == "pet" || name == "pet_template":
+		body = petJSONSchema(body, name == "pet")
+	case petKinds[name] != nil:
+		// The blocks of each type of pet in a defaults block.
+		body = kindJSONSchema(petKinds[name], false)
+	case name == "aliases":
+		body["additionalProperties"] = jsonSchema{"type": "string"}
+	}
+
+	schema := body
+	if repeated {
+		schema = jsonSchema{"oneOf": []jsonSchema{body, {"type": "array", "items": body}}}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if strings.HasSuffix(t.Field(i).Tag.Get("hcl"), ",label") {
+			schema = jsonSchema{"type": "object", "additionalProperties": schema}
+		}
+	}
+	return schema
+}
+
+// petJSONSchema completes the schema of pet blocks, or of pet templates,
+// with the characteristics of each type of pet. Pets need a type, unless
+// they extend a template that has one.
+func petJSONSchema(body jsonSchema, pet bool) jsonSchema {
+	properties := body["properties"].(jsonSchema)
+	properties[extendsKey] = jsonSchema{"type": "string"}
+	properties["characteristics"] = jsonSchema{"type": "object"}
+	delete(body, "required")
+	if pet {
+		body["anyOf"] = []jsonSchema{{"required": []string{"type"}}, {"required": []string{extendsKey}}}
+	}
+
+	conditions := []jsonSchema{}
+	for _, name := range petKindNames() {
+		conditions = append(conditions, jsonSchema{
+			"if": jsonSchema{"properties": jsonSchema{"type": jsonSchema{"const": name}}, "required": []string{"type"}},
+			"then": jsonSchema{"properties": jsonSchema{
+				"characteristics": kindJSONSchema(petKinds[name], true),
+			}},
+		})
+	}
+	body["allOf"] = conditions
+	return body
+}
+
+// kindJSONSchema returns the schema of the characteristics of pets of kind
+// k. Attributes the kind requires are only required of pets, not of the
+// defaults for them.
+func kindJSONSchema(k *petKind, pet bool) jsonSchema {
+	properties, required := jsonSchema{}, []string{}
+	names := []string{}
+	for name := range k.spec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attr, ok := k.spec[name].(*hcldec.AttrSpec)
+		if !ok {
+			continue
+		}
+		properties[name] = ctyJSONSchema(attr.Type)
+		if attr.Required && pet {
+			required = append(required, name)
+		}
+	}
+
+	schema := jsonSchema{"type": "object", "properties": properties, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// attrJSONSchema returns the schema of an attribute decoded into t. Any
+// attribute can be a string, as strings are templates in HCL's JSON syntax.
+func attrJSONSchema(t reflect.Type) jsonSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == expressionType:
+		return jsonSchema{}
+	case t == durationType:
+		return jsonSchema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return jsonSchema{"type": "string"}
+	case reflect.Bool:
+		return jsonSchema{"type": []string{"boolean", "string"}}
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return jsonSchema{"type": []string{"number", "string"}}
+	case reflect.Slice:
+		return jsonSchema{"type": []string{"array", "string"}, "items": attrJSONSchema(t.Elem())}
+	case reflect.Map:
+		return jsonSchema{"type": []string{"object", "string"}, "additionalProperties": attrJSONSchema(t.Elem())}
+	}
+	return jsonSchema{}
+}
+
+// ctyJSONSchema returns the schema of a characteristic of type ty, which
+// can be a string template like any other attribute.
+func ctyJSONSchema(ty cty.Type) jsonSchema {
+	switch {
+	case ty == cty.String:
+		return jsonSchema{"type": "string"}
+	case ty == cty.Bool:
+		return jsonSchema{"type": []string{"boolean", "string"}}
+	case ty == cty.Number || ty == cty.DynamicPseudoType:
+		// Measurements are numbers, or strings with a unit.
+		return jsonSchema{"type": []string{"number", "string"}}
+	case ty.IsListType() || ty.IsSetType():
+		return jsonSchema{"type": []string{"array", "string"}, "items": ctyJSONSchema(ty.ElementType())}
+	case ty.IsMapType():
+		return jsonSchema{"type": []string{"object", "string"}, "additionalProperties": ctyJSONSchema(ty.ElementType())}
+	}
+	return jsonSchema{}
+}
@@ -0,0 +1,49 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, WriteJSONSchema(out)) {
+		return
+	}
+	schema := map[string]interface{}{}
+	if !assert.NoError(t, json.Unmarshal(out.Bytes(), &schema)) {
+		return
+	}
+	assert.Equal(t, jsonSchemaDraft, schema["$schema"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{"type": "string"},
+		},
+		"required":             []interface{}{"path"},
+		"additionalProperties": false,
+	}, properties["state"])
+
+	// Pets are keyed by name, and have the characteristics of their type.
+	pets := properties["pet"].(map[string]interface{})
+	assert.Equal(t, "object", pets["type"])
+	pet := pets["additionalProperties"].(map[string]interface{})["oneOf"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, pet["properties"], "owner")
+	assert.Contains(t, pet["properties"], "moods")
+	dog := pet["allOf"].([]interface{})[1].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"properties": map[string]interface{}{"type": map[string]interface{}{"const": "dog"}},
+		"required":   []interface{}{"type"},
+	}, dog["if"])
+	characteristics := dog["then"].(map[string]interface{})["properties"].(map[string]interface{})["characteristics"]
+	assert.Equal(t, map[string]interface{}{"type": "string"},
+		characteristics.(map[string]interface{})["properties"].(map[string]interface{})["breed"])
+}
@@ -0,0 +1,85 @@
+package petsounds
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SkippedPet is a pet that failed to decode, and was left out of a
+// configuration decoded with KeepGoing.
+type SkippedPet struct {
+	Name string
+	Err  error
+}
+
+// withoutSkipped returns the interactions that are not to or from a pet of
+// skipped.
+func withoutSkipped(interactions []*Interaction, skipped []*SkippedPet) []*Interaction {
+	if len(skipped) == 0 {
+		return interactions
+	}
+	names := []string{}
+	for _, s := range skipped {
+		names = append(names, s.Name)
+	}
+	kept := []*Interaction{}
+	for _, i := range interactions {
+		if !contains(names, i.From) && !contains(names, i.To) {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// keepGoing is the --keep-going flag, which also keeps the pets that were
+// skipped loading the configuration, so that they can be reported once the
+// command is done with the rest.
+type keepGoing struct {
+	on      bool
+	skipped []*SkippedPet
+}
+
+func (k *keepGoing) String() string {
+	return strconv.FormatBool(k.on)
+}
+
+func (k *keepGoing) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	k.on = on
+	return nil
+}
+
+func (k *keepGoing) IsBoolFlag() bool {
+	return true
+}
+
+// flagKeepGoing returns the keepGoing of the --keep-going flag of flags, or
+// nil if it has none.
+func flagKeepGoing(flags *flag.FlagSet) *keepGoing {
+	f := flags.Lookup("keep-going")
+	if f == nil {
+		return nil
+	}
+	k, _ := f.Value.(*keepGoing)
+	return k
+}
+
+// err returns an error listing the skipped pets, which pet-sounds exits
+// with exitPartial for, or nil if none were skipped.
+func (k *keepGoing) err() error {
+	if k == nil || len(k.skipped) == 0 {
+		return nil
+	}
+	errs := []string{}
+	for _, s := range k.skipped {
+		errs = append(errs, "\n  "+s.Err.Error())
+	}
+	return withExitCode(exitPartial, fmt.Errorf(
+		"skipped %d pet(s) that failed to decode:%s", len(k.skipped), strings.Join(errs, ""),
+	))
+}
@@ -0,0 +1,59 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeConfigKeepGoing(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`
+pet "Ink" {
+  type = "cat"
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    age = -1
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = pet.Swinney.sound
+  }
+}
+
+interaction {
+  from = "Swinney"
+  to   = "Ink"
+  verb = "chases"
+}
+`)
+	_, err := DecodeConfig(src, "keep_going.hcl", LoadOptions{})
+	assert.Error(t, err)
+
+	config, err := DecodeConfig(src, "keep_going.hcl", LoadOptions{KeepGoing: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []Pet{&Cat{Name: "Ink", Sound: "meow"}}, clearDeclRanges(config.Pets))
+	assert.Empty(t, config.Interactions)
+	if assert.Len(t, config.Skipped, 2) {
+		assert.Equal(t, "Swinney", config.Skipped[0].Name)
+		assert.Contains(t, config.Skipped[0].Err.Error(), "keep_going.hcl:9,11-13: Invalid age")
+		assert.Equal(t, "Neko", config.Skipped[1].Name)
+		assert.EqualError(t, config.Skipped[1].Err,
+			"error in DecodeConfig: pet `Neko` refers to pet `Swinney`, which failed to decode")
+	}
+
+	k := &keepGoing{on: true, skipped: config.Skipped}
+	err = k.err()
+	assert.Equal(t, exitPartial, exitCode(err))
+	assert.Contains(t, err.Error(), "skipped 2 pet(s) that failed to decode:\n  ")
+	assert.NoError(t, (&keepGoing{on: true}).err())
+}
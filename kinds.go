@@ -0,0 +1,268 @@
+package petsounds
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// petKind describes how to decode one type of pet. The characteristics of a
+// pet are decoded with an hcldec specification built from the hcl tags of
+// its struct, so adding a species is a matter of adding an entry to
+// petKinds.
+type petKind struct {
+	// new returns the pet p declares with its built in defaults.
+	new func(p *PetHCL, moods *MoodMachine, conditions *Conditions, feedings []*Feeding, visits []*VetVisit) Pet
+	// generate returns a random pet of the kind named name, for generated
+	// configurations.
+	generate func(r *rand.Rand, name string) Pet
+	// defaults returns the body of the kind's block in a defaults block, if
+	// there is one.
+	defaults func(d *DefaultsHCL) hcl.Body
+	// validate checks the decoded characteristics of a pet, which were
+	// decoded from body.
+	validate func(pet Pet, body hcl.Body) hcl.Diagnostics
+	// display is how pets of the kind are shown in styled output.
+	display petDisplay
+
+	spec   hcldec.ObjectSpec
+	fields []petField
+}
+
+// petDisplay is how a kind of pet is shown when output is styled.
+type petDisplay struct {
+	// emoji prefixes each line the pet writes.
+	emoji string
+	// emphasis is a format that decorates the pet's sound, like "~%s~".
+	emphasis string
+	// art are the templates the pet can be drawn with in --art mode.
+	art map[string]string
+}
+
+// unknownDisplay is the display of pets of types that are not in petKinds.
+var unknownDisplay = petDisplay{emoji: "🐾", emphasis: "%s"}
+
+// petField is an attribute of a pet's characteristics and the index of the
+// struct field it is decoded into.
+type petField struct {
+	name  string
+	index int
+}
+
+// petKinds are the types of pet a configuration can declare, by name.
+var petKinds = map[string]*petKind{
+	"cat": newPetKind(Cat{}, &petKind{
+		new: func(p *PetHCL, moods *MoodMachine, conditions *Conditions, feedings []*Feeding, visits []*VetVisit) Pet {
+			return &Cat{
+				Name: p.Name, Sound: defaultCatSound, Moods: moods, Conditions: conditions, Feedings: feedings,
+				VetVisits: visits, Disabled: p.Enabled != nil && !*p.Enabled, Tags: p.Tags, Household: p.household,
+				Owner: p.Owner, Language: p.Language, declRange: p.declRange,
+			}
+		},
+		defaults: func(d *DefaultsHCL) hcl.Body {
+			if d == nil || d.CatHCL == nil {
+				return nil
+			}
+			return d.CatHCL.HCL
+		},
+		validate: func(pet Pet, body hcl.Body) hcl.Diagnostics {
+			cat := pet.(*Cat)
+			diags := validateCharacteristics(body, "cat", cat.SoundStrategy, cat.ActionStrategy, cat.Age, cat.Weight, cat.Length)
+			diags = append(diags, validateArt(body, "cat", cat.Art, catArt)...)
+			diags = append(diags, validateVolume(body, "cat", cat.Volume)...)
+			return append(diags, validateRepeat(body, "cat", cat.Repeat)...)
+		},
+		generate: func(r *rand.Rand, name string) Pet {
+			sounds := []string{"meow", "purr", "mrrp", "chirp", "hiss"}
+			cat := &Cat{Name: name, Sound: sounds[r.Intn(len(sounds))]}
+			if r.Intn(2) == 0 {
+				age := r.Intn(21)
+				cat.Age = &age
+			}
+			if r.Intn(3) == 0 {
+				cat.Sounds = []string{sounds[r.Intn(len(sounds))], sounds[r.Intn(len(sounds))]}
+				cat.SoundStrategy = strategyRandom
+			}
+			return cat
+		},
+		display: petDisplay{emoji: "🐱", emphasis: "~%s~", art: catArt},
+	}),
+	"dog": newPetKind(Dog{}, &petKind{
+		new: func(p *PetHCL, moods *MoodMachine, conditions *Conditions, feedings []*Feeding, visits []*VetVisit) Pet {
+			return &Dog{
+				Name: p.Name, Breed: defaultDogBreed, Moods: moods, Conditions: conditions, Feedings: feedings,
+				VetVisits: visits, Disabled: p.Enabled != nil && !*p.Enabled, Tags: p.Tags, Household: p.household,
+				Owner: p.Owner, Language: p.Language, declRange: p.declRange,
+			}
+		},
+		defaults: func(d *DefaultsHCL) hcl.Body {
+			if d == nil || d.DogHCL == nil {
+				return nil
+			}
+			return d.DogHCL.HCL
+		},
+		validate: func(pet Pet, body hcl.Body) hcl.Diagnostics {
+			dog := pet.(*Dog)
+			diags := validateCharacteristics(body, "dog", dog.SoundStrategy, dog.ActionStrategy, dog.Age, dog.Weight, dog.Length)
+			diags = append(diags, validateArt(body, "dog", dog.Art, dogArt)...)
+			diags = append(diags, validateVolume(body, "dog", dog.Volume)...)
+			return append(diags, validateRepeat(body, "dog", dog.Repeat)...)
+		},
+		generate: func(r *rand.Rand, name string) Pet {
+			sounds := []string{"barks", "woofs", "yips", "howls", "growls"}
+			dog := &Dog{Name: name, Breed: dogBreeds[r.Intn(len(dogBreeds))], Sound: sounds[r.Intn(len(sounds))]}
+			if r.Intn(2) == 0 {
+				age := r.Intn(17)
+				dog.Age = &age
+			}
+			if r.Intn(2) == 0 {
+				vaccinated := r.Intn(4) != 0
+				dog.Vaccinated = &vaccinated
+			}
+			return dog
+		},
+		display: petDisplay{emoji: "🐶", emphasis: "%s!", art: dogArt},
+	}),
+}
+
+// petKindNames returns the names of petKinds, sorted.
+func petKindNames() []string {
+	names := []string{}
+	for name := range petKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newPetKind completes k with the specification of the hcl tagged fields of
+// the struct v.
+func newPetKind(v interface{}, k *petKind) *petKind {
+	k.spec = hcldec.ObjectSpec{}
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("hcl"), ",")
+		if tag[0] == "" {
+			continue
+		}
+		ty, err := gocty.ImpliedType(reflect.Zero(t.Field(i).Type).Interface())
+		if err != nil {
+			panic(fmt.Sprintf("pet field %s.%s: %s", t.Name(), t.Field(i).Name, err))
+		}
+		// Measurements can be strings with a unit, which decode converts.
+		if measuredAttrs[tag[0]] != nil {
+			ty = cty.DynamicPseudoType
+		}
+		// Durations are strings, which decode parses.
+		if t.Field(i).Type == durationType {
+			ty = cty.String
+		}
+		k.spec[tag[0]] = &hcldec.AttrSpec{
+			Name:     tag[0],
+			Type:     ty,
+			Required: len(tag) < 2 || tag[1] != "optional",
+		}
+		k.fields = append(k.fields, petField{name: tag[0], index: i})
+	}
+	return k
+}
+
+// HCLUnmarshaler is implemented by pets that decode their own
+// characteristics, for fields the hcl tags of a struct can't describe, such
+// as unions or values computed from other characteristics. UnmarshalHCL is
+// called with each body the pet's characteristics are set in, in turn: the
+// defaults of the file, those of the pet's household, and its own.
+type HCLUnmarshaler interface {
+	UnmarshalHCL(body hcl.Body, ctx *hcl.EvalContext) hcl.Diagnostics
+}
+
+// decode decodes the attributes body sets into pet, leaving the rest as
+// they were. Pets that are HCLUnmarshalers decode body themselves.
+func (k *petKind) decode(body hcl.Body, ctx *hcl.EvalContext, pet Pet) hcl.Diagnostics {
+	if u, ok := pet.(HCLUnmarshaler); ok {
+		return u.UnmarshalHCL(body, ctx)
+	}
+
+	val, diags := hcldec.Decode(body, k.spec, ctx)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	v := reflect.ValueOf(pet).Elem()
+	sensitive := fieldsOf(pet).sensitive
+	for _, f := range k.fields {
+		attr := val.GetAttr(f.name)
+		if attr.IsNull() {
+			continue
+		}
+		// Go fields can't hold marks, so the pet keeps which of its
+		// characteristics are sensitive instead, as set by the last body
+		// to set them.
+		setSensitive(sensitive, f.name, isSensitive(attr))
+		attr, _ = attr.UnmarkDeep()
+		if units, ok := measuredAttrs[f.name]; ok {
+			var err error
+			if attr, err = units.value(attr); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Invalid %s", f.name),
+					Detail:   fmt.Sprintf("Unsuitable value for %s: %s.", f.name, err),
+					Subject:  attributeRange(body, f.name),
+				})
+				continue
+			}
+		}
+		if field := v.Field(f.index); field.Type() == durationType {
+			d, err := parseDuration(attr)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Invalid %s", f.name),
+					Detail:   fmt.Sprintf("Unsuitable value for %s: %s.", f.name, err),
+					Subject:  attributeRange(body, f.name),
+				})
+				continue
+			}
+			field.SetInt(int64(d))
+			continue
+		}
+		if err := gocty.FromCtyValue(attr, v.Field(f.index).Addr().Interface()); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsuitable value",
+				Detail:   fmt.Sprintf("Unsuitable value for %s: %s.", f.name, err),
+				Subject:  attributeRange(body, f.name),
+			})
+		}
+	}
+	return diags
+}
+
+// validateCharacteristics checks the characteristics every type of pet
+// shares, which were decoded from body.
+func validateCharacteristics(
+	body hcl.Body, petType, soundStrategy, actionStrategy string, age *int, weight, length *float64,
+) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	for _, s := range []struct{ attr, strategy string }{
+		{"sound_strategy", soundStrategy},
+		{"action_strategy", actionStrategy},
+	} {
+		if err := validateStrategy(s.attr, s.strategy); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid strategy",
+				Detail:   fmt.Sprintf("The %s has an %s.", petType, err),
+				Subject:  attributeRange(body, s.attr),
+			})
+		}
+	}
+	return append(diags, validateVitals(body, petType, age, weight, length)...)
+}
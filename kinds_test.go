@@ -0,0 +1,151 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestPetKindDecode(t *testing.T) {
+	tcs := []struct {
+		name     string
+		kind     string
+		src      string
+		want     Pet
+		wantDiag string
+		wantLine int
+	}{
+		{
+			name: "cat",
+			kind: "cat",
+			src:  "sound = \"purr\"\nage = 3\nvaccinated = true\n",
+			want: &Cat{Name: "Ink", Sound: "purr", Age: intPtr(3), Vaccinated: boolPtr(true)},
+		},
+		{
+			name: "dog keeps defaults",
+			kind: "dog",
+			src:  "sounds = [\"woof\", \"arf\"]\n",
+			want: &Dog{Name: "Ink", Breed: defaultDogBreed, Sounds: []string{"woof", "arf"}},
+		},
+		{
+			name:     "wrong type",
+			kind:     "dog",
+			src:      "breed = \"corgi\"\nage = \"old\"\n",
+			wantDiag: "Incorrect attribute value type",
+			wantLine: 2,
+		},
+		{
+			name:     "fractional age",
+			kind:     "cat",
+			src:      "age = 2.5\n",
+			wantDiag: "Unsuitable value",
+			wantLine: 1,
+		},
+		{
+			name:     "cat breed",
+			kind:     "cat",
+			src:      "sound = \"meow\"\nbreed = \"tabby\"\n",
+			wantDiag: "Unsupported argument",
+			wantLine: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, diags := hclsyntax.ParseConfig([]byte(tc.src), "kinds.hcl", hcl.InitialPos)
+			if !assert.False(t, diags.HasErrors()) {
+				return
+			}
+
+			kind := petKinds[tc.kind]
+			pet := kind.new(&PetHCL{Name: "Ink"}, nil, nil, nil, nil)
+			diags = kind.decode(file.Body, nil, pet)
+			if tc.wantDiag == "" {
+				assert.False(t, diags.HasErrors(), diags.Error())
+				assert.Equal(t, tc.want, pet)
+				return
+			}
+			if assert.Len(t, diags, 1) {
+				assert.Equal(t, tc.wantDiag, diags[0].Summary)
+				assert.Equal(t, tc.wantLine, diags[0].Subject.Start.Line)
+			}
+		})
+	}
+}
+
+// chorusPet decodes its own characteristics, as its sound can be a string
+// or a list of strings to say together.
+type chorusPet struct {
+	Sound string `hcl:"sound"`
+}
+
+func (c *chorusPet) Say(w io.Writer) { fmt.Fprintln(w, c.Sound) }
+func (c *chorusPet) Act(w io.Writer) {}
+
+func (c *chorusPet) UnmarshalHCL(body hcl.Body, ctx *hcl.EvalContext) hcl.Diagnostics {
+	attrs, diags := body.JustAttributes()
+	if attr, ok := attrs["sound"]; ok {
+		val, valDiags := attr.Expr.Value(ctx)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			return diags
+		}
+		if val.Type() == cty.String {
+			c.Sound = val.AsString()
+			return diags
+		}
+		sounds := []string{}
+		for _, v := range val.AsValueSlice() {
+			sounds = append(sounds, v.AsString())
+		}
+		c.Sound = strings.Join(sounds, " ")
+	}
+	return diags
+}
+
+func TestPetKindDecodeUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	// The spec of the kind would reject a list.
+	kind := newPetKind(chorusPet{}, &petKind{})
+	for src, want := range map[string]string{
+		`sound = "tweet"`:            "tweet",
+		`sound = ["tweet", "cheep"]`: "tweet cheep",
+	} {
+		file, diags := hclsyntax.ParseConfig([]byte(src), "kinds.hcl", hcl.InitialPos)
+		if !assert.False(t, diags.HasErrors()) {
+			return
+		}
+		pet := &chorusPet{}
+		diags = kind.decode(file.Body, nil, pet)
+		if assert.False(t, diags.HasErrors(), diags.Error()) {
+			assert.Equal(t, want, pet.Sound)
+		}
+	}
+}
+
+func TestValidateCharacteristics(t *testing.T) {
+	src := []byte("sound_strategy = \"shuffle\"\nage = 40\n")
+	file, diags := hclsyntax.ParseConfig(src, "kinds.hcl", hcl.InitialPos)
+	if !assert.False(t, diags.HasErrors()) {
+		return
+	}
+
+	got := validateCharacteristics(file.Body, "cat", "shuffle", "", intPtr(40), nil, nil)
+	summaries, lines := []string{}, []int{}
+	for _, d := range got {
+		summaries = append(summaries, d.Summary)
+		lines = append(lines, d.Subject.Start.Line)
+	}
+	assert.Equal(t, []string{"Invalid strategy", "Invalid age"}, summaries)
+	assert.Equal(t, []int{1, 2}, lines)
+}
@@ -0,0 +1,445 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The severities of lint findings, named as SARIF names its levels.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityNote    = "note"
+	// severityOff turns a rule off.
+	severityOff = "off"
+)
+
+// lintRule is something the linter checks configuration files for.
+type lintRule struct {
+	id          string
+	severity    string
+	description string
+}
+
+// lintRules are the rules Lint checks. Files that can't be decoded break
+// the invalid-config rule, with a finding for each problem decoding them.
+var lintRules = []lintRule{
+	{"invalid-config", severityError, "The configuration can't be decoded."},
+	{"missing-characteristics", severityWarning, "A pet has no characteristics block and extends no template, so it has no sound."},
+	{"unused-variable", severityNote, "A variable is declared but never used as var.<name>."},
+	{"empty-sound", severityWarning, "A sound is empty or only whitespace, so the pet says nothing."},
+}
+
+// LintHCL is the lint block, which sets the severity of lint rules for the
+// file it is in, or turns them off:
+//   lint {
+//     rule "unused-variable" {
+//       severity = "warning"
+//     }
+//   }
+type LintHCL struct {
+	Rules []*LintRuleHCL `hcl:"rule,block"`
+}
+
+// LintRuleHCL is a rule block of a lint block.
+type LintRuleHCL struct {
+	ID       string `hcl:"id,label"`
+	Severity string `hcl:"severity"`
+}
+
+// LintFinding is a problem Lint found in a configuration file.
+type LintFinding struct {
+	Rule     string
+	Severity string
+	Message  string
+	Range    hcl.Range
+}
+
+// Lint checks src, the contents of a configuration file named filename,
+// for problems. It reports the problems that stop the file being decoded,
+// and style problems that don't, sorted by where they are in the file.
+// Only files in HCL's native syntax can be linted.
+func Lint(src []byte, filename string, opts LoadOptions) ([]LintFinding, error) {
+	format := opts.Format
+	if format == "" {
+		format = formatOf(filename)
+	}
+	if format != FormatHCL {
+		return nil, fmt.Errorf("error in Lint: only hcl files can be linted, not %s", format)
+	}
+
+	findings := []LintFinding{}
+	if _, err := DecodeConfig(src, filename, opts); err != nil {
+		for _, diag := range errorDiagnostics(err) {
+			finding := LintFinding{Rule: "invalid-config", Severity: severityError, Message: diag.Summary}
+			if diag.Detail != "" {
+				finding.Message += ": " + diag.Detail
+			}
+			if diag.Subject != nil {
+				finding.Range = *diag.Subject
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	style, err := lintStyle(src, filename, opts.LintRules)
+	if err != nil {
+		return nil, fmt.Errorf("error in Lint: %w", err)
+	}
+	findings = append(findings, style...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Range.Start.Byte < findings[j].Range.Start.Byte
+	})
+	return findings, nil
+}
+
+// lintStyle checks src, the contents of an HCL configuration file named
+// filename, for the problems that don't stop it being decoded, with the
+// severities its lint block sets, and then those rules sets. Files that
+// can't be parsed have no style problems, as their parse errors are
+// reported when they are decoded.
+func lintStyle(src []byte, filename string, rules map[string]string) ([]LintFinding, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil
+	}
+	body := file.Body.(*hclsyntax.Body)
+	severities, diags := lintSeverities(body)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	ids := []string{}
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := checkLintRule(id, rules[id]); err != nil {
+			return nil, err
+		}
+		severities[id] = rules[id]
+	}
+
+	findings := []LintFinding{}
+	findings = append(findings, lintPets(body, declaredPetTypes(body))...)
+	findings = append(findings, lintVariables(body)...)
+	findings = append(findings, lintSounds(body)...)
+	configured := []LintFinding{}
+	for _, f := range findings {
+		if severity, ok := severities[f.Rule]; ok {
+			f.Severity = severity
+		}
+		if f.Severity != severityOff {
+			configured = append(configured, f)
+		}
+	}
+	return configured, nil
+}
+
+// lintSeverities returns the severities the lint blocks of body set, by
+// rule.
+func lintSeverities(body *hclsyntax.Body) (map[string]string, hcl.Diagnostics) {
+	severities, diags := map[string]string{}, hcl.Diagnostics{}
+	for _, block := range body.Blocks {
+		if block.Type != "lint" {
+			continue
+		}
+		lint := &LintHCL{}
+		if decodeDiags := gohcl.DecodeBody(block.Body, nil, lint); decodeDiags.HasErrors() {
+			diags = append(diags, decodeDiags...)
+			continue
+		}
+		for i, rule := range lint.Rules {
+			if err := checkLintRule(rule.ID, rule.Severity); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid lint rule",
+					Detail:   err.Error(),
+					Subject:  lintRuleRange(block.Body, i),
+				})
+				continue
+			}
+			severities[rule.ID] = rule.Severity
+		}
+	}
+	return severities, diags
+}
+
+// lintRuleRange returns the range of the i'th rule block of body.
+func lintRuleRange(body *hclsyntax.Body, i int) *hcl.Range {
+	for _, block := range body.Blocks {
+		if block.Type != "rule" {
+			continue
+		}
+		if i == 0 {
+			rng := block.DefRange()
+			return &rng
+		}
+		i--
+	}
+	return nil
+}
+
+// checkLintRule returns an error if id is not a rule that can be
+// configured, or severity not a severity it can be given.
+func checkLintRule(id, severity string) error {
+	ids := []string{}
+	for _, rule := range lintRules[1:] {
+		ids = append(ids, rule.id)
+	}
+	switch {
+	case id == "invalid-config":
+		return fmt.Errorf("the invalid-config lint rule can't be configured")
+	case !contains(ids, id):
+		if suggestion := suggest(id, ids); suggestion != "" {
+			return fmt.Errorf("unknown lint rule `%s`, did you mean `%s`?", id, suggestion)
+		}
+		return fmt.Errorf("unknown lint rule `%s`", id)
+	}
+	switch severity {
+	case severityError, severityWarning, severityNote, severityOff:
+		return nil
+	}
+	return fmt.Errorf("unknown severity `%s` for lint rule `%s`, expected error, warning, note or off", severity, id)
+}
+
+// parseLintRules parses -lint-rule flags, each of which is id=severity.
+func parseLintRules(values []string) (map[string]string, error) {
+	rules := map[string]string{}
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid lint rule `%s`, expected id=severity", v)
+		}
+		if err := checkLintRule(parts[0], parts[1]); err != nil {
+			return nil, err
+		}
+		rules[parts[0]] = parts[1]
+	}
+	return rules, nil
+}
+
+// lintPets finds the pets of body, or of its households, that have no
+// characteristics. Pets of petTypes, the types declared by the file's
+// pet_type blocks, have none to give.
+func lintPets(body *hclsyntax.Body, petTypes []string) []LintFinding {
+	findings := []LintFinding{}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "household":
+			findings = append(findings, lintPets(block.Body, petTypes)...)
+		case "pet":
+			if _, ok := block.Body.Attributes[extendsKey]; ok {
+				continue
+			}
+			if attr, ok := block.Body.Attributes["type"]; ok {
+				if val, diags := attr.Expr.Value(nil); !diags.HasErrors() && val.Type() == cty.String &&
+					val.IsKnown() && !val.IsNull() && contains(petTypes, val.AsString()) {
+					continue
+				}
+			}
+			if hasBlock(block.Body, "characteristics") {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				Rule:     "missing-characteristics",
+				Severity: severityWarning,
+				Message:  fmt.Sprintf("pet `%s` has no characteristics block", strings.Join(block.Labels, " ")),
+				Range:    block.DefRange(),
+			})
+		}
+	}
+	return findings
+}
+
+// hasBlock reports whether body has a block of type blockType.
+func hasBlock(body *hclsyntax.Body, blockType string) bool {
+	for _, block := range body.Blocks {
+		if block.Type == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+// lintVariables finds the variables of body that are not used anywhere in
+// it.
+func lintVariables(body *hclsyntax.Body) []LintFinding {
+	used := map[string]bool{}
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		expr, ok := node.(hclsyntax.Expression)
+		if !ok {
+			return nil
+		}
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() != "var" || len(traversal) < 2 {
+				continue
+			}
+			switch step := traversal[1].(type) {
+			case hcl.TraverseAttr:
+				used[step.Name] = true
+			case hcl.TraverseIndex:
+				if step.Key.Type() == cty.String {
+					used[step.Key.AsString()] = true
+				}
+			}
+		}
+		return nil
+	})
+
+	findings := []LintFinding{}
+	for _, block := range body.Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 || used[block.Labels[0]] {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Rule:     "unused-variable",
+			Severity: severityNote,
+			Message:  fmt.Sprintf("variable `%s` is never used", block.Labels[0]),
+			Range:    block.LabelRanges[0],
+		})
+	}
+	return findings
+}
+
+// lintSounds finds the sounds in body that are empty strings, or only
+// whitespace. Sounds that are not constants can't be checked.
+func lintSounds(body *hclsyntax.Body) []LintFinding {
+	findings := []LintFinding{}
+	if attr, ok := body.Attributes["sound"]; ok {
+		value, diags := attr.Expr.Value(nil)
+		if !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() && !value.IsNull() &&
+			strings.TrimSpace(value.AsString()) == "" {
+			findings = append(findings, LintFinding{
+				Rule:     "empty-sound",
+				Severity: severityWarning,
+				Message:  "sound is empty",
+				Range:    attr.Expr.Range(),
+			})
+		}
+	}
+	for _, block := range body.Blocks {
+		findings = append(findings, lintSounds(block.Body)...)
+	}
+	return findings
+}
+
+// lintDiagnostics returns the findings that are errors or warnings as
+// diagnostics, for commands that report them alongside the other problems
+// of a configuration. Notes are left out.
+func lintDiagnostics(findings []LintFinding) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	for _, f := range findings {
+		severity := hcl.DiagWarning
+		switch f.Severity {
+		case severityNote:
+			continue
+		case severityError:
+			severity = hcl.DiagError
+		}
+		diag := &hcl.Diagnostic{Severity: severity, Summary: "Lint rule " + f.Rule, Detail: f.Message}
+		if f.Range.Filename != "" {
+			rng := f.Range
+			diag.Subject = &rng
+		}
+		diags = append(diags, diag)
+	}
+	return diags
+}
+
+// lintFailed reports whether any of findings is an error.
+func lintFailed(findings []LintFinding) bool {
+	for _, f := range findings {
+		if f.Severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteLintText writes findings to w, one per line, as in
+//   pets.hcl:3,1-11: warning: pet `Ink` has no characteristics block (missing-characteristics)
+func WriteLintText(w io.Writer, findings []LintFinding) error {
+	for _, f := range findings {
+		location := f.Range.String()
+		if f.Range.Filename == "" {
+			location = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s (%s)\n", location, f.Severity, f.Message, f.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The version of SARIF WriteLintSARIF writes, and its schema.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// WriteLintSARIF writes findings to w as a SARIF log, for code review tools
+// to show them on the lines they are about.
+func WriteLintSARIF(w io.Writer, findings []LintFinding) error {
+	rules := []map[string]interface{}{}
+	for _, rule := range lintRules {
+		rules = append(rules, map[string]interface{}{
+			"id":                   rule.id,
+			"shortDescription":     map[string]string{"text": rule.description},
+			"defaultConfiguration": map[string]string{"level": rule.severity},
+		})
+	}
+
+	results := []map[string]interface{}{}
+	for _, f := range findings {
+		result := map[string]interface{}{
+			"ruleId":  f.Rule,
+			"level":   f.Severity,
+			"message": map[string]string{"text": f.Message},
+		}
+		if f.Range.Filename != "" {
+			result["locations"] = []map[string]interface{}{{
+				"physicalLocation": map[string]interface{}{
+					"artifactLocation": map[string]string{"uri": f.Range.Filename},
+					"region": map[string]int{
+						"startLine":   f.Range.Start.Line,
+						"startColumn": f.Range.Start.Column,
+						"endLine":     f.Range.End.Line,
+						"endColumn":   f.Range.End.Column,
+					},
+				},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	log := map[string]interface{}{
+		"$schema": sarifSchema,
+		"version": sarifVersion,
+		"runs": []map[string]interface{}{{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":           "pet-sounds",
+					"version":        buildInfo().Version,
+					"informationUri": "https://github.com/russellrollins/pet-sounds",
+					"rules":          rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+	out, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
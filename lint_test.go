@@ -0,0 +1,259 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "clean",
+			src: `variable "sound" {
+  default = "meow"
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = var.sound
+  }
+}
+`,
+			want: []string{},
+		},
+		{
+			name: "unused variable",
+			src: `variable "sound" {
+  default = "meow"
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "purr"
+  }
+}
+`,
+			want: []string{"unused-variable"},
+		},
+		{
+			name: "empty sound",
+			src: `pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "  "
+  }
+}
+`,
+			want: []string{"empty-sound"},
+		},
+		{
+			name: "missing characteristics",
+			src: `pet "Ink" {
+  type = "cat"
+}
+`,
+			want: []string{"missing-characteristics"},
+		},
+		{
+			name: "unknown type",
+			src: `pet "Ink" {
+  type = "cta"
+  characteristics {
+    sound = "meow"
+  }
+}
+`,
+			want: []string{"invalid-config"},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := Lint([]byte(tc.src), "pets.hcl", LoadOptions{})
+			if !assert.NoError(t, err) {
+				return
+			}
+			rules := []string{}
+			for _, f := range findings {
+				rules = append(rules, f.Rule)
+			}
+			assert.ElementsMatch(t, tc.want, rules)
+		})
+	}
+}
+
+func TestLintOnlyHCL(t *testing.T) {
+	t.Parallel()
+
+	_, err := Lint([]byte("pets: []\n"), "pets.yaml", LoadOptions{})
+	assert.EqualError(t, err, "error in Lint: only hcl files can be linted, not yaml")
+}
+
+func TestWriteLintSARIF(t *testing.T) {
+	t.Parallel()
+
+	findings, err := Lint([]byte(`pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = ""
+  }
+}
+`), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	text := &bytes.Buffer{}
+	assert.NoError(t, WriteLintText(text, findings))
+	assert.Equal(t, "pets.hcl:4,13-15: warning: sound is empty (empty-sound)\n", text.String())
+
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, WriteLintSARIF(out, findings)) {
+		return
+	}
+	log := struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}{}
+	if !assert.NoError(t, json.Unmarshal(out.Bytes(), &log)) {
+		return
+	}
+	assert.Equal(t, sarifVersion, log.Version)
+	if !assert.Len(t, log.Runs, 1) || !assert.Len(t, log.Runs[0].Results, 1) {
+		return
+	}
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(lintRules))
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "empty-sound", result.RuleID)
+	assert.Equal(t, "warning", result.Level)
+	assert.Equal(t, "pets.hcl", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 4, result.Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, 13, result.Locations[0].PhysicalLocation.Region.StartColumn)
+}
+
+func TestLintSeverities(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`lint {
+  rule "missing-characteristics" {
+    severity = "off"
+  }
+  rule "unused-variable" {
+    severity = "error"
+  }
+}
+
+variable "sound" {
+  default = "meow"
+}
+
+pet "Ink" {
+  type = "cat"
+}
+`)
+	tcs := []struct {
+		name  string
+		rules map[string]string
+		want  map[string]string
+	}{
+		{
+			name: "lint block",
+			want: map[string]string{"unused-variable": severityError},
+		},
+		{
+			name:  "overridden",
+			rules: map[string]string{"missing-characteristics": severityWarning, "unused-variable": severityOff},
+			want:  map[string]string{"missing-characteristics": severityWarning},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := Lint(src, "pets.hcl", LoadOptions{LintRules: tc.rules})
+			if !assert.NoError(t, err) {
+				return
+			}
+			severities := map[string]string{}
+			for _, f := range findings {
+				severities[f.Rule] = f.Severity
+			}
+			assert.Equal(t, tc.want, severities)
+		})
+	}
+}
+
+func TestLintSeveritiesInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := Lint([]byte(`lint {
+  rule "empty-sounds" {
+    severity = "loud"
+  }
+}
+`), "pets.hcl", LoadOptions{})
+	assert.EqualError(t, err, "error in Lint: pets.hcl:2,3-24: Invalid lint rule; "+
+		"unknown lint rule `empty-sounds`, did you mean `empty-sound`?")
+
+	_, err = parseLintRules([]string{"invalid-config=off"})
+	assert.EqualError(t, err, "the invalid-config lint rule can't be configured")
+	_, err = parseLintRules([]string{"empty-sound=loud"})
+	assert.EqualError(t, err, "unknown severity `loud` for lint rule `empty-sound`, expected error, warning, note or off")
+	_, err = parseLintRules([]string{"empty-sound"})
+	assert.EqualError(t, err, "invalid lint rule `empty-sound`, expected id=severity")
+}
+
+func TestLintDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	diags := lintDiagnostics([]LintFinding{
+		{Rule: "empty-sound", Severity: severityWarning, Message: "sound is empty"},
+		{Rule: "unused-variable", Severity: severityNote, Message: "variable `sound` is never used"},
+		{Rule: "missing-characteristics", Severity: severityError, Message: "pet `Ink` has no characteristics block"},
+	})
+	if assert.Len(t, diags, 2) {
+		assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+		assert.Equal(t, "Lint rule empty-sound", diags[0].Summary)
+		assert.Equal(t, hcl.DiagError, diags[1].Severity)
+	}
+}
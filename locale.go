@@ -0,0 +1,262 @@
+package petsounds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Locale is a language pack, with the sounds and actions pets fall back to
+// in that language when their configuration doesn't set their own. Packs
+// are written in HCL, with a block for each type of pet:
+//   cat {
+//     sound = "nyan"
+//     actions = {
+//       default = "hirune suru"
+//       hungry  = "esa wo nedaru"
+//     }
+//   }
+// where the default action is for pets without a mood. The sound replaces
+// a pet type's built in sound, so a cat configured to meow says nyan too.
+type Locale struct {
+	Name    string
+	Sounds  map[string]string
+	Actions map[string]map[Mood]string
+}
+
+// localeDefaultMood is the key of the action for pets without a mood.
+const localeDefaultMood = "default"
+
+// localePetHCL is the block of one type of pet in a language pack.
+type localePetHCL struct {
+	Sound   string            `hcl:"sound,optional"`
+	Actions map[string]string `hcl:"actions,optional"`
+}
+
+// builtinLocales are the language packs built into pet-sounds, by name.
+var builtinLocales = map[string]string{
+	"en": `
+cat {
+  sound = "meow"
+  actions = {
+    default = "snoozes"
+    sleepy  = "snoozes"
+    hungry  = "paws at the food bowl"
+    playful = "chases a piece of string"
+  }
+}
+
+dog {
+  sound = "barks"
+  actions = {
+    default = "plays"
+    sleepy  = "naps in a sunbeam"
+    hungry  = "begs for treats"
+    playful = "plays"
+  }
+}
+`,
+	"ja": `
+cat {
+  sound = "nyan"
+  actions = {
+    default = "hirune suru"
+    sleepy  = "hirune suru"
+    hungry  = "esa no osara wo tataku"
+    playful = "himo wo oikakeru"
+  }
+}
+
+dog {
+  sound = "wan wan"
+  actions = {
+    default = "asobu"
+    sleepy  = "hinata de hirune suru"
+    hungry  = "oyatsu wo nedaru"
+    playful = "asobu"
+  }
+}
+`,
+	"fr": `
+cat {
+  sound = "miaou"
+  actions = {
+    default = "fait la sieste"
+    sleepy  = "fait la sieste"
+    hungry  = "gratte la gamelle"
+    playful = "court après une ficelle"
+  }
+}
+
+dog {
+  sound = "ouaf ouaf"
+  actions = {
+    default = "joue"
+    sleepy  = "fait la sieste au soleil"
+    hungry  = "réclame des friandises"
+    playful = "joue"
+  }
+}
+`,
+}
+
+// LoadLocale returns the language pack for lang. lang is either the name of
+// a pack, or the path to a pack file ending in .hcl. A pack named
+// <lang>.hcl in the locales directory of the user's configuration directory
+// overrides the built in pack of the same name, and can add packs of its
+// own.
+func LoadLocale(lang string) (*Locale, error) {
+	if strings.HasSuffix(lang, ".hcl") {
+		src, err := ioutil.ReadFile(lang)
+		if err != nil {
+			return nil, fmt.Errorf("error in LoadLocale: %w", err)
+		}
+		return parseLocale(strings.TrimSuffix(filepath.Base(lang), ".hcl"), src, lang, nil)
+	}
+
+	var locale *Locale
+	if src, ok := builtinLocales[lang]; ok {
+		var err error
+		if locale, err = parseLocale(lang, []byte(src), lang+".hcl", nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		filename := filepath.Join(dir, "pet-sounds", "locales", lang+".hcl")
+		src, err := ioutil.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error in LoadLocale: %w", err)
+		}
+		if err == nil {
+			return parseLocale(lang, src, filename, locale)
+		}
+	}
+	if locale != nil {
+		return locale, nil
+	}
+
+	names := []string{}
+	for name := range builtinLocales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf(
+		"error in LoadLocale: unknown language `%s`, expected one of %s", lang, strings.Join(names, ", "),
+	)
+}
+
+// parseLocale parses the language pack src, read from filename, over base,
+// which may be nil.
+func parseLocale(name string, src []byte, filename string, base *Locale) (*Locale, error) {
+	locale := &Locale{Name: name, Sounds: map[string]string{}, Actions: map[string]map[Mood]string{}}
+	if base != nil {
+		for petType, sound := range base.Sounds {
+			locale.Sounds[petType] = sound
+		}
+		for petType, actions := range base.Actions {
+			locale.Actions[petType] = map[Mood]string{}
+			for mood, action := range actions {
+				locale.Actions[petType][mood] = action
+			}
+		}
+	}
+
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error in LoadLocale parsing `%s`: %w", filename, diags)
+	}
+
+	// Packs have a block for each kind of pet.
+	schema := &hcl.BodySchema{}
+	for petType := range petKinds {
+		schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: petType})
+	}
+	content, diags := file.Body.Content(schema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error in LoadLocale decoding `%s`: %w", filename, diags)
+	}
+
+	for _, block := range content.Blocks {
+		pet := &localePetHCL{}
+		if diags := gohcl.DecodeBody(block.Body, nil, pet); diags.HasErrors() {
+			return nil, fmt.Errorf("error in LoadLocale decoding `%s`: %w", filename, diags)
+		}
+		if pet.Sound != "" {
+			locale.Sounds[block.Type] = pet.Sound
+		}
+		for mood, action := range pet.Actions {
+			if mood == localeDefaultMood {
+				mood = ""
+			}
+			if locale.Actions[block.Type] == nil {
+				locale.Actions[block.Type] = map[Mood]string{}
+			}
+			locale.Actions[block.Type][Mood(mood)] = action
+		}
+	}
+	return locale, nil
+}
+
+// sound returns the sound of pets of petType in l, or fallback if l has
+// none or is nil.
+func (l *Locale) sound(petType, fallback string) string {
+	if l == nil || l.Sounds[petType] == "" {
+		return fallback
+	}
+	return l.Sounds[petType]
+}
+
+// action returns the action of pets of petType in mood in l, or fallback if
+// l has none or is nil.
+func (l *Locale) action(petType string, mood Mood, fallback string) string {
+	if l == nil || l.Actions[petType][mood] == "" {
+		return fallback
+	}
+	return l.Actions[petType][mood]
+}
+
+// LocalizeLanguages has each of pets with a language of its own fall back
+// to the sounds and actions of the language pack of that language, over
+// any that Localize gave it. Each pack is loaded once, however many pets
+// speak it.
+func LocalizeLanguages(pets []Pet) error {
+	locales := map[string]*Locale{}
+	for _, p := range pets {
+		lang := petLanguage(p)
+		if lang == "" {
+			continue
+		}
+		if _, ok := locales[lang]; !ok {
+			locale, err := LoadLocale(lang)
+			if err != nil {
+				name, _ := petIdentity(p)
+				return fmt.Errorf("error in LocalizeLanguages for pet `%s`: %w", name, err)
+			}
+			locales[lang] = locale
+		}
+		Localize([]Pet{p}, locales[lang])
+	}
+	return nil
+}
+
+// petLanguage returns the language of a pet, which is empty for pets that
+// speak the language of the rest.
+func petLanguage(p Pet) string {
+	return fieldsOf(p).language
+}
+
+// Localize has pets fall back to the sounds and actions of l.
+func Localize(pets []Pet, l *Locale) {
+	Walk(pets, VisitorFuncs{
+		Cat: func(c *Cat) { c.locale = l },
+		Dog: func(d *Dog) { d.locale = l },
+	})
+}
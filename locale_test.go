@@ -0,0 +1,124 @@
+package petsounds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	tcs := []struct {
+		name string
+		lang string
+		pets []Pet
+		want string
+	}{
+		{
+			name: "ja",
+			lang: "ja",
+			pets: []Pet{&Cat{Name: "Ink", Sound: defaultCatSound}, &Dog{Name: "Swinney", Breed: "Dachshund"}},
+			want: "Ink nyan\nInk hirune suru\nSwinney the Dachshund wan wan\nSwinney the Dachshund asobu\n",
+		},
+		{
+			name: "configured sounds",
+			lang: "ja",
+			pets: []Pet{&Cat{Name: "Ink", Sound: "mrrp", Actions: []string{"stretches"}}},
+			want: "Ink mrrp\nInk stretches\n",
+		},
+		{
+			name: "pack file",
+			lang: "testdata/locales/pirate.hcl",
+			pets: []Pet{&Cat{Name: "Ink", Sound: defaultCatSound}, &Dog{Name: "Swinney", Breed: "Dachshund"}},
+			want: "Ink yarr\nInk snoozes\nSwinney the Dachshund barks\nSwinney the Dachshund digs for treasure\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			locale, err := LoadLocale(tc.lang)
+			if !assert.Nil(t, err) {
+				return
+			}
+			Localize(tc.pets, locale)
+			out := &bytes.Buffer{}
+			for _, p := range tc.pets {
+				p.Say(out)
+				p.Act(out)
+			}
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestLoadLocaleUserPack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-locale")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+
+	locales := filepath.Join(dir, "pet-sounds", "locales")
+	if !assert.Nil(t, os.MkdirAll(locales, 0755)) {
+		return
+	}
+	src := []byte("dog {\n  sound = \"wau wau\"\n}\n")
+	if !assert.Nil(t, ioutil.WriteFile(filepath.Join(locales, "ja.hcl"), src, 0644)) {
+		return
+	}
+
+	// The user's pack is merged over the built in one.
+	locale, err := LoadLocale("ja")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wau wau", locale.sound("dog", defaultDogSound))
+		assert.Equal(t, "nyan", locale.sound("cat", defaultCatSound))
+	}
+
+	_, err = LoadLocale("de")
+	assert.EqualError(t, err, "error in LoadLocale: unknown language `de`, expected one of en, fr, ja")
+}
+
+func TestLocalizeLanguages(t *testing.T) {
+	t.Parallel()
+
+	pets := mustParse(t, `
+pet "Ink" {
+  type     = "cat"
+  language = "fr"
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+`)
+	// The pet's own language is spoken over the one every pet is given.
+	locale, err := LoadLocale("en")
+	if !assert.Nil(t, err) {
+		return
+	}
+	Localize(pets, locale)
+	if !assert.Nil(t, LocalizeLanguages(pets)) {
+		return
+	}
+
+	out := &bytes.Buffer{}
+	for _, p := range pets {
+		p.Say(out)
+	}
+	assert.Equal(t, "Ink miaou\nSwinney the Dachshund barks\n", out.String())
+
+	pets = mustParse(t, "pet \"Ink\" {\n  type     = \"cat\"\n  language = \"de\"\n}\n")
+	assert.EqualError(t, LocalizeLanguages(pets),
+		"error in LocalizeLanguages for pet `Ink`: error in LoadLocale: unknown language `de`, expected one of en, fr, ja")
+}
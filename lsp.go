@@ -0,0 +1,478 @@
+package petsounds
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// languageServer is a Language Server Protocol server for configuration
+// files, for the lsp command. It reports the diagnostics of decoding the
+// open files, completes attribute and block names, including the
+// characteristics of each type of pet, documents functions on hover, and
+// goes from references to other pets to the pets' blocks.
+type languageServer struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// docs are the contents of the open files, by URI.
+	docs map[string]string
+}
+
+// lspMessage is a JSON-RPC request, response or notification.
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lspMethodNotFound is the JSON-RPC error of requests for methods the server
+// does not have.
+const lspMethodNotFound = -32601
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspCompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// The kinds of completion items the server offers.
+const (
+	lspCompletionProperty = 10
+	lspCompletionModule   = 9
+)
+
+// textDocumentPosition is the parameters of requests about a position in
+// a file.
+type textDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+// newLanguageServer returns a languageServer that reads requests from in and
+// writes responses to out.
+func newLanguageServer(in io.Reader, out io.Writer) *languageServer {
+	return &languageServer{in: bufio.NewReader(in), out: out, docs: map[string]string{}}
+}
+
+// Run serves requests until the client exits or in ends.
+func (s *languageServer) Run() error {
+	for {
+		msg, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error in languageServer reading request: %w", err)
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			continue
+		}
+		response := &lspMessage{JSONRPC: "2.0", ID: msg.ID, Result: result}
+		if err != nil {
+			response = &lspMessage{JSONRPC: "2.0", ID: msg.ID, Error: &lspError{Code: lspMethodNotFound, Message: err.Error()}}
+		} else if result == nil {
+			// A null result still has to be sent.
+			response.Result = json.RawMessage("null")
+		}
+		if err := s.write(response); err != nil {
+			return fmt.Errorf("error in languageServer writing response: %w", err)
+		}
+	}
+}
+
+// read reads the next message, which is framed by a Content-Length header.
+func (s *languageServer) read() (*lspMessage, error) {
+	length := -1
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(line), "content-length:") {
+			if length, err = strconv.Atoi(strings.TrimSpace(line[len("content-length:"):])); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without a Content-Length")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	msg := &lspMessage{}
+	return msg, json.Unmarshal(body, msg)
+}
+
+// write writes msg, framed by a Content-Length header.
+func (s *languageServer) write(msg *lspMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// handle returns the result of msg. Notifications the server has no use
+// for are ignored.
+func (s *languageServer) handle(msg *lspMessage) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1,
+				"completionProvider": map[string]interface{}{},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "pet-sounds", "version": buildInfo().Version},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		params := struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		params := struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		// Files are synced whole, so the last change is the file.
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		params := textDocumentPosition{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.write(&lspMessage{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: mustJSON(
+			map[string]interface{}{"uri": params.TextDocument.URI, "diagnostics": []lspDiagnostic{}},
+		)})
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		params := textDocumentPosition{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		src := s.docs[params.TextDocument.URI]
+		offset := lspOffset(src, params.Position)
+		switch msg.Method {
+		case "textDocument/completion":
+			return lspCompletions(src, offset), nil
+		case "textDocument/hover":
+			return lspHover(src, offset), nil
+		default:
+			return lspDefinition(params.TextDocument.URI, src, offset), nil
+		}
+	}
+	if msg.ID != nil && !strings.HasPrefix(msg.Method, "$/") {
+		return nil, fmt.Errorf("method `%s` not found", msg.Method)
+	}
+	return nil, nil
+}
+
+// publishDiagnostics sends the diagnostics of decoding the file at uri.
+func (s *languageServer) publishDiagnostics(uri string) error {
+	diagnostics := []lspDiagnostic{}
+	config, err := DecodeConfig([]byte(s.docs[uri]), lspFilename(uri), LoadOptions{})
+	var diags hcl.Diagnostics
+	if err != nil {
+		diags = errorDiagnostics(err)
+	} else {
+		diags = config.Warnings
+	}
+
+	for _, d := range diags {
+		diagnostic := lspDiagnostic{Severity: 1, Source: "pet-sounds", Message: d.Summary}
+		if d.Severity == hcl.DiagWarning {
+			diagnostic.Severity = 2
+		}
+		if d.Detail != "" {
+			diagnostic.Message += ": " + d.Detail
+		}
+		if d.Subject != nil {
+			diagnostic.Range = lspRangeOf(*d.Subject)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return s.write(&lspMessage{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: mustJSON(
+		map[string]interface{}{"uri": uri, "diagnostics": diagnostics},
+	)})
+}
+
+// lspCompletions returns the names of the attributes and blocks that can be
+// written at offset of src: those of the body of the innermost block
+// around it, or, in characteristics, those of the pet's type.
+func lspCompletions(src string, offset int) []lspCompletionItem {
+	file, _ := hclsyntax.ParseConfig([]byte(src), "", hcl.InitialPos)
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return []lspCompletionItem{}
+	}
+
+	t := reflect.TypeOf(PetsHCL{})
+	var pet *hclsyntax.Block
+	for {
+		block := blockAt(body, offset)
+		if block == nil {
+			break
+		}
+		if block.Type == "pet" {
+			pet = block
+		}
+		if block.Type == "characteristics" || petKinds[block.Type] != nil {
+			return characteristicCompletions(block.Type, pet)
+		}
+		if t = blockField(t, block.Type); t == nil {
+			return []lspCompletionItem{}
+		}
+		body = block.Body
+	}
+
+	items := []lspCompletionItem{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("hcl"), ",")
+		switch {
+		case tag[0] == "" || len(tag) > 1 && tag[1] == "label":
+		case len(tag) > 1 && tag[1] == "block":
+			items = append(items, lspCompletionItem{Label: tag[0], Kind: lspCompletionModule, Detail: "block"})
+		default:
+			items = append(items, lspCompletionItem{Label: tag[0], Kind: lspCompletionProperty, Detail: "attribute"})
+		}
+	}
+	return items
+}
+
+// characteristicCompletions returns the characteristics of the pets of the
+// type of a defaults block, or of pet, or of every type if it is not known.
+func characteristicCompletions(blockType string, pet *hclsyntax.Block) []lspCompletionItem {
+	kinds := petKindNames()
+	if petKinds[blockType] != nil {
+		kinds = []string{blockType}
+	} else if pet != nil {
+		if attr, ok := pet.Body.Attributes["type"]; ok {
+			if val, diags := attr.Expr.Value(nil); !diags.HasErrors() && val.Type().FriendlyName() == "string" {
+				if petKinds[val.AsString()] != nil {
+					kinds = []string{val.AsString()}
+				}
+			}
+		}
+	}
+
+	seen, items := map[string]bool{}, []lspCompletionItem{}
+	for _, kind := range kinds {
+		names := []string{}
+		for name := range petKinds[kind].spec {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				items = append(items, lspCompletionItem{Label: name, Kind: lspCompletionProperty, Detail: kind + " characteristic"})
+			}
+		}
+	}
+	return items
+}
+
+// blockAt returns the block of body whose braces are around offset.
+func blockAt(body *hclsyntax.Body, offset int) *hclsyntax.Block {
+	for _, block := range body.Blocks {
+		if block.OpenBraceRange.End.Byte <= offset && offset <= block.CloseBraceRange.Start.Byte {
+			return block
+		}
+	}
+	return nil
+}
+
+// blockField returns the struct the blocks of type name of the struct t are
+// decoded into, or nil if t has no such blocks.
+func blockField(t reflect.Type, name string) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("hcl") != name+",block" {
+			continue
+		}
+		ft := t.Field(i).Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		return ft
+	}
+	return nil
+}
+
+// lspHover returns the documentation of the function called at offset of
+// src, or nil if there is none.
+func lspHover(src string, offset int) interface{} {
+	start, end := offset, offset
+	for start > 0 && isIdentByte(src[start-1]) {
+		start--
+	}
+	for end < len(src) && isIdentByte(src[end]) {
+		end++
+	}
+	word := src[start:end]
+	for _, f := range contextFunctions {
+		if f.name != word {
+			continue
+		}
+		fn := f.new(context.Background(), LoadOptions{})
+		return map[string]interface{}{
+			"contents": map[string]string{
+				"kind":  "markdown",
+				"value": fmt.Sprintf("`%s`\n\n%s", functionSignature(f.name, fn), f.description),
+			},
+		}
+	}
+	return nil
+}
+
+// isIdentByte reports whether b can be part of an identifier.
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '-' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// lspDefinition returns the location of the block of the pet referred to at
+// offset of src, or nil if there is no reference to a pet there.
+func lspDefinition(uri, src string, offset int) interface{} {
+	file, _ := hclsyntax.ParseConfig([]byte(src), lspFilename(uri), hcl.InitialPos)
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	for _, ref := range bodyPetReferences(body) {
+		if offset < ref.rng.Start.Byte || offset > ref.rng.End.Byte {
+			continue
+		}
+		for _, block := range body.Blocks {
+			switch {
+			case block.Type == "pet" && len(block.Labels) == 1 && block.Labels[0] == ref.name:
+				return lspLocation{URI: uri, Range: lspRangeOf(block.DefRange())}
+			case block.Type == "household" && len(block.Labels) == 1 && strings.HasPrefix(ref.name, block.Labels[0]+"."):
+				name := strings.TrimPrefix(ref.name, block.Labels[0]+".")
+				for _, p := range block.Body.Blocks {
+					if p.Type == "pet" && len(p.Labels) == 1 && p.Labels[0] == name {
+						return lspLocation{URI: uri, Range: lspRangeOf(p.DefRange())}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lspFilename returns the path of the file:// URI uri.
+func lspFilename(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return uri
+}
+
+// lspOffset returns the byte offset of pos in src.
+func lspOffset(src string, pos lspPosition) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	for char := 0; char < pos.Character && offset < len(src) && src[offset] != '\n'; char++ {
+		_, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// lspRangeOf returns rng as an LSP range. LSP positions are zero based.
+func lspRangeOf(rng hcl.Range) lspRange {
+	return lspRange{
+		Start: lspPosition{Line: rng.Start.Line - 1, Character: rng.Start.Column - 1},
+		End:   lspPosition{Line: rng.End.Line - 1, Character: rng.End.Column - 1},
+	}
+}
+
+// mustJSON returns v encoded as JSON, for values that always encode.
+func mustJSON(v interface{}) json.RawMessage {
+	out, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package petsounds
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lspTestConfig = `pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = "meow"
+  }
+}
+
+pet "Ink" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+    sound = pet.Neko.sound
+  }
+}
+
+pet "Mo" {
+  type = "cow"
+}
+`
+
+// lspRequests frames each of msgs as the client sends them.
+func lspRequests(msgs ...string) *bytes.Buffer {
+	in := &bytes.Buffer{}
+	for _, msg := range msgs {
+		fmt.Fprintf(in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return in
+}
+
+// lspResponses returns the messages the server wrote to out.
+func lspResponses(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	s := &languageServer{in: bufio.NewReader(out)}
+	msgs := []map[string]interface{}{}
+	for {
+		msg, err := s.read()
+		if err != nil {
+			return msgs
+		}
+		raw, _ := json.Marshal(msg)
+		m := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(raw, &m))
+		msgs = append(msgs, m)
+	}
+}
+
+func TestLanguageServer(t *testing.T) {
+	t.Parallel()
+
+	open, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "method": "textDocument/didOpen",
+		"params": map[string]interface{}{
+			"textDocument": map[string]string{"uri": "file:///pets.hcl", "text": lspTestConfig},
+		},
+	})
+	at := func(id int, method string, line, char int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":{"textDocument":{"uri":"file:///pets.hcl"},"position":{"line":%d,"character":%d}}}`,
+			id, method, line, char)
+	}
+	in := lspRequests(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		string(open),
+		at(2, "textDocument/completion", 10, 4),
+		at(3, "textDocument/completion", 8, 2),
+		at(4, "textDocument/definition", 11, 18),
+		at(5, "textDocument/hover", 11, 4),
+		`{"jsonrpc":"2.0","id":6,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, newLanguageServer(in, out).Run()) {
+		return
+	}
+	msgs := lspResponses(t, out)
+	if !assert.Len(t, msgs, 7) {
+		return
+	}
+
+	capabilities := msgs[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, capabilities["definitionProvider"])
+
+	// The unknown type of Mo is reported where it is written.
+	assert.Equal(t, "textDocument/publishDiagnostics", msgs[1]["method"])
+	diagnostics := msgs[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if assert.Len(t, diagnostics, 1) {
+		diagnostic := diagnostics[0].(map[string]interface{})
+		assert.Contains(t, diagnostic["message"], "unknown pet type `cow`")
+		assert.Equal(t, map[string]interface{}{"line": float64(16), "character": float64(9)},
+			diagnostic["range"].(map[string]interface{})["start"])
+	}
+
+	labels := func(msg map[string]interface{}) []string {
+		names := []string{}
+		for _, item := range msg["result"].([]interface{}) {
+			names = append(names, item.(map[string]interface{})["label"].(string))
+		}
+		return names
+	}
+	assert.Contains(t, labels(msgs[2]), "breed")
+	assert.Contains(t, labels(msgs[3]), "characteristics")
+	assert.Contains(t, labels(msgs[3]), "owner")
+	assert.NotContains(t, labels(msgs[3]), "breed")
+
+	assert.Equal(t, map[string]interface{}{
+		"uri": "file:///pets.hcl",
+		"range": map[string]interface{}{
+			"start": map[string]interface{}{"line": float64(0), "character": float64(0)},
+			"end":   map[string]interface{}{"line": float64(0), "character": float64(12)},
+		},
+	}, msgs[4]["result"])
+
+	// sound isn't a function, so there is nothing to say about it.
+	assert.Nil(t, msgs[5]["result"])
+	assert.Nil(t, msgs[6]["error"])
+}
+
+func TestLSPHover(t *testing.T) {
+	t.Parallel()
+
+	src := `sound = random("meow", "purr")`
+	hover := lspHover(src, strings.Index(src, "dom"))
+	if !assert.NotNil(t, hover) {
+		return
+	}
+	contents := hover.(map[string]interface{})["contents"].(map[string]string)
+	assert.Contains(t, contents["value"], "random(")
+	assert.Contains(t, contents["value"], "Returns one of its arguments")
+}
@@ -1,42 +1,976 @@
-package main
+package petsounds
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"math/rand"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"golang.org/x/term"
+)
+
+const (
+	defaultFileName      = "pets.hcl"
+	defaultJSONFileName  = "pets.json"
+	defaultStateFileName = "pets.state.json"
 )
 
+// The codes pet-sounds exits with, so that wrapper scripts can tell what
+// went wrong.
 const (
-	defaultFileName = "pets.hcl"
+	// exitUsage is for bad flags and arguments, and any error that is not
+	// in one of the classes below.
+	exitUsage = 1
+	// exitParse is for configurations that could not be read or parsed.
+	exitParse = 2
+	// exitDecode is for configurations that were parsed, but could not be
+	// decoded or failed validation.
+	exitDecode = 3
+	// exitRuntime is for pets that failed to Say or Act.
+	exitRuntime = 4
+	// exitPartial is for commands that ran with -keep-going, but skipped
+	// pets that failed to decode.
+	exitPartial = 5
 )
 
-func main() {
-	if err := inner(); err != nil {
+// Main runs the pet-sounds command with args, the arguments it was given
+// after its name, and returns the code it exits with.
+func Main(args []string) int {
+	err := inner(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
 		fmt.Printf("pet-sounds error: %s\n", err.Error())
-		os.Exit(1)
+		return exitCode(err)
+	}
+	return 0
+}
+
+// exitError is an error that pet-sounds exits with code for.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode returns err with the code pet-sounds exits with for it, or
+// nil if err is nil.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the code pet-sounds exits with for err.
+func exitCode(err error) int {
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitUsage
+}
+
+// defaultCommand reads the configuration and has each pet Say and Act once.
+// With -record, the output is also compared with a golden file of the
+// output of an earlier run, recording it if there is none yet. With
+// -resolve, the configuration a run would use is written, with variables and
+// functions substituted, instead of the pets being run. With
+// -interval, the runner waits that long between pets.
+func defaultCommand(flags *flag.FlagSet) func(args []string) error {
+	runner := &Runner{Out: os.Stdout, Warnings: os.Stderr}
+	loadConfig := configFlags(flags)
+	setupRunner, _ := runnerFlags(flags, runner)
+	record := flags.String("record", "", "compare the output with a golden file in this directory, recording it the first time")
+	update := flags.Bool("update", false, "record the golden file again, with -record")
+	resolve := flags.Bool("resolve", false, "write the configuration with every expression evaluated, as literal HCL, instead of running the pets")
+	flags.DurationVar(&runner.Pace, "interval", 0, "the time to wait between pets, such as 500ms")
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if *resolve {
+			return WriteResolvedConfig(os.Stdout, config)
+		}
+		if err := setupRunner(config); err != nil {
+			return err
+		}
+		defer closeRunner(runner)
+		output := &bytes.Buffer{}
+		if *record != "" {
+			runner.Out = io.MultiWriter(runner.Out, output)
+		}
+
+		if err := runner.Run(config.Pets); err != nil {
+			return withExitCode(exitRuntime, err)
+		}
+		runner.Interact(config.Interactions)
+
+		if *record != "" {
+			return withExitCode(exitRuntime, checkGolden(goldenPath(*record, config.Filename), output.Bytes(), *update))
+		}
+		return nil
+	}
+}
+
+// runCommand runs a Simulation over the pets, stopping after the requested
+// number of ticks or when interrupted, and reloading the configuration on
+// SIGHUP. With -schedule, it runs a Scheduler that feeds the pets at their
+// feeding times instead, until interrupted.
+func runCommand(flags *flag.FlagSet) func(args []string) error {
+	sim := &Simulation{Runner: Runner{Out: os.Stdout, Warnings: os.Stderr}}
+	sched := &Scheduler{}
+	loadConfig := configFlags(flags)
+	setupRunner, preparePets := runnerFlags(flags, &sim.Runner)
+	flags.IntVar(&sim.Ticks, "ticks", 0, "the number of ticks to simulate, 0 runs until interrupted")
+	flags.DurationVar(&sim.Interval, "interval", time.Second, "the time between ticks")
+	schedule := flags.Bool("schedule", false, "stay running, feeding the pets at their feeding times")
+	flags.DurationVar(&sched.Jitter, "jitter", 0, "delay each feeding by a random time up to this, with -schedule")
+	flags.StringVar(&sched.CatchUp, "catch-up", catchUpOnce,
+		"what to do about missed feeding times with -schedule, once, all or skip")
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := setupRunner(config); err != nil {
+			return err
+		}
+		defer closeRunner(&sim.Runner)
+
+		// Cancel the simulation on Ctrl-C, letting the current tick, or the
+		// current feeding, finish.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+		go func() {
+			select {
+			case <-interrupt:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if *schedule {
+			sched.Runner = sim.Runner
+			return withExitCode(exitRuntime, sched.Run(ctx, config.Pets))
+		}
+		pets := NewPetSet(config.Pets)
+		go reloadOnHangup(ctx, pets, reloadPets(loadConfig, preparePets), sim.Warnings)
+		return withExitCode(exitRuntime, sim.RunSet(ctx, pets))
+	}
+}
+
+// tuiCommand shows a Dashboard of the pets, reading its keys from stdin,
+// which must be a terminal, in raw mode.
+func tuiCommand(flags *flag.FlagSet) func(args []string) error {
+	dashboard := &Dashboard{Runner: Runner{Out: os.Stdout, Warnings: os.Stderr}}
+	loadConfig := configFlags(flags)
+	setupRunner, _ := runnerFlags(flags, &dashboard.Runner)
+
+	return func(args []string) error {
+		if !isTerminal(os.Stdin) {
+			return withExitCode(exitUsage, fmt.Errorf("tui needs a terminal, use `pet-sounds shell` to read commands from a pipe"))
+		}
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := setupRunner(config); err != nil {
+			return err
+		}
+		defer closeRunner(&dashboard.Runner)
+		state, err := term.MakeRaw(int(os.Stdin.Fd()))
+		if err != nil {
+			return withExitCode(exitRuntime, fmt.Errorf("error setting up the terminal: %w", err))
+		}
+		defer term.Restore(int(os.Stdin.Fd()), state)
+		return withExitCode(exitRuntime, dashboard.Run(os.Stdin, config.Pets))
+	}
+}
+
+// shellCommand runs a Shell on the pets, reading its commands from stdin.
+// The reload command loads the configuration again.
+func shellCommand(flags *flag.FlagSet) func(args []string) error {
+	shell := &Shell{Runner: Runner{Out: os.Stdout, Warnings: os.Stderr}}
+	loadConfig := configFlags(flags)
+	setupRunner, preparePets := runnerFlags(flags, &shell.Runner)
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := setupRunner(config); err != nil {
+			return err
+		}
+		defer closeRunner(&shell.Runner)
+		shell.Reload = reloadPets(loadConfig, preparePets)
+		if isTerminal(os.Stdin) {
+			shell.Prompt = "pet-sounds> "
+		}
+		return withExitCode(exitRuntime, shell.Run(os.Stdin, NewPetSet(config.Pets)))
+	}
+}
+
+// serveCommand serves the web dashboard of the pets at /ui, and streams
+// what they say and do from /events, until interrupted. With -simulate, it
+// runs a Simulation of the pets alongside. The configuration is reloaded on
+// SIGHUP. /healthz and /readyz answer the probes of an orchestrator, and
+// are served from the start, with /readyz failing until the configuration
+// has loaded. On SIGINT or SIGTERM, /readyz fails for the -shutdown-grace
+// period while the server goes on serving, and then the requests in flight
+// are drained before it exits.
+func serveCommand(flags *flag.FlagSet) func(args []string) error {
+	runner := &Runner{Out: os.Stdout, Warnings: os.Stderr}
+	loadConfig := configFlags(flags)
+	setupRunner, preparePets := runnerFlags(flags, runner)
+	addr := flags.String("addr", "localhost:8080", "the address to serve the dashboard on")
+	simulate := flags.Duration("simulate", 0, "run a simulation with this time between ticks, 0 runs none")
+	grace := flags.Duration("shutdown-grace", 5*time.Second, "the time to go on serving once interrupted, with /readyz failing, before requests are drained")
+	drain := flags.Duration("shutdown-timeout", 10*time.Second, "the time requests in flight have to finish once interrupted")
+	var origins []string
+	flags.Var((*stringsFlag)(&origins), "allow-origin", "an origin, such as https://pets.example.com, whose pages may stream /events over a WebSocket besides those of the dashboard's own host; can be given more than once")
+
+	return func(args []string) error {
+		probes := &health{}
+		mux := http.NewServeMux()
+		probes.register(mux)
+		listener, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return withExitCode(exitRuntime, err)
+		}
+		server := &http.Server{Handler: mux}
+		served := make(chan error, 1)
+		go func() {
+			served <- server.Serve(listener)
+		}()
+		defer server.Close()
+
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := setupRunner(config); err != nil {
+			return err
+		}
+		defer closeRunner(runner)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := newEventBroker(runner.Clock)
+		events.origins = origins
+		runner.Hooks = events.hooks(runner.Hooks)
+		pets := NewPetSet(config.Pets)
+		go reloadOnHangup(ctx, pets, reloadPets(loadConfig, preparePets), runner.Warnings)
+		ui := newUIHandler(runner, pets)
+		mux.Handle("/ui", ui)
+		mux.Handle("/ui/", ui)
+		mux.Handle("/events", events)
+
+		if *simulate > 0 {
+			sim := &Simulation{Runner: *runner, Interval: *simulate}
+			go func() {
+				if err := sim.RunSet(ctx, pets); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(runner.Warnings, "pet-sounds: %s\n", err)
+				}
+			}()
+		}
+		probes.setReady(true)
+		fmt.Fprintf(logWriter(flags, logInfo), "pet-sounds: serving the dashboard at http://%s/ui\n", listener.Addr())
+
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupt)
+		select {
+		case err := <-served:
+			return withExitCode(exitRuntime, err)
+		case <-interrupt:
+		}
+		fmt.Fprintf(logWriter(flags, logInfo), "pet-sounds: shutting down in %s, then draining requests in flight\n", *grace)
+		cancel()
+		return withExitCode(exitRuntime, probes.shutdown(server, events, *grace, *drain))
+	}
+}
+
+// schemaCommand writes the JSON Schema of configuration files written in
+// HCL's JSON syntax to stdout.
+func schemaCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		return WriteJSONSchema(os.Stdout)
+	}
+}
+
+// lintCommand writes the problems Lint finds in a configuration file to
+// stdout, as text or as SARIF. It fails if any of them are errors.
+func lintCommand(flags *flag.FlagSet) func(args []string) error {
+	format := flags.String("format", "text", "the format to write findings in, text or sarif; -o takes precedence")
+	var lintRules []string
+	flags.Var((*stringsFlag)(&lintRules), "lint-rule", "set the severity of a lint rule, as id=error, warning, note or off; can be given more than once")
+
+	return func(args []string) error {
+		inputFile, output := flagString(flags, "file"), flagString(flags, "o")
+		if output == "" {
+			output = *format
+		}
+		write := WriteLintText
+		switch output {
+		case "text":
+		case "sarif":
+			write = WriteLintSARIF
+		default:
+			return withExitCode(exitUsage, fmt.Errorf("unknown format `%s`, expected text or sarif", output))
+		}
+
+		source, err := NewConfigSource(inputFile)
+		if err != nil {
+			return err
+		}
+		src, err := source.Fetch(context.Background())
+		if err != nil {
+			return withExitCode(exitParse, err)
+		}
+		rules, err := parseLintRules(lintRules)
+		if err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		findings, err := Lint(src, inputFile, LoadOptions{Format: source.Format(), LintRules: rules})
+		if err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		if err := write(os.Stdout, findings); err != nil {
+			return err
+		}
+		if lintFailed(findings) {
+			return withExitCode(exitDecode, fmt.Errorf("%s has errors", inputFile))
+		}
+		return nil
+	}
+}
+
+// lspCommand runs a language server for configuration files, speaking the
+// Language Server Protocol over stdin and stdout.
+func lspCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		return newLanguageServer(os.Stdin, os.Stdout).Run()
+	}
+}
+
+// graphCommand writes the pets and the relationships between them to stdout
+// as Graphviz DOT.
+func graphCommand(flags *flag.FlagSet) func(args []string) error {
+	loadConfig := configFlags(flags)
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		return WriteDOT(os.Stdout, config)
 	}
 }
 
-func inner() error {
-	var inputFile string
-	flag.StringVar(&inputFile, "file", defaultFileName, "the file to read pet configuration from")
-	flag.StringVar(&inputFile, "f", defaultFileName, "the file to read pet configuration from (shorthand)")
-	flag.Parse()
+// explainCommand writes the value of a characteristic of a pet, given as
+// an argument such as Ink.sound, and where the value came from.
+func explainCommand(flags *flag.FlagSet) func(args []string) error {
+	loadConfig := configFlags(flags)
+
+	return func(args []string) error {
+		if len(args) != 1 {
+			return withExitCode(exitUsage, fmt.Errorf("explain takes a characteristic, such as Ink.sound"))
+		}
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		return Explain(os.Stdout, config, args[0])
+	}
+}
 
-	// There is a random function for the HCL configuration.
-	rand.Seed(time.Now().Unix())
+// feedCommand writes the feeding schedule of each pet to stdout. With -due,
+// it writes only the pets that need feeding now, that is whose feeding time
+// has come within the last -window.
+func feedCommand(flags *flag.FlagSet) func(args []string) error {
+	loadConfig := configFlags(flags)
+	due := flags.Bool("due", false, "only write the pets that need feeding now")
+	window := flags.Duration("window", time.Hour, "how long a pet needs feeding for once its feeding time comes, with -due")
 
-	pets, err := ReadConfig(inputFile)
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		writeFeedings(os.Stdout, config.Pets, time.Now(), *due, *window)
+		return nil
+	}
+}
+
+// remindersCommand writes the vet visits that are overdue, or coming up in
+// the next few days, and the birthdays coming up, to stdout.
+func remindersCommand(flags *flag.FlagSet) func(args []string) error {
+	loadConfig := configFlags(flags)
+	within := flags.Int("within", 30, "also write the visits due in this many days")
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		writeReminders(os.Stdout, config.Pets, time.Now(), *within)
+		return nil
+	}
+}
+
+// planCommand compares the pets of the configuration with the pets in the
+// state file, as they were last applied, and writes the pets that would be
+// added, changed or removed to stdout.
+func planCommand(flags *flag.FlagSet) func(args []string) error {
+	loadConfig := configFlags(flags)
+	stateFile := flags.String("state", defaultStateFileName, "the state file the pets were last applied to")
+	noColor := flags.Bool("no-color", false, "do not color the plan")
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		old, err := LoadManagedState(*stateFile)
+		if err != nil {
+			return withExitCode(exitParse, err)
+		}
+		current, err := NewManagedState(config.Pets)
+		if err != nil {
+			return withExitCode(exitRuntime, err)
+		}
+		writePlan(os.Stdout, old.Diff(current), !*noColor && colorOutput(os.Stdout))
+		return nil
+	}
+}
+
+// applyCommand runs the pets that plan would add or change, once each, and
+// records them in the state file. Pets that were removed are dropped from
+// the state file. The plan is confirmed first, unless -auto-approve is given.
+func applyCommand(flags *flag.FlagSet) func(args []string) error {
+	runner := &Runner{Out: os.Stdout, Warnings: os.Stderr}
+	loadConfig := configFlags(flags)
+	setupRunner, _ := runnerFlags(flags, runner)
+	stateFile := flags.String("state", defaultStateFileName, "the state file to apply the pets to")
+	noColor := flags.Bool("no-color", false, "do not color the plan")
+	autoApprove := flags.Bool("auto-approve", false, "apply the changes without asking first")
+	var targets []string
+	flags.Var((*stringsFlag)(&targets), "target", "only apply the changes to the pet with this name, can be given more than once")
+
+	return func(args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := setupRunner(config); err != nil {
+			return err
+		}
+		defer closeRunner(runner)
+
+		old, err := LoadManagedState(*stateFile)
+		if err != nil {
+			return withExitCode(exitParse, err)
+		}
+		current, err := NewManagedState(config.Pets)
+		if err != nil {
+			return withExitCode(exitRuntime, err)
+		}
+		changes, err := targetChanges(old.Diff(current), old, current, targets)
+		if err != nil {
+			return err
+		}
+
+		writePlan(os.Stdout, changes, !*noColor && colorOutput(os.Stdout))
+		if len(changes) == 0 {
+			return nil
+		}
+		if !*autoApprove && !confirmApply(os.Stdin, os.Stdout) {
+			return fmt.Errorf("apply cancelled")
+		}
+		fmt.Fprintln(os.Stdout)
+
+		run := map[string]bool{}
+		for _, c := range changes {
+			if c.Action != changeRemove {
+				run[c.Name] = true
+			}
+		}
+		pets := []Pet{}
+		for _, p := range config.Pets {
+			if name, _ := petIdentity(p); run[name] {
+				pets = append(pets, p)
+			}
+		}
+
+		// Pets that fail are left as they were in the state, so that they
+		// are applied again next time.
+		var mu sync.Mutex
+		failed := map[string]bool{}
+		runner.Hooks = &Hooks{Error: func(p Pet, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			name, _ := petIdentity(p)
+			failed[name] = true
+		}}
+		runErr := runner.Run(pets)
+
+		applied := []*PetChange{}
+		for _, c := range changes {
+			if !failed[c.Name] {
+				applied = append(applied, c)
+			}
+		}
+		if err := old.applyChanges(current, applied).Save(*stateFile); err != nil {
+			return withExitCode(exitRuntime, err)
+		}
+		return withExitCode(exitRuntime, runErr)
+	}
+}
+
+// convertCommand converts a configuration file between native HCL syntax
+// and JSON, writing the result to stdout. The file is given as an argument
+// and defaults to pets.hcl when converting to JSON, and pets.json when
+// converting to HCL.
+func convertCommand(flags *flag.FlagSet) func(args []string) error {
+	to := flags.String("to", "json", "the format to convert to, json or hcl")
+
+	return func(args []string) error {
+		convert, inputFile := ConvertToJSON, defaultFileName
+		switch *to {
+		case "json":
+		case "hcl":
+			convert, inputFile = ConvertToHCL, defaultJSONFileName
+		default:
+			return fmt.Errorf("unknown conversion format `%s`", *to)
+		}
+		if len(args) > 0 {
+			inputFile = args[0]
+		}
+
+		src, err := ioutil.ReadFile(inputFile)
+		if err != nil {
+			return withExitCode(exitParse, fmt.Errorf("error reading `%s`: %w", inputFile, err))
+		}
+		return withExitCode(exitParse, convert(os.Stdout, src, inputFile))
+	}
+}
+
+// importCommand converts a CSV list of pets, given as an argument, into a
+// configuration file.
+func importCommand(flags *flag.FlagSet) func(args []string) error {
+	columnFlag := flags.String("columns", "", "map nonstandard CSV headers to columns, as header=column,...")
+
+	return func(args []string) error {
+		// Flags can come after the CSV file too, as in `import shelter.csv
+		// -o pets.hcl`.
+		if len(args) == 0 {
+			return fmt.Errorf("import needs a CSV file to import")
+		}
+		inputFile := args[0]
+		if err := parseFlags(flags, args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() > 0 {
+			return fmt.Errorf("import needs a single CSV file to import")
+		}
+		columns, err := parseColumns(*columnFlag)
+		if err != nil {
+			return err
+		}
+
+		input, err := os.Open(inputFile)
+		if err != nil {
+			return withExitCode(exitParse, fmt.Errorf("error opening `%s`: %w", inputFile, err))
+		}
+		defer input.Close()
+		pets, err := ImportCSV(input, columns)
+		if err != nil {
+			return withExitCode(exitDecode, err)
+		}
+
+		return writeConfigFile(pets, flagString(flags, "o"))
+	}
+}
+
+// generateCommand writes a configuration of random pets, for demos,
+// benchmarks and fuzzing, to stdout or the file given with -o.
+func generateCommand(flags *flag.FlagSet) func(args []string) error {
+	n := flags.Int("n", 10, "the number of pets to generate")
+	types := flags.String("types", "", "the types of pet to generate, as a comma separated list; defaults to every type")
+	seed := flags.Int64("seed", 0, "the seed of the random pets, 0 picks one at random")
+
+	return func(args []string) error {
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		pets, err := GeneratePets(rand.New(rand.NewSource(*seed)), *n, splitTypes(*types))
+		if err != nil {
+			return err
+		}
+		return writeConfigFile(pets, flagString(flags, "o"))
+	}
+}
+
+// writeConfigFile writes pets as a configuration file to output, or to
+// stdout if output is empty.
+func writeConfigFile(pets []Pet, output string) error {
+	if output == "" {
+		return WriteConfig(os.Stdout, pets)
+	}
+	out, err := os.Create(output)
 	if err != nil {
+		return fmt.Errorf("error creating `%s`: %w", output, err)
+	}
+	if err := WriteConfig(out, pets); err != nil {
+		out.Close()
 		return err
 	}
+	return out.Close()
+}
+
+// configFlags registers the flags used to select and check the configuration
+// file. The returned function loads the configuration once the flags have
+// been parsed.
+func configFlags(flags *flag.FlagSet) func() (*Config, error) {
+	var format, cacheDir, checksum, envFile, profile, mergeStrategy string
+	var validateBreeds, vault, traceEval, noAssetCheck bool
+	var timeout time.Duration
+	var filter PetFilter
+	var sortBy string
+	var seed int64
+	var lintRules, packs []string
+	flags.StringVar(&format, "format", "", "the format of the configuration file, hcl, json, yaml or toml; defaults to the file's extension")
+	flags.BoolVar(&validateBreeds, "validate-breeds", false, "reject dogs whose breed is not in the breed registry")
+	flags.StringVar(&envFile, "env-file", "", "a file of KEY=VALUE lines to add to the env namespace")
+	flags.BoolVar(&vault, "vault", false, "enable the vault function, reading secrets from the Vault at VAULT_ADDR")
+	flags.BoolVar(&traceEval, "trace-eval", false, "write each characteristic evaluated, the variables it read and each value random draws to stderr")
+	flags.DurationVar(&timeout, "fetch-timeout", defaultFetchTimeout, "the time allowed to fetch a configuration URL")
+	flags.StringVar(&cacheDir, "cache-dir", defaultCacheDir(), "the directory to cache HTTP(S) configuration URLs in, empty disables caching")
+	flags.StringVar(&checksum, "checksum", "", "pin the configuration to a checksum, as sha256:<hex>")
+	flags.StringVar(&filter.Type, "type", "", "only load pets of this type")
+	flags.StringVar(&filter.Household, "household", "", "only load the pets of this household")
+	flags.Var((*stringsFlag)(&filter.Names), "name", "only load the pet with this name, can be given more than once")
+	flags.Var((*stringsFlag)(&filter.Tags), "tag", "only load the pets with this tag, can be given more than once")
+	flags.StringVar(&profile, "profile", "", "declare the pets, and set the variables, of this profile block; without it no profile's pets are declared")
+	flags.StringVar(&sortBy, "sort", "", "order pets by name, type or file; defaults to the order they are declared in")
+	flags.Int64Var(&seed, "seed", 0, "seed the random function and the random choices of pets, for reproducible runs; 0 uses the seed attribute of the file, or else the time")
+	flags.Var((*stringsFlag)(&lintRules), "lint-rule", "set the severity of a lint rule, as id=error, warning, note or off; can be given more than once")
+	flags.BoolVar(&noAssetCheck, "no-asset-check", false, "skip checking that the files of characteristics such as photo exist")
+	flags.Var((*stringsFlag)(&packs), "pack", "enable the pet types of a pet pack, farm or aquarium; can be given more than once")
+	flags.StringVar(&mergeStrategy, "merge-strategy", mergeError,
+		"what to do when included files or the files of a module declare the same thing, error or override to keep the last one")
+	keepGoing := &keepGoing{}
+	flags.Var(keepGoing, "keep-going", "skip the pets that fail to decode and run the rest, reporting them at the end and exiting with 5")
+	// Commands that reload the configuration only parse the files, and
+	// decode the pets, that have changed since it was last loaded.
+	parseCache, decodeCache := NewParseCache(), NewDecodeCache()
+
+	return func() (*Config, error) {
+		inputFile := flagString(flags, "file")
+		source, err := NewConfigSource(inputFile)
+		if err != nil {
+			return nil, err
+		}
+		switch s := source.(type) {
+		case *HTTPSource:
+			s.Timeout, s.CacheDir = timeout, cacheDir
+		case *S3Source:
+			s.Timeout = timeout
+		case *GCSSource:
+			s.Timeout = timeout
+		case *GitSource:
+			s.Timeout = timeout
+		}
+
+		if err := checkMergeStrategy(mergeStrategy); err != nil {
+			return nil, withExitCode(exitUsage, err)
+		}
+		src, err := source.Fetch(context.Background())
+		if err != nil {
+			return nil, withExitCode(exitParse, err)
+		}
+		if err := verifyChecksum(src, checksum); err != nil {
+			return nil, withExitCode(exitDecode, fmt.Errorf("error verifying `%s`: %w", inputFile, err))
+		}
+		opts := LoadOptions{
+			Format: format, Profile: profile, Packs: packs, NoAssetCheck: noAssetCheck, KeepGoing: keepGoing.on,
+			MergeStrategy: mergeStrategy, ParseCache: parseCache, DecodeCache: decodeCache, Timings: flagTimings(flags),
+			Spans: flagSpans(flags),
+		}
+		if seed != 0 {
+			opts.Rand = rand.NewSource(seed)
+		}
+		if traceEval {
+			opts.Trace = os.Stderr
+		}
+		if opts.Format == "" {
+			opts.Format = source.Format()
+		}
+		if envFile != "" {
+			if opts.Env, err = ReadEnvFile(envFile); err != nil {
+				return nil, withExitCode(exitParse, err)
+			}
+		}
+		if vault {
+			if opts.Vault, err = NewVaultClient(); err != nil {
+				return nil, err
+			}
+		}
+		config, err := DecodeConfig(src, inputFile, opts)
+		if errors.Is(err, ErrParse) {
+			return nil, withExitCode(exitParse, err)
+		}
+		if err != nil {
+			return nil, withExitCode(exitDecode, err)
+		}
+		warnings := logWriter(flags, logWarn)
+		printWarnings(warnings, config.Warnings)
+		keepGoing.skipped = config.Skipped
+
+		// Style problems are warnings, unless their lint rules are
+		// configured as errors.
+		if opts.Format == FormatHCL {
+			if opts.LintRules, err = parseLintRules(lintRules); err != nil {
+				return nil, withExitCode(exitUsage, err)
+			}
+			findings, err := lintStyle(src, inputFile, opts.LintRules)
+			if err != nil {
+				return nil, withExitCode(exitDecode, fmt.Errorf("error linting `%s`: %w", inputFile, err))
+			}
+			diags := lintDiagnostics(findings)
+			if diags.HasErrors() {
+				return nil, withExitCode(
+					exitDecode, fmt.Errorf("error linting `%s`: %s", inputFile, formatDiagnostics(diags.Errs())),
+				)
+			}
+			printWarnings(warnings, diags)
+		}
+
+		if validateBreeds {
+			diags := ValidateBreeds(config)
+			if diags.HasErrors() {
+				return nil, withExitCode(
+					exitDecode, fmt.Errorf("error validating breeds: %s", formatDiagnostics(diags.Errs())),
+				)
+			}
+			printWarnings(warnings, diags)
+		}
+		if err := filter.Apply(config); err != nil {
+			return nil, err
+		}
+		if err := SortPets(config.Pets, sortBy); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+}
 
-	for _, p := range pets {
-		p.Say()
-		p.Act()
+// defaultCacheDir returns the directory configuration URLs are cached in by
+// default, or an empty string if the user has no cache directory.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
 	}
+	return filepath.Join(dir, "pet-sounds")
+}
 
-	return nil
+// printWarnings writes each warning in diags to w.
+func printWarnings(w io.Writer, diags hcl.Diagnostics) {
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagWarning {
+			fmt.Fprintf(w, "pet-sounds warning: %s\n", formatDiagnostics([]error{diag}))
+		}
+	}
+}
+
+// formatDiagnostics joins diagnostics into a single message. Unlike
+// hcl.Diagnostic's own Error method, it leaves out the source location of
+// diagnostics that do not have one.
+func formatDiagnostics(errs []error) string {
+	messages := []string{}
+	for _, err := range errs {
+		if diag, ok := err.(*hcl.Diagnostic); ok && diag.Subject == nil {
+			messages = append(messages, diag.Summary+"; "+diag.Detail)
+		} else {
+			messages = append(messages, err.Error())
+		}
+	}
+	return strings.Join(messages, ", ")
+}
+
+// runnerFlags registers the flags that configure how pets are run. Some of
+// them depend on the configuration, so the first returned function finishes
+// setting up runner once the configuration has been loaded. The second
+// readies pets for runner the same way the configuration's pets were, for
+// the pets of a configuration reloaded afterwards.
+func runnerFlags(flags *flag.FlagSet, runner *Runner) (func(config *Config) error, func(pets []Pet) error) {
+	var play, tts, emoji, art bool
+	var lang string
+	var locale *Locale
+	out := &RotatingFile{}
+	outFormat := eventLogText
+	flags.IntVar(&runner.Parallel, "parallel", 1, "the number of pets to run at once")
+	flags.BoolVar(&runner.Live, "live", false, "with -parallel, write lines as pets write them, prefixed with their names, instead of in order")
+	flags.BoolVar(&play, "play", false, "play each pet's sound through the speakers")
+	flags.BoolVar(&tts, "tts", false, "read each pet's lines aloud with text to speech")
+	flags.BoolVar(&emoji, "emoji", false, "start each line with an emoji of the pet's type, and decorate sounds")
+	flags.BoolVar(&art, "art", false, "draw each pet as ASCII art, saying its lines in a speech bubble")
+	flags.StringVar(&out.Path, "out", "", "also write what pets say and do to this file, as text or JSON with -out-format")
+	flags.StringVar(&outFormat, "out-format", eventLogText, "the format of the -out file, text or json for a JSON object per event")
+	flags.Var((*sizeFlag)(&out.MaxSize), "out-max-size", "rotate the -out file once it reaches this size, such as 10MB; 0 never does")
+	flags.DurationVar(&out.MaxAge, "out-max-age", 0, "rotate the -out file once it is this old, such as 24h; 0 never does")
+	flags.IntVar(&out.Keep, "out-keep", 0, "the number of rotated -out files to keep, 0 keeps them all")
+	flags.StringVar(&lang, "lang", "", "the language pets speak when they aren't told what to say, by name or as a language pack file")
+
+	preparePets := func(pets []Pet) error {
+		if locale != nil {
+			Localize(pets, locale)
+		}
+		if err := LocalizeLanguages(pets); err != nil {
+			return err
+		}
+		if runner.Style != nil {
+			SetStyle(pets, runner.Style)
+		}
+		if runner.State != nil {
+			runner.State.Restore(pets)
+		}
+		return nil
+	}
+
+	setupRunner := func(config *Config) error {
+		runner.Warnings, runner.Spans = logWriter(flags, logWarn), flagSpans(flags)
+		if logEnabled(flags, logDebug) {
+			logger := log.New(os.Stderr, "pet-sounds debug: ", 0)
+			runner.Middleware = append(runner.Middleware, LogMiddleware(logger))
+		}
+		// The Speaker would read the art aloud along with the pet's lines.
+		if art && tts {
+			return withExitCode(exitUsage, fmt.Errorf("-art and -tts cannot be used together"))
+		}
+		output := flagString(flags, "o")
+		if output == "" {
+			output = outputText
+		}
+		if output != outputText && output != outputNDJSON {
+			return withExitCode(exitUsage, fmt.Errorf("unknown output format `%s`, expected text or ndjson", output))
+		}
+		ndjson := output == outputNDJSON
+		// Events are written as they happened, without decoration.
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{{"-emoji", emoji}, {"-art", art}, {"-tts", tts}} {
+			if ndjson && f.set {
+				return withExitCode(exitUsage, fmt.Errorf("-o ndjson and %s cannot be used together", f.name))
+			}
+		}
+		if lang != "" {
+			var err error
+			if locale, err = LoadLocale(lang); err != nil {
+				return err
+			}
+		}
+		if emoji || art || ndjson {
+			runner.Style = &Style{Emoji: emoji, Art: art, NDJSON: ndjson}
+		}
+		if config.State != nil {
+			store, err := OpenStateStore(config.State.Path)
+			if err != nil {
+				return err
+			}
+			runner.State = store
+		}
+		if err := preparePets(config.Pets); err != nil {
+			return err
+		}
+		if out.Path != "" {
+			if outFormat != eventLogText && outFormat != eventLogJSON {
+				return withExitCode(exitUsage, fmt.Errorf("unknown -out-format `%s`, expected text or json", outFormat))
+			}
+			out.Clock = runner.Clock
+			eventLog, err := NewEventLog(out, outFormat)
+			if err != nil {
+				return err
+			}
+			runner.Log = eventLog
+		}
+		if play {
+			runner.Audio = &AudioPlayer{}
+		}
+		if tts {
+			runner.Speaker = NewSpeaker(config.TTS)
+		}
+		if config.Broker != nil {
+			mqtt, err := DialMQTT(config.Broker)
+			if err != nil {
+				return err
+			}
+			runner.MQTT = mqtt
+		}
+		for _, notify := range config.Notify {
+			notifier, err := NewNotifier(notify)
+			if err != nil {
+				return err
+			}
+			runner.Notifiers = append(runner.Notifiers, notifier)
+		}
+		return nil
+	}
+	return setupRunner, preparePets
+}
+
+// reloadPets returns a function that loads the configuration again, with
+// its pets readied by preparePets, for reloadOnHangup.
+func reloadPets(loadConfig func() (*Config, error), preparePets func(pets []Pet) error) func() ([]Pet, error) {
+	return func() ([]Pet, error) {
+		config, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		if err := preparePets(config.Pets); err != nil {
+			return nil, err
+		}
+		return config.Pets, nil
+	}
+}
+
+// closeRunner closes what runnerFlags opened for runner once a command is
+// done with it, reporting failures as warnings.
+func closeRunner(runner *Runner) {
+	if err := runner.Close(); err != nil {
+		fmt.Fprintf(runner.Warnings, "pet-sounds warning: %s\n", err)
+	}
 }
@@ -0,0 +1,119 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tcs := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{
+			name: "unknown flag",
+			args: []string{"-no-such-flag"},
+			want: exitUsage,
+		},
+		{
+			name: "unknown command",
+			args: []string{"rn", "-f", "testdata/basic.hcl"},
+			want: exitUsage,
+		},
+		{
+			name: "unknown log level",
+			args: []string{"-f", "testdata/basic.hcl", "-log-level", "loud"},
+			want: exitUsage,
+		},
+		{
+			name: "unknown pet name",
+			args: []string{"-f", "testdata/basic.hcl", "-name", "Nemo"},
+			want: exitUsage,
+		},
+		{
+			name: "unknown output format",
+			args: []string{"-f", "testdata/basic.hcl", "-o", "xml"},
+			want: exitUsage,
+		},
+		{
+			name: "ndjson with art",
+			args: []string{"-f", "testdata/basic.hcl", "-o", "ndjson", "-art"},
+			want: exitUsage,
+		},
+		{
+			name: "unknown merge strategy",
+			args: []string{"-f", "testdata/merge.hcl", "-merge-strategy", "union"},
+			want: exitUsage,
+		},
+		{
+			name: "conflicting files",
+			args: []string{"-f", "testdata/merge.hcl"},
+			want: exitDecode,
+		},
+		{
+			name: "missing file",
+			args: []string{"-f", "testdata/missing.hcl"},
+			want: exitParse,
+		},
+		{
+			name: "syntax error",
+			args: []string{"-f", "testdata/basic.yaml", "-format", "toml"},
+			want: exitParse,
+		},
+		{
+			name: "validation error",
+			args: []string{"-f", "testdata/vitals_invalid.hcl"},
+			want: exitDecode,
+		},
+		{
+			name: "keep going past a validation error",
+			args: []string{"-f", "testdata/vitals_invalid.hcl", "-keep-going"},
+			want: exitPartial,
+		},
+		{
+			name: "failed precondition",
+			args: []string{"-f", "testdata/conditions.hcl"},
+			want: exitRuntime,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			err := inner(tc.args)
+			if assert.Error(t, err) {
+				assert.Equal(t, tc.want, exitCode(err), err.Error())
+			}
+		})
+	}
+}
+
+func TestApplyCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, defaultStateFileName)
+
+	// Ink is applied first, then Swinney, who fails his precondition and so
+	// is left to be applied again.
+	args := []string{"apply", "-f", "testdata/conditions.hcl", "-state", stateFile, "-auto-approve"}
+	assert.NoError(t, inner(append(args, "-target", "Ink")))
+	state, err := LoadManagedState(stateFile)
+	if assert.NoError(t, err) && assert.Len(t, state.Pets, 1) {
+		assert.Equal(t, "Ink", state.Pets[0].Name)
+	}
+
+	err = inner(args)
+	assert.Equal(t, exitRuntime, exitCode(err))
+	state, err = LoadManagedState(stateFile)
+	if assert.NoError(t, err) {
+		assert.Len(t, state.Pets, 1)
+	}
+}
@@ -0,0 +1,195 @@
+package petsounds
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ManagedState records the pets a configuration declared the last time it
+// was applied, and the blocks they were declared in, so that later runs can
+// tell which pets are new, which have changed and which have been removed.
+// It is kept in pets.state.json, next to the configuration.
+type ManagedState struct {
+	Version int           `json:"version"`
+	Pets    []*ManagedPet `json:"pets"`
+}
+
+// ManagedPet is a pet in a ManagedState.
+type ManagedPet struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Block is where the pet was declared, as file:line,column.
+	Block string `json:"block,omitempty"`
+	// Fingerprint is a hash of the source of the block the pet was declared
+	// in, so it changes whenever the block is edited, but not when its
+	// expressions evaluate differently from one run to the next.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// managedStateVersion is the version of the state file format.
+const managedStateVersion = 1
+
+// The actions in the changes between two ManagedStates.
+const (
+	changeAdd    = "add"
+	changeUpdate = "change"
+	changeRemove = "remove"
+)
+
+// PetChange is a pet that differs between two ManagedStates. Old is nil for
+// pets that were added, and New for pets that were removed.
+type PetChange struct {
+	Action string
+	Name   string
+	Old    *ManagedPet
+	New    *ManagedPet
+}
+
+// NewManagedState returns the state of pets as they are now.
+func NewManagedState(pets []Pet) (*ManagedState, error) {
+	state := &ManagedState{Version: managedStateVersion, Pets: []*ManagedPet{}}
+	for _, p := range pets {
+		name, petType := petIdentity(p)
+		fingerprint, err := petFingerprint(p)
+		if err != nil {
+			return nil, fmt.Errorf("error in NewManagedState fingerprinting `%s`: %w", name, err)
+		}
+		managed := &ManagedPet{Name: name, Type: petType, Fingerprint: fingerprint}
+		if r := declRange(p); r.Filename != "" {
+			managed.Block = fmt.Sprintf("%s:%d,%d", r.Filename, r.Start.Line, r.Start.Column)
+		}
+		state.Pets = append(state.Pets, managed)
+	}
+	return state, nil
+}
+
+// petFingerprint returns the fingerprint of p: the hash of the source of its
+// block, so that it only changes when the block does, and not when an
+// expression in it, like a call to random, evaluates differently. Pets that
+// were not decoded from a configuration are fingerprinted as WriteConfig
+// writes them.
+func petFingerprint(p Pet) (string, error) {
+	if sum := fieldsOf(p).sourceSum; sum != nil && *sum != "" {
+		return *sum, nil
+	}
+	out := &bytes.Buffer{}
+	if err := WriteConfig(out, []Pet{p}); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(out.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// petSourceSum returns a hash of the name of p and the source of its block,
+// from cache: each attribute's expression as it was written, including
+// those merged into the block from other blocks, like those of override
+// files, and each nested block in turn. It is empty when the source of an expression is not in cache.
+func petSourceSum(cache *ParseCache, p *PetHCL) string {
+	if p.block == nil {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%q ", p.Name)
+	if !writeBlockSource(h, cache, p.block) {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeBlockSource writes the source of block, from cache, to w. It reports
+// whether all of it was in cache.
+func writeBlockSource(w io.Writer, cache *ParseCache, block *hclsyntax.Block) bool {
+	fmt.Fprintf(w, "%s %q {", block.Type, block.Labels)
+	names := []string{}
+	for name := range block.Body.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := block.Body.Attributes[name].Expr.Range()
+		src := cache.source(r.Filename)
+		if src == nil || r.End.Byte > len(src) || r.Start.Byte > r.End.Byte {
+			return false
+		}
+		fmt.Fprintf(w, "%s = %q;", name, src[r.Start.Byte:r.End.Byte])
+	}
+	for _, b := range block.Body.Blocks {
+		if !writeBlockSource(w, cache, b) {
+			return false
+		}
+	}
+	fmt.Fprint(w, "}")
+	return true
+}
+
+// LoadManagedState reads the state in the file at filename. A file that
+// does not exist yet has no pets.
+func LoadManagedState(filename string) (*ManagedState, error) {
+	src, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &ManagedState{Version: managedStateVersion, Pets: []*ManagedPet{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in LoadManagedState: %w", err)
+	}
+
+	state := &ManagedState{}
+	if err := json.Unmarshal(src, state); err != nil {
+		return nil, fmt.Errorf("error in LoadManagedState reading `%s`: %w", filename, err)
+	}
+	if state.Version > managedStateVersion {
+		return nil, fmt.Errorf(
+			"error in LoadManagedState: `%s` has version %d, this version of pet-sounds reads up to %d",
+			filename, state.Version, managedStateVersion,
+		)
+	}
+	return state, nil
+}
+
+// Save writes s to the file at filename.
+func (s *ManagedState) Save(filename string) error {
+	src, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error in ManagedState.Save: %w", err)
+	}
+	if err := ioutil.WriteFile(filename, append(src, '\n'), 0644); err != nil {
+		return fmt.Errorf("error in ManagedState.Save: %w", err)
+	}
+	return nil
+}
+
+// Diff returns the changes from s to current: pets that are new in current,
+// pets whose fingerprint has changed, and pets that are no longer in it.
+// Added and changed pets come in the order of current, followed by removed
+// pets in the order of s.
+func (s *ManagedState) Diff(current *ManagedState) []*PetChange {
+	old := map[string]*ManagedPet{}
+	for _, p := range s.Pets {
+		old[p.Name] = p
+	}
+	changes, kept := []*PetChange{}, map[string]bool{}
+	for _, p := range current.Pets {
+		kept[p.Name] = true
+		switch o := old[p.Name]; {
+		case o == nil:
+			changes = append(changes, &PetChange{Action: changeAdd, Name: p.Name, New: p})
+		case o.Fingerprint != p.Fingerprint || o.Type != p.Type:
+			changes = append(changes, &PetChange{Action: changeUpdate, Name: p.Name, Old: o, New: p})
+		}
+	}
+	for _, p := range s.Pets {
+		if !kept[p.Name] {
+			changes = append(changes, &PetChange{Action: changeRemove, Name: p.Name, Old: p})
+		}
+	}
+	return changes
+}
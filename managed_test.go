@@ -0,0 +1,123 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagedState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, defaultStateFileName)
+
+	// Nothing has been applied yet.
+	old, err := LoadManagedState(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, old.Pets)
+
+	pets, err := ReadConfig("testdata/basic.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	current, err := NewManagedState(pets)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, current.Pets, 2) {
+		assert.Equal(t, "Ink", current.Pets[0].Name)
+		assert.Equal(t, "cat", current.Pets[0].Type)
+		assert.Equal(t, "testdata/basic.hcl:1,1", current.Pets[0].Block)
+		assert.Len(t, current.Pets[0].Fingerprint, 64)
+	}
+	changes := old.Diff(current)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, changeAdd, changes[0].Action)
+		assert.Equal(t, "Swinney", changes[1].Name)
+	}
+
+	// Saved and loaded again, the state has no changes.
+	if !assert.NoError(t, current.Save(filename)) {
+		return
+	}
+	saved, err := LoadManagedState(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, current, saved)
+	assert.Empty(t, saved.Diff(current))
+
+	// Ink's block is edited to change her sound, Swinney leaves and Ada
+	// arrives.
+	edited := filepath.Join(dir, "pets.hcl")
+	src := "pet \"Ink\" {\n  type = \"cat\"\n  characteristics {\n    sound = \"purr\"\n  }\n}\n"
+	if !assert.NoError(t, ioutil.WriteFile(edited, []byte(src), 0644)) {
+		return
+	}
+	pets, err = ReadConfig(edited)
+	if !assert.NoError(t, err) {
+		return
+	}
+	next, err := NewManagedState([]Pet{pets[0], &Cat{Name: "Ada", Sound: defaultCatSound}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	changes = saved.Diff(next)
+	if assert.Len(t, changes, 3) {
+		assert.Equal(t, &PetChange{Action: changeUpdate, Name: "Ink", Old: saved.Pets[0], New: next.Pets[0]}, changes[0])
+		assert.Equal(t, &PetChange{Action: changeAdd, Name: "Ada", New: next.Pets[1]}, changes[1])
+		assert.Equal(t, &PetChange{Action: changeRemove, Name: "Swinney", Old: saved.Pets[1]}, changes[2])
+	}
+}
+
+func TestLoadManagedStateInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, defaultStateFileName)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"version": 2, "pets": []}`), 0644))
+	_, err = LoadManagedState(filename)
+	assert.EqualError(t, err, "error in LoadManagedState: `"+filename+"` has version 2, this version of pet-sounds reads up to 1")
+}
+
+func TestPetFingerprint(t *testing.T) {
+	t.Parallel()
+
+	// Expressions that evaluate differently each time leave the
+	// fingerprint as it is, while edits to the block change it.
+	src := `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = random("meow", "mrrp", "purr", "chirp", "hiss", "trill")
+  }
+}
+`
+	fingerprints := map[string]bool{}
+	for i := 0; i < 10; i++ {
+		pets := mustParse(t, src)
+		fingerprint, err := petFingerprint(pets[0])
+		if !assert.NoError(t, err) {
+			return
+		}
+		fingerprints[fingerprint] = true
+	}
+	assert.Len(t, fingerprints, 1)
+
+	edited, err := petFingerprint(mustParse(t, strings.Replace(src, `"hiss", `, "", 1))[0])
+	if assert.NoError(t, err) {
+		assert.False(t, fingerprints[edited])
+	}
+}
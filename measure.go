@@ -0,0 +1,103 @@
+package petsounds
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// unitTable is the units a quantity can be measured in, each as a multiple
+// of the quantity's canonical unit, which pets keep the quantity in.
+type unitTable struct {
+	canonical string
+	units     map[string]float64
+}
+
+// massUnits are the units of weights, kept in kilograms.
+var massUnits = &unitTable{
+	canonical: "kg",
+	units: map[string]float64{
+		"kg":  1,
+		"g":   0.001,
+		"lb":  0.45359237,
+		"lbs": 0.45359237,
+		"oz":  0.028349523125,
+	},
+}
+
+// lengthUnits are the units of lengths, kept in centimetres.
+var lengthUnits = &unitTable{
+	canonical: "cm",
+	units: map[string]float64{
+		"cm": 1,
+		"mm": 0.1,
+		"m":  100,
+		"in": 2.54,
+		"ft": 30.48,
+	},
+}
+
+// measuredAttrs are the characteristics that are measurements, by name. In
+// configuration they are either numbers in the canonical unit, or strings
+// of a number and a unit, such as "4.2kg" or "9 lb".
+var measuredAttrs = map[string]*unitTable{
+	"weight": massUnits,
+	"length": lengthUnits,
+}
+
+// measurementDigits is the number of significant digits measurements are
+// written with, more than any unit's factor has.
+const measurementDigits = 10
+
+// roundMeasurement returns f rounded to measurementDigits significant
+// digits, so that 9lb is written as 4.08233133 kilograms rather than
+// 4.082331330000001.
+func roundMeasurement(f float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'g', measurementDigits, 64), 64)
+	return rounded
+}
+
+// parse returns s, a number followed by one of the units of t, in the
+// canonical unit of t. A number without a unit is in the canonical unit.
+func (t *unitTable) parse(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.+-", r)
+	})
+	number, unit := s, t.canonical
+	if i >= 0 {
+		number, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("`%s` is not a number followed by a unit, such as 4.2%s", s, t.canonical)
+	}
+	factor, ok := t.units[strings.ToLower(unit)]
+	if !ok {
+		units := []string{}
+		for u := range t.units {
+			units = append(units, u)
+		}
+		sort.Strings(units)
+		return 0, fmt.Errorf("unknown unit `%s`, expected one of %s", unit, strings.Join(units, ", "))
+	}
+	return value * factor, nil
+}
+
+// value returns the measurement v, a number or a string with a unit, as a
+// number in the canonical unit of t.
+func (t *unitTable) value(v cty.Value) (cty.Value, error) {
+	if v.Type() != cty.String || !v.IsKnown() {
+		return convert.Convert(v, cty.Number)
+	}
+	f, err := t.parse(v.AsString())
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return cty.NumberFloatVal(f), nil
+}
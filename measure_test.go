@@ -0,0 +1,86 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitTableParse(t *testing.T) {
+	tcs := []struct {
+		name    string
+		units   *unitTable
+		input   string
+		want    float64
+		wantErr string
+	}{
+		{name: "canonical", units: massUnits, input: "4.2kg", want: 4.2},
+		{name: "no unit", units: massUnits, input: "4.2", want: 4.2},
+		{name: "pounds", units: massUnits, input: "9lb", want: 4.08233133},
+		{name: "space and case", units: massUnits, input: " 500 G ", want: 0.5},
+		{name: "feet", units: lengthUnits, input: "2ft", want: 60.96},
+		{
+			name:    "unknown unit",
+			units:   massUnits,
+			input:   "2st",
+			wantErr: "unknown unit `st`, expected one of g, kg, lb, lbs, oz",
+		},
+		{
+			name:    "no number",
+			units:   lengthUnits,
+			input:   "long",
+			wantErr: "`long` is not a number followed by a unit, such as 4.2cm",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.units.parse(tc.input)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.InDelta(t, tc.want, got, 1e-6)
+			}
+		})
+	}
+}
+
+func TestMeasuredCharacteristics(t *testing.T) {
+	pets := mustParse(t, `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    weight = "9lb"
+    length = "18in"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    weight = 7.5
+  }
+}
+`)
+	assert.InDelta(t, 4.08233133, *pets[0].(*Cat).Weight, 1e-6)
+	assert.InDelta(t, 45.72, *pets[0].(*Cat).Length, 1e-6)
+	assert.Equal(t, 7.5, *pets[1].(*Dog).Weight)
+
+	_, err := DecodeConfig([]byte(`
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    weight = "2st"
+  }
+}
+`), "test.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"test.hcl:5,14-19: Invalid weight; Unsuitable value for weight: unknown unit `st`, expected one of g, kg, lb, lbs, oz.")
+	}
+}
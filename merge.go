@@ -0,0 +1,146 @@
+package petsounds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// The merge strategies, which decide what happens when the files of a
+// configuration, the files it includes and the files of a module, declare
+// the same attribute or block. Files are merged in order: a file comes before
+// the files it includes, which come in the order of their include blocks,
+// and the files of a module come in name order.
+const (
+	// mergeError rejects conflicting declarations. It is the default.
+	mergeError = "error"
+	// mergeOverride resolves conflicting declarations in favour of the file
+	// merged last. A block that is overridden keeps its place among the
+	// others.
+	mergeOverride = "override"
+)
+
+// mergeStrategies are the merge strategies, for messages.
+var mergeStrategies = []string{mergeError, mergeOverride}
+
+// checkMergeStrategy returns an error if strategy is not one of
+// mergeStrategies, or empty for mergeError.
+func checkMergeStrategy(strategy string) error {
+	if strategy == "" || contains(mergeStrategies, strategy) {
+		return nil
+	}
+	return fmt.Errorf(
+		"unknown merge strategy `%s`, expected %s", strategy, strings.Join(mergeStrategies, " or "),
+	)
+}
+
+// repeatedBlockTypes are the top level blocks that can be declared any
+// number of times, and so never conflict. The other blocks conflict with
+// blocks of the same type and labels, such as two pets of the same name,
+// or two defaults blocks.
+var repeatedBlockTypes = []string{"interaction", "notify", includeBlockType}
+
+// mergeAttributes adds the attributes of the body from to body, which is
+// merged before it, by strategy. Each file declares its own schema version,
+// which has already been used to migrate it, so it is left out.
+func mergeAttributes(body, from *hclsyntax.Body, strategy string) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	for name, attr := range from.Attributes {
+		if name == schemaVersionKey {
+			continue
+		}
+		if existing, ok := body.Attributes[name]; ok {
+			if strategy != mergeOverride {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate attribute",
+					Detail: fmt.Sprintf(
+						"The attribute `%s` was already set at %s.", name, existing.NameRange,
+					),
+					Subject: attr.NameRange.Ptr(),
+				})
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Overridden attribute",
+				Detail: fmt.Sprintf(
+					"The attribute `%s` set at %s is overridden by this one.", name, existing.NameRange,
+				),
+				Subject: attr.NameRange.Ptr(),
+			})
+		}
+		body.Attributes[name] = attr
+	}
+	return diags
+}
+
+// mergeBlocks returns blocks with the conflicts between them resolved by
+// strategy. ranks are the positions in the merge order of the files of each
+// of blocks; blocks of the same file never conflict.
+func mergeBlocks(blocks hclsyntax.Blocks, ranks []int, strategy string) (hclsyntax.Blocks, hcl.Diagnostics) {
+	diags := hcl.Diagnostics{}
+	ranks = append([]int{}, ranks...)
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return ranks[order[i]] < ranks[order[j]] })
+
+	// slots are where each block ends up, by index in blocks, with nil for
+	// the blocks that were dropped.
+	slots := append(hclsyntax.Blocks{}, blocks...)
+	first := map[string]int{}
+	for _, i := range order {
+		block := blocks[i]
+		if contains(repeatedBlockTypes, block.Type) {
+			continue
+		}
+		key := blockName(block)
+		j, ok := first[key]
+		if !ok {
+			first[key] = i
+			continue
+		}
+		if ranks[j] == ranks[i] {
+			continue
+		}
+		if strategy != mergeOverride {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Conflicting declaration",
+				Detail:   fmt.Sprintf("`%s` is already declared at %s.", key, slots[j].DefRange()),
+				Subject:  block.DefRange().Ptr(),
+			})
+			continue
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Overridden declaration",
+			Detail:   fmt.Sprintf("`%s` declared at %s is overridden by this one.", key, slots[j].DefRange()),
+			Subject:  block.DefRange().Ptr(),
+		})
+		slots[j], slots[i] = block, nil
+		ranks[j] = ranks[i]
+	}
+
+	merged := hclsyntax.Blocks{}
+	for _, block := range slots {
+		if block != nil {
+			merged = append(merged, block)
+		}
+	}
+	return merged, diags
+}
+
+// blockName describes block by its type and labels, such as `pet "Ink"`.
+func blockName(block *hclsyntax.Block) string {
+	name := block.Type
+	for _, label := range block.Labels {
+		name += fmt.Sprintf(" %q", label)
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package petsounds
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeStrategy(t *testing.T) {
+	t.Parallel()
+
+	src, err := ioutil.ReadFile("testdata/merge.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = DecodeConfig(src, "testdata/merge.hcl", LoadOptions{})
+	var diags hcl.Diagnostics
+	if assert.True(t, errors.As(err, &diags), "want diagnostics, got %v", err) && assert.Len(t, diags, 3) {
+		assert.Equal(t, "testdata/merge/cats.hcl:1,1-21: Duplicate attribute; "+
+			"The attribute `allow_unknown_breeds` was already set at testdata/merge.hcl:1,1-21.", diags[0].Error())
+		assert.Equal(t, "testdata/merge/cats.hcl:3,1-11: Conflicting declaration; "+
+			"`defaults` is already declared at testdata/merge.hcl:3,1-11.", diags[1].Error())
+		assert.Equal(t, "testdata/merge/cats.hcl:9,1-12: Conflicting declaration; "+
+			"`pet \"Ink\"` is already declared at testdata/merge.hcl:9,1-12.", diags[2].Error())
+	}
+
+	config, err := DecodeConfig(src, "testdata/merge.hcl", LoadOptions{MergeStrategy: mergeOverride})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The included file is merged last, so its declarations win, but Ink
+	// keeps its place.
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "hiss"},
+		&Cat{Name: "Tom", Sound: "mrrp"},
+		&Dog{Name: "Swinney", Breed: "Dachshund"},
+	}, clearDeclRanges(config.Pets))
+	assert.True(t, config.AllowUnknownBreeds)
+	if assert.Len(t, config.Warnings, 3) {
+		assert.Equal(t, "testdata/merge/cats.hcl:9,1-12: Overridden declaration; "+
+			"`pet \"Ink\"` declared at testdata/merge.hcl:9,1-12 is overridden by this one.", config.Warnings[2].Error())
+	}
+
+	_, err = DecodeConfig(src, "testdata/merge.hcl", LoadOptions{MergeStrategy: "union"})
+	assert.EqualError(t, err, "error in DecodeConfig: unknown merge strategy `union`, expected error or override")
+}
@@ -0,0 +1,115 @@
+package petsounds
+
+import (
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// PetMiddleware wraps a pet in behaviour of its own, such as logging or
+// timing what it does, returning the wrapped pet. A Runner applies its
+// middleware to every pet it runs, so the behaviour is shared by every type
+// of pet rather than written into each of them.
+type PetMiddleware func(p Pet) Pet
+
+// PetFuncs is a Pet that calls its functions in place of the Say and Act of
+// the Pet it wraps, for writing middleware. A nil function leaves the
+// wrapped pet's method as it is.
+type PetFuncs struct {
+	Pet
+	SayFunc func(w io.Writer)
+	ActFunc func(w io.Writer)
+}
+
+func (p PetFuncs) Say(w io.Writer) {
+	if p.SayFunc == nil {
+		p.Pet.Say(w)
+		return
+	}
+	p.SayFunc(w)
+}
+
+func (p PetFuncs) Act(w io.Writer) {
+	if p.ActFunc == nil {
+		p.Pet.Act(w)
+		return
+	}
+	p.ActFunc(w)
+}
+
+// Unwrap returns the pet p wraps.
+func (p PetFuncs) Unwrap() Pet {
+	return p.Pet
+}
+
+// unwrapPet returns the pet at the centre of any middleware wrapping p.
+func unwrapPet(p Pet) Pet {
+	for {
+		wrapper, ok := p.(interface{ Unwrap() Pet })
+		if !ok {
+			return p
+		}
+		p = wrapper.Unwrap()
+	}
+}
+
+// chain wraps p in middleware, so that the first middleware is the
+// outermost.
+func chain(p Pet, middleware []PetMiddleware) Pet {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		p = middleware[i](p)
+	}
+	return p
+}
+
+// eachEvent is a PetMiddleware that calls fn around both the Say and the
+// Act of pets, with the event and a function to carry it out.
+func eachEvent(fn func(p Pet, event string, do func())) PetMiddleware {
+	return func(p Pet) Pet {
+		return PetFuncs{
+			Pet:     p,
+			SayFunc: func(w io.Writer) { fn(p, eventSay, func() { p.Say(w) }) },
+			ActFunc: func(w io.Writer) { fn(p, eventAct, func() { p.Act(w) }) },
+		}
+	}
+}
+
+// LogMiddleware logs each time a pet Says or Acts to logger.
+func LogMiddleware(logger *log.Logger) PetMiddleware {
+	return eachEvent(func(p Pet, event string, do func()) {
+		name, petType := petIdentity(p)
+		logger.Printf("%s %s: %s", petType, name, event)
+		do()
+	})
+}
+
+// TimingMiddleware calls record with how long each Say and Act of a pet
+// took.
+func TimingMiddleware(record func(p Pet, event string, d time.Duration)) PetMiddleware {
+	return eachEvent(func(p Pet, event string, do func()) {
+		start := time.Now()
+		do()
+		record(p, event, time.Since(start))
+	})
+}
+
+// RateLimitMiddleware spaces out what pets do, so that no more than one
+// Say or Act starts in each interval, across all of the pets it wraps.
+func RateLimitMiddleware(interval time.Duration) PetMiddleware {
+	var mu sync.Mutex
+	var next time.Time
+	return eachEvent(func(p Pet, event string, do func()) {
+		mu.Lock()
+		now := time.Now()
+		wait := next.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		next = now.Add(wait + interval)
+		mu.Unlock()
+
+		time.Sleep(wait)
+		do()
+	})
+}
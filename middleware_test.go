@@ -0,0 +1,101 @@
+package petsounds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	// tag is middleware that writes name around everything a pet does.
+	tag := func(name string) PetMiddleware {
+		return func(p Pet) Pet {
+			return PetFuncs{Pet: p, SayFunc: func(w io.Writer) {
+				fmt.Fprintf(w, "<%s>\n", name)
+				p.Say(w)
+				fmt.Fprintf(w, "</%s>\n", name)
+			}}
+		}
+	}
+
+	tcs := []struct {
+		name       string
+		middleware []PetMiddleware
+		want       string
+	}{
+		{
+			name: "none",
+			want: "Ink meow\nInk snoozes\n",
+		},
+		{
+			name:       "first is outermost",
+			middleware: []PetMiddleware{tag("a"), tag("b")},
+			want:       "<a>\n<b>\nInk meow\n</b>\n</a>\nInk snoozes\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			runner := &Runner{Out: out, Middleware: tc.middleware}
+			assert.NoError(t, runner.Run([]Pet{&Cat{Name: "Ink", Sound: defaultCatSound}}))
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestMiddlewareKeepsPet(t *testing.T) {
+	t.Parallel()
+
+	cat := &Cat{Name: "Ink", Voice: "Samantha"}
+	wrapped := chain(cat, []PetMiddleware{eachEvent(func(p Pet, event string, do func()) { do() })})
+
+	assert.Equal(t, Pet(cat), unwrapPet(wrapped))
+	name, petType := petIdentity(wrapped)
+	assert.Equal(t, "Ink", name)
+	assert.Equal(t, "cat", petType)
+	assert.Equal(t, "Samantha", petVoice(wrapped))
+}
+
+func TestLogMiddleware(t *testing.T) {
+	t.Parallel()
+
+	logs := &bytes.Buffer{}
+	runner := &Runner{Out: &bytes.Buffer{}, Middleware: []PetMiddleware{LogMiddleware(log.New(logs, "", 0))}}
+	assert.NoError(t, runner.Run([]Pet{&Dog{Name: "Swinney", Breed: "Dachshund"}}))
+	assert.Equal(t, "dog Swinney: say\ndog Swinney: act\n", logs.String())
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	events := []string{}
+	timing := TimingMiddleware(func(p Pet, event string, d time.Duration) {
+		assert.True(t, d >= 0)
+		name, _ := petIdentity(p)
+		events = append(events, name+" "+event)
+	})
+	runner := &Runner{Out: &bytes.Buffer{}, Middleware: []PetMiddleware{timing}}
+	assert.NoError(t, runner.Run([]Pet{&Cat{Name: "Ink"}}))
+	assert.Equal(t, []string{"Ink say", "Ink act"}, events)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	interval := 20 * time.Millisecond
+	runner := &Runner{Out: &bytes.Buffer{}, Middleware: []PetMiddleware{RateLimitMiddleware(interval)}}
+
+	// Two pets Saying and Acting is four events, three intervals apart.
+	start := time.Now()
+	assert.NoError(t, runner.Run([]Pet{&Cat{Name: "Ink"}, &Dog{Name: "Swinney"}}))
+	assert.True(t, time.Since(start) >= 3*interval)
+}
@@ -0,0 +1,367 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ModuleHCL is a module block, which adds the pets of the configuration
+// files in another directory, such as:
+//   module "barn" {
+//     source = "./modules/barn"
+//     count  = 2
+//     sound  = "moo"
+//   }
+// Every other attribute sets an input variable of the module. The pets of a
+// module are named after it, as barn.Daisy, or barn[0].Daisy, barn[1].Daisy
+// and so on with count, so that modules can be used more than once. They
+// take their defaults from the root configuration file's defaults block.
+type ModuleHCL struct {
+	Name   string   `hcl:"name,label"`
+	Source string   `hcl:"source"`
+	Count  *int     `hcl:"count,optional"`
+	Inputs hcl.Body `hcl:",remain"`
+}
+
+// VariableHCL is a variable block, which declares a variable used in the
+// file as var.<name>, such as:
+//   variable "sound" {
+//     default = "moo"
+//   }
+// Modules set their variables from the module block, and variables without
+// a default must be set. In the root configuration file, variables are
+// always their defaults.
+type VariableHCL struct {
+	Name    string         `hcl:"name,label"`
+	Default hcl.Expression `hcl:"default,optional"`
+}
+
+// variablesContext returns a child of parent with the variables declared in
+// body, which are set to the values of inputs, or to their defaults. inputs
+// are set by the module block body is loaded by, if any, or by the profile
+// of body that is selected.
+func variablesContext(body *hclsyntax.Body, parent *hcl.EvalContext, inputs map[string]cty.Value) (*hcl.EvalContext, hcl.Diagnostics) {
+	diags := hcl.Diagnostics{}
+	vars := map[string]cty.Value{}
+	for _, block := range body.Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		name := block.Labels[0]
+		if value, ok := inputs[name]; ok {
+			vars[name] = value
+			continue
+		}
+		attr, ok := block.Body.Attributes["default"]
+		if !ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing variable",
+				Detail:   fmt.Sprintf("The variable `%s` has no default, so it must be set.", name),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+		// Defaults are constants, as they are decoded before anything else.
+		value, valueDiags := attr.Expr.Value(nil)
+		diags = append(diags, valueDiags...)
+		vars[name] = value
+	}
+
+	ctx := parent.NewChild()
+	ctx.Variables = map[string]cty.Value{"var": cty.ObjectVal(vars)}
+	return ctx, diags
+}
+
+// declaresVariable reports whether body has a variable block for name.
+func declaresVariable(body *hclsyntax.Body, name string) bool {
+	for _, block := range body.Blocks {
+		if block.Type == "variable" && len(block.Labels) == 1 && block.Labels[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeModules adds the pets and interactions of every module block in
+// petsHCL to it. callerContext is the context petsHCL was decoded in, used
+// for the inputs of its modules, while each module is decoded in a child of
+// baseContext with its own variables. chain is the files and modules that
+// led to petsHCL, which stops modules using themselves. The files of each
+// module are merged by strategy.
+func decodeModules(ctx context.Context, cache *ParseCache, petsHCL *PetsHCL, callerContext, baseContext *hcl.EvalContext, chain []string, strategy string) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	for _, m := range petsHCL.ModulesHCL {
+		// Sources are relative to the file the module block is in.
+		path := m.Source
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(m.Inputs.MissingItemRange().Filename), path)
+		}
+		moduleChain := append(append([]string{}, chain...), path)
+		for _, f := range chain {
+			if samePath(f, path) {
+				return append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Module cycle",
+					Detail:   fmt.Sprintf("The module `%s` uses itself. %s", m.Name, includeChain(moduleChain)),
+					Subject:  m.Inputs.MissingItemRange().Ptr(),
+				})
+			}
+		}
+
+		body, moduleDiags := loadModule(ctx, cache, path, moduleChain, strategy)
+		for _, d := range moduleDiags {
+			if d.Subject == nil {
+				d.Subject = m.Inputs.MissingItemRange().Ptr()
+			}
+		}
+		diags = append(diags, moduleDiags...)
+		if moduleDiags.HasErrors() {
+			continue
+		}
+
+		inputs, inputDiags := m.Inputs.JustAttributes()
+		for name, attr := range inputs {
+			if !declaresVariable(body, name) {
+				inputDiags = append(inputDiags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Undeclared variable",
+					Detail:   fmt.Sprintf("The module `%s` has no variable `%s`.", m.Name, name),
+					Subject:  attr.NameRange.Ptr(),
+				})
+			}
+		}
+		diags = append(diags, inputDiags...)
+		if inputDiags.HasErrors() {
+			continue
+		}
+
+		instances := []int{-1}
+		if m.Count != nil {
+			if *m.Count < 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid count",
+					Detail:   fmt.Sprintf("The count of module `%s` cannot be negative.", m.Name),
+					Subject:  m.Inputs.MissingItemRange().Ptr(),
+				})
+				continue
+			}
+			instances = instances[:0]
+			for i := 0; i < *m.Count; i++ {
+				instances = append(instances, i)
+			}
+		}
+
+		for _, i := range instances {
+			prefix := m.Name + "."
+			instanceVars := map[string]cty.Value{}
+			if i >= 0 {
+				prefix = fmt.Sprintf("%s[%d].", m.Name, i)
+				instanceVars["count"] = cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(i))})
+			}
+
+			// Inputs, like the module's own pets, can use count.index.
+			inputContext := callerContext.NewChild()
+			inputContext.Variables = instanceVars
+			values := map[string]cty.Value{}
+			for name, attr := range inputs {
+				value, valueDiags := attr.Expr.Value(inputContext)
+				diags = append(diags, valueDiags...)
+				values[name] = value
+			}
+
+			moduleContext, varDiags := variablesContext(body, baseContext, values)
+			diags = append(diags, varDiags...)
+			if varDiags.HasErrors() {
+				break
+			}
+			for k, v := range instanceVars {
+				moduleContext.Variables[k] = v
+			}
+
+			modulePets := &PetsHCL{}
+			decodeDiags := gohcl.DecodeBody(body, moduleContext, modulePets)
+			setDeclRanges(modulePets, body)
+			addHouseholds(modulePets, body)
+			for blockType, found := range map[string]bool{
+				"tts":      modulePets.TTSHCL != nil,
+				"state":    modulePets.StateHCL != nil,
+				"broker":   modulePets.BrokerHCL != nil,
+				"notify":   len(modulePets.NotifyHCL) > 0,
+				"defaults": modulePets.DefaultsHCL != nil,
+				"owner":    len(modulePets.OwnersHCL) > 0,
+				"aliases":  modulePets.AliasesHCL != nil,
+			} {
+				if found && !decodeDiags.HasErrors() {
+					decodeDiags = append(decodeDiags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unsupported block type",
+						Detail: fmt.Sprintf(
+							"A %s block can only be in the root configuration file, not a module.", blockType,
+						),
+						Subject: m.Inputs.MissingItemRange().Ptr(),
+					})
+				}
+			}
+			if !decodeDiags.HasErrors() {
+				decodeDiags = decodeModules(ctx, cache, modulePets, moduleContext, baseContext, moduleChain, strategy)
+			}
+			diags = append(diags, decodeDiags...)
+			if decodeDiags.HasErrors() {
+				break
+			}
+
+			for _, p := range modulePets.PetHCLBodies {
+				p.Name = prefix + p.Name
+				if p.evalContext == nil {
+					p.evalContext = moduleContext
+				}
+				petsHCL.PetHCLBodies = append(petsHCL.PetHCLBodies, p)
+			}
+			for _, interaction := range modulePets.InteractionsHCL {
+				interaction.From, interaction.To = prefix+interaction.From, prefix+interaction.To
+				petsHCL.InteractionsHCL = append(petsHCL.InteractionsHCL, interaction)
+			}
+		}
+	}
+	return diags
+}
+
+// loadModule parses the configuration files of the module at path, the last
+// of chain, into a single body, with the pet templates in it resolved. A
+// module is a directory of configuration files, merged in name order by
+// strategy, or a single file.
+func loadModule(ctx context.Context, cache *ParseCache, path string, chain []string, strategy string) (*hclsyntax.Body, hcl.Diagnostics) {
+	files := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		var diags hcl.Diagnostics
+		if files, diags = dirFiles(path, "module", chain); diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	body, diags := mergeFiles(ctx, cache, files, chain, strategy)
+	return body, append(diags, resolveTemplates(body)...)
+}
+
+// dirFiles returns the configuration files in the directory at path, the
+// last of chain, in name order. A directory without any is an error; what
+// names the directory in diagnostics.
+func dirFiles(path, what string, chain []string) ([]string, hcl.Diagnostics) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unreadable " + what,
+			Detail:   fmt.Sprintf("%s. %s", err, includeChain(chain)),
+		}}
+	}
+	files := []string{}
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".hcl", ".yaml", ".yml", ".toml":
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Empty " + what,
+			Detail:   fmt.Sprintf("The %s `%s` has no configuration files. %s", what, path, includeChain(chain)),
+		}}
+	}
+	return files, nil
+}
+
+// mergeFiles parses files, as files included by the last of chain, and
+// merges them by strategy into a single body, in the order they are given.
+func mergeFiles(ctx context.Context, cache *ParseCache, files []string, chain []string, strategy string) (*hclsyntax.Body, hcl.Diagnostics) {
+	body := &hclsyntax.Body{Attributes: hclsyntax.Attributes{}}
+	diags := hcl.Diagnostics{}
+	bodies, fileDiags := parseFiles(ctx, cache, files, chain, strategy)
+	ranks := []int{}
+	for i, fileBody := range bodies {
+		diags = append(diags, fileDiags[i]...)
+		if fileBody == nil {
+			continue
+		}
+		if body.SrcRange.Filename == "" {
+			body.SrcRange, body.EndRange = fileBody.SrcRange, fileBody.EndRange
+		}
+		for _, block := range fileBody.Blocks {
+			body.Blocks = append(body.Blocks, block)
+			ranks = append(ranks, i)
+		}
+		diags = append(diags, mergeAttributes(body, fileBody, strategy)...)
+	}
+	var mergeDiags hcl.Diagnostics
+	body.Blocks, mergeDiags = mergeBlocks(body.Blocks, ranks, strategy)
+	return body, append(diags, mergeDiags...)
+}
+
+// loadConfigDir parses the configuration files in dir, other than its
+// override files, into a single body, as DecodeConfig does for a directory
+// given as its filename. The files are merged in name order by strategy,
+// and the override files are left to applyOverrides.
+func loadConfigDir(ctx context.Context, cache *ParseCache, dir, strategy string) (*hclsyntax.Body, hcl.Diagnostics) {
+	chain := []string{dir}
+	files, diags := dirFiles(dir, "configuration directory", chain)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	configFiles := files[:0]
+	for _, f := range files {
+		if !isOverrideFile(f) {
+			configFiles = append(configFiles, f)
+		}
+	}
+	return mergeFiles(ctx, cache, configFiles, chain, strategy)
+}
+
+// isConfigDir reports whether filename is a directory of configuration
+// files, which FileSource fetches as nil src.
+func isConfigDir(src []byte, filename string) bool {
+	if src != nil {
+		return false
+	}
+	info, err := os.Stat(filename)
+	return err == nil && info.IsDir()
+}
+
+// maxParallelParses bounds how many files parseFiles parses at once.
+var maxParallelParses = runtime.GOMAXPROCS(0)
+
+// parseFiles parses each of files with parseFragment, as files included by
+// the last of chain, merging their includes by strategy. Modules and
+// configuration directories can have hundreds of files, so they are parsed
+// concurrently, at most maxParallelParses at a time. The bodies and
+// diagnostics of the files are returned in the same order as files.
+func parseFiles(ctx context.Context, cache *ParseCache, files []string, chain []string, strategy string) ([]*hclsyntax.Body, []hcl.Diagnostics) {
+	bodies := make([]*hclsyntax.Body, len(files))
+	diags := make([]hcl.Diagnostics, len(files))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallelParses)
+	for i, f := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, f string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			bodies[i], diags[i] = parseFragment(ctx, cache, f, append(append([]string{}, chain...), f), strategy)
+		}(i, f)
+	}
+	wg.Wait()
+	return bodies, diags
+}
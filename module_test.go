@@ -0,0 +1,127 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+	config, err := LoadConfig("testdata/module.hcl")
+	if !assert.Nil(t, err, "error while parsing input") {
+		return
+	}
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "meow"},
+		&Dog{Name: "barn[0].Rex", Breed: "Corgi", Sound: "yip 0"},
+		&Cat{Name: "barn[0].Tom", Sound: "meow"},
+		&Dog{Name: "barn[1].Rex", Breed: "Corgi", Sound: "bark 1"},
+		&Cat{Name: "barn[1].Tom", Sound: "meow"},
+	}, clearDeclRanges(config.Pets))
+	assert.Equal(t, []*Interaction{
+		{From: "Ink", To: "barn[1].Rex", Verb: "hisses at"},
+		{From: "barn[0].Rex", To: "barn[0].Tom", Verb: "chases"},
+		{From: "barn[1].Rex", To: "barn[1].Tom", Verb: "chases"},
+	}, config.Interactions)
+}
+
+func TestModuleErrors(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "undeclared variable",
+			input: "testdata/module_invalid.hcl",
+			want:  []string{"module_invalid.hcl:3,3-9: Undeclared variable"},
+		},
+		{
+			name:  "missing variable",
+			input: "testdata/module_missing.hcl",
+			want:  []string{"variables.hcl:1,10-17: Missing variable"},
+		},
+		{
+			name:  "cycle",
+			input: "testdata/module_cycle.hcl",
+			want: []string{
+				"Module cycle",
+				"Include chain: testdata/module_cycle.hcl -> testdata/modules/cycle -> testdata/modules/cycle.",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadConfig(tc.input)
+			if assert.Error(t, err) {
+				for _, want := range tc.want {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadModuleManyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-module")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	want := []string{}
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("Pet%03d", i)
+		want = append(want, name)
+		src := fmt.Sprintf("pet %q {\n  type = \"cat\"\n}\n", name)
+		if !assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name+".hcl"), []byte(src), 0644)) {
+			return
+		}
+	}
+
+	// However the files are parsed, their blocks are merged in name order.
+	body, diags := loadModule(context.Background(), nil, dir, []string{"pets.hcl", dir}, mergeError)
+	if assert.False(t, diags.HasErrors(), diags.Error()) {
+		got := []string{}
+		for _, block := range body.Blocks {
+			got = append(got, block.Labels[0])
+		}
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestLoadConfigDir(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfigContext(context.Background(), "testdata/configdir")
+	if !assert.Nil(t, err, "error while parsing input") {
+		return
+	}
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "mrrp"},
+		&Dog{Name: "Swinney", Breed: "Dachshund"},
+	}, clearDeclRanges(config.Pets))
+	assert.Equal(t, []*Interaction{
+		{From: "Ink", To: "Swinney", Verb: "hisses at"},
+	}, config.Interactions)
+
+	dir, err := ioutil.TempDir("", "pet-sounds-config")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	_, err = LoadConfigContext(context.Background(), dir)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Empty configuration directory")
+	}
+}
@@ -0,0 +1,213 @@
+package petsounds
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Mood is the internal state of a pet. A pet's mood changes as it Says and
+// Acts, and as time passes, and the pet's output varies with its mood.
+type Mood string
+
+const (
+	MoodHungry  Mood = "hungry"
+	MoodSleepy  Mood = "sleepy"
+	MoodPlayful Mood = "playful"
+
+	defaultMood = MoodPlayful
+)
+
+// defaultMoodTransitions are used when a moods block declares no transitions
+// of its own. A playful pet tires itself out playing, a sleepy pet wakes up
+// hungry, and a hungry pet perks up once it has asked for food.
+var defaultMoodTransitions = []moodTransition{
+	{from: MoodPlayful, to: MoodSleepy, on: eventAct},
+	{from: MoodSleepy, to: MoodHungry, on: eventAct},
+	{from: MoodHungry, to: MoodPlayful, on: eventSay},
+}
+
+// MoodsHCL is the optional moods block of a pet. It is represented in hcl as:
+//   moods {
+//     initial = "<hungry | sleepy | playful>"
+//     transition {
+//       from  = "<mood>"
+//       to    = "<mood>"
+//       on    = "<say | act>"
+//       after = "<duration, e.g. 10s>"
+//     }
+//   }
+// A transition fires when the pet does the "on" event, once the pet has been
+// in the "from" mood for the "after" duration, or when both are true if both
+// are set.
+type MoodsHCL struct {
+	Initial     string `hcl:"initial,optional"`
+	Transitions []*struct {
+		From  string `hcl:"from"`
+		To    string `hcl:"to"`
+		On    string `hcl:"on,optional"`
+		After string `hcl:"after,optional"`
+	} `hcl:"transition,block"`
+}
+
+// moodTransition is a validated transition between two moods.
+type moodTransition struct {
+	from  Mood
+	to    Mood
+	on    string
+	after time.Duration
+}
+
+// MoodMachine tracks the mood of a single pet. The zero value is not usable,
+// use NewMoodMachine. All methods are safe to call on a nil *MoodMachine,
+// which represents a pet without moods.
+type MoodMachine struct {
+	mu          sync.Mutex
+	initial     Mood
+	mood        Mood
+	since       time.Time
+	transitions []moodTransition
+	clock       Clock
+}
+
+// NewMoodMachine validates a decoded moods block and returns a MoodMachine
+// in the block's initial mood.
+func NewMoodMachine(moods *MoodsHCL) (*MoodMachine, error) {
+	return newMoodMachine(moods, systemClock{})
+}
+
+// newMoodMachine is NewMoodMachine with time passing by clock.
+func newMoodMachine(moods *MoodsHCL, clock Clock) (*MoodMachine, error) {
+	initial := defaultMood
+	if moods.Initial != "" {
+		initial = Mood(moods.Initial)
+		if !validMood(initial) {
+			return nil, fmt.Errorf("error in NewMoodMachine: unknown initial mood `%s`", initial)
+		}
+	}
+
+	transitions := defaultMoodTransitions
+	if len(moods.Transitions) > 0 {
+		transitions = []moodTransition{}
+	}
+	for _, t := range moods.Transitions {
+		transition := moodTransition{from: Mood(t.From), to: Mood(t.To), on: t.On}
+		if !validMood(transition.from) {
+			return nil, fmt.Errorf("error in NewMoodMachine: unknown transition mood `%s`", t.From)
+		}
+		if !validMood(transition.to) {
+			return nil, fmt.Errorf("error in NewMoodMachine: unknown transition mood `%s`", t.To)
+		}
+		switch t.On {
+		case "", eventSay, eventAct:
+		default:
+			return nil, fmt.Errorf("error in NewMoodMachine: unknown transition event `%s`", t.On)
+		}
+		if t.After != "" {
+			after, err := time.ParseDuration(t.After)
+			if err != nil {
+				return nil, fmt.Errorf("error in NewMoodMachine parsing transition duration: %w", err)
+			}
+			transition.after = after
+		}
+		if transition.on == "" && transition.after == 0 {
+			return nil, fmt.Errorf(
+				"error in NewMoodMachine: transition from `%s` to `%s` needs an `on` event or an `after` duration",
+				t.From, t.To,
+			)
+		}
+		transitions = append(transitions, transition)
+	}
+
+	return &MoodMachine{
+		initial:     initial,
+		mood:        initial,
+		since:       clock.Now(),
+		transitions: transitions,
+		clock:       clock,
+	}, nil
+}
+
+// Mood returns the current mood, first applying any transitions that are due
+// purely because of time passing. A nil MoodMachine has no mood.
+func (m *MoodMachine) Mood() Mood {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.transition("")
+	return m.mood
+}
+
+// Record tells the MoodMachine the pet did event, applying any transition it
+// triggers.
+func (m *MoodMachine) Record(event string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.transition(event)
+}
+
+// restore puts the pet back in mood, as it was in a previous run. Moods
+// that are not known are ignored.
+func (m *MoodMachine) restore(mood Mood) {
+	if m == nil || !validMood(mood) {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mood = mood
+	m.since = m.clock.Now()
+}
+
+// transition applies the first transition out of the current mood that
+// matches event and has waited long enough. An empty event only matches
+// transitions that are purely time based. The caller must hold m.mu.
+func (m *MoodMachine) transition(event string) {
+	now := m.clock.Now()
+	for _, t := range m.transitions {
+		if t.from != m.mood || t.on != event || now.Sub(m.since) < t.after {
+			continue
+		}
+		m.mood = t.to
+		m.since = now
+		return
+	}
+}
+
+// hcl returns the moods block that configures a MoodMachine like m.
+// Transitions are left out when m uses the default transitions.
+func (m *MoodMachine) hcl() *MoodsHCL {
+	moods := &MoodsHCL{Initial: string(m.initial)}
+	if reflect.DeepEqual(m.transitions, defaultMoodTransitions) {
+		return moods
+	}
+	for _, t := range m.transitions {
+		transition := &struct {
+			From  string `hcl:"from"`
+			To    string `hcl:"to"`
+			On    string `hcl:"on,optional"`
+			After string `hcl:"after,optional"`
+		}{From: string(t.from), To: string(t.to), On: t.on}
+		if t.after != 0 {
+			transition.After = t.after.String()
+		}
+		moods.Transitions = append(moods.Transitions, transition)
+	}
+	return moods
+}
+
+func validMood(mood Mood) bool {
+	switch mood {
+	case MoodHungry, MoodSleepy, MoodPlayful:
+		return true
+	}
+	return false
+}
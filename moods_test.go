@@ -0,0 +1,127 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoodMachine(t *testing.T) {
+
+	tcs := []struct {
+		name   string
+		moods  *MoodsHCL
+		events []string
+		want   []Mood
+	}{
+		{
+			name:   "default transitions",
+			moods:  &MoodsHCL{},
+			events: []string{eventSay, eventAct, eventAct, eventAct, eventSay},
+			want:   []Mood{MoodPlayful, MoodSleepy, MoodHungry, MoodHungry, MoodPlayful},
+		},
+		{
+			name:   "initial mood",
+			moods:  &MoodsHCL{Initial: "hungry"},
+			events: []string{eventSay},
+			want:   []Mood{MoodPlayful},
+		},
+		{
+			name: "custom transitions replace defaults",
+			moods: func() *MoodsHCL {
+				m := &MoodsHCL{}
+				m.Transitions = append(m.Transitions, &struct {
+					From  string `hcl:"from"`
+					To    string `hcl:"to"`
+					On    string `hcl:"on,optional"`
+					After string `hcl:"after,optional"`
+				}{From: "playful", To: "hungry", On: "say"})
+				return m
+			}(),
+			events: []string{eventAct, eventSay, eventAct},
+			want:   []Mood{MoodPlayful, MoodHungry, MoodHungry},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewMoodMachine(tc.moods)
+			if assert.Nil(t, err, "error while creating mood machine") {
+				got := []Mood{}
+				for _, event := range tc.events {
+					m.Record(event)
+					got = append(got, m.Mood())
+				}
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMoodMachineAfter(t *testing.T) {
+	m, err := NewMoodMachine(&MoodsHCL{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	m.transitions = []moodTransition{
+		{from: MoodPlayful, to: MoodSleepy, after: time.Hour},
+	}
+
+	assert.Equal(t, MoodPlayful, m.Mood())
+	m.since = m.since.Add(-2 * time.Hour)
+	assert.Equal(t, MoodSleepy, m.Mood())
+}
+
+func TestMoodMachineErrors(t *testing.T) {
+	transition := func(from, to, on, after string) *MoodsHCL {
+		m := &MoodsHCL{}
+		m.Transitions = append(m.Transitions, &struct {
+			From  string `hcl:"from"`
+			To    string `hcl:"to"`
+			On    string `hcl:"on,optional"`
+			After string `hcl:"after,optional"`
+		}{From: from, To: to, On: on, After: after})
+		return m
+	}
+
+	for name, moods := range map[string]*MoodsHCL{
+		"unknown initial":  {Initial: "grumpy"},
+		"unknown from":     transition("grumpy", "hungry", "say", ""),
+		"unknown to":       transition("hungry", "grumpy", "say", ""),
+		"unknown event":    transition("hungry", "sleepy", "eat", ""),
+		"bad duration":     transition("hungry", "sleepy", "", "soon"),
+		"never transition": transition("hungry", "sleepy", "", ""),
+	} {
+		_, err := NewMoodMachine(moods)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMoodOutput(t *testing.T) {
+	pets, err := ReadConfig("testdata/moods.hcl")
+	if !assert.Nil(t, err, "error while parsing input") {
+		return
+	}
+
+	out := &bytes.Buffer{}
+	runner := &Runner{Out: out}
+	runner.Run(pets)
+	runner.Run(pets)
+
+	assert.Equal(t, ""+
+		"Ink meow (sleepy)\n"+
+		"Ink snoozes\n"+
+		"Swinney the Dachshund barks (playful)\n"+
+		"Swinney the Dachshund begs for treats\n"+
+		"Ink meow (hungry)\n"+
+		"Ink chases a piece of string\n"+
+		"Swinney the Dachshund barks (hungry)\n"+
+		"Swinney the Dachshund begs for treats\n",
+		out.String(),
+	)
+}
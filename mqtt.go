@@ -0,0 +1,165 @@
+package petsounds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BrokerHCL is the broker block, which publishes what each pet says and does
+// to an MQTT broker, for home automation, such as:
+//   broker {
+//     address = "localhost:1883"
+//   }
+// Each event is published to <topic_prefix>/<pet name>/<event>, as in
+// pets/Ink/say, with the line the pet wrote as the message.
+type BrokerHCL struct {
+	Address     string `hcl:"address"`
+	ClientID    string `hcl:"client_id,optional"`
+	TopicPrefix string `hcl:"topic_prefix,optional"`
+	Username    string `hcl:"username,optional"`
+	Password    string `hcl:"password,optional"`
+}
+
+// The defaults of the optional attributes of a broker block.
+const (
+	defaultMQTTClientID    = "pet-sounds"
+	defaultMQTTTopicPrefix = "pets"
+)
+
+// mqttDialTimeout is how long DialMQTT waits for the broker to accept the
+// connection.
+const mqttDialTimeout = 10 * time.Second
+
+// MQTTPublisher publishes pet events to an MQTT broker, speaking just
+// enough of MQTT 3.1.1 to publish messages at most once. It is safe for
+// concurrent use.
+type MQTTPublisher struct {
+	broker *BrokerHCL
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// DialMQTT connects to the broker of a broker block.
+func DialMQTT(broker *BrokerHCL) (*MQTTPublisher, error) {
+	b := *broker
+	if b.ClientID == "" {
+		b.ClientID = defaultMQTTClientID
+	}
+	if b.TopicPrefix == "" {
+		b.TopicPrefix = defaultMQTTTopicPrefix
+	}
+	m := &MQTTPublisher{broker: &b}
+	if err := m.connect(); err != nil {
+		return nil, fmt.Errorf("error in DialMQTT: %w", err)
+	}
+	return m, nil
+}
+
+// connect opens a new connection to the broker.
+func (m *MQTTPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", m.broker.Address, mqttDialTimeout)
+	if err != nil {
+		return err
+	}
+
+	// The session is clean and without keep alive, so the broker keeps the
+	// connection open between events however far apart they are.
+	flags := byte(0x02)
+	payload := mqttString(m.broker.ClientID)
+	if m.broker.Username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(m.broker.Username)...)
+		if m.broker.Password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(m.broker.Password)...)
+		}
+	}
+	variable := append(mqttString("MQTT"), 4, flags, 0, 0)
+	conn.SetDeadline(time.Now().Add(mqttDialTimeout))
+	if _, err := conn.Write(mqttPacket(0x10, append(variable, payload...))); err != nil {
+		conn.Close()
+		return err
+	}
+	connack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		conn.Close()
+		return fmt.Errorf("broker `%s` did not acknowledge the connection: %w", m.broker.Address, err)
+	}
+	if connack[0] != 0x20 || connack[3] != 0 {
+		conn.Close()
+		return fmt.Errorf("broker `%s` refused the connection with code %d", m.broker.Address, connack[3])
+	}
+	conn.SetDeadline(time.Time{})
+	m.conn = conn
+	return nil
+}
+
+// Publish publishes line, written by p for event, to the topic of p and
+// event. A broken connection is connected again once.
+func (m *MQTTPublisher) Publish(p Pet, event, line string) error {
+	name, _ := petIdentity(p)
+	topic := strings.Join([]string{m.broker.TopicPrefix, name, event}, "/")
+	packet := mqttPacket(0x30, append(mqttString(topic), line...))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn != nil {
+		if _, err := m.conn.Write(packet); err == nil {
+			return nil
+		}
+		m.conn.Close()
+		m.conn = nil
+	}
+	if err := m.connect(); err != nil {
+		return fmt.Errorf("error in MQTTPublisher publishing to `%s`: %w", topic, err)
+	}
+	if _, err := m.conn.Write(packet); err != nil {
+		return fmt.Errorf("error in MQTTPublisher publishing to `%s`: %w", topic, err)
+	}
+	return nil
+}
+
+// Close disconnects from the broker.
+func (m *MQTTPublisher) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return nil
+	}
+	m.conn.Write(mqttPacket(0xe0, nil))
+	err := m.conn.Close()
+	m.conn = nil
+	return err
+}
+
+// mqttPacket returns the control packet of type and flags header with body,
+// prefixed by its remaining length.
+func mqttPacket(header byte, body []byte) []byte {
+	packet := &bytes.Buffer{}
+	packet.WriteByte(header)
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet.WriteByte(b)
+		if n == 0 {
+			break
+		}
+	}
+	packet.Write(body)
+	return packet.Bytes()
+}
+
+// mqttString returns s as an MQTT string, prefixed by its length.
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
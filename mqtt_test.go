@@ -0,0 +1,139 @@
+package petsounds
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readMQTTPacket reads a control packet from r, returning its header byte
+// and body.
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func TestMQTTPublisher(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	type packet struct {
+		header byte
+		body   []byte
+	}
+	received := make(chan packet, 8)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			header, body, err := readMQTTPacket(r)
+			if err != nil {
+				close(received)
+				return
+			}
+			if header == 0x10 {
+				conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+			}
+			received <- packet{header, body}
+		}
+	}()
+
+	mqtt, err := DialMQTT(&BrokerHCL{Address: listener.Addr().String(), Username: "ink"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	runner := &Runner{Out: &bytes.Buffer{}, MQTT: mqtt}
+	assert.NoError(t, runner.Run([]Pet{&Cat{Name: "Ink", Sound: "meow"}}))
+	assert.NoError(t, mqtt.Close())
+
+	connect := <-received
+	assert.Equal(t, byte(0x10), connect.header)
+	assert.Equal(t, append(mqttString("MQTT"), 4, 0x82, 0, 0), connect.body[:10])
+	assert.Equal(t, append(mqttString("pet-sounds"), mqttString("ink")...), connect.body[10:])
+
+	say := <-received
+	assert.Equal(t, packet{0x30, append(mqttString("pets/Ink/say"), "Ink meow"...)}, say)
+	act := <-received
+	assert.Equal(t, packet{0x30, append(mqttString("pets/Ink/act"), "Ink snoozes"...)}, act)
+	assert.Equal(t, packet{0xe0, []byte{}}, <-received)
+}
+
+func TestMQTTPacket(t *testing.T) {
+	t.Parallel()
+
+	// Remaining lengths of 128 and more take more than one byte.
+	packet := mqttPacket(0x30, make([]byte, 321))
+	assert.Equal(t, []byte{0x30, 0xc1, 0x02}, packet[:3])
+	assert.Len(t, packet, 324)
+}
+
+func TestDialMQTTRefused(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		readMQTTPacket(bufio.NewReader(conn))
+		conn.Write([]byte{0x20, 0x02, 0x00, 0x05})
+	}()
+
+	_, err = DialMQTT(&BrokerHCL{Address: listener.Addr().String()})
+	assert.EqualError(t, err,
+		"error in DialMQTT: broker `"+listener.Addr().String()+"` refused the connection with code 5")
+}
+
+func TestBrokerBlock(t *testing.T) {
+	t.Parallel()
+
+	config, err := DecodeConfig([]byte(`
+broker {
+  address      = "localhost:1883"
+  topic_prefix = "home/pets"
+}
+
+pet "Ink" {
+  type = "cat"
+}
+`), "test.hcl", LoadOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, &BrokerHCL{Address: "localhost:1883", TopicPrefix: "home/pets"}, config.Broker)
+	}
+}
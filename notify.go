@@ -0,0 +1,160 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotifyHCL is a notify block, which POSTs what pets say and do to a
+// webhook, such as:
+//   notify {
+//     url    = "https://hooks.slack.com/services/..."
+//     events = ["say"]
+//   }
+// Events are sent as a JSON object with the line the pet wrote as both its
+// text and content fields, which Slack and Discord webhooks read
+// respectively. events limits the events sent, all of them if left out.
+// With summary set, a single message counting each pet's events is sent at
+// the end of the run instead.
+type NotifyHCL struct {
+	URL     string   `hcl:"url"`
+	Events  []string `hcl:"events,optional"`
+	Summary bool     `hcl:"summary,optional"`
+	Retries *int     `hcl:"retries,optional"`
+}
+
+// defaultNotifyRetries is the number of times a Notifier tries a webhook
+// again after it fails, unless its notify block says otherwise.
+const defaultNotifyRetries = 3
+
+// Notifier sends the events of the pets a Runner runs to the webhook of a
+// notify block. It is safe for concurrent use.
+type Notifier struct {
+	URL     string
+	Events  []string
+	Summary bool
+	Retries int
+	Client  *http.Client
+
+	// retryDelay is the wait before the first retry, doubling for each one
+	// after it.
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	counts map[string]map[string]int
+}
+
+// NewNotifier returns the Notifier of a notify block. It is an error for
+// the block to name an event pets don't have, as that is most likely a
+// typo.
+func NewNotifier(notify *NotifyHCL) (*Notifier, error) {
+	events := []string{eventSay, eventAct}
+	for _, e := range notify.Events {
+		if contains(events, e) {
+			continue
+		}
+		if suggestion := suggest(e, events); suggestion != "" {
+			return nil, fmt.Errorf("error in NewNotifier: unknown event `%s`, did you mean `%s`?", e, suggestion)
+		}
+		return nil, fmt.Errorf("error in NewNotifier: unknown event `%s`, expected say or act", e)
+	}
+
+	retries := defaultNotifyRetries
+	if notify.Retries != nil {
+		retries = *notify.Retries
+	}
+	return &Notifier{
+		URL: notify.URL, Events: notify.Events, Summary: notify.Summary, Retries: retries,
+		retryDelay: time.Second,
+	}, nil
+}
+
+// Notify sends line, which p wrote for event, to the webhook, unless the
+// Notifier leaves out the event or only sends a summary.
+func (n *Notifier) Notify(p Pet, event, line string) error {
+	if len(n.Events) > 0 && !contains(n.Events, event) {
+		return nil
+	}
+	if n.Summary {
+		name, _ := petIdentity(p)
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.counts == nil {
+			n.counts = map[string]map[string]int{}
+		}
+		if n.counts[name] == nil {
+			n.counts[name] = map[string]int{}
+		}
+		n.counts[name][event]++
+		return nil
+	}
+	return n.send(line)
+}
+
+// Close sends the summary of the run, if the Notifier sends one and there
+// was anything to summarize.
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.Summary || len(n.counts) == 0 {
+		return nil
+	}
+
+	names := []string{}
+	for name := range n.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{}
+	for _, name := range names {
+		events := []string{}
+		for event, count := range n.counts[name] {
+			events = append(events, fmt.Sprintf("%s %d", event, count))
+		}
+		sort.Strings(events)
+		lines = append(lines, fmt.Sprintf("%s: %s", name, strings.Join(events, ", ")))
+	}
+	n.counts = nil
+	return n.send(strings.Join(lines, "\n"))
+}
+
+// send POSTs text to the webhook, trying again after failures.
+func (n *Notifier) send(text string) error {
+	body, err := json.Marshal(map[string]string{"text": text, "content": text})
+	if err != nil {
+		return fmt.Errorf("error in Notifier encoding request: %w", err)
+	}
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	delay := n.retryDelay
+	for attempt := 0; ; attempt++ {
+		err = n.post(client, body)
+		if err == nil || attempt >= n.Retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post makes a single POST of body to the webhook.
+func (n *Notifier) post(client *http.Client, body []byte) error {
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error in Notifier calling `%s`: %w", n.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error in Notifier: `%s` responded %s", n.URL, resp.Status)
+	}
+	return nil
+}
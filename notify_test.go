@@ -0,0 +1,122 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// webhook is a test server that records the texts POSTed to it, failing
+// the first fail requests.
+type webhook struct {
+	mu    sync.Mutex
+	fail  int
+	calls int
+	texts []string
+}
+
+func (h *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if h.calls <= h.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	payload := map[string]string{}
+	json.NewDecoder(r.Body).Decode(&payload)
+	if payload["text"] == payload["content"] {
+		h.texts = append(h.texts, payload["text"])
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	retries := 0
+	tcs := []struct {
+		name      string
+		notify    NotifyHCL
+		fail      int
+		want      []string
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "every event",
+			want:      []string{"Ink meow", "Ink snoozes"},
+			wantCalls: 2,
+		},
+		{
+			name:      "say only",
+			notify:    NotifyHCL{Events: []string{"say"}},
+			want:      []string{"Ink meow"},
+			wantCalls: 1,
+		},
+		{
+			name:      "retried",
+			notify:    NotifyHCL{Events: []string{"say"}},
+			fail:      2,
+			want:      []string{"Ink meow"},
+			wantCalls: 3,
+		},
+		{
+			name:      "out of retries",
+			notify:    NotifyHCL{Events: []string{"say"}, Retries: &retries},
+			fail:      1,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "summary",
+			notify:    NotifyHCL{Summary: true},
+			want:      []string{"Ink: act 1, say 1\nSwinney: act 1, say 1"},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hook := &webhook{fail: tc.fail}
+			server := httptest.NewServer(hook)
+			defer server.Close()
+
+			tc.notify.URL = server.URL
+			n, err := NewNotifier(&tc.notify)
+			if !assert.NoError(t, err) {
+				return
+			}
+			n.retryDelay = 0
+			pets := []Pet{&Cat{Name: "Ink", Sound: "meow"}}
+			if tc.notify.Summary {
+				pets = append(pets, &Dog{Name: "Swinney", Breed: "Dachshund"})
+			}
+
+			runner := &Runner{Out: &bytes.Buffer{}, Notifiers: []*Notifier{n}}
+			err = runner.Run(pets)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, runner.Close())
+			assert.Equal(t, tc.want, hook.texts)
+			assert.Equal(t, tc.wantCalls, hook.calls)
+		})
+	}
+}
+
+func TestNewNotifierUnknownEvent(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewNotifier(&NotifyHCL{URL: "http://localhost", Events: []string{"says"}})
+	assert.EqualError(t, err, "error in NewNotifier: unknown event `says`, did you mean `say`?")
+}
@@ -0,0 +1,444 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPTracer is a SpanTracer that exports its spans to an OpenTelemetry
+// collector, with OTLP over HTTP encoded as JSON. Ended spans are exported
+// in the background, in batches, every Interval and as soon as a batch is
+// full, so that ending a span never waits on the collector. The rest are
+// exported when the tracer is flushed or shut down. It is safe for
+// concurrent use.
+type OTLPTracer struct {
+	// Endpoint is the base URL of the collector, such as
+	// http://localhost:4318. Spans are posted to its /v1/traces.
+	Endpoint string
+
+	// Headers are set on each export, such as to authenticate with the
+	// collector.
+	Headers map[string]string
+
+	// ServiceName is the service.name of the spans. Without it, it is
+	// pet-sounds.
+	ServiceName string
+
+	// Interval is the time between the exports of the spans that have
+	// ended. Without it, they are exported every 5 seconds.
+	Interval time.Duration
+
+	// Timeout is the time each export in the background has to finish.
+	// Without it, it is 10 seconds.
+	Timeout time.Duration
+
+	// Client exports the spans. Without it, a client that gives up on
+	// requests after Timeout is used.
+	Client *http.Client
+
+	mu      sync.Mutex
+	spans   []*otlpSpan
+	dropped int
+	err     error
+	// root, if set, is the parent of the spans started without one, such
+	// as the span of the command that is running.
+	root *otlpSpan
+
+	// exporting is held for each export, so that Flush returns once the
+	// exports in the background are done too.
+	exporting sync.Mutex
+
+	// The exporter in the background, started with the first span: full is
+	// signalled once there are a batch of spans to export, and stop is
+	// closed to end it, closing stopped once it has.
+	startOnce, stopOnce sync.Once
+	full                chan struct{}
+	stop, stopped       chan struct{}
+}
+
+// The batches of spans an OTLPTracer exports: otlpBatchSize is the number
+// of spans exported at once, and otlpQueueSize the number of ended spans
+// that can wait to be exported, beyond which spans are dropped rather than
+// kept while the collector falls behind.
+const (
+	otlpBatchSize = 512
+	otlpQueueSize = 8 * otlpBatchSize
+)
+
+// The defaults of an OTLPTracer's Interval and Timeout.
+const (
+	otlpDefaultInterval = 5 * time.Second
+	otlpDefaultTimeout  = 10 * time.Second
+)
+
+// otlpSpanKey is the key of the span in the contexts of an OTLPTracer.
+type otlpSpanKey struct{}
+
+// otlpSpan is a span of an OTLPTracer.
+type otlpSpan struct {
+	tracer   *OTLPTracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	attrs    map[string]string
+	start    time.Time
+	end      time.Time
+	err      error
+}
+
+// Start starts a span as a child of the span of ctx, or of the tracer's
+// root span, or as the first of a new trace.
+func (t *OTLPTracer) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, Span) {
+	t.startOnce.Do(t.startExporter)
+	s := &otlpSpan{tracer: t, name: name, attrs: attrs, start: time.Now()}
+	parent, _ := ctx.Value(otlpSpanKey{}).(*otlpSpan)
+	if parent == nil {
+		parent = t.root
+	}
+	if parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	return context.WithValue(ctx, otlpSpanKey{}, s), s
+}
+
+// End ends the span, queueing it to be exported.
+func (s *otlpSpan) End(err error) {
+	s.end, s.err = time.Now(), err
+	t := s.tracer
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= otlpQueueSize {
+		t.dropped++
+		return
+	}
+	t.spans = append(t.spans, s)
+	if len(t.spans) >= otlpBatchSize && t.full != nil {
+		select {
+		case t.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// startExporter starts exporting the spans that have ended in the
+// background, until the tracer is shut down.
+func (t *OTLPTracer) startExporter() {
+	t.mu.Lock()
+	t.full = make(chan struct{}, 1)
+	t.mu.Unlock()
+	t.stop, t.stopped = make(chan struct{}), make(chan struct{})
+	interval := t.Interval
+	if interval <= 0 {
+		interval = otlpDefaultInterval
+	}
+
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+			case <-t.full:
+			}
+			if err := t.exportEnded(t.exportContext); err != nil {
+				t.mu.Lock()
+				if t.err == nil {
+					t.err = err
+				}
+				t.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// exportContext returns the context of an export in the background, which
+// gives up after the tracer's Timeout.
+func (t *OTLPTracer) exportContext() (context.Context, context.CancelFunc) {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = otlpDefaultTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// exportEnded exports the spans that have ended and not been exported yet,
+// a batch at a time, each with a context from newContext. It stops at the
+// first batch that fails, which is dropped.
+func (t *OTLPTracer) exportEnded(newContext func() (context.Context, context.CancelFunc)) error {
+	t.exporting.Lock()
+	defer t.exporting.Unlock()
+	for {
+		t.mu.Lock()
+		batch := t.spans
+		if len(batch) > otlpBatchSize {
+			batch = batch[:otlpBatchSize]
+		}
+		t.spans = t.spans[len(batch):]
+		t.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ctx, cancel := newContext()
+		err := t.export(ctx, batch)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Flush exports the spans that have ended and not been exported yet. It
+// returns the first error exporting spans since the last flush.
+func (t *OTLPTracer) Flush(ctx context.Context) error {
+	exportErr := t.exportEnded(func() (context.Context, context.CancelFunc) {
+		return ctx, func() {}
+	})
+
+	t.mu.Lock()
+	err, dropped := t.err, t.dropped
+	t.err, t.dropped = nil, 0
+	t.mu.Unlock()
+	if err == nil {
+		err = exportErr
+	}
+	if err == nil && dropped > 0 {
+		err = fmt.Errorf("error in OTLPTracer: dropped %d spans, as the collector fell behind", dropped)
+	}
+	return err
+}
+
+// Shutdown stops exporting spans in the background, and flushes the spans
+// that are left. Spans that end after it are only exported by Flush.
+func (t *OTLPTracer) Shutdown(ctx context.Context) error {
+	t.startOnce.Do(func() {})
+	t.stopOnce.Do(func() {
+		if t.stop != nil {
+			close(t.stop)
+			<-t.stopped
+		}
+	})
+	return t.Flush(ctx)
+}
+
+// export posts spans to the collector.
+func (t *OTLPTracer) export(ctx context.Context, spans []*otlpSpan) error {
+	body, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return fmt.Errorf("error in OTLPTracer encoding spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Endpoint+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error in OTLPTracer exporting spans: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	client := t.Client
+	if client == nil {
+		timeout := t.Timeout
+		if timeout <= 0 {
+			timeout = otlpDefaultTimeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error in OTLPTracer exporting spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("error in OTLPTracer exporting spans: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of an export of spans, as far as pet-sounds uses
+// it.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope      `json:"scope"`
+		Spans []otlpSpanJSON `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpanJSON struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// The OTLP span kind and status codes pet-sounds uses.
+const (
+	otlpKindInternal = 1
+	otlpStatusError  = 2
+)
+
+// request returns the export of spans.
+func (t *OTLPTracer) request(spans []*otlpSpan) otlpRequest {
+	service := t.ServiceName
+	if service == "" {
+		service = "pet-sounds"
+	}
+	encoded := []otlpSpanJSON{}
+	for _, s := range spans {
+		span := otlpSpanJSON{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              otlpKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.attrs),
+		}
+		if s.parentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != nil {
+			span.Status = otlpStatus{Code: otlpStatusError, Message: s.err.Error()}
+		}
+		encoded = append(encoded, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]string{"service.name": service})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "pet-sounds", Version: buildInfo().Version}, Spans: encoded}},
+	}}}
+}
+
+// otlpAttributes returns attrs as OTLP attributes, in key order.
+func otlpAttributes(attrs map[string]string) []otlpAttribute {
+	keys := []string{}
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	encoded := []otlpAttribute{}
+	for _, k := range keys {
+		encoded = append(encoded, otlpAttribute{Key: k, Value: otlpValue{StringValue: attrs[k]}})
+	}
+	return encoded
+}
+
+// otlpFlushTimeout is the time the spans of a command have to be exported
+// once it is done.
+const otlpFlushTimeout = 10 * time.Second
+
+// otlpFlag is the -otlp-endpoint flag, with the OTLPTracer that exports
+// the spans of the command to it once the command has started.
+type otlpFlag struct {
+	endpoint string
+	tracer   *OTLPTracer
+}
+
+func (o *otlpFlag) String() string {
+	if o == nil {
+		return ""
+	}
+	return o.endpoint
+}
+
+func (o *otlpFlag) Set(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("expected the http(s) URL of a collector, such as http://localhost:4318")
+	}
+	o.endpoint = strings.TrimSuffix(value, "/")
+	return nil
+}
+
+// start starts exporting spans, under a span of the command named name,
+// with headers given as name=value. The returned function ends the span of
+// the command and exports what is left, reporting failures to warnings, as
+// the command itself has done its work.
+func (o *otlpFlag) start(name string, headers []string, warnings io.Writer) (func(), error) {
+	if o.endpoint == "" {
+		return func() {}, nil
+	}
+	t := &OTLPTracer{Endpoint: o.endpoint, Headers: map[string]string{}}
+	for _, h := range headers {
+		i := strings.Index(h, "=")
+		if i < 1 {
+			return nil, withExitCode(exitUsage, fmt.Errorf("invalid -otlp-header `%s`, expected name=value", h))
+		}
+		t.Headers[h[:i]] = h[i+1:]
+	}
+	_, root := t.Start(context.Background(), name, nil)
+	t.root = root.(*otlpSpan)
+	o.tracer = t
+
+	return func() {
+		root.End(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), otlpFlushTimeout)
+		defer cancel()
+		if err := t.Shutdown(ctx); err != nil {
+			fmt.Fprintf(warnings, "pet-sounds warning: %s\n", err)
+		}
+	}, nil
+}
+
+// flagSpans returns the SpanTracer of the -otlp-endpoint flag of flags, or
+// nil if the command's spans are not exported.
+func flagSpans(flags *flag.FlagSet) SpanTracer {
+	f := flags.Lookup("otlp-endpoint")
+	if f == nil {
+		return nil
+	}
+	o, _ := f.Value.(*otlpFlag)
+	if o == nil || o.tracer == nil {
+		return nil
+	}
+	return o.tracer
+}
@@ -0,0 +1,142 @@
+package petsounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPTracer(t *testing.T) {
+	t.Parallel()
+
+	exports := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer woof", r.Header.Get("Authorization"))
+		req := otlpRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		exports <- req
+	}))
+	defer collector.Close()
+
+	tracer := &OTLPTracer{Endpoint: collector.URL, Headers: map[string]string{"Authorization": "Bearer woof"}}
+	defer tracer.Shutdown(context.Background())
+	ctx, parent := tracer.Start(context.Background(), "pet-sounds", nil)
+	_, child := tracer.Start(ctx, spanDecode, petSpanAttributes("Ink", "cat"))
+	child.End(errors.New("no sound"))
+	parent.End(nil)
+	if !assert.NoError(t, tracer.Flush(context.Background())) {
+		return
+	}
+
+	req := <-exports
+	if !assert.Len(t, req.ResourceSpans, 1) || !assert.Len(t, req.ResourceSpans[0].ScopeSpans, 1) {
+		return
+	}
+	assert.Equal(t, []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: "pet-sounds"}}},
+		req.ResourceSpans[0].Resource.Attributes)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	decode, root := spans[0], spans[1]
+	assert.Equal(t, spanDecode, decode.Name)
+	assert.Equal(t, root.TraceID, decode.TraceID)
+	assert.Equal(t, root.SpanID, decode.ParentSpanID)
+	assert.Empty(t, root.ParentSpanID)
+	assert.Len(t, root.TraceID, 32)
+	assert.Len(t, root.SpanID, 16)
+	assert.Equal(t, []otlpAttribute{
+		{Key: "pet.name", Value: otlpValue{StringValue: "Ink"}},
+		{Key: "pet.type", Value: otlpValue{StringValue: "cat"}},
+	}, decode.Attributes)
+	assert.Equal(t, otlpStatus{Code: otlpStatusError, Message: "no sound"}, decode.Status)
+	assert.Equal(t, otlpStatus{}, root.Status)
+
+	// Nothing is left to export.
+	assert.NoError(t, tracer.Flush(context.Background()))
+	assert.Empty(t, exports)
+}
+
+func TestOTLPTracerExportError(t *testing.T) {
+	t.Parallel()
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "over quota", http.StatusTooManyRequests)
+	}))
+	defer collector.Close()
+
+	tracer := &OTLPTracer{Endpoint: collector.URL}
+	_, span := tracer.Start(context.Background(), spanParse, nil)
+	span.End(nil)
+	err := tracer.Shutdown(context.Background())
+	if assert.Error(t, err) {
+		assert.Equal(t, "error in OTLPTracer exporting spans: 429 Too Many Requests: over quota", err.Error())
+	}
+}
+
+func TestOTLPTracerInterval(t *testing.T) {
+	t.Parallel()
+
+	exports := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := otlpRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		exports <- req
+	}))
+	defer collector.Close()
+
+	// The span is exported in the background, without a flush.
+	tracer := &OTLPTracer{Endpoint: collector.URL, Interval: 10 * time.Millisecond}
+	_, span := tracer.Start(context.Background(), spanParse, nil)
+	span.End(nil)
+	select {
+	case req := <-exports:
+		assert.Equal(t, spanParse, req.ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the span was not exported")
+	}
+
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+	assert.Empty(t, exports)
+}
+
+func TestOTLPTracerDropped(t *testing.T) {
+	t.Parallel()
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+
+	// Spans beyond the queue are dropped while the collector falls behind.
+	tracer := &OTLPTracer{Endpoint: collector.URL}
+	tracer.startOnce.Do(func() {})
+	for i := 0; i < otlpQueueSize+2; i++ {
+		_, span := tracer.Start(context.Background(), spanParse, nil)
+		span.End(nil)
+	}
+	err := tracer.Shutdown(context.Background())
+	if assert.Error(t, err) {
+		assert.Equal(t, "error in OTLPTracer: dropped 2 spans, as the collector fell behind", err.Error())
+	}
+}
+
+func TestOTLPFlag(t *testing.T) {
+	t.Parallel()
+
+	o := &otlpFlag{}
+	assert.Error(t, o.Set("localhost:4318"))
+	assert.NoError(t, o.Set("http://localhost:4318/"))
+	assert.Equal(t, "http://localhost:4318", o.String())
+
+	_, err := o.start("pet-sounds", []string{"Authorization"}, nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, exitUsage, exitCode(err))
+	}
+}
@@ -0,0 +1,143 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// overrideFiles returns the override files of the configuration file
+// filename: override.hcl and the files ending in _override.hcl in the same
+// directory, or in filename itself if it is a configuration directory, in
+// name order. Override files do not have overrides of their own.
+func overrideFiles(filename string) []string {
+	if isOverrideFile(filename) {
+		return nil
+	}
+	dir := filepath.Dir(filename)
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		dir = filename
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*_override.hcl"))
+	if all, _ := filepath.Glob(filepath.Join(dir, "override.hcl")); len(all) > 0 {
+		files = append(files, all...)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// isOverrideFile reports whether filename is named like an override file.
+func isOverrideFile(filename string) bool {
+	base := filepath.Base(filename)
+	return base == "override.hcl" || strings.HasSuffix(base, "_override.hcl")
+}
+
+// applyOverrides merges the override files of filename into body, the file's
+// own body, after anything it includes. The files that override files
+// include are merged by strategy. Override files replace attributes of
+// the blocks already in body, rather than adding blocks, so that a local
+// override file can change part of a shared configuration:
+//   pet "Ink" {
+//     characteristics {
+//       sound = "mrrp"
+//     }
+//   }
+func applyOverrides(ctx context.Context, cache *ParseCache, body *hclsyntax.Body, filename, strategy string) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	for _, f := range overrideFiles(filename) {
+		override, overrideDiags := parseFragment(ctx, cache, f, []string{filename, f}, strategy)
+		diags = append(diags, overrideDiags...)
+		if override == nil {
+			continue
+		}
+
+		for name, attr := range override.Attributes {
+			if name != schemaVersionKey {
+				body.Attributes[name] = attr
+			}
+		}
+		for _, block := range override.Blocks {
+			found := false
+			for _, base := range body.Blocks {
+				if base.Type == block.Type && sameLabels(base.Labels, block.Labels) {
+					overrideBody(base.Body, block.Body)
+					found = true
+				}
+			}
+			if !found {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Missing base block",
+					Detail: fmt.Sprintf(
+						"There is no `%s` block to override; override files can only change blocks that are already declared.",
+						blockName(block),
+					),
+					Subject: block.DefRange().Ptr(),
+				})
+			}
+		}
+	}
+	return diags
+}
+
+// overrideBody merges the body override into body. Attributes in override
+// replace those in body. A block type that appears once in both, like a
+// pet's characteristics, is merged in turn, while other nested blocks in
+// override replace every block of their type in body.
+func overrideBody(body, override *hclsyntax.Body) {
+	for name, attr := range override.Attributes {
+		body.Attributes[name] = attr
+	}
+
+	overrides := map[string]hclsyntax.Blocks{}
+	for _, block := range override.Blocks {
+		overrides[block.Type] = append(overrides[block.Type], block)
+	}
+	count := map[string]int{}
+	for _, block := range body.Blocks {
+		count[block.Type]++
+	}
+
+	blocks := hclsyntax.Blocks{}
+	done := map[string]bool{}
+	for _, block := range body.Blocks {
+		replacements, ok := overrides[block.Type]
+		switch {
+		case !ok:
+			blocks = append(blocks, block)
+		case done[block.Type]:
+		case count[block.Type] == 1 && len(replacements) == 1 && len(block.Labels) == 0:
+			overrideBody(block.Body, replacements[0].Body)
+			blocks = append(blocks, block)
+		default:
+			// Replacements take the place of the first block they replace.
+			blocks = append(blocks, replacements...)
+		}
+		done[block.Type] = true
+	}
+	for _, block := range override.Blocks {
+		if count[block.Type] == 0 {
+			blocks = append(blocks, block)
+		}
+	}
+	body.Blocks = blocks
+}
+
+// sameLabels reports whether two blocks have the same labels.
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideFiles(t *testing.T) {
+
+	tcs := []struct {
+		name     string
+		filename string
+		want     []string
+	}{
+		{
+			name:     "in name order",
+			filename: "testdata/override/pets.hcl",
+			want:     []string{"testdata/override/local_override.hcl", "testdata/override/override.hcl"},
+		},
+		{
+			name:     "override file",
+			filename: "testdata/override/override.hcl",
+			want:     nil,
+		},
+		{
+			name:     "no overrides",
+			filename: "testdata/basic.hcl",
+			want:     nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, overrideFiles(tc.filename))
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	config, err := LoadConfig("testdata/override/pets.hcl")
+	if assert.Nil(t, err, "error while parsing input") {
+		age := 4
+		assert.Equal(t, []Pet{
+			&Cat{Name: "Ink", Sound: "mrrp", Age: &age},
+			&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "arf"},
+		}, clearDeclRanges(config.Pets))
+	}
+
+	_, err = LoadConfig("testdata/override_missing/pets.hcl")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "pets_override.hcl:1,1-12: Missing base block")
+	}
+}
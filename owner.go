@@ -0,0 +1,71 @@
+package petsounds
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// OwnerHCL is an owner block, with the contact details of someone pets
+// belong to, such as:
+//   owner "russell" {
+//     phone = "555-0100"
+//   }
+// Pets name their owner with the owner attribute of their pet block.
+type OwnerHCL struct {
+	Name  string `hcl:"name,label"`
+	Phone string `hcl:"phone,optional"`
+	Email string `hcl:"email,optional"`
+}
+
+// checkOwners returns an error diagnostic for each of pets whose owner is
+// not one of owners.
+func checkOwners(pets []*PetHCL, owners []*OwnerHCL) hcl.Diagnostics {
+	names, declared := []string{}, map[string]bool{}
+	for _, o := range owners {
+		names = append(names, o.Name)
+		declared[o.Name] = true
+	}
+
+	var diags hcl.Diagnostics
+	for _, p := range pets {
+		if p.Owner == "" || declared[p.Owner] {
+			continue
+		}
+		detail := fmt.Sprintf("The pet `%s` belongs to `%s`, but there is no owner block called `%s`.", p.Name, p.Owner, p.Owner)
+		if suggestion := suggest(p.Owner, names); suggestion != "" {
+			detail += fmt.Sprintf(" Did you mean `%s`?", suggestion)
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown owner",
+			Detail:   detail,
+			Subject:  p.declRange.Ptr(),
+		})
+	}
+	return diags
+}
+
+// Owner returns the owner block called name, or nil if there is none.
+func (c *Config) Owner(name string) *OwnerHCL {
+	for _, o := range c.Owners {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// petOwner returns the name of the owner of a pet, which is empty for pets
+// without one.
+func petOwner(p Pet) string {
+	switch pet := unwrapPet(p).(type) {
+	case *Cat:
+		return pet.Owner
+	case *Dog:
+		return pet.Owner
+	case *CustomPet:
+		return pet.Owner
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwners(t *testing.T) {
+	t.Parallel()
+
+	config, err := DecodeConfig([]byte(`
+owner "russell" {
+  phone = "555-0100"
+}
+
+pet "Ink" {
+  type  = "cat"
+  owner = "russell"
+}
+
+pet "Swinney" {
+  type = "dog"
+}
+`), "test.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "meow", Owner: "russell"},
+		&Dog{Name: "Swinney", Breed: "mutt"},
+	}, clearDeclRanges(config.Pets))
+	assert.Equal(t, &OwnerHCL{Name: "russell", Phone: "555-0100"}, config.Owner("russell"))
+	assert.Nil(t, config.Owner("swinney"))
+}
+
+func TestUnknownOwner(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecodeConfig([]byte(`
+owner "russell" {
+  phone = "555-0100"
+}
+
+pet "Ink" {
+  type  = "cat"
+  owner = "russel"
+}
+`), "test.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"test.hcl:6,1-12: Unknown owner; The pet `Ink` belongs to `russel`, but there is no owner block called `russel`. Did you mean `russell`?",
+		)
+	}
+}
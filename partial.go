@@ -0,0 +1,70 @@
+package petsounds
+
+import (
+	"context"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// PartialPet is a pet after the first pass of decoding, with its
+// characteristics left undecoded. Its type can be any name, so programs
+// that embed pet-sounds can decode pets of types of their own.
+type PartialPet struct {
+	Name      string
+	Type      string
+	DeclRange hcl.Range
+
+	// Body is the pet's characteristics block, undecoded, or an empty body
+	// if it has none.
+	Body hcl.Body
+
+	// EvalContext is the context to decode Body in. It is the
+	// configuration's, except for pets from modules, which have their
+	// module's.
+	EvalContext *hcl.EvalContext
+}
+
+// PartialConfig is the result of the first pass of decoding a
+// configuration file.
+type PartialConfig struct {
+	Pets []*PartialPet
+
+	// EvalContext is the context the configuration's expressions are
+	// evaluated in, with its variables, functions and sound pack.
+	EvalContext *hcl.EvalContext
+
+	// Warnings are problems found while loading the file that did not stop
+	// it from loading.
+	Warnings hcl.Diagnostics
+}
+
+// DecodePartial is DecodeConfigContext for only the first pass of decoding
+// src, which leaves the characteristics of each pet for the caller to
+// decode, in a second pass of its own. Unlike the other ways of decoding a
+// configuration, pets of unknown types are not an error.
+func DecodePartial(ctx context.Context, src []byte, filename string, opts LoadOptions) (*PartialConfig, error) {
+	opts = opts.withDefaults()
+	petsHCL, evalContext, warnings, err := decodeGenericPets(ctx, src, filename, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pets := []*PartialPet{}
+	for _, p := range petsHCL.PetHCLBodies {
+		pet := &PartialPet{
+			Name:        p.Name,
+			Type:        p.Type,
+			DeclRange:   p.declRange,
+			Body:        hcl.EmptyBody(),
+			EvalContext: evalContext,
+		}
+		if p.CharacteristicsHCL != nil {
+			pet.Body = p.CharacteristicsHCL.HCL
+		}
+		if p.evalContext != nil {
+			pet.EvalContext = p.evalContext
+		}
+		pets = append(pets, pet)
+	}
+	return &PartialConfig{Pets: pets, EvalContext: evalContext, Warnings: warnings}, nil
+}
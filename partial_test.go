@@ -0,0 +1,85 @@
+package petsounds
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodePartial(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`
+variable "sound" {
+  default = "dook"
+}
+
+pet "Bandit" {
+  type = "ferret"
+  characteristics {
+    sound = "${var.sound}${length("ab")}"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+}
+`)
+	config, err := DecodePartial(context.Background(), src, "ferret.hcl", LoadOptions{})
+	if !assert.NoError(t, err) || !assert.Len(t, config.Pets, 2) {
+		return
+	}
+
+	bandit := config.Pets[0]
+	assert.Equal(t, "Bandit", bandit.Name)
+	assert.Equal(t, "ferret", bandit.Type)
+	assert.Equal(t, 6, bandit.DeclRange.Start.Line)
+	ferret := &struct {
+		Sound string `hcl:"sound"`
+	}{}
+	if diags := gohcl.DecodeBody(bandit.Body, bandit.EvalContext, ferret); assert.False(t, diags.HasErrors(), diags.Error()) {
+		assert.Equal(t, "dook2", ferret.Sound)
+	}
+
+	// A pet without characteristics has an empty body.
+	ink := config.Pets[1]
+	attrs, diags := ink.Body.JustAttributes()
+	assert.False(t, diags.HasErrors())
+	assert.Empty(t, attrs)
+	assert.True(t, ink.EvalContext == config.EvalContext)
+}
+
+func TestDecodePartialModules(t *testing.T) {
+	t.Parallel()
+
+	src, err := ioutil.ReadFile("testdata/module.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	config, err := DecodePartial(context.Background(), src, "testdata/module.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	names := []string{}
+	for _, p := range config.Pets {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"Ink", "barn[0].Rex", "barn[0].Tom", "barn[1].Rex", "barn[1].Tom"}, names)
+
+	// Pets from modules are decoded in the context of their module.
+	rex := config.Pets[3]
+	dog := &struct {
+		Breed string   `hcl:"breed"`
+		Sound string   `hcl:"sound"`
+		Rest  hcl.Body `hcl:",remain"`
+	}{}
+	if diags := gohcl.DecodeBody(rex.Body, rex.EvalContext, dog); assert.False(t, diags.HasErrors(), diags.Error()) {
+		assert.Equal(t, "Corgi", dog.Breed)
+		assert.Equal(t, "bark 1", dog.Sound)
+	}
+}
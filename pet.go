@@ -1,16 +1,24 @@
-package main
+package petsounds
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
-	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
 
 const (
@@ -19,19 +27,82 @@ const (
 
 	defaultCatSound = "meow"
 	defaultDogBreed = "mutt"
+	defaultDogSound = "barks"
 )
 
 // The Pet interface is used to implement the "application" logic of our toy
 // example here. Each Pet is represented in hcl as:
 //   pet "<PET NAME>" {
 //     type = "<dog | cat>"
+//     enabled = <bool, true if left out>
+//     tags = [<labels for selecting pets with -tag>]
+//     owner = "<name of an owner block, optional>"
+//     language = "<language pack the pet speaks, optional>"
 //     characteristics {
 //       // characteristics unique to dogs or cats
 //     }
 //   }
+// Say and Act write whole lines to the io.Writer they are given, which lets a
+// Runner share a single output between many pets at once.
 type Pet interface {
-	Say()
-	Act()
+	Say(w io.Writer)
+	Act(w io.Writer)
+}
+
+// PetInfo is implemented by pets that can say who they are, so code that
+// only shows a pet's identity, like filters, output formats and servers,
+// needs no type switch. It is PetName rather than Name because the pet
+// types keep their names in a Name field. Type must not depend on the pet,
+// so that it can be called on a nil pointer.
+type PetInfo interface {
+	PetName() string
+	Type() string
+}
+
+// petFields are the fields that more than one type of pet has. Types leave
+// the fields they don't have zero, and pets of types without a field of
+// their own, like an age for a CustomPet, have a nil pointer to it.
+type petFields struct {
+	sound       string
+	sounds      []string
+	audioFile   string
+	voice       string
+	age         **int
+	birthdate   string
+	napDuration time.Duration
+	moods       *MoodMachine
+	conditions  *Conditions
+	feedings    []*Feeding
+	vetVisits   []*VetVisit
+	disabled    bool
+	language    string
+	origins     map[string]*Origin
+	validations *[]*ValidationHCL
+	sourceSum   *string
+	sensitive   *map[string]bool
+}
+
+// petAccessor is implemented by the pet types, so code that reads the
+// fields they share needs no type switch, and Walk can call the method of
+// a Visitor for a pet's type.
+type petAccessor interface {
+	fields() petFields
+	visit(v Visitor)
+}
+
+// isSensitive returns whether the characteristic name was set to a
+// sensitive value.
+func (f petFields) isSensitive(name string) bool {
+	return f.sensitive != nil && (*f.sensitive)[name]
+}
+
+// fieldsOf returns the fields of p, which are all zero for pets of types
+// that tools define themselves.
+func fieldsOf(p Pet) petFields {
+	if a, ok := unwrapPet(p).(petAccessor); ok {
+		return a.fields()
+	}
+	return petFields{}
 }
 
 // PetsHCL is a generic structure that could be either cats or dogs. The Type
@@ -40,55 +111,433 @@ type Pet interface {
 // Note the use of the `hcl:",remain"` tag, which puts all undecoded HCL into
 // an hcl.Body for use later.
 type PetsHCL struct {
-	PetHCLBodies []*struct {
-		Name               string `hcl:",label"`
-		Type               string `hcl:"type"`
-		CharacteristicsHCL *struct {
-			HCL hcl.Body `hcl:",remain"`
-		} `hcl:"characteristics,block"`
-	} `hcl:"pet,block"`
-}
-
-// Note the optional `hcl:"sound,optional"` tag on the Sound field. This Field
-// is unique to cats, and a dog characteristic block would have a type error
-// when decoding.
+	PetHCLBodies    []*PetHCL         `hcl:"pet,block"`
+	InteractionsHCL []*InteractionHCL `hcl:"interaction,block"`
+	TTSHCL          *TTSHCL           `hcl:"tts,block"`
+	StateHCL        *StateHCL         `hcl:"state,block"`
+	BrokerHCL       *BrokerHCL        `hcl:"broker,block"`
+	NotifyHCL       []*NotifyHCL      `hcl:"notify,block"`
+	VariablesHCL    []*VariableHCL    `hcl:"variable,block"`
+	ModulesHCL      []*ModuleHCL      `hcl:"module,block"`
+	DefaultsHCL     *DefaultsHCL      `hcl:"defaults,block"`
+	// HouseholdsHCL keep their pets, which are also added to PetHCLBodies
+	// once they are decoded.
+	HouseholdsHCL []*HouseholdHCL `hcl:"household,block"`
+	OwnersHCL     []*OwnerHCL     `hcl:"owner,block"`
+	AliasesHCL    *AliasesHCL     `hcl:"aliases,block"`
+	LintHCL       *LintHCL        `hcl:"lint,block"`
+	PetTypesHCL   []*PetTypeHCL   `hcl:"pet_type,block"`
+	// IncludesHCL, PetTemplatesHCL and ProfilesHCL are always empty, as
+	// include blocks are replaced by the contents of the files they include,
+	// pet templates by the pets that extend them, and profiles by the pets
+	// of the selected one, before decoding.
+	IncludesHCL     []*IncludeHCL     `hcl:"include,block"`
+	PetTemplatesHCL []*PetTemplateHCL `hcl:"pet_template,block"`
+	ProfilesHCL     []*ProfileHCL     `hcl:"profile,block"`
+
+	AllowUnknownBreeds bool     `hcl:"allow_unknown_breeds,optional"`
+	SchemaVersion      int      `hcl:"schema_version,optional"`
+	SoundPack          string   `hcl:"sound_pack,optional"`
+	Seed               int64    `hcl:"seed,optional"`
+	RequiredEnv        []string `hcl:"required_env,optional"`
+	Packs              []string `hcl:"packs,optional"`
+}
+
+// DefaultsHCL is the defaults block, which sets the characteristics of every
+// cat and every dog that their own characteristics leave out, in place of
+// the built in defaults:
+//   defaults {
+//     cat {
+//       sound = "purr"
+//     }
+//     dog {
+//       breed = "corgi"
+//     }
+//   }
+type DefaultsHCL struct {
+	CatHCL *struct {
+		HCL hcl.Body `hcl:",remain"`
+	} `hcl:"cat,block"`
+	DogHCL *struct {
+		HCL hcl.Body `hcl:",remain"`
+	} `hcl:"dog,block"`
+}
+
+// PetHCL is a single pet block of a PetsHCL.
+type PetHCL struct {
+	Name               string   `hcl:"name,label"`
+	Type               string   `hcl:"type"`
+	Enabled            *bool    `hcl:"enabled,optional"`
+	Tags               []string `hcl:"tags,optional"`
+	Owner              string   `hcl:"owner,optional"`
+	Language           string   `hcl:"language,optional"`
+	CharacteristicsHCL *struct {
+		HCL hcl.Body `hcl:",remain"`
+	} `hcl:"characteristics,block"`
+	MoodsHCL          *MoodsHCL        `hcl:"moods,block"`
+	ValidationsHCL    []*ValidationHCL `hcl:"validation,block"`
+	PreconditionsHCL  []*ConditionHCL  `hcl:"precondition,block"`
+	PostconditionsHCL []*ConditionHCL  `hcl:"postcondition,block"`
+	FeedingsHCL       []*FeedingHCL    `hcl:"feeding,block"`
+	VetVisitsHCL      []*VetVisitHCL   `hcl:"vet_visit,block"`
+
+	// evalContext is the context the rest of the pet is decoded in, when it
+	// differs from the configuration file's, as for pets from modules.
+	evalContext *hcl.EvalContext
+	// declRange is the range of the pet block's type and labels, and
+	// typeRange the range of its type attribute's value. blockRange is the
+	// range of the whole block, and block the block itself, with whatever
+	// was merged into it.
+	declRange  hcl.Range
+	typeRange  hcl.Range
+	blockRange hcl.Range
+	block      *hclsyntax.Block
+	// household is the name of the household block of the pet, if any,
+	// and householdDefaults the household's defaults block.
+	household         string
+	householdDefaults *DefaultsHCL
+	// kind is the kind of pets whose type is declared by a pet_type block
+	// of the configuration.
+	kind *petKind
+}
+
+// Config is everything decoded from a pet configuration file: the pets
+// themselves, the interactions between them, and settings for how they are
+// run.
+type Config struct {
+	// Filename is the name of the file the configuration was loaded from.
+	Filename string
+
+	Pets         []Pet
+	Interactions []*Interaction
+	TTS          *TTSHCL
+	State        *StateHCL
+	Broker       *BrokerHCL
+	Notify       []*NotifyHCL
+	Owners       []*OwnerHCL
+	// PetTypes are the pet_type blocks of the configuration, and Packs the
+	// pet packs it enables, which declare the rest of its pet types.
+	PetTypes []*PetTypeHCL
+	Packs    []string
+
+	// References are the references of the characteristics of the pets to
+	// other pets.
+	References []*Reference
+
+	// AllowUnknownBreeds turns unknown dog breeds from errors into warnings
+	// when validating breeds.
+	AllowUnknownBreeds bool
+
+	// Warnings are problems found while loading the file that did not stop
+	// it from loading, such as deprecated attributes from an older schema
+	// version.
+	Warnings hcl.Diagnostics
+	// Skipped are the pets that failed to decode, and were left out of
+	// Pets, when the configuration was decoded with KeepGoing.
+	Skipped []*SkippedPet
+}
+
+// Note the optional `hcl:"sound,optional"` tag on the Sound field. Leaving it
+// out of a cat characteristic block keeps the default cat sound.
+// Sounds and SoundStrategy, like Actions and ActionStrategy, are shared by
+// every pet type. When sounds are configured a pet picks one of them each time
+// it Says, instead of using its single sound. AudioFile overrides the sound
+// bundled for the pet's type in --play mode, and Voice picks the voice used
+// to read the pet's lines aloud in --tts mode. Photo is the path of a
+// picture of the pet, relative to the file it is declared in. Volume, loud
+// or quiet, is how loudly the pet says things, and Repeat is how many times
+// it says its sound, picking from its sounds each time. When no actions are
+// configured, a pet falls back to the defaults for its type and mood.
+// Age, Weight, Length and Vaccinated are also shared by every pet type, and
+// are left nil when they are not configured. A Birthdate sets Age, as of the
+// day the pet is decoded. Weight is kept in kilograms and Length in
+// centimetres, whatever unit they were configured in. DeclRange returns
+// where the pet was declared, so tools can point back at its block.
 type Cat struct {
-	Name  string
-	Sound string `hcl:"sound,optional"`
+	Name           string
+	Sound          string        `hcl:"sound,optional"`
+	Sounds         []string      `hcl:"sounds,optional"`
+	SoundStrategy  string        `hcl:"sound_strategy,optional"`
+	AudioFile      string        `hcl:"audio_file,optional"`
+	Photo          string        `hcl:"photo,optional"`
+	Voice          string        `hcl:"voice,optional"`
+	Volume         string        `hcl:"volume,optional"`
+	Repeat         int           `hcl:"repeat,optional"`
+	Art            string        `hcl:"art,optional"`
+	Actions        []string      `hcl:"actions,optional"`
+	ActionStrategy string        `hcl:"action_strategy,optional"`
+	Age            *int          `hcl:"age,optional"`
+	Birthdate      string        `hcl:"birthdate,optional"`
+	Weight         *float64      `hcl:"weight,optional"`
+	Length         *float64      `hcl:"length,optional"`
+	Vaccinated     *bool         `hcl:"vaccinated,optional"`
+	NapDuration    time.Duration `hcl:"nap_duration,optional"`
+	Moods          *MoodMachine
+	Conditions     *Conditions
+	Feedings       []*Feeding
+	VetVisits      []*VetVisit
+	Disabled       bool
+	Tags           []string
+	Household      string
+	Owner          string
+	Language       string
+
+	sounds      chooser
+	actions     chooser
+	declRange   hcl.Range
+	origins     map[string]*Origin
+	validations []*ValidationHCL
+	sourceSum   string
+	sensitive   map[string]bool
+	locale      *Locale
+	style       *Style
+}
+
+// catActions are what a cat does in each mood. A cat without moods snoozes.
+var catActions = map[Mood]string{
+	"":          "snoozes",
+	MoodSleepy:  "snoozes",
+	MoodHungry:  "paws at the food bowl",
+	MoodPlayful: "chases a piece of string",
+}
+
+// DeclRange returns the range of the type and labels of the block the cat
+// was declared in, which is empty for cats that were not loaded from a
+// configuration.
+func (c *Cat) DeclRange() hcl.Range {
+	return c.declRange
+}
+
+// PetName returns the cat's Name, to which the name of its household, if
+// it has one, is added as a prefix when the cat is decoded.
+func (c *Cat) PetName() string {
+	return c.Name
+}
+
+// Type returns "cat".
+func (*Cat) Type() string {
+	return "cat"
+}
+
+func (c *Cat) fields() petFields {
+	return petFields{
+		sound: c.Sound, sounds: c.Sounds, audioFile: c.AudioFile, voice: c.Voice, age: &c.Age,
+		birthdate: c.Birthdate, napDuration: c.NapDuration, moods: c.Moods, conditions: c.Conditions,
+		feedings: c.Feedings, vetVisits: c.VetVisits, disabled: c.Disabled, language: c.Language, origins: c.origins,
+		validations: &c.validations, sourceSum: &c.sourceSum, sensitive: &c.sensitive,
+	}
+}
+
+func (c *Cat) visit(v Visitor) {
+	v.VisitCat(c)
 }
 
 // Implement the Pet interface.
-func (c *Cat) Say() {
-	fmt.Printf("%s %s\n", c.Name, c.Sound)
+func (c *Cat) Say(w io.Writer) {
+	sound := c.Sound
+	if sound == defaultCatSound {
+		sound = c.locale.sound("cat", sound)
+	}
+	sound = redacted(sound, c.sensitive["sound"])
+	sound = repeated(c.Repeat, func() string {
+		if len(c.Sounds) > 0 {
+			return redacted(c.sounds.choose(c.Sounds, c.SoundStrategy), c.sensitive["sounds"])
+		}
+		return sound
+	})
+	c.style.write(w, utterance{
+		pet: c.Name, petType: "cat", event: eventSay, text: sound, who: c.Name, mood: c.Moods.Mood(), art: c.Art,
+		volume: c.Volume,
+	})
+	c.Moods.Record(eventSay)
 }
-func (c *Cat) Act() {
-	fmt.Printf("%s snoozes\n", c.Name)
+func (c *Cat) Act(w io.Writer) {
+	action := c.locale.action("cat", c.Moods.Mood(), catActions[c.Moods.Mood()])
+	if len(c.Actions) > 0 {
+		action = redacted(c.actions.choose(c.Actions, c.ActionStrategy), c.sensitive["actions"])
+	}
+	c.style.write(w, utterance{
+		pet: c.Name, petType: "cat", event: eventAct, text: action, who: c.Name, mood: c.Moods.Mood(),
+	})
+	c.Moods.Record(eventAct)
 }
 
 // Note the optional `hcl:"breed,optional"` tag on the Breed field. This Field
 // is unique to dogs, and a cat characteristic block would have a type error
-// when decoding.
+// when decoding. A dog without a sound barks.
 type Dog struct {
-	Name  string
-	Breed string `hcl:"breed,optional"`
+	Name           string
+	Breed          string        `hcl:"breed,optional"`
+	Sound          string        `hcl:"sound,optional"`
+	Sounds         []string      `hcl:"sounds,optional"`
+	SoundStrategy  string        `hcl:"sound_strategy,optional"`
+	AudioFile      string        `hcl:"audio_file,optional"`
+	Photo          string        `hcl:"photo,optional"`
+	Voice          string        `hcl:"voice,optional"`
+	Volume         string        `hcl:"volume,optional"`
+	Repeat         int           `hcl:"repeat,optional"`
+	Art            string        `hcl:"art,optional"`
+	Actions        []string      `hcl:"actions,optional"`
+	ActionStrategy string        `hcl:"action_strategy,optional"`
+	Age            *int          `hcl:"age,optional"`
+	Birthdate      string        `hcl:"birthdate,optional"`
+	Weight         *float64      `hcl:"weight,optional"`
+	Length         *float64      `hcl:"length,optional"`
+	Vaccinated     *bool         `hcl:"vaccinated,optional"`
+	NapDuration    time.Duration `hcl:"nap_duration,optional"`
+	Moods          *MoodMachine
+	Conditions     *Conditions
+	Feedings       []*Feeding
+	VetVisits      []*VetVisit
+	Disabled       bool
+	Tags           []string
+	Household      string
+	Owner          string
+	Language       string
+
+	sounds      chooser
+	actions     chooser
+	declRange   hcl.Range
+	origins     map[string]*Origin
+	validations []*ValidationHCL
+	sourceSum   string
+	sensitive   map[string]bool
+	locale      *Locale
+	style       *Style
+}
+
+// dogActions are what a dog does in each mood. A dog without moods plays.
+var dogActions = map[Mood]string{
+	"":          "plays",
+	MoodSleepy:  "naps in a sunbeam",
+	MoodHungry:  "begs for treats",
+	MoodPlayful: "plays",
+}
+
+// DeclRange returns the range of the type and labels of the block the dog
+// was declared in, which is empty for dogs that were not loaded from a
+// configuration.
+func (d *Dog) DeclRange() hcl.Range {
+	return d.declRange
+}
+
+// PetName returns the dog's Name, to which the name of its household, if
+// it has one, is added as a prefix when the dog is decoded.
+func (d *Dog) PetName() string {
+	return d.Name
+}
+
+// Type returns "dog".
+func (*Dog) Type() string {
+	return "dog"
+}
+
+// who is how the dog is named on its lines, with its breed.
+func (d *Dog) who() string {
+	return fmt.Sprintf("%s the %s", d.Name, redacted(d.Breed, d.sensitive["breed"]))
+}
+
+func (d *Dog) fields() petFields {
+	return petFields{
+		sound: d.Sound, sounds: d.Sounds, audioFile: d.AudioFile, voice: d.Voice, age: &d.Age,
+		birthdate: d.Birthdate, napDuration: d.NapDuration, moods: d.Moods, conditions: d.Conditions,
+		feedings: d.Feedings, vetVisits: d.VetVisits, disabled: d.Disabled, language: d.Language, origins: d.origins,
+		validations: &d.validations, sourceSum: &d.sourceSum, sensitive: &d.sensitive,
+	}
+}
+
+func (d *Dog) visit(v Visitor) {
+	v.VisitDog(d)
 }
 
 // Implement the Pet interface.
-func (d *Dog) Say() {
-	fmt.Printf("%s the %s barks\n", d.Name, d.Breed)
+func (d *Dog) Say(w io.Writer) {
+	sound := d.locale.sound("dog", defaultDogSound)
+	if d.Sound != "" {
+		sound = redacted(d.Sound, d.sensitive["sound"])
+	}
+	sound = repeated(d.Repeat, func() string {
+		if len(d.Sounds) > 0 {
+			return redacted(d.sounds.choose(d.Sounds, d.SoundStrategy), d.sensitive["sounds"])
+		}
+		return sound
+	})
+	d.style.write(w, utterance{
+		pet: d.Name, petType: "dog", event: eventSay, text: sound, who: d.who(), mood: d.Moods.Mood(), art: d.Art,
+		volume: d.Volume,
+	})
+	d.Moods.Record(eventSay)
 }
-func (d *Dog) Act() {
-	fmt.Printf("%s the %s plays\n", d.Name, d.Breed)
+func (d *Dog) Act(w io.Writer) {
+	action := d.locale.action("dog", d.Moods.Mood(), dogActions[d.Moods.Mood()])
+	if len(d.Actions) > 0 {
+		action = redacted(d.actions.choose(d.Actions, d.ActionStrategy), d.sensitive["actions"])
+	}
+	d.style.write(w, utterance{
+		pet: d.Name, petType: "dog", event: eventAct, text: action, who: d.who(), mood: d.Moods.Mood(),
+	})
+	d.Moods.Record(eventAct)
 }
 
 // ReadConfig decodes the HCL file at filename into a slice of Pets and returns
 // it.
 func ReadConfig(filename string) ([]Pet, error) {
+	return ReadConfigContext(context.Background(), filename)
+}
+
+// ReadConfigContext is ReadConfig for a file or URL, as given to
+// NewConfigSource, that gives up when ctx is done.
+func ReadConfigContext(ctx context.Context, filename string) ([]Pet, error) {
+	config, err := LoadConfigContext(ctx, filename)
+	if err != nil {
+		return []Pet{}, err
+	}
+	return config.Pets, nil
+}
+
+// The syntaxes a configuration file can be written in. FormatJSON is HCL's
+// JSON syntax, as the convert command writes it.
+const (
+	FormatHCL  = "hcl"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// LoadConfig decodes the HCL file at filename into a Config and returns it.
+// Files with a .json, .yaml, .yml or .toml extension are decoded as HCL's
+// JSON syntax, YAML or TOML instead.
+func LoadConfig(filename string) (*Config, error) {
+	return LoadConfigFormat(filename, "")
+}
+
+// LoadConfigContext is LoadConfig for a file or URL, as given to
+// NewConfigSource, that gives up when ctx is done. Fetching the file,
+// decrypting it, and reading secrets from Vault all stop with ctx.
+func LoadConfigContext(ctx context.Context, filename string) (*Config, error) {
+	source, err := NewConfigSource(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error in LoadConfigContext: %w", err)
+	}
+	src, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in LoadConfigContext: %w", err)
+	}
+	return DecodeConfigContext(ctx, src, filename, LoadOptions{Format: source.Format()})
+}
+
+// LoadConfigFormat is LoadConfig for a file written in format, one of
+// FormatHCL, FormatJSON, FormatYAML or FormatTOML. An empty format is chosen by the
+// file's extension.
+func LoadConfigFormat(filename, format string) (*Config, error) {
 	// First, open a file handle to the input filename.
 	input, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		err = &sentinelError{err, ErrConfigNotFound}
+	}
 	if err != nil {
-		return []Pet{}, fmt.Errorf(
-			"error in ReadConfig openin pet config file: %w", err,
+		return nil, fmt.Errorf(
+			"error in LoadConfig openin pet config file: %w", err,
 		)
 	}
 	defer input.Close()
@@ -98,26 +547,314 @@ func ReadConfig(filename string) ([]Pet, error) {
 	// io.Reader as an input, instead relying on byte slices.
 	src, err := ioutil.ReadAll(input)
 	if err != nil {
-		return []Pet{}, fmt.Errorf(
-			"error in ReadConfig reading input `%s`: %w", filename, err,
+		return nil, fmt.Errorf(
+			"error in LoadConfig reading input `%s`: %w", filename, err,
 		)
 	}
 
-	// Instantiate an HCL parser with the source byte slice.
-	parser := hclparse.NewParser()
-	srcHCL, diag := parser.ParseHCL(src, filename)
+	return DecodeConfig(src, filename, LoadOptions{Format: format})
+}
+
+// LoadOptions change how a configuration file is decoded.
+type LoadOptions struct {
+	// Format is one of FormatHCL, FormatJSON, FormatYAML or FormatTOML, or
+	// empty to choose the format by the extension of the file name.
+	Format string
+	// Env holds extra variables for the env namespace, such as those read
+	// from an env file. Variables in the process environment take
+	// precedence over them.
+	Env map[string]string
+	// Getenv, if set, reads the process environment in place of
+	// os.Getenv, such as to give each test an environment of its own.
+	Getenv func(key string) string
+	// Vault, if set, reads secrets for the vault function. Without it, the
+	// vault function is disabled.
+	Vault *VaultClient
+	// ParseCache, if set, keeps the files that are parsed for reloading the
+	// configuration later.
+	ParseCache *ParseCache
+	// Hooks, if set, has its Decoded hook called with each decoded pet.
+	Hooks *Hooks
+	// Rand is the source of randomness of the random function and of pets
+	// that choose their sounds and actions at random, so that a seeded
+	// source makes runs reproducible. Without it, a source seeded with the
+	// time the program started is used.
+	Rand rand.Source
+	// Clock is the time that pets' moods change by. Without it, the system
+	// clock is used.
+	Clock Clock
+	// Functions are extra functions configurations can call, by name. They
+	// replace the built in functions of the same name.
+	Functions map[string]function.Function
+	// Trace, if set, is written each characteristic as it is evaluated,
+	// with the variables it read, and each value the random function draws,
+	// for debugging surprising values.
+	Trace io.Writer
+	// Timings, if set, adds the time spent parsing the file and decoding it.
+	Timings *Timings
+	// Spans, if set, records spans of parsing the file, creating its
+	// evaluation context and decoding each pet, as children of the span of
+	// the context the configuration is decoded with.
+	Spans SpanTracer
+	// LintRules set the severity of lint rules by id, or turn them off,
+	// over the lint block of the file.
+	LintRules map[string]string
+	// Profile selects the profile block whose pets and variable values
+	// apply. Without it, the pets of profile blocks are left out.
+	Profile string
+	// Packs enables pet packs, such as farm, along with those the file's
+	// packs attribute enables.
+	Packs []string
+	// NoAssetCheck skips checking that the files of asset characteristics,
+	// such as photo, exist.
+	NoAssetCheck bool
+	// KeepGoing leaves the pets that fail to decode out of the
+	// configuration, along with the interactions they are part of, instead
+	// of failing to decode it at all.
+	KeepGoing bool
+	// MergeStrategy is what to do when the files of the configuration
+	// declare the same attribute or block, error or override. Empty is
+	// error.
+	MergeStrategy string
+	// DecodeCache, if set, keeps the pets that are decoded, so that
+	// decoding the configuration again only decodes the pets whose blocks
+	// have changed. It needs a ParseCache, which is created if there is
+	// none.
+	DecodeCache *DecodeCache
+
+	// random reads from Rand for every pet of a configuration, so that
+	// they can share a Rand that is not safe for concurrent use.
+	random *lockedRand
+	// tracer writes to Trace for every pet of a configuration.
+	tracer *evalTracer
+}
+
+// withDefaults returns opts with the random source, clock, tracer and
+// parse cache that decoding one configuration shares.
+func (opts LoadOptions) withDefaults() LoadOptions {
+	if opts.random == nil {
+		opts.random = newLockedRand(opts.Rand)
+	}
+	if opts.tracer == nil {
+		opts.tracer = newEvalTracer(opts.Trace)
+	}
+	// The parse cache keeps the source of every file, which pets are
+	// fingerprinted with.
+	if opts.ParseCache == nil {
+		opts.ParseCache = NewParseCache()
+	}
+	opts.Clock = clockOrSystem(opts.Clock)
+	return opts
+}
+
+// DecodeConfig decodes src, the contents of a configuration file named
+// filename, into a Config and returns it.
+func DecodeConfig(src []byte, filename string, opts LoadOptions) (*Config, error) {
+	return DecodeConfigContext(context.Background(), src, filename, opts)
+}
+
+// DecodeConfigContext is DecodeConfig that gives up when ctx is done.
+func DecodeConfigContext(ctx context.Context, src []byte, filename string, opts LoadOptions) (*Config, error) {
+	opts = opts.withDefaults()
+	// Decoding is timed apart from parsing the file, which is timed as
+	// it is parsed.
+	start, parsed := time.Now(), opts.Timings.get(phaseParse)
+	defer func() {
+		opts.Timings.add(phaseDecode, time.Since(start)-(opts.Timings.get(phaseParse)-parsed))
+	}()
+	it, err := DecodePetIterator(ctx, src, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pets that refer to other pets are decoded after them, but are still
+	// returned in the order they were declared.
+	declared := make([]Pet, len(it.order))
+	for {
+		pet, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		declared[it.declared()] = pet
+	}
+	petsHCL := it.petsHCL
+
+	// Skipped pets leave gaps.
+	pets := []Pet{}
+	for _, pet := range declared {
+		if pet != nil {
+			pets = append(pets, pet)
+		}
+	}
+	skipped := it.Skipped()
+
+	// Interactions can only be between pets that have been declared, so they
+	// are decoded once all the pets are known.
+	interactions, err := newInteractions(petsHCL)
+	if err != nil {
+		return nil, fmt.Errorf("error in DecodeConfig decoding interactions: %w", err)
+	}
+	interactions = withoutSkipped(interactions, skipped)
+
+	return &Config{
+		Filename:           filename,
+		Pets:               pets,
+		Interactions:       interactions,
+		References:         configReferences(petsHCL.PetHCLBodies),
+		TTS:                petsHCL.TTSHCL,
+		State:              petsHCL.StateHCL,
+		Broker:             petsHCL.BrokerHCL,
+		Notify:             petsHCL.NotifyHCL,
+		Owners:             petsHCL.OwnersHCL,
+		PetTypes:           filePetTypes(petsHCL.PetTypesHCL),
+		Packs:              petsHCL.Packs,
+		AllowUnknownBreeds: petsHCL.AllowUnknownBreeds,
+		Warnings:           it.warnings,
+		Skipped:            skipped,
+	}, nil
+}
+
+// decodeGenericPets parses src, the contents of a configuration file named
+// filename, along with the files it includes, and does the first pass of
+// decoding it. It returns the generic pets and the context to decode them
+// in, along with any warnings. A seed attribute in the file replaces the
+// random source of opts.
+func decodeGenericPets(ctx context.Context, src []byte, filename string, opts *LoadOptions) (*PetsHCL, *hcl.EvalContext, hcl.Diagnostics, error) {
+	format := opts.Format
+	if format == "" {
+		format = formatOf(filename)
+	}
+
+	if err := checkMergeStrategy(opts.MergeStrategy); err != nil {
+		return nil, nil, nil, fmt.Errorf("error in DecodeConfig: %w", err)
+	}
+
+	start := time.Now()
+	_, span := startSpan(ctx, opts.Spans, spanParse, map[string]string{"file": filename, "format": format})
+	dir := isConfigDir(src, filename)
+	var body *hclsyntax.Body
+	var parsed hcl.Diagnostics
+	if dir {
+		// Each file of a directory is translated, migrated and has its
+		// includes resolved as it is parsed, concurrently with the others.
+		body, parsed = loadConfigDir(ctx, opts.ParseCache, filename, opts.MergeStrategy)
+	} else {
+		src, err := translateConfig(ctx, src, filename, format)
+		if err != nil {
+			span.End(err)
+			return nil, nil, nil, fmt.Errorf("error in DecodeConfig: %w", err)
+		}
+
+		// Parse the source byte slice, unless the same source has been
+		// parsed into opts.ParseCache before.
+		body, parsed = opts.ParseCache.parse(src, filename)
+	}
+	opts.Timings.add(phaseParse, time.Since(start))
+	if parsed.HasErrors() {
+		span.End(parsed)
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig parsing HCL: %w", &sentinelError{parsed, ErrParse},
+		)
+	}
+	span.End(nil)
+
+	// Missing environment variables are reported all at once, before
+	// anything is decoded with them.
+	required, diag := checkRequiredEnv(body, *opts)
 	if diag.HasErrors() {
-		return []Pet{}, fmt.Errorf(
-			"error in ReadConfig parsing HCL: %w", diag,
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig checking environment: %w", diag,
+		)
+	}
+	*opts = opts.withRequiredEnv(required)
+
+	// Files written for an older schema version are upgraded in memory
+	// before decoding, so the rest of decoding only knows the latest one.
+	// The files of a directory were upgraded as they were parsed, so only
+	// their warnings are kept.
+	warnings := parsed
+	if !dir {
+		warnings = migrateSchema(body)
+	}
+	if warnings.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig migrating schema: %w", warnings,
+		)
+	}
+
+	// The contents of included files are added to the file's own, so that
+	// they are decoded as if they had been written in it.
+	if !dir {
+		warnings = append(warnings, resolveIncludes(ctx, opts.ParseCache, body, []string{filename}, opts.MergeStrategy)...)
+	}
+	if warnings.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig including files: %w", warnings,
+		)
+	}
+
+	// Override files next to the file are merged in last, so that local
+	// changes don't need the shared configuration to be edited.
+	warnings = append(warnings, applyOverrides(ctx, opts.ParseCache, body, filename, opts.MergeStrategy)...)
+	if warnings.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig applying override files: %w", warnings,
+		)
+	}
+
+	// The pets of the selected profile are added to the file's own, and
+	// those of the other profiles are left out.
+	profileInputs, err := applyProfile(body, opts.Profile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig applying profile: %w", err,
+		)
+	}
+
+	// Pets that extend templates are given the template's body, with their
+	// own merged over it.
+	warnings = append(warnings, resolveTemplates(body)...)
+	if warnings.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig extending pet templates: %w", warnings,
+		)
+	}
+
+	// A seed in the file seeds the random function and the pets' random
+	// choices, unless opts already has a source of its own.
+	if diag := seedRandom(body, opts); diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig seeding random: %w", diag,
 		)
 	}
 
 	// Call a helper function which creates an HCL context for use in
 	// decoding the parsed HCL.
-	evalContext, err := createContext()
+	_, span = startSpan(ctx, opts.Spans, spanContext, nil)
+	baseContext, err := createContext(ctx, *opts)
+	span.End(err)
 	if err != nil {
-		return []Pet{}, fmt.Errorf(
-			"error in ReadConfig creating HCL evaluation context: %w", err,
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig creating HCL evaluation context: %w", err,
+		)
+	}
+
+	// Variables declared in the file are set to their defaults.
+	evalContext, diag := variablesContext(body, baseContext, profileInputs)
+	if diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig decoding variables: %w", diag,
+		)
+	}
+
+	// The sounds of the file's sound pack are available to every pet.
+	evalContext, diag = packContext(opts.ParseCache, body, filename, evalContext)
+	if diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig loading sound pack: %w", diag,
 		)
 	}
 
@@ -127,89 +864,283 @@ func ReadConfig(filename string) ([]Pet, error) {
 	// undecoded in an hcl.Body. This Body will be decoded into different pet
 	// types later, once the context of the Type is known.
 	petsHCL := &PetsHCL{}
-	if diag := gohcl.DecodeBody(srcHCL.Body, evalContext, petsHCL); diag.HasErrors() {
-		return []Pet{}, fmt.Errorf(
-			"error in ReadConfig decoding HCL configuration: %w", diag,
+	if diag := gohcl.DecodeBody(body, evalContext, petsHCL); diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig decoding HCL configuration: %w", diag,
 		)
 	}
+	setDeclRanges(petsHCL, body)
+	addHouseholds(petsHCL, body)
 
-	// Iterate through the generic pets, switch on type, then decode the
-	// hcl.Body into the correct pet type. This allows "polymorphism" in the
-	// pet blocks.
-	pets := []Pet{}
-	for _, p := range petsHCL.PetHCLBodies {
-		switch petType := p.Type; petType {
-		case "cat":
-			cat := &Cat{Name: p.Name, Sound: defaultCatSound}
-			if p.CharacteristicsHCL != nil {
-				if diag := gohcl.DecodeBody(p.CharacteristicsHCL.HCL, evalContext, cat); diag.HasErrors() {
-					return []Pet{}, fmt.Errorf(
-						"error in ReadConfig decoding cat HCL configuration: %w", diag,
-					)
-				}
-			}
-			pets = append(pets, cat)
-		case "dog":
-			dog := &Dog{Name: p.Name, Breed: defaultDogBreed}
-			if p.CharacteristicsHCL != nil {
-				if diag := gohcl.DecodeBody(p.CharacteristicsHCL.HCL, evalContext, dog); diag.HasErrors() {
-					return []Pet{}, fmt.Errorf(
-						"error in ReadConfig decoding dog HCL configuration: %w", diag,
-					)
-				}
+	// Pets from modules are added to the file's own, each decoded in the
+	// context of its module.
+	if diag := decodeModules(ctx, opts.ParseCache, petsHCL, evalContext, baseContext, []string{filename}, opts.MergeStrategy); diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig decoding modules: %w", diag,
+		)
+	}
+
+	// Pets can be declared with the aliases of the file for their types.
+	if diag := resolveAliases(petsHCL, evalContext); diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig resolving type aliases: %w", diag,
+		)
+	}
+	if err := addPetPacks(petsHCL, body, opts.Packs); err != nil {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig adding pet packs: %w", err,
+		)
+	}
+	if diag := declarePetTypes(petsHCL, evalContext); diag.HasErrors() {
+		return nil, nil, nil, fmt.Errorf(
+			"error in DecodeConfig declaring pet types: %w", diag,
+		)
+	}
+	return petsHCL, evalContext, warnings, nil
+}
+
+// setDeclRanges records where each pet of petsHCL was declared, from the
+// body it was decoded from.
+func setDeclRanges(petsHCL *PetsHCL, body *hclsyntax.Body) {
+	i := 0
+	for _, block := range body.Blocks {
+		if block.Type == "pet" && i < len(petsHCL.PetHCLBodies) {
+			petsHCL.PetHCLBodies[i].declRange = block.DefRange()
+			petsHCL.PetHCLBodies[i].typeRange = block.DefRange()
+			petsHCL.PetHCLBodies[i].blockRange = block.Range()
+			petsHCL.PetHCLBodies[i].block = block
+			if attr, ok := block.Body.Attributes["type"]; ok {
+				petsHCL.PetHCLBodies[i].typeRange = attr.Expr.Range()
 			}
-			pets = append(pets, dog)
-		default:
-			// Error in the case of an unknown type. In the future, more types
-			// could be added to the switch to support, for example, fish
-			// owners.
-			return []Pet{}, fmt.Errorf("error in ReadConfig: unknown pet type `%s`", petType)
+			i++
+		}
+	}
+	i = 0
+	for _, block := range body.Blocks {
+		if block.Type == "pet_type" && i < len(petsHCL.PetTypesHCL) {
+			petsHCL.PetTypesHCL[i].declRange = block.DefRange()
+			i++
+		}
+	}
+}
+
+// decodePet decodes the generic pet p into the correct pet type, with the
+// defaults of its configuration file and of its household. Pets are decoded
+// in evalContext, unless they have their own, as pets from modules do, and
+// read the time and random numbers from opts. The characteristics of p can
+// refer to others, the pets decoded before it, by name.
+func decodePet(p *PetHCL, defaults *DefaultsHCL, evalContext *hcl.EvalContext, others map[string]cty.Value, opts LoadOptions) (Pet, error) {
+	petContext := evalContext
+	if p.evalContext != nil {
+		petContext = p.evalContext
+	}
+
+	// Pets only have moods when they declare a moods block.
+	var moods *MoodMachine
+	if p.MoodsHCL != nil {
+		var err error
+		moods, err = newMoodMachine(p.MoodsHCL, opts.Clock)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"error in DecodeConfig decoding moods of pet `%s`: %w", p.Name, err,
+			)
+		}
+	}
+
+	conditions, err := NewConditions(p.PreconditionsHCL, p.PostconditionsHCL, petContext)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error in DecodeConfig decoding conditions of pet `%s`: %w", p.Name, err,
+		)
+	}
+
+	feedings, err := NewFeedings(p.FeedingsHCL)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error in DecodeConfig decoding feeding of pet `%s`: %w", p.Name, err,
+		)
+	}
+
+	visits, err := NewVetVisits(p.VetVisitsHCL)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error in DecodeConfig decoding vet visits of pet `%s`: %w", p.Name, err,
+		)
+	}
+
+	// The characteristics body is kept for pointing validation
+	// diagnostics at the offending attribute.
+	var characteristics hcl.Body
+	if p.CharacteristicsHCL != nil {
+		characteristics = p.CharacteristicsHCL.HCL
+	}
+
+	// Unknown types are an error. More types, for example for fish owners,
+	// can be supported by adding them to petKinds, or declared with a
+	// pet_type block.
+	kind, ok := petKinds[p.Type]
+	if !ok && p.kind != nil {
+		kind, ok = p.kind, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("error in DecodeConfig: %w", &ErrUnknownPetType{Type: p.Type, Range: p.typeRange})
+	}
+
+	// Where each characteristic came from is recorded as it is decoded, so
+	// later blocks take the place of earlier ones, as their values do.
+	origins := map[string]*Origin{}
+	pet := kind.new(p, moods, conditions, feedings, visits)
+	Walk([]Pet{pet}, VisitorFuncs{
+		Cat: func(c *Cat) { c.sounds.rand, c.actions.rand, c.origins = opts.random, opts.random, origins },
+		Dog: func(d *Dog) { d.sounds.rand, d.actions.rand, d.origins = opts.random, opts.random, origins },
+	})
+	// The defaults of the pet's household are decoded over the file's.
+	for i, d := range []*DefaultsHCL{defaults, p.householdDefaults} {
+		body := kind.defaults(d)
+		if body == nil {
+			continue
+		}
+		defaultsContext := headerContext(p.Name, p.Type, evalContext)
+		if diag := kind.decode(body, defaultsContext, pet); diag.HasErrors() {
+			diag = redactDiagnostics(diag, body, pet)
+			return nil, fmt.Errorf(
+				"error in DecodeConfig decoding %s defaults: %w", p.Type, diag,
+			)
+		}
+		opts.tracer.characteristics(p.Name, kind, body, defaultsContext, pet)
+		block := originDefaults
+		if i > 0 {
+			block = originHouseholdDefaults
+		}
+		for name, o := range kind.origins(body, block) {
+			origins[name] = o
+		}
+	}
+	if characteristics != nil {
+		characteristicsContext := headerContext(p.Name, p.Type, referenceContext(others, petContext))
+		if diag := kind.decode(characteristics, characteristicsContext, pet); diag.HasErrors() {
+			diag = redactDiagnostics(diag, characteristics, pet)
+			return nil, fmt.Errorf(
+				"error in DecodeConfig decoding %s HCL configuration: %w", p.Type, diag,
+			)
+		}
+		opts.tracer.characteristics(p.Name, kind, characteristics, characteristicsContext, pet)
+		for name, o := range kind.origins(characteristics, originCharacteristics) {
+			origins[name] = o
 		}
 	}
-	return pets, nil
+	if diag := resolveAssets(kind, pet, characteristics, p.declRange.Filename, opts.NoAssetCheck); diag.HasErrors() {
+		diag = redactDiagnostics(diag, characteristics, pet)
+		return nil, fmt.Errorf("error in DecodeConfig validating %s `%s`: %w", p.Type, p.Name, diag)
+	}
+	if diag := setBirthdateAge(pet, characteristics, clockOrSystem(opts.Clock).Now()); diag.HasErrors() {
+		diag = redactDiagnostics(diag, characteristics, pet)
+		return nil, fmt.Errorf("error in DecodeConfig validating %s `%s`: %w", p.Type, p.Name, diag)
+	}
+	if diag := kind.validate(pet, characteristics); diag.HasErrors() {
+		diag = redactDiagnostics(diag, characteristics, pet)
+		return nil, fmt.Errorf("error in DecodeConfig validating %s `%s`: %w", p.Type, p.Name, diag)
+	}
+
+	// User-defined validations run last, against the fully decoded pet.
+	if diag := checkValidations(p.ValidationsHCL, pet, petContext); diag.HasErrors() {
+		return nil, fmt.Errorf("error in DecodeConfig validating %s `%s`: %w", p.Type, p.Name, diag)
+	}
+	// The validations are kept, so that a configuration written from the
+	// pet checks it the same way.
+	fields := fieldsOf(pet)
+	if fields.validations != nil {
+		*fields.validations = p.ValidationsHCL
+	}
+	// So is a hash of the source of the pet's block, which fingerprints
+	// the pet by what was written, rather than by what it evaluated to.
+	if fields.sourceSum != nil {
+		*fields.sourceSum = petSourceSum(opts.ParseCache, p)
+	}
+	return pet, nil
+}
+
+// formatOf returns the format of a configuration file from its extension,
+// defaulting to HCL.
+func formatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return FormatHCL
+}
+
+// translateConfig returns src, a configuration file written in format, as
+// native syntax HCL. Files encrypted with SOPS are decrypted first, so that
+// they can be committed alongside plain configurations, and JSON, YAML and
+// TOML files are translated so that they are decoded exactly like HCL
+// files.
+func translateConfig(ctx context.Context, src []byte, filename, format string) ([]byte, error) {
+	var err error
+	if isSOPS(src) {
+		if src, err = decryptSOPS(ctx, src, format); err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case FormatHCL:
+		return src, nil
+	case FormatJSON:
+		src, err = jsonToHCL(src, filename)
+	case FormatYAML:
+		src, err = yamlToHCL(src, filename)
+	case FormatTOML:
+		src, err = tomlToHCL(src, filename)
+	default:
+		return nil, fmt.Errorf("unknown format `%s`", format)
+	}
+	if err != nil {
+		return nil, &sentinelError{err, ErrParse}
+	}
+	return src, nil
 }
 
 // createContext is a helper function that creates an *hcl.EvalContext to be
-// used in decoding HCL. It creates a set of variables at env.KEY
-// (namely, CAT_SOUND). It also creates a function "random(...string)" that can
-// be used to assign a random value in an HCL config.
-func createContext() (*hcl.EvalContext, error) {
-	// Extract the sound cats make from the environment, with a default.
-	catSound := defaultCatSound
-	if os.Getenv(catSoundKey) != "" {
-		catSound = os.Getenv(catSoundKey)
+// used in decoding HCL. It creates a set of variables at env.KEY, namely
+// CAT_SOUND along with every variable in opts.Env, and the functions of the
+// contextFunctions registry in functions.go, built for opts.
+func createContext(ctx context.Context, opts LoadOptions) (*hcl.EvalContext, error) {
+	// Variables from opts.Env are overridden by the process environment, like
+	// the sound cats make, which also has a default.
+	envVals := map[string]cty.Value{}
+	for k, v := range opts.Env {
+		if opts.getenv(k) != "" {
+			v = opts.getenv(k)
+		}
+		envVals[k] = cty.StringVal(v)
+	}
+	if _, ok := envVals[catSoundKey]; !ok {
+		envVals[catSoundKey] = cty.StringVal(defaultCatSound)
+		if opts.getenv(catSoundKey) != "" {
+			envVals[catSoundKey] = cty.StringVal(opts.getenv(catSoundKey))
+		}
 	}
 
 	// variables is a list of cty.Value for use in Decoding HCL. These will
 	// be nested by using ObjectVal as a value. For istance:
 	//   env.CAT_SOUND => "meow"
 	variables := map[string]cty.Value{
-		environmentKey: cty.ObjectVal(map[string]cty.Value{
-			catSoundKey: cty.StringVal(catSound),
-		}),
-	}
-
-	// functions is a list of cty.Functions for use in Decoding HCL.
-	functions := map[string]function.Function{
-		"random": function.New(&function.Spec{
-			// Params represents required positional arguments, of which random
-			// has none.
-			Params: []function.Parameter{},
-			// VarParam allows a "VarArgs" type input, in this case, of
-			// strings.
-			VarParam: &function.Parameter{Type: cty.String},
-			// Type is used to determine the output type from the inputs. In
-			// the case of Random it only accepts strings and only returns
-			// strings.
-			Type: function.StaticReturnType(cty.String),
-			// Impl is the actual function. A "VarArgs" number of cty.String
-			// will be passed in and a random one returned, also as a
-			// cty.String.
-			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
-				resp := args[rand.Intn(len(args))]
-				return cty.StringVal(resp.AsString()), nil
-			},
-		}),
+		environmentKey: cty.ObjectVal(envVals),
+	}
+
+	// functions is a list of cty.Functions for use in Decoding HCL, built
+	// from the registry of contextFunctions.
+	functions := map[string]function.Function{}
+	for _, f := range contextFunctions {
+		functions[f.name] = f.new(ctx, opts)
+	}
+	for name, f := range opts.Functions {
+		functions[name] = f
 	}
 
 	// Return the constructed hcl.EvalContext.
@@ -218,3 +1149,130 @@ func createContext() (*hcl.EvalContext, error) {
 		Functions: functions,
 	}, nil
 }
+
+// newRandomFunc returns a function that returns one of its arguments at
+// random, read from r, tracing each draw to tracer. Arguments that are
+// lists, sets or tuples contribute each of their elements instead, so that
+// values can be chosen from variables. When its first argument is a number
+// and its second a string, as in
+//   random(2, ", ", var.breeds)
+// it instead chooses that many different values, joined by the string. It
+// is a good example of a function spec.
+func newRandomFunc(r *lockedRand, tracer *evalTracer) function.Function {
+	return function.New(&function.Spec{
+		// Params represents required positional arguments, of which random
+		// has none.
+		Params: []function.Parameter{},
+		// VarParam allows a "VarArgs" type input, in this case, of strings or
+		// collections of strings, which are checked by Impl.
+		VarParam: &function.Parameter{Name: "values", Type: cty.DynamicPseudoType},
+		// Type is used to determine the output type from the inputs. In the
+		// case of Random it only returns strings.
+		Type: function.StaticReturnType(cty.String),
+		// Impl is the actual function. A "VarArgs" number of values will be
+		// passed in and a random one returned, as a cty.String.
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			count, sep, offset := 1, "", 0
+			if len(args) >= 2 && args[0].Type() == cty.Number && args[1].Type() == cty.String {
+				n, accuracy := args[0].AsBigFloat().Int64()
+				if accuracy != big.Exact || n < 1 {
+					return cty.NilVal, function.NewArgErrorf(0, "random needs a whole number of values to choose, of at least 1")
+				}
+				count, sep, offset = int(n), args[1].AsString(), 2
+			}
+
+			values := []cty.Value{}
+			for i, arg := range args[offset:] {
+				elems := []cty.Value{arg}
+				if ty := arg.Type(); ty.IsListType() || ty.IsSetType() || ty.IsTupleType() {
+					elems = arg.AsValueSlice()
+				}
+				for _, elem := range elems {
+					value, err := convert.Convert(elem, cty.String)
+					if err != nil || value.IsNull() {
+						return cty.NilVal, function.NewArgErrorf(offset+i, "random only chooses from strings and lists of strings")
+					}
+					values = append(values, value)
+				}
+			}
+			if len(values) == 0 {
+				return cty.NilVal, fmt.Errorf("random needs at least one value to choose from")
+			}
+			if count > len(values) {
+				return cty.NilVal, fmt.Errorf("random can't choose %d different values from %d", count, len(values))
+			}
+
+			// The values are chosen without replacement, by shuffling the
+			// first count of them into place.
+			chosen := []string{}
+			for i := 0; i < count; i++ {
+				j := i + r.Intn(len(values)-i)
+				values[i], values[j] = values[j], values[i]
+				chosen = append(chosen, values[i].AsString())
+			}
+			resp := cty.StringVal(strings.Join(chosen, sep))
+			tracer.random(args, resp)
+			return resp, nil
+		},
+	})
+}
+
+// newShuffleFunc returns a function that returns its list argument in an
+// order chosen at random, read from r, so that configurations can shuffle
+// lists like actions without calling random for each element.
+func newShuffleFunc(r *lockedRand) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "list", Type: cty.List(cty.DynamicPseudoType)},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[0].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if args[0].LengthInt() < 2 {
+				return args[0], nil
+			}
+			values := args[0].AsValueSlice()
+			for i := len(values) - 1; i > 0; i-- {
+				j := r.Intn(i + 1)
+				values[i], values[j] = values[j], values[i]
+			}
+			return cty.ListVal(values), nil
+		},
+	})
+}
+
+// newEnvFunc returns a function that returns the environment variable
+// name, or its default when the variable is not set, looking name up like
+// the env namespace does: in the process environment, and then in opts.Env.
+func newEnvFunc(opts LoadOptions) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "name", Type: cty.String},
+			{Name: "default", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if v, ok := opts.lookupEnv(args[0].AsString()); ok {
+				return cty.StringVal(v), nil
+			}
+			return args[1], nil
+		},
+	})
+}
+
+// lengthFunc returns the number of characters in a string, or the number of
+// elements in a collection. It builds on the cty standard library, which has
+// separate functions for the two.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "value", Type: cty.DynamicPseudoType},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
@@ -1,14 +1,21 @@
-package main
+package petsounds
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestReadConfig(t *testing.T) {
-
 	tcs := []struct {
 		name        string
 		input       string
@@ -34,6 +41,33 @@ func TestReadConfig(t *testing.T) {
 				&Cat{Name: "Whiskers", Sound: "meow"},
 			},
 		},
+		{
+			name:  "yaml",
+			input: "testdata/basic.yaml",
+			want: []Pet{
+				&Cat{Name: "Ink", Sound: "meow"},
+				&Dog{Name: "Swinney", Breed: "Dachshund"},
+			},
+		},
+		{
+			name:  "yaml variables",
+			input: "testdata/variables.yaml",
+			environment: map[string]string{
+				"CAT_SOUND": "nyan",
+			},
+			want: []Pet{
+				&Cat{Name: "Neko", Sound: "nyan"},
+				&Cat{Name: "Whiskers", Sound: "meow"},
+			},
+		},
+		{
+			name:  "toml",
+			input: "testdata/basic.toml",
+			want: []Pet{
+				&Cat{Name: "Ink", Sound: "meow"},
+				&Dog{Name: "Swinney", Breed: "Dachshund"},
+			},
+		},
 		{
 			name:  "functions",
 			input: "testdata/function.hcl",
@@ -41,6 +75,52 @@ func TestReadConfig(t *testing.T) {
 				&Dog{Name: "Spot", Breed: "Pug"},
 			},
 		},
+		{
+			name:  "actions",
+			input: "testdata/actions.hcl",
+			want: []Pet{
+				&Dog{
+					Name:           "Swinney",
+					Breed:          "Dachshund",
+					Actions:        []string{"fetches the ball", "digs a hole"},
+					ActionStrategy: "cycle",
+				},
+				&Cat{Name: "Ink", Sound: "meow", Actions: []string{"knocks a glass off the table"}},
+			},
+		},
+		{
+			name:  "sounds",
+			input: "testdata/sounds.hcl",
+			want: []Pet{
+				&Cat{
+					Name:          "Ink",
+					Sound:         "meow",
+					Sounds:        []string{"meow", "mrrp", "hiss"},
+					SoundStrategy: "cycle",
+				},
+				&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "woofs"},
+			},
+		},
+		{
+			name:  "vitals",
+			input: "testdata/vitals.hcl",
+			want: []Pet{
+				&Cat{
+					Name: "Ink", Sound: "meow", Age: intPtr(4), Weight: float64Ptr(5.5), Vaccinated: boolPtr(true),
+					NapDuration: 45 * time.Minute,
+				},
+			},
+		},
+		{
+			name:  "defaults",
+			input: "testdata/defaults.hcl",
+			want: []Pet{
+				&Cat{Name: "Ink", Sound: "purr", Age: intPtr(2)},
+				&Cat{Name: "Neko", Sound: "mew", Age: intPtr(2)},
+				&Dog{Name: "Swinney", Breed: "Dachshund"},
+				&Dog{Name: "Biscuit", Breed: "Corgi"},
+			},
+		},
 	}
 
 	for _, tc := range tcs {
@@ -52,12 +132,150 @@ func TestReadConfig(t *testing.T) {
 				os.Setenv(k, v)
 			}
 
-			got, err := ReadConfig(tc.input)
+			// The functions case expects the sequence of a random source
+			// seeded with 1.
+			src, err := ioutil.ReadFile(tc.input)
+			if !assert.NoError(t, err) {
+				return
+			}
+			config, err := DecodeConfig(src, tc.input, LoadOptions{Rand: rand.NewSource(1)})
 			if assert.Nil(t, err, "error while parsing input") {
-				assert.Equal(t, tc.want, got)
+				assert.Equal(t, tc.want, clearDeclRanges(config.Pets))
 			} else {
 				assert.Fail(t, err.Error())
 			}
 		})
 	}
 }
+
+func TestReadConfigContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.hcl" {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(sourceConfig))
+	}))
+	defer server.Close()
+
+	got, err := ReadConfigContext(context.Background(), server.URL+"/pets.hcl")
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{&Cat{Name: "Ink", Sound: "meow"}}, clearDeclRanges(got))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = ReadConfigContext(ctx, server.URL+"/slow.hcl")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "want deadline exceeded, got %v", err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = ReadConfigContext(ctx, "testdata/basic.hcl")
+	assert.True(t, errors.Is(err, context.Canceled), "want canceled, got %v", err)
+}
+
+func TestDeclRange(t *testing.T) {
+	tcs := []struct {
+		name      string
+		input     string
+		want      []string
+		wantLines []int
+	}{
+		{
+			name:      "basic",
+			input:     "testdata/basic.hcl",
+			want:      []string{"testdata/basic.hcl", "testdata/basic.hcl"},
+			wantLines: []int{1, 5},
+		},
+		{
+			name:      "module",
+			input:     "testdata/module.hcl",
+			want:      []string{"testdata/module.hcl", "testdata/modules/barn/pets.hcl", "testdata/modules/barn/pets.hcl"},
+			wantLines: []int{5, 1, 9},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := LoadConfig(tc.input)
+			if !assert.Nil(t, err) {
+				return
+			}
+			files, lines := []string{}, []int{}
+			for _, p := range config.Pets[:len(tc.want)] {
+				rng := declRange(p)
+				files = append(files, rng.Filename)
+				lines = append(lines, rng.Start.Line)
+			}
+			assert.Equal(t, tc.want, files)
+			assert.Equal(t, tc.wantLines, lines)
+		})
+	}
+
+	assert.Equal(t, hcl.Range{}, (&Cat{Name: "Ink"}).DeclRange())
+}
+
+// clearDeclRanges clears where each of pets and its characteristics were
+// declared, and the random source it was decoded with, for comparing
+// decoded pets with pets built in tests.
+func clearDeclRanges(pets []Pet) []Pet {
+	for _, p := range pets {
+		switch pet := p.(type) {
+		case *Cat:
+			pet.declRange, pet.origins, pet.validations, pet.sourceSum = hcl.Range{}, nil, nil, ""
+			pet.sounds.rand, pet.actions.rand = nil, nil
+		case *Dog:
+			pet.declRange, pet.origins, pet.validations, pet.sourceSum = hcl.Range{}, nil, nil, ""
+			pet.sounds.rand, pet.actions.rand = nil, nil
+		}
+	}
+	return pets
+}
+
+// mustParse decodes the configuration src and returns its pets, failing t
+// if it does not decode.
+func mustParse(t *testing.T, src string) []Pet {
+	t.Helper()
+	return mustParseEnv(t, src, nil)
+}
+
+// mustParseEnv is mustParse with env in the env namespace, standing in for
+// the process environment, which tests running in parallel can't change.
+func mustParseEnv(t *testing.T, src string, env map[string]string) []Pet {
+	t.Helper()
+	config, err := DecodeConfig([]byte(src), "test.hcl", LoadOptions{Env: env})
+	if err != nil {
+		t.Fatalf("error decoding configuration: %s", err)
+	}
+	return config.Pets
+}
+
+func intPtr(i int) *int             { return &i }
+func float64Ptr(f float64) *float64 { return &f }
+func boolPtr(b bool) *bool          { return &b }
+
+func TestPetInfo(t *testing.T) {
+	t.Parallel()
+
+	pets := []Pet{
+		&Cat{Name: "Ink"},
+		&Dog{Name: "home.Swinney"},
+		PetFuncs{Pet: &Cat{Name: "Neko"}},
+	}
+	names, types := []string{}, []string{}
+	for _, p := range pets {
+		name, petType := petIdentity(p)
+		names, types = append(names, name), append(types, petType)
+	}
+	assert.Equal(t, []string{"Ink", "home.Swinney", "Neko"}, names)
+	assert.Equal(t, []string{"cat", "dog", "cat"}, types)
+
+	var cat *Cat
+	assert.Equal(t, "cat", cat.Type())
+	name, petType := petIdentity(PetFuncs{})
+	assert.Equal(t, "", name)
+	assert.Equal(t, "unknown", petType)
+}
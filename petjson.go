@@ -0,0 +1,155 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// petJSON is how pets are encoded as JSON: the pet's type, which tells
+// which type to decode the rest into, its name and the attributes of its
+// pet block, and its characteristics keyed as they are in configurations.
+// Moods, conditions, feedings and vet visits are left out, as they are
+// decoded from blocks of their own.
+type petJSON struct {
+	Type            string                     `json:"type"`
+	Name            string                     `json:"name"`
+	Enabled         *bool                      `json:"enabled,omitempty"`
+	Tags            []string                   `json:"tags,omitempty"`
+	Household       string                     `json:"household,omitempty"`
+	Owner           string                     `json:"owner,omitempty"`
+	Language        string                     `json:"language,omitempty"`
+	Characteristics map[string]json.RawMessage `json:"characteristics,omitempty"`
+}
+
+// MarshalJSON encodes the cat as JSON, with "cat" as its type.
+func (c *Cat) MarshalJSON() ([]byte, error) {
+	return marshalPetJSON(c)
+}
+
+// UnmarshalJSON decodes JSON written by MarshalJSON into the cat.
+func (c *Cat) UnmarshalJSON(data []byte) error {
+	return unmarshalPetJSON(data, c)
+}
+
+// MarshalJSON encodes the dog as JSON, with "dog" as its type.
+func (d *Dog) MarshalJSON() ([]byte, error) {
+	return marshalPetJSON(d)
+}
+
+// UnmarshalJSON decodes JSON written by MarshalJSON into the dog.
+func (d *Dog) UnmarshalJSON(data []byte) error {
+	return unmarshalPetJSON(data, d)
+}
+
+// UnmarshalPetJSON decodes a pet encoded as JSON by its MarshalJSON method
+// into a pet of the type it says it is, with that type's defaults for the
+// characteristics it leaves out.
+func UnmarshalPetJSON(data []byte) (Pet, error) {
+	header := &petJSON{}
+	if err := json.Unmarshal(data, header); err != nil {
+		return nil, fmt.Errorf("error in UnmarshalPetJSON: %w", err)
+	}
+	kind, ok := petKinds[header.Type]
+	if !ok {
+		return nil, fmt.Errorf("error in UnmarshalPetJSON: %w", &ErrUnknownPetType{Type: header.Type})
+	}
+	pet := kind.new(&PetHCL{Name: header.Name, Type: header.Type}, nil, nil, nil, nil)
+	if err := unmarshalPetJSON(data, pet); err != nil {
+		return nil, fmt.Errorf("error in UnmarshalPetJSON: %w", err)
+	}
+	return pet, nil
+}
+
+// marshalPetJSON encodes p, one of the types of petKinds, as JSON.
+func marshalPetJSON(p Pet) ([]byte, error) {
+	name, petType := petIdentity(p)
+	kind := petKinds[petType]
+	v := reflect.ValueOf(p).Elem()
+	out := &petJSON{
+		Type: petType, Name: name, Tags: v.FieldByName("Tags").Interface().([]string),
+		Household: v.FieldByName("Household").String(), Owner: v.FieldByName("Owner").String(),
+		Language: v.FieldByName("Language").String(), Characteristics: map[string]json.RawMessage{},
+	}
+	if v.FieldByName("Disabled").Bool() {
+		enabled := false
+		out.Enabled = &enabled
+	}
+
+	for _, f := range kind.fields {
+		field := v.Field(f.index)
+		if field.IsZero() {
+			continue
+		}
+		value := field.Interface()
+		if field.Type() == durationType {
+			value = time.Duration(field.Int()).String()
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding characteristic `%s` of `%s`: %w", f.name, name, err)
+		}
+		out.Characteristics[f.name] = raw
+	}
+	return json.Marshal(out)
+}
+
+// unmarshalPetJSON decodes data into p, one of the types of petKinds. It is
+// an error for data to be a pet of another type, or to have
+// characteristics p's type doesn't.
+func unmarshalPetJSON(data []byte, p Pet) error {
+	in := &petJSON{}
+	if err := json.Unmarshal(data, in); err != nil {
+		return err
+	}
+	_, petType := petIdentity(p)
+	if in.Type != petType {
+		return fmt.Errorf("cannot decode a pet of type `%s` into a %s", in.Type, petType)
+	}
+	kind := petKinds[petType]
+
+	v := reflect.ValueOf(p).Elem()
+	v.FieldByName("Name").SetString(in.Name)
+	v.FieldByName("Disabled").SetBool(in.Enabled != nil && !*in.Enabled)
+	v.FieldByName("Tags").Set(reflect.ValueOf(in.Tags))
+	v.FieldByName("Household").SetString(in.Household)
+	v.FieldByName("Owner").SetString(in.Owner)
+	v.FieldByName("Language").SetString(in.Language)
+
+	names := []string{}
+	for name := range in.Characteristics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		index := -1
+		for _, f := range kind.fields {
+			if f.name == name {
+				index = f.index
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("%s `%s` has no characteristic `%s`", petType, in.Name, name)
+		}
+
+		field := v.Field(index)
+		if field.Type() == durationType {
+			var s string
+			if err := json.Unmarshal(in.Characteristics[name], &s); err != nil {
+				return fmt.Errorf("characteristic `%s`: %w", name, err)
+			}
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("characteristic `%s`: %w", name, err)
+			}
+			field.SetInt(int64(d))
+			continue
+		}
+		if err := json.Unmarshal(in.Characteristics[name], field.Addr().Interface()); err != nil {
+			return fmt.Errorf("characteristic `%s`: %w", name, err)
+		}
+	}
+	return nil
+}
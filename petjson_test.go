@@ -0,0 +1,67 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetJSON(t *testing.T) {
+	t.Parallel()
+
+	age, weight := 3, 4.5
+	tcs := []struct {
+		name string
+		pet  Pet
+		json string
+	}{
+		{
+			name: "cat",
+			pet: &Cat{
+				Name: "Ink", Sound: "mew", Sounds: []string{"mew", "purr"}, Age: &age, Weight: &weight,
+				NapDuration: 90 * time.Minute, Tags: []string{"indoor"}, Owner: "Russell",
+			},
+			json: `{"type":"cat","name":"Ink","tags":["indoor"],"owner":"Russell","characteristics":` +
+				`{"age":3,"nap_duration":"1h30m0s","sound":"mew","sounds":["mew","purr"],"weight":4.5}}`,
+		},
+		{
+			name: "dog",
+			pet:  &Dog{Name: "home.Swinney", Breed: "Corgi", Disabled: true, Household: "home", Language: "fr"},
+			json: `{"type":"dog","name":"home.Swinney","enabled":false,"household":"home","language":"fr",` +
+				`"characteristics":{"breed":"Corgi"}}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := json.Marshal(tc.pet)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.JSONEq(t, tc.json, string(out))
+
+			pet, err := UnmarshalPetJSON(out)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.pet, pet)
+			}
+		})
+	}
+}
+
+func TestPetJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := UnmarshalPetJSON([]byte(`{"type":"cta","name":"Ink"}`))
+	assert.EqualError(t, err, "error in UnmarshalPetJSON: unknown pet type `cta`, did you mean `cat`?")
+
+	_, err = UnmarshalPetJSON([]byte(`{"type":"cat","name":"Ink","characteristics":{"breed":"Corgi"}}`))
+	assert.EqualError(t, err, "error in UnmarshalPetJSON: cat `Ink` has no characteristic `breed`")
+
+	err = json.Unmarshal([]byte(`{"type":"dog","name":"Swinney"}`), &Cat{})
+	assert.EqualError(t, err, "cannot decode a pet of type `dog` into a cat")
+}
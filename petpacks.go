@@ -0,0 +1,156 @@
+package petsounds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// packsKey is the attribute that enables pet packs for a configuration:
+//   packs = ["farm"]
+const packsKey = "packs"
+
+// petPacks are the optional sets of pet types that ship with pet-sounds, by
+// name, as the pet_type blocks that declare them. Their types are only
+// known to configurations that enable them, with the packs attribute or
+// the -pack flag.
+var petPacks = map[string]string{
+	"farm": `
+pet_type "horse" {
+  say_template = "${name} neighs"
+  act_template = "${name} gallops around the paddock"
+}
+
+pet_type "chicken" {
+  say_template = "${name} clucks"
+  act_template = "${name} pecks at the ground"
+}
+
+pet_type "goat" {
+  say_template = "${name} bleats"
+  act_template = "${name} chews on the fence"
+}
+`,
+	"aquarium": `
+pet_type "goldfish" {
+  say_template = "${name} blows bubbles"
+  act_template = "${name} swims in circles"
+}
+
+pet_type "shrimp" {
+  say_template = "${name} clicks"
+  act_template = "${name} scuttles across the gravel"
+}
+`,
+}
+
+// petPackNames returns the names of petPacks, sorted.
+func petPackNames() []string {
+	names := []string{}
+	for name := range petPacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addPetPacks adds the pet types of the packs enabled by the packs
+// attribute of body, and of packs, to the pet_type blocks of petsHCL, and
+// sets its Packs to all of them. Unknown packs in the file are returned as
+// hcl.Diagnostics.
+func addPetPacks(petsHCL *PetsHCL, body *hclsyntax.Body, packs []string) error {
+	enabled, diags := filePetPacks(body)
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, pack := range packs {
+		if err := checkPetPack(pack); err != nil {
+			return err
+		}
+		if !contains(enabled, pack) {
+			enabled = append(enabled, pack)
+		}
+	}
+
+	for _, pack := range enabled {
+		types, diags := petPackTypes(pack)
+		if diags.HasErrors() {
+			return diags
+		}
+		petsHCL.PetTypesHCL = append(petsHCL.PetTypesHCL, types...)
+	}
+	petsHCL.Packs = enabled
+	return nil
+}
+
+// filePetPacks returns the packs the packs attribute of body enables.
+func filePetPacks(body *hclsyntax.Body) ([]string, hcl.Diagnostics) {
+	attr, ok := body.Attributes[packsKey]
+	if !ok {
+		return nil, nil
+	}
+	// Like the seed, the packs are constants, as they decide the types of
+	// pet that are decoded.
+	var packs []string
+	diags := gohcl.DecodeExpression(attr.Expr, nil, &packs)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	for _, pack := range packs {
+		if err := checkPetPack(pack); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown pet pack",
+				Detail:   err.Error(),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+	return packs, diags
+}
+
+// checkPetPack returns an error if pack is not one of petPacks.
+func checkPetPack(pack string) error {
+	if _, ok := petPacks[pack]; ok {
+		return nil
+	}
+	if suggestion := suggest(pack, petPackNames()); suggestion != "" {
+		return fmt.Errorf("unknown pet pack `%s`, did you mean `%s`?", pack, suggestion)
+	}
+	return fmt.Errorf("unknown pet pack `%s`, expected one of %s", pack, strings.Join(petPackNames(), ", "))
+}
+
+// filePetTypes returns the pet types of types that are declared by the
+// configuration, rather than by a pet pack.
+func filePetTypes(types []*PetTypeHCL) []*PetTypeHCL {
+	declared := []*PetTypeHCL{}
+	for _, t := range types {
+		if t.pack == "" {
+			declared = append(declared, t)
+		}
+	}
+	return declared
+}
+
+// petPackTypes returns the pet_type blocks of pack.
+func petPackTypes(pack string) ([]*PetTypeHCL, hcl.Diagnostics) {
+	file, diags := hclsyntax.ParseConfig([]byte(petPacks[pack]), pack+".pack.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	packHCL := &struct {
+		PetTypesHCL []*PetTypeHCL `hcl:"pet_type,block"`
+	}{}
+	if diags := gohcl.DecodeBody(file.Body, nil, packHCL); diags.HasErrors() {
+		return nil, diags
+	}
+	for i, block := range file.Body.(*hclsyntax.Body).Blocks {
+		packHCL.PetTypesHCL[i].declRange = block.DefRange()
+		packHCL.PetTypesHCL[i].pack = pack
+	}
+	return packHCL.PetTypesHCL, nil
+}
@@ -0,0 +1,73 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetPacks(t *testing.T) {
+	t.Parallel()
+
+	src := `packs = ["farm"]
+
+pet "Boxer" {
+  type = "horse"
+}
+
+pet "Goldie" {
+  type = "goldfish"
+}
+`
+	_, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	assert.EqualError(t, err, "error in DecodeConfig: pets.hcl:8,10-20: unknown pet type `goldfish`")
+
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Packs: []string{"aquarium", "farm"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&Runner{Out: out}).Run(config.Pets))
+	assert.Equal(t, "Boxer neighs\nBoxer gallops around the paddock\nGoldie blows bubbles\nGoldie swims in circles\n", out.String())
+
+	// Every pack declares its types.
+	for _, pack := range petPackNames() {
+		types, diags := petPackTypes(pack)
+		assert.False(t, diags.HasErrors(), pack)
+		assert.NotEmpty(t, types, pack)
+	}
+}
+
+func TestPetPacksInvalid(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		src   string
+		packs []string
+		want  string
+	}{
+		{
+			name: "unknown in file",
+			src:  `packs = ["frm"]`,
+			want: "error in DecodeConfig adding pet packs: pets.hcl:1,9-16: Unknown pet pack; " +
+				"unknown pet pack `frm`, did you mean `farm`?",
+		},
+		{
+			name:  "unknown flag",
+			packs: []string{"zoo"},
+			want:  "error in DecodeConfig adding pet packs: unknown pet pack `zoo`, expected one of aquarium, farm",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeConfig([]byte(tc.src), "pets.hcl", LoadOptions{Packs: tc.packs})
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package petsoundstest helps test code built on the petsounds package. Its
+// helpers decode configurations written inline in tests, in a fake
+// environment and with a random source that makes the same choices every
+// run, so that tests are concise and can run in parallel.
+package petsoundstest
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	petsounds "github.com/russellrollins/pet-sounds"
+)
+
+// DefaultSeed is the seed of the random source configurations are decoded
+// with, unless Seed gives another.
+const DefaultSeed = 1
+
+// Option changes the LoadOptions a configuration is decoded with.
+type Option func(opts *petsounds.LoadOptions)
+
+// Env gives the configuration env as its whole environment, for the env
+// namespace and the env and required_env checks. Without it, the
+// environment is empty. The process environment is never read, as tests
+// running in parallel can't each change it.
+func Env(env map[string]string) Option {
+	return func(opts *petsounds.LoadOptions) {
+		opts.Env = env
+	}
+}
+
+// Seed has the configuration's random choices, such as those of the random
+// function and of pets with random sound strategies, made by Rand(seed).
+func Seed(seed int64) Option {
+	return func(opts *petsounds.LoadOptions) {
+		opts.Rand = Rand(seed)
+	}
+}
+
+// Rand returns a random source seeded with seed, which makes the same
+// choices each time it is created with it.
+func Rand(seed int64) rand.Source {
+	return rand.NewSource(seed)
+}
+
+// MustDecode decodes the configuration src, as a file called pets.hcl in the
+// working directory, with opts applied in turn, and returns it. It fails t
+// if the configuration does not decode.
+func MustDecode(t testing.TB, src string, opts ...Option) *petsounds.Config {
+	t.Helper()
+	loadOpts := petsounds.LoadOptions{
+		Rand: Rand(DefaultSeed),
+		Getenv: func(string) string {
+			return ""
+		},
+	}
+	for _, opt := range opts {
+		opt(&loadOpts)
+	}
+	config, err := petsounds.DecodeConfig([]byte(src), "pets.hcl", loadOpts)
+	if err != nil {
+		t.Fatalf("error decoding configuration: %s", err)
+		return nil
+	}
+	return config
+}
+
+// MustParse is MustDecode that returns only the pets of the configuration.
+func MustParse(t testing.TB, src string, opts ...Option) []petsounds.Pet {
+	t.Helper()
+	config := MustDecode(t, src, opts...)
+	if config == nil {
+		return nil
+	}
+	return config.Pets
+}
+
+// Output has each of pets Say and Act once, in order, and returns what they
+// wrote. It fails t if any of them fails.
+func Output(t testing.TB, pets []petsounds.Pet) string {
+	t.Helper()
+	out := &bytes.Buffer{}
+	if err := (&petsounds.Runner{Out: out}).Run(pets); err != nil {
+		t.Fatalf("error running pets: %s", err)
+	}
+	return out.String()
+}
@@ -0,0 +1,80 @@
+package petsoundstest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	petsounds "github.com/russellrollins/pet-sounds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustParse(t *testing.T) {
+	t.Parallel()
+
+	pets := MustParse(t, `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = env.INK_SOUND
+  }
+}
+`, Env(map[string]string{"INK_SOUND": "mrrp"}))
+	assert.Equal(t, "Ink mrrp\nInk snoozes\n", Output(t, pets))
+}
+
+func TestMustParseEnv(t *testing.T) {
+	// The process environment is left out of the configuration's.
+	os.Setenv("PETSOUNDSTEST_SOUND", "hiss")
+	defer os.Unsetenv("PETSOUNDSTEST_SOUND")
+
+	pets := MustParse(t, `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = env("PETSOUNDSTEST_SOUND", "purr")
+  }
+}
+`)
+	assert.Equal(t, "Ink purr\nInk snoozes\n", Output(t, pets))
+}
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+
+	src := `
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = random("Dachshund", "Corgi", "Beagle", "Pug", "Poodle", "Akita")
+  }
+}
+`
+	breeds := []string{}
+	for _, seed := range []int64{DefaultSeed, 7, DefaultSeed} {
+		config := MustDecode(t, src, Seed(seed))
+		breeds = append(breeds, config.Pets[0].(*petsounds.Dog).Breed)
+	}
+	assert.Equal(t, breeds[0], breeds[2])
+	assert.Equal(t, breeds[0], MustParse(t, src)[0].(*petsounds.Dog).Breed)
+}
+
+// fatalT records the failure of a test instead of stopping it.
+type fatalT struct {
+	testing.TB
+	failed string
+}
+
+func (t *fatalT) Helper() {}
+
+func (t *fatalT) Fatalf(format string, args ...interface{}) {
+	t.failed = fmt.Sprintf(format, args...)
+}
+
+func TestMustParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	ft := &fatalT{TB: t}
+	assert.Nil(t, MustParse(ft, `pet "Ink" {`))
+	assert.Contains(t, ft.failed, "error decoding configuration: ")
+}
@@ -0,0 +1,400 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"go.starlark.net/starlark"
+)
+
+// PetTypeHCL is a pet_type block, which declares a type of pet in the
+// configuration instead of in Go, such as:
+//   pet_type "hamster" {
+//     say_template = "${name} squeaks"
+//     act_template = "${name} runs the wheel"
+//   }
+// Pets of the type are declared like any other. The templates are the
+// whole lines the pets write, evaluated each time they Say or Act, with the
+// pet's name, type and mood.
+//
+// Types that templates can't express set a behavior_script instead, the
+// path of a Starlark script relative to the file, whose functions make the
+// lines with the pet's characteristics too; see petScript. ScriptMaxSteps
+// and ScriptTimeout limit each run of the script.
+type PetTypeHCL struct {
+	Name           string         `hcl:"name,label"`
+	SayTemplate    hcl.Expression `hcl:"say_template,optional"`
+	ActTemplate    hcl.Expression `hcl:"act_template,optional"`
+	BehaviorScript hcl.Expression `hcl:"behavior_script,optional"`
+	ScriptMaxSteps hcl.Expression `hcl:"script_max_steps,optional"`
+	ScriptTimeout  hcl.Expression `hcl:"script_timeout,optional"`
+
+	declRange hcl.Range
+	// pack is the pet pack that declares the type, if one does.
+	pack string
+}
+
+// CustomPet is a pet of a type declared with a pet_type block. Only pets of
+// types with a behavior script have characteristics, which can be any
+// attributes for the script to read.
+type CustomPet struct {
+	Name       string
+	Moods      *MoodMachine
+	Conditions *Conditions
+	Feedings   []*Feeding
+	VetVisits  []*VetVisit
+	Disabled   bool
+	Tags       []string
+	Household  string
+	Owner      string
+	Language   string
+
+	petType         string
+	say, act        hcl.Expression
+	script          *petScript
+	characteristics map[string]cty.Value
+	evalContext     *hcl.EvalContext
+	declRange       hcl.Range
+	validations     []*ValidationHCL
+	sourceSum       string
+	sensitive       map[string]bool
+	style           *Style
+}
+
+// DeclRange returns the range of the type and labels of the block the pet
+// was declared in.
+func (c *CustomPet) DeclRange() hcl.Range {
+	return c.declRange
+}
+
+// PetName returns the pet's name.
+func (c *CustomPet) PetName() string {
+	return c.Name
+}
+
+// Type returns the name of the pet_type block of the pet.
+func (c *CustomPet) Type() string {
+	if c == nil {
+		return ""
+	}
+	return c.petType
+}
+
+func (c *CustomPet) fields() petFields {
+	return petFields{
+		moods: c.Moods, conditions: c.Conditions, feedings: c.Feedings, vetVisits: c.VetVisits, disabled: c.Disabled,
+		language: c.Language, validations: &c.validations, sourceSum: &c.sourceSum, sensitive: &c.sensitive,
+	}
+}
+
+// visit calls the VisitUnknown method of v, as Visitor has no method for
+// pet types declared in configuration.
+func (c *CustomPet) visit(v Visitor) {
+	v.VisitUnknown(c)
+}
+
+// Implement the Pet interface.
+func (c *CustomPet) Say(w io.Writer) {
+	c.style.write(w, utterance{
+		pet: c.Name, petType: c.petType, event: eventSay, text: c.render(eventSay), mood: c.Moods.Mood(),
+	})
+	c.Moods.Record(eventSay)
+}
+func (c *CustomPet) Act(w io.Writer) {
+	c.style.write(w, utterance{
+		pet: c.Name, petType: c.petType, event: eventAct, text: c.render(eventAct), mood: c.Moods.Mood(),
+	})
+	c.Moods.Record(eventAct)
+}
+
+// UnmarshalHCL decodes the characteristics of pets whose type has a
+// behavior script, keeping each attribute as it is for the script. Pets of
+// types with templates have none, so any attribute is unsupported.
+func (c *CustomPet) UnmarshalHCL(body hcl.Body, ctx *hcl.EvalContext) hcl.Diagnostics {
+	if c.script == nil {
+		_, diags := hcldec.Decode(body, hcldec.ObjectSpec{}, ctx)
+		return diags
+	}
+	attrs, diags := body.JustAttributes()
+	if c.characteristics == nil {
+		c.characteristics = map[string]cty.Value{}
+	}
+	for name, attr := range attrs {
+		val, valDiags := attr.Expr.Value(ctx)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			setSensitive(&c.sensitive, name, isSensitive(val))
+			c.characteristics[name], _ = val.UnmarkDeep()
+		}
+	}
+	return diags
+}
+
+// render returns the line the pet makes for event as it is now, from its
+// type's script or template. Both are checked when the pet is decoded, so
+// one that fails later, as a function it calls might, writes its error
+// instead.
+func (c *CustomPet) render(event string) string {
+	if c.script != nil {
+		fn := c.script.say
+		if event == eventAct {
+			fn = c.script.act
+		}
+		line, err := c.script.line(fn, starlarkPet(c))
+		if err != nil {
+			return err.Error()
+		}
+		return line
+	}
+	template := c.say
+	if event == eventAct {
+		template = c.act
+	}
+	line, diags := c.template(template)
+	if diags.HasErrors() {
+		return diags.Error()
+	}
+	return line
+}
+
+// template evaluates template for the pet, with its name, type and mood.
+func (c *CustomPet) template(template hcl.Expression) (string, hcl.Diagnostics) {
+	ctx := &hcl.EvalContext{}
+	if c.evalContext != nil {
+		ctx = c.evalContext.NewChild()
+	}
+	ctx.Variables = map[string]cty.Value{
+		"name": cty.StringVal(c.Name),
+		"type": cty.StringVal(c.petType),
+		"mood": cty.StringVal(string(c.Moods.Mood())),
+	}
+	ctx.Functions = sensitiveFunctions
+	val, diags := template.Value(ctx)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if val.Type() != cty.String || val.IsNull() || !val.IsKnown() {
+		return "", hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid template",
+			Detail:   fmt.Sprintf("The templates of the pet type `%s` must make strings.", c.petType),
+			Subject:  template.Range().Ptr(),
+		}}
+	}
+	if isSensitive(val) {
+		return sensitiveText, nil
+	}
+	return val.AsString(), nil
+}
+
+// declarePetTypes gives the pets of petsHCL whose type is declared by one of
+// its pet_type blocks the kind of that type. The types are decoded in
+// evalContext, so their templates can call the file's functions.
+func declarePetTypes(petsHCL *PetsHCL, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	kinds := map[string]*petKind{}
+	for _, t := range petsHCL.PetTypesHCL {
+		if _, ok := petKinds[t.Name]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid pet type",
+				Detail:   fmt.Sprintf("`%s` is already a pet type.", t.Name),
+				Subject:  t.declRange.Ptr(),
+			})
+			continue
+		}
+		if _, ok := kinds[t.Name]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate pet type",
+				Detail:   fmt.Sprintf("The pet type `%s` is declared more than once.", t.Name),
+				Subject:  t.declRange.Ptr(),
+			})
+			continue
+		}
+		if diag := checkPetTypeTemplates(t); diag != nil {
+			diags = append(diags, diag)
+			continue
+		}
+		var script *petScript
+		if !isNullExpr(t.BehaviorScript) {
+			var scriptDiags hcl.Diagnostics
+			if script, scriptDiags = loadBehaviorScript(t, evalContext); scriptDiags.HasErrors() {
+				diags = append(diags, scriptDiags...)
+				continue
+			}
+		}
+		kinds[t.Name] = customPetKind(t, script, evalContext)
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, p := range petsHCL.PetHCLBodies {
+		p.kind = kinds[p.Type]
+	}
+	return nil
+}
+
+// checkPetTypeTemplates returns a diagnostic if t does not set either both
+// of its templates or a behavior script. Optional expressions that are left
+// out are null.
+func checkPetTypeTemplates(t *PetTypeHCL) *hcl.Diagnostic {
+	if !isNullExpr(t.BehaviorScript) {
+		if !isNullExpr(t.SayTemplate) || !isNullExpr(t.ActTemplate) {
+			return &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Conflicting behavior",
+				Detail:   fmt.Sprintf("The pet type `%s` has both a behavior_script and templates; use one or the other.", t.Name),
+				Subject:  t.BehaviorScript.Range().Ptr(),
+			}
+		}
+		return nil
+	}
+	for _, template := range []struct {
+		name string
+		expr hcl.Expression
+	}{{"say_template", t.SayTemplate}, {"act_template", t.ActTemplate}} {
+		if isNullExpr(template.expr) {
+			return &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing template",
+				Detail:   fmt.Sprintf("The pet type `%s` has no %s.", t.Name, template.name),
+				Subject:  t.declRange.Ptr(),
+			}
+		}
+	}
+	return nil
+}
+
+// loadBehaviorScript loads the behavior script of t, resolved relative to
+// the file t is declared in, with the limits t sets.
+func loadBehaviorScript(t *PetTypeHCL, evalContext *hcl.EvalContext) (*petScript, hcl.Diagnostics) {
+	invalid := func(expr hcl.Expression, detail string) hcl.Diagnostics {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid behavior script",
+			Detail:   fmt.Sprintf("The behavior script of the pet type `%s` %s.", t.Name, detail),
+			Subject:  expr.Range().Ptr(),
+		}}
+	}
+
+	var path string
+	if diags := gohcl.DecodeExpression(t.BehaviorScript, evalContext, &path); diags.HasErrors() {
+		return nil, diags
+	}
+	filename := t.declRange.Filename
+	if isURL(filename) {
+		return nil, invalid(t.BehaviorScript, "can't be read, as the file it is declared in was fetched from a URL")
+	}
+	if !filepath.IsAbs(path) && filename != "" {
+		path = filepath.Join(filepath.Dir(filename), path)
+	}
+
+	maxSteps := uint64(defaultScriptMaxSteps)
+	if !isNullExpr(t.ScriptMaxSteps) {
+		var steps int
+		if diags := gohcl.DecodeExpression(t.ScriptMaxSteps, evalContext, &steps); diags.HasErrors() {
+			return nil, diags
+		}
+		if steps < 1 {
+			return nil, invalid(t.ScriptMaxSteps, "must be allowed at least one step")
+		}
+		maxSteps = uint64(steps)
+	}
+	timeout := defaultScriptTimeout
+	if !isNullExpr(t.ScriptTimeout) {
+		val, diags := t.ScriptTimeout.Value(evalContext)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		d, err := parseDuration(val)
+		if err == nil && d == 0 {
+			err = fmt.Errorf("a timeout must be above zero")
+		}
+		if err != nil {
+			return nil, invalid(t.ScriptTimeout, fmt.Sprintf("has an invalid script_timeout: %s", err))
+		}
+		timeout = d
+	}
+
+	script, err := loadPetScript(path, maxSteps, timeout)
+	if err != nil {
+		return nil, invalid(t.BehaviorScript, fmt.Sprintf("can't be loaded: %s", err))
+	}
+	return script, nil
+}
+
+// isNullExpr reports whether expr is an optional attribute that was left
+// out, which gohcl decodes as a null expression.
+func isNullExpr(expr hcl.Expression) bool {
+	if expr == nil {
+		return true
+	}
+	val, diags := expr.Value(nil)
+	return !diags.HasErrors() && val.IsNull()
+}
+
+// declaredPetTypes returns the names of the pet types declared by the
+// pet_type blocks of body, and by the packs it enables.
+func declaredPetTypes(body *hclsyntax.Body) []string {
+	names := []string{}
+	for _, block := range body.Blocks {
+		if block.Type == "pet_type" && len(block.Labels) == 1 {
+			names = append(names, block.Labels[0])
+		}
+	}
+	packs, _ := filePetPacks(body)
+	for _, pack := range packs {
+		types, _ := petPackTypes(pack)
+		for _, t := range types {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// customPetKind returns the kind of the pets of the pet type t.
+func customPetKind(t *PetTypeHCL, script *petScript, evalContext *hcl.EvalContext) *petKind {
+	return newPetKind(CustomPet{}, &petKind{
+		new: func(p *PetHCL, moods *MoodMachine, conditions *Conditions, feedings []*Feeding, visits []*VetVisit) Pet {
+			return &CustomPet{
+				Name: p.Name, Moods: moods, Conditions: conditions, Feedings: feedings, VetVisits: visits,
+				Disabled: p.Enabled != nil && !*p.Enabled, Tags: p.Tags, Household: p.household, Owner: p.Owner,
+				Language: p.Language, petType: t.Name, say: t.SayTemplate, act: t.ActTemplate, script: script,
+				evalContext: evalContext, declRange: p.declRange,
+			}
+		},
+		defaults: func(d *DefaultsHCL) hcl.Body {
+			return nil
+		},
+		validate: func(pet Pet, body hcl.Body) hcl.Diagnostics {
+			c := pet.(*CustomPet)
+			diags := hcl.Diagnostics{}
+			if c.script != nil {
+				for _, fn := range []starlark.Callable{c.script.say, c.script.act} {
+					if _, err := c.script.line(fn, starlarkPet(c)); err != nil {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Behavior script failed",
+							Detail:   fmt.Sprintf("The %s function of the pet type `%s` failed: %s.", fn.Name(), c.petType, err),
+							Subject:  c.declRange.Ptr(),
+						})
+					}
+				}
+				return diags
+			}
+			for _, template := range []hcl.Expression{c.say, c.act} {
+				_, templateDiags := c.template(template)
+				diags = append(diags, templateDiags...)
+			}
+			return diags
+		},
+		display: unknownDisplay,
+	})
+}
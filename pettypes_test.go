@@ -0,0 +1,173 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetTypes(t *testing.T) {
+	t.Parallel()
+
+	src := `pet_type "hamster" {
+  say_template = "${name} squeaks"
+  act_template = "${name} runs the wheel${mood == "" ? "" : " while ${mood}"}"
+}
+
+pet "Hammy" {
+  type = "hamster"
+  tags = ["small"]
+}
+
+pet "Nibbles" {
+  type    = "hamster"
+  enabled = false
+}
+
+pet "Ink" {
+  type = "cat"
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&Runner{Out: out}).Run(config.Pets))
+	assert.Equal(t, "Hammy squeaks\nHammy runs the wheel\nInk meow\nInk snoozes\n", out.String())
+
+	name, petType := petIdentity(config.Pets[0])
+	assert.Equal(t, "Hammy", name)
+	assert.Equal(t, "hamster", petType)
+	assert.Equal(t, []string{"small"}, petTags(config.Pets[0]))
+
+	assert.NoError(t, PetFilter{Type: "hamster"}.Apply(config))
+	assert.Len(t, config.Pets, 2)
+}
+
+func TestPetTypesScript(t *testing.T) {
+	t.Parallel()
+
+	src := `pet_type "hamster" {
+  behavior_script = "testdata/scripts/hamster.star"
+}
+
+pet "Hammy" {
+  type = "hamster"
+
+  characteristics {
+    laps = 3
+  }
+}
+
+pet "Nibbles" {
+  type = "hamster"
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&Runner{Out: out}).Run(config.Pets))
+	assert.Equal(
+		t,
+		"Hammy squeaks\nHammy runs 3 laps of the wheel\nNibbles squeaks\nNibbles runs 1 lap of the wheel\n",
+		out.String(),
+	)
+}
+
+func TestPetTypesInvalid(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "built in type",
+			src:  "pet_type \"cat\" {\n  say_template = \"meow\"\n  act_template = \"naps\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:1,1-17: Invalid pet type; `cat` is already a pet type.",
+		},
+		{
+			name: "duplicate",
+			src: "pet_type \"hamster\" {\n  say_template = \"squeak\"\n  act_template = \"runs\"\n}\n" +
+				"pet_type \"hamster\" {\n  say_template = \"squeak\"\n  act_template = \"runs\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:5,1-21: Duplicate pet type; " +
+				"The pet type `hamster` is declared more than once.",
+		},
+		{
+			name: "unknown variable",
+			src: "pet_type \"hamster\" {\n  say_template = \"${nme} squeaks\"\n  act_template = \"runs\"\n}\n" +
+				"pet \"Hammy\" {\n  type = \"hamster\"\n}\n",
+			want: "error in DecodeConfig validating hamster `Hammy`: pets.hcl:2,21-24: Unknown variable; " +
+				"There is no variable named \"nme\". Did you mean \"name\"?",
+		},
+		{
+			name: "missing template",
+			src:  "pet_type \"hamster\" {\n  say_template = \"squeak\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:1,1-21: Missing template; " +
+				"The pet type `hamster` has no act_template.",
+		},
+		{
+			name: "script and templates",
+			src:  "pet_type \"hamster\" {\n  say_template = \"squeak\"\n  behavior_script = \"testdata/scripts/hamster.star\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:3,21-52: Conflicting behavior; " +
+				"The pet type `hamster` has both a behavior_script and templates; use one or the other.",
+		},
+		{
+			name: "missing script",
+			src:  "pet_type \"hamster\" {\n  behavior_script = \"testdata/scripts/missing.star\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:2,21-52: Invalid behavior script; " +
+				"The behavior script of the pet type `hamster` can't be loaded: " +
+				"open testdata/scripts/missing.star: no such file or directory.",
+		},
+		{
+			name: "script without act",
+			src:  "pet_type \"hamster\" {\n  behavior_script = \"testdata/scripts/silent.star\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:2,21-51: Invalid behavior script; " +
+				"The behavior script of the pet type `hamster` can't be loaded: testdata/scripts/silent.star defines no act function.",
+		},
+		{
+			name: "script steps",
+			src: "pet_type \"hamster\" {\n  behavior_script = \"testdata/scripts/busy.star\"\n  script_max_steps = 1000\n}\n" +
+				"pet \"Hammy\" {\n  type = \"hamster\"\n}\n",
+			want: "error in DecodeConfig validating hamster `Hammy`: pets.hcl:5,1-14: Behavior script failed; " +
+				"The say function of the pet type `hamster` failed: " +
+				"testdata/scripts/busy.star:4:5: Starlark computation cancelled: too many steps.",
+		},
+		{
+			name: "script timeout",
+			src:  "pet_type \"hamster\" {\n  behavior_script = \"testdata/scripts/hamster.star\"\n  script_timeout = \"0s\"\n}\n",
+			want: "error in DecodeConfig declaring pet types: pets.hcl:3,20-24: Invalid behavior script; " +
+				"The behavior script of the pet type `hamster` has an invalid script_timeout: a timeout must be above zero.",
+		},
+		{
+			name: "script characteristics",
+			src: "pet_type \"hamster\" {\n  behavior_script = \"testdata/scripts/hamster.star\"\n}\n" +
+				"pet \"Hammy\" {\n  type = \"hamster\"\n  characteristics {\n    laps = \"many\"\n  }\n}\n",
+			want: "error in DecodeConfig validating hamster `Hammy`: pets.hcl:4,1-14: Behavior script failed; " +
+				"The act function of the pet type `hamster` failed: " +
+				"testdata/scripts/hamster.star:8:44: %d format requires integer: cannot convert string to int.",
+		},
+		{
+			name: "characteristics",
+			src: "pet_type \"hamster\" {\n  say_template = \"squeak\"\n  act_template = \"runs\"\n}\n" +
+				"pet \"Hammy\" {\n  type = \"hamster\"\n  characteristics {\n    sound = \"squeak\"\n  }\n}\n",
+			want: "error in DecodeConfig decoding hamster HCL configuration: pets.hcl:8,5-10: Unsupported argument; " +
+				"An argument named \"sound\" is not expected here.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeConfig([]byte(tc.src), "pets.hcl", LoadOptions{})
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
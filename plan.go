@@ -0,0 +1,138 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// planMarkers are the markers and ANSI colors of each action in a plan.
+var planMarkers = map[string]struct {
+	marker string
+	color  string
+}{
+	changeAdd:    {"+", "\x1b[32m"},
+	changeUpdate: {"~", "\x1b[33m"},
+	changeRemove: {"-", "\x1b[31m"},
+}
+
+// writePlan writes changes to w, one pet per line marked with +, ~ or - for
+// pets that will be added, changed or removed, followed by a summary. With
+// color, the markers are colored green, yellow and red.
+func writePlan(w io.Writer, changes []*PetChange, color bool) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes. The pets match the state.")
+		return
+	}
+
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+		pet := c.New
+		if pet == nil {
+			pet = c.Old
+		}
+		m := planMarkers[c.Action]
+		line := fmt.Sprintf("%s %s %q", m.marker, pet.Type, pet.Name)
+		if pet.Block != "" {
+			line += fmt.Sprintf(" (%s)", pet.Block)
+		}
+		if color {
+			line = m.color + line + "\x1b[0m"
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "\nPlan: %d to add, %d to change, %d to remove.\n",
+		counts[changeAdd], counts[changeUpdate], counts[changeRemove])
+}
+
+// colorOutput reports whether output written to f should be colored: when
+// f is a terminal, and NO_COLOR is not set.
+func colorOutput(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// targetChanges returns the changes to the pets named in targets, or all of
+// changes when there are no targets. It is an error for a target to name a
+// pet that is in neither state, as that is most likely a typo.
+func targetChanges(changes []*PetChange, old, current *ManagedState, targets []string) ([]*PetChange, error) {
+	if len(targets) == 0 {
+		return changes, nil
+	}
+
+	names, known := []string{}, map[string]bool{}
+	for _, p := range append(append([]*ManagedPet{}, current.Pets...), old.Pets...) {
+		if !known[p.Name] {
+			names = append(names, p.Name)
+			known[p.Name] = true
+		}
+	}
+	targeted := map[string]bool{}
+	for _, t := range targets {
+		if !known[t] {
+			if suggestion := suggest(t, names); suggestion != "" {
+				return nil, fmt.Errorf("no pet named `%s`, did you mean `%s`?", t, suggestion)
+			}
+			return nil, fmt.Errorf("no pet named `%s`", t)
+		}
+		targeted[t] = true
+	}
+
+	selected := []*PetChange{}
+	for _, c := range changes {
+		if targeted[c.Name] {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
+// applyChanges returns the state s becomes once changes, from s to current,
+// have been applied. Pets with changes that were not applied are kept as
+// they were in s.
+func (s *ManagedState) applyChanges(current *ManagedState, changes []*PetChange) *ManagedState {
+	applied := map[string]bool{}
+	for _, c := range changes {
+		applied[c.Name] = true
+	}
+	old, declared := map[string]*ManagedPet{}, map[string]bool{}
+	for _, p := range s.Pets {
+		old[p.Name] = p
+	}
+
+	next := &ManagedState{Version: managedStateVersion, Pets: []*ManagedPet{}}
+	for _, p := range current.Pets {
+		declared[p.Name] = true
+		o := old[p.Name]
+		switch {
+		case applied[p.Name], o != nil && o.Fingerprint == p.Fingerprint && o.Type == p.Type:
+			next.Pets = append(next.Pets, p)
+		case o != nil:
+			next.Pets = append(next.Pets, o)
+		}
+	}
+	for _, p := range s.Pets {
+		if !declared[p.Name] && !applied[p.Name] {
+			next.Pets = append(next.Pets, p)
+		}
+	}
+	return next
+}
+
+// confirmApply asks on w whether to apply the plan, reading the answer from
+// r. Only yes is taken as approval.
+func confirmApply(r io.Reader, w io.Writer) bool {
+	fmt.Fprint(w, "\nApply these changes? Only 'yes' will be accepted: ")
+	answer := ""
+	fmt.Fscanln(r, &answer)
+	return answer == "yes"
+}
@@ -0,0 +1,124 @@
+package petsounds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePlan(t *testing.T) {
+	ink := &ManagedPet{Name: "Ink", Type: "cat", Block: "pets.hcl:1,1", Fingerprint: "a"}
+	newInk := &ManagedPet{Name: "Ink", Type: "cat", Block: "pets.hcl:1,1", Fingerprint: "b"}
+	ada := &ManagedPet{Name: "Ada", Type: "cat", Block: "pets.hcl:9,1", Fingerprint: "c"}
+	swinney := &ManagedPet{Name: "Swinney", Type: "dog", Fingerprint: "d"}
+	changes := []*PetChange{
+		{Action: changeUpdate, Name: "Ink", Old: ink, New: newInk},
+		{Action: changeAdd, Name: "Ada", New: ada},
+		{Action: changeRemove, Name: "Swinney", Old: swinney},
+	}
+
+	tcs := []struct {
+		name    string
+		changes []*PetChange
+		color   bool
+		want    string
+	}{
+		{
+			name: "no changes",
+			want: "No changes. The pets match the state.\n",
+		},
+		{
+			name:    "changes",
+			changes: changes,
+			want: `~ cat "Ink" (pets.hcl:1,1)
++ cat "Ada" (pets.hcl:9,1)
+- dog "Swinney"
+
+Plan: 1 to add, 1 to change, 1 to remove.
+`,
+		},
+		{
+			name:    "color",
+			changes: changes[1:2],
+			color:   true,
+			want:    "\x1b[32m+ cat \"Ada\" (pets.hcl:9,1)\x1b[0m\n\nPlan: 1 to add, 0 to change, 0 to remove.\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			writePlan(out, tc.changes, tc.color)
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	ink := &ManagedPet{Name: "Ink", Type: "cat", Fingerprint: "a"}
+	newInk := &ManagedPet{Name: "Ink", Type: "cat", Fingerprint: "b"}
+	ada := &ManagedPet{Name: "Ada", Type: "cat", Fingerprint: "c"}
+	swinney := &ManagedPet{Name: "Swinney", Type: "dog", Fingerprint: "d"}
+	rex := &ManagedPet{Name: "Rex", Type: "dog", Fingerprint: "e"}
+	old := &ManagedState{Version: managedStateVersion, Pets: []*ManagedPet{ink, swinney, rex}}
+	current := &ManagedState{Version: managedStateVersion, Pets: []*ManagedPet{newInk, ada, rex}}
+	changes := old.Diff(current)
+
+	tcs := []struct {
+		name    string
+		targets []string
+		want    []*ManagedPet
+		err     string
+	}{
+		{
+			name: "everything",
+			want: []*ManagedPet{newInk, ada, rex},
+		},
+		{
+			name:    "target",
+			targets: []string{"Ink"},
+			want:    []*ManagedPet{newInk, rex, swinney},
+		},
+		{
+			name:    "target removal",
+			targets: []string{"Swinney", "Rex"},
+			want:    []*ManagedPet{ink, rex},
+		},
+		{
+			name:    "unknown target",
+			targets: []string{"Swiney"},
+			err:     "no pet named `Swiney`, did you mean `Swinney`?",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			selected, err := targetChanges(changes, old, current, tc.targets)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, old.applyChanges(current, selected).Pets)
+			}
+		})
+	}
+}
+
+func TestConfirmApply(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	assert.True(t, confirmApply(strings.NewReader("yes\n"), out))
+	assert.Equal(t, "\nApply these changes? Only 'yes' will be accepted: ", out.String())
+	assert.False(t, confirmApply(strings.NewReader("y\n"), out))
+	assert.False(t, confirmApply(strings.NewReader(""), out))
+}
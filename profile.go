@@ -0,0 +1,174 @@
+package petsounds
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The phases of a command that Timings measures. Execute is the time the
+// command takes that is not spent loading the configuration.
+const (
+	phaseParse   = "parse"
+	phaseDecode  = "decode"
+	phaseExecute = "execute"
+)
+
+// Timings are how long each phase of a command took, for --timings. It is
+// also the value of the flag, so commands can find it with flagTimings.
+// Timings that are nil or not enabled measure nothing. It is safe for
+// concurrent use.
+type Timings struct {
+	enabled bool
+
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+func (t *Timings) String() string {
+	if t == nil {
+		return "false"
+	}
+	return strconv.FormatBool(t.enabled)
+}
+
+func (t *Timings) Set(s string) error {
+	enabled, err := strconv.ParseBool(s)
+	t.enabled = enabled
+	return err
+}
+
+func (t *Timings) IsBoolFlag() bool {
+	return true
+}
+
+// add adds d to the time taken by phase.
+func (t *Timings) add(phase string, d time.Duration) {
+	if t == nil || !t.enabled {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.durations == nil {
+		t.durations = map[string]time.Duration{}
+	}
+	t.durations[phase] += d
+}
+
+// get returns the time taken by phase so far.
+func (t *Timings) get(phase string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.durations[phase]
+}
+
+// write writes the time each phase took to w on one line, along with the
+// total, of which execute is what the other phases leave.
+func (t *Timings) write(w io.Writer, total time.Duration) error {
+	if t == nil || !t.enabled {
+		return nil
+	}
+	parse, decode := t.get(phaseParse), t.get(phaseDecode)
+	t.add(phaseExecute, total-parse-decode)
+	phases := []string{}
+	for _, phase := range []string{phaseParse, phaseDecode, phaseExecute} {
+		phases = append(phases, fmt.Sprintf("%s %s", phase, t.get(phase).Round(time.Microsecond)))
+	}
+	_, err := fmt.Fprintf(w, "pet-sounds timings: %s, total %s\n", strings.Join(phases, ", "), total.Round(time.Microsecond))
+	return err
+}
+
+// flagTimings returns the Timings of the --timings flag of flags, or nil if
+// it has none.
+func flagTimings(flags *flag.FlagSet) *Timings {
+	f := flags.Lookup("timings")
+	if f == nil {
+		return nil
+	}
+	t, _ := f.Value.(*Timings)
+	return t
+}
+
+// instrumentation is the profiling and timing of a command, which every
+// command has flags for.
+type instrumentation struct {
+	name        string
+	cpuProfile  string
+	memProfile  string
+	timings     *Timings
+	spans       *otlpFlag
+	spanHeaders []string
+}
+
+// instrumentFlags registers the flags that profile and time a command.
+func instrumentFlags(flags *flag.FlagSet) *instrumentation {
+	i := &instrumentation{name: flags.Name(), timings: &Timings{}, spans: &otlpFlag{}}
+	flags.StringVar(&i.cpuProfile, "cpuprofile", "", "write a CPU profile of the command to this file, for go tool pprof")
+	flags.StringVar(&i.memProfile, "memprofile", "", "write a heap profile to this file when the command ends, for go tool pprof")
+	flags.Var(i.timings, "timings", "write how long parsing, decoding and executing took to stderr")
+	flags.Var(i.spans, "otlp-endpoint", "export spans of parsing, decoding and running pets to this OpenTelemetry collector, such as http://localhost:4318")
+	flags.Var((*stringsFlag)(&i.spanHeaders), "otlp-header", "a header to export spans with, as name=value; can be given more than once")
+	return i
+}
+
+// start starts profiling the command, returning the function that stops
+// it, writes the profiles and the timings, and exports the spans.
+func (i *instrumentation) start() (func() error, error) {
+	began := time.Now()
+	endSpans, err := i.spans.start(i.name, i.spanHeaders, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	var cpu *os.File
+	if i.cpuProfile != "" {
+		var err error
+		if cpu, err = os.Create(i.cpuProfile); err != nil {
+			return nil, fmt.Errorf("error creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpu); err != nil {
+			cpu.Close()
+			return nil, fmt.Errorf("error starting CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			if err := cpu.Close(); err != nil {
+				return fmt.Errorf("error writing CPU profile: %w", err)
+			}
+		}
+		if i.memProfile != "" {
+			if err := writeHeapProfile(i.memProfile); err != nil {
+				return err
+			}
+		}
+		endSpans()
+		return i.timings.write(os.Stderr, time.Since(began))
+	}, nil
+}
+
+// writeHeapProfile writes a profile of the memory in use to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating heap profile: %w", err)
+	}
+	defer f.Close()
+	// Collecting garbage first leaves only the memory still in use.
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("error writing heap profile: %w", err)
+	}
+	return f.Close()
+}
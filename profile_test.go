@@ -0,0 +1,77 @@
+package petsounds
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimings(t *testing.T) {
+	t.Parallel()
+
+	flags := flag.NewFlagSet("pet-sounds", flag.ContinueOnError)
+	instrument := instrumentFlags(flags)
+	if !assert.NoError(t, flags.Parse([]string{"-timings"})) {
+		return
+	}
+	timings := flagTimings(flags)
+	if !assert.Equal(t, instrument.timings, timings) {
+		return
+	}
+
+	src, err := ioutil.ReadFile("testdata/basic.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = DecodeConfig(src, "testdata/basic.hcl", LoadOptions{Timings: timings})
+	if !assert.NoError(t, err) {
+		return
+	}
+	parse, decode := timings.get(phaseParse), timings.get(phaseDecode)
+	assert.True(t, parse > 0, "parse took %s", parse)
+	assert.True(t, decode > 0, "decode took %s", decode)
+
+	out := &bytes.Buffer{}
+	total := parse + decode + time.Second
+	if assert.NoError(t, timings.write(out, total)) {
+		assert.True(t, strings.HasPrefix(out.String(), "pet-sounds timings: parse "), out.String())
+		assert.Contains(t, out.String(), ", execute 1s, total ")
+	}
+}
+
+func TestTimingsDisabled(t *testing.T) {
+	t.Parallel()
+
+	timings := &Timings{}
+	timings.add(phaseParse, time.Second)
+	assert.Equal(t, time.Duration(0), timings.get(phaseParse))
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, timings.write(out, time.Second))
+	assert.Empty(t, out.String())
+	assert.Nil(t, flagTimings(flag.NewFlagSet("pet-sounds", flag.ContinueOnError)))
+}
+
+func TestProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	cpu, mem := filepath.Join(dir, "cpu.out"), filepath.Join(dir, "mem.out")
+	assert.NoError(t, inner([]string{"version", "-cpuprofile", cpu, "-memprofile", mem}))
+	for _, path := range []string{cpu, mem} {
+		info, err := os.Stat(path)
+		if assert.NoError(t, err) {
+			assert.NotZero(t, info.Size(), path)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package petsounds
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// profileBlockType is the block that holds the pets and variable values of
+// one environment of a configuration, such as dev or prod, so that every
+// environment can share the rest of one file.
+const profileBlockType = "profile"
+
+// ProfileHCL is a profile block, such as:
+//   profile "prod" {
+//     variables = {
+//       sound = "WOOF"
+//     }
+//     pet "Rex" {
+//       type = "dog"
+//     }
+//   }
+// Its pets and households are only declared when the profile is selected,
+// and its variables replace the defaults of the file's variables.
+type ProfileHCL struct {
+	Name          string          `hcl:"name,label"`
+	Variables     hcl.Expression  `hcl:"variables,optional"`
+	PetHCLBodies  []*PetHCL       `hcl:"pet,block"`
+	HouseholdsHCL []*HouseholdHCL `hcl:"household,block"`
+}
+
+// profileSchema is what a profile block can contain.
+var profileSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "variables"}},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "pet", LabelNames: []string{"name"}},
+		{Type: "household", LabelNames: []string{"name"}},
+	},
+}
+
+// applyProfile removes the profile blocks from body, adding the pets and
+// households of the one named profile to it. It returns the values the
+// profile sets for the file's variables. Without a profile, the pets of
+// every profile block are left out. Problems with the profile blocks are
+// returned as hcl.Diagnostics.
+func applyProfile(body *hclsyntax.Body, profile string) (map[string]cty.Value, error) {
+	diags := hcl.Diagnostics{}
+	var selected *hclsyntax.Block
+	names, blocks := []string{}, []*hclsyntax.Block{}
+	for _, block := range body.Blocks {
+		if block.Type != profileBlockType {
+			blocks = append(blocks, block)
+			continue
+		}
+		if len(block.Labels) != 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid profile",
+				Detail:   "A profile block has a single label, its name.",
+				Subject:  block.DefRange().Ptr(),
+			})
+			continue
+		}
+		names = append(names, block.Labels[0])
+		if block.Labels[0] == profile {
+			selected = block
+		}
+	}
+	body.Blocks = blocks
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if profile == "" {
+		return nil, nil
+	}
+	if selected == nil {
+		if suggestion := suggest(profile, names); suggestion != "" {
+			return nil, fmt.Errorf("unknown profile `%s`, did you mean `%s`?", profile, suggestion)
+		}
+		return nil, fmt.Errorf("unknown profile `%s`", profile)
+	}
+
+	content, contentDiags := selected.Body.Content(profileSchema)
+	if contentDiags.HasErrors() {
+		return nil, contentDiags
+	}
+	body.Blocks = append(body.Blocks, selected.Body.Blocks...)
+
+	attr, ok := content.Attributes["variables"]
+	if !ok {
+		return nil, nil
+	}
+	// Like the defaults they replace, the values are constants.
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if !value.Type().IsObjectType() && !value.Type().IsMapType() {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid profile variables",
+			Detail:   "The variables of a profile are an object of values by variable name.",
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	inputs, names := value.AsValueMap(), []string{}
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !declaresVariable(body, name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Undeclared variable",
+				Detail:   fmt.Sprintf("The profile `%s` sets the variable `%s`, which has no variable block.", profile, name),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return inputs, nil
+}
@@ -0,0 +1,107 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileBlocks(t *testing.T) {
+	src, err := ioutil.ReadFile("testdata/profiles.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	tcs := []struct {
+		name    string
+		profile string
+		pets    []string
+		sound   string
+		err     string
+	}{
+		{
+			name:  "none",
+			pets:  []string{"Ink"},
+			sound: "meow",
+		},
+		{
+			name:    "dev",
+			profile: "dev",
+			pets:    []string{"Ink", "Tester"},
+			sound:   "meow",
+		},
+		{
+			name:    "prod",
+			profile: "prod",
+			pets:    []string{"Ink", "Swinney"},
+			sound:   "MEOW",
+		},
+		{
+			name:    "unknown",
+			profile: "prd",
+			err:     "error in DecodeConfig applying profile: unknown profile `prd`, did you mean `prod`?",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := DecodeConfig(src, "testdata/profiles.hcl", LoadOptions{Profile: tc.profile})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			names := []string{}
+			for _, p := range config.Pets {
+				name, _ := petIdentity(p)
+				names = append(names, name)
+			}
+			assert.Equal(t, tc.pets, names)
+			assert.Equal(t, tc.sound, config.Pets[0].(*Cat).Sound)
+		})
+	}
+}
+
+func TestProfileBlocksInvalid(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{
+			name: "undeclared variable",
+			src: `profile "prod" {
+  variables = {
+    sound = "MEOW"
+  }
+}`,
+			err: "error in DecodeConfig applying profile: pets.hcl:2,15-4,4: Undeclared variable; " +
+				"The profile `prod` sets the variable `sound`, which has no variable block.",
+		},
+		{
+			name: "unsupported block",
+			src: `profile "prod" {
+  defaults {}
+}`,
+			err: "error in DecodeConfig applying profile: pets.hcl:2,3-11: Unsupported block type; " +
+				"Blocks of type \"defaults\" are not expected here.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeConfig([]byte(tc.src), "pets.hcl", LoadOptions{Profile: "prod"})
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package petsounds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// petsKey is the namespace the characteristics of one pet refer to other
+// pets by, as in pet.Ink.sound.
+const petsKey = "pet"
+
+// petReference is a reference from the characteristics of one pet to
+// another pet, called name.
+type petReference struct {
+	name string
+	rng  hcl.Range
+}
+
+// Reference is a reference from the characteristics of the pet From to the
+// pet To, as in pet.Ink.sound, which has To decoded before From.
+type Reference struct {
+	From, To string
+}
+
+// configReferences returns the references of the characteristics of pets
+// to other pets, once for each pair of pets, in the order they are written.
+func configReferences(pets []*PetHCL) []*Reference {
+	refs, seen := []*Reference{}, map[Reference]bool{}
+	for _, p := range pets {
+		for _, ref := range petReferences(p) {
+			r := Reference{From: p.Name, To: ref.name}
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			refs = append(refs, &r)
+		}
+	}
+	return refs
+}
+
+// petReferences returns the references of the characteristics of p to
+// other pets, in the order they are written.
+func petReferences(p *PetHCL) []petReference {
+	if p.CharacteristicsHCL == nil {
+		return nil
+	}
+	body, ok := p.CharacteristicsHCL.HCL.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	return bodyPetReferences(body)
+}
+
+// bodyPetReferences returns the references to other pets in the attributes
+// of body and of its blocks.
+func bodyPetReferences(body *hclsyntax.Body) []petReference {
+	refs := []petReference{}
+	attrs := []*hclsyntax.Attribute{}
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	// Attributes are a map, so they are put back in the order they were
+	// written for the references to be too.
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	for _, attr := range attrs {
+		for _, traversal := range attr.Expr.Variables() {
+			if traversal.RootName() != petsKey || len(traversal) < 2 {
+				continue
+			}
+			switch step := traversal[1].(type) {
+			case hcl.TraverseAttr:
+				refs = append(refs, petReference{name: step.Name, rng: traversal.SourceRange()})
+			case hcl.TraverseIndex:
+				if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+					refs = append(refs, petReference{name: step.Key.AsString(), rng: traversal.SourceRange()})
+				}
+			}
+		}
+	}
+	for _, block := range body.Blocks {
+		refs = append(refs, bodyPetReferences(block.Body)...)
+	}
+	return refs
+}
+
+// decodeOrder returns the indexes of pets in the order they are decoded in:
+// the order they are declared in, except that a pet is decoded after the
+// pets its characteristics refer to. References to pets that are not
+// declared, and pets that refer to each other in a cycle, are errors.
+func decodeOrder(pets []*PetHCL) ([]int, hcl.Diagnostics) {
+	index, names := map[string]int{}, []string{}
+	for i, p := range pets {
+		index[p.Name] = i
+		names = append(names, p.Name)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(pets))
+	order, path := []int{}, []string{}
+	var diags hcl.Diagnostics
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = visiting
+		path = append(path, pets[i].Name)
+		for _, ref := range petReferences(pets[i]) {
+			j, ok := index[ref.name]
+			if !ok {
+				detail := fmt.Sprintf("There is no pet named `%s`.", ref.name)
+				if suggestion := suggest(ref.name, names); suggestion != "" {
+					detail = fmt.Sprintf("There is no pet named `%s`, did you mean `%s`?", ref.name, suggestion)
+				}
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unknown pet",
+					Detail:   detail,
+					Subject:  ref.rng.Ptr(),
+				})
+				continue
+			}
+			switch state[j] {
+			case unvisited:
+				visit(j)
+			case visiting:
+				cycle := append(append([]string{}, path[indexOf(path, ref.name):]...), ref.name)
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Pet reference cycle",
+					Detail: fmt.Sprintf(
+						"The characteristics of these pets refer to each other in a cycle: %s.",
+						strings.Join(cycle, " -> "),
+					),
+					Subject: ref.rng.Ptr(),
+				})
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		order = append(order, i)
+	}
+	for i := range pets {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+	return order, diags
+}
+
+// indexOf returns the index of the last s in values, or -1.
+func indexOf(values []string, s string) int {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// referenceContext returns a child of evalContext in which the pets that
+// have been decoded, by name in others, are available in the pet
+// namespace.
+func referenceContext(others map[string]cty.Value, evalContext *hcl.EvalContext) *hcl.EvalContext {
+	ctx := evalContext.NewChild()
+	ctx.Variables = map[string]cty.Value{petsKey: cty.ObjectVal(others)}
+	return ctx
+}
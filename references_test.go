@@ -0,0 +1,144 @@
+package petsounds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetReferences(t *testing.T) {
+	t.Parallel()
+
+	// Swinney is declared first, but decoded after the pets it refers to.
+	pets := mustParse(t, `
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+    sound = "copies ${pet.Ink.name}, ${pet["Neko"].sound}"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = pet.Neko.sound
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = "nyan"
+  }
+}
+`)
+	assert.Equal(t, []Pet{
+		&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "copies Ink, nyan"},
+		&Cat{Name: "Ink", Sound: "nyan"},
+		&Cat{Name: "Neko", Sound: "nyan"},
+	}, clearDeclRanges(pets))
+}
+
+func TestPetReferenceErrors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "unknown",
+			input: `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = pet.Nekko.sound
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+}
+`,
+			want: "test.hcl:5,13-28: Unknown pet; There is no pet named `Nekko`, did you mean `Neko`?",
+		},
+		{
+			name: "cycle",
+			input: `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = pet.Neko.sound
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = pet.Ink.sound
+  }
+}
+`,
+			want: "test.hcl:12,13-26: Pet reference cycle; The characteristics of these pets refer to each other in a cycle: Ink -> Neko -> Ink.",
+		},
+		{
+			name: "self",
+			input: `
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = pet.Ink.sound
+  }
+}
+`,
+			want: "test.hcl:5,13-26: Pet reference cycle; The characteristics of these pets refer to each other in a cycle: Ink -> Ink.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeConfig([]byte(tc.input), "test.hcl", LoadOptions{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+
+			_, err = DecodePetHeaders(context.Background(), []byte(tc.input), "test.hcl", LoadOptions{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
+
+func TestPetHeaderReferences(t *testing.T) {
+	t.Parallel()
+
+	headers, err := DecodePetHeaders(context.Background(), []byte(`
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = pet.Neko.sound
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = "nyan"
+  }
+}
+`), "test.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ink, err := headers[0].Pet()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "nyan", ink.(*Cat).Sound)
+	}
+}
@@ -0,0 +1,132 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// PetSet is the pets a long running command runs, such as the run and serve
+// commands, which are replaced all at once when the configuration is
+// reloaded. It is safe for concurrent use.
+type PetSet struct {
+	mu   sync.RWMutex
+	pets []Pet
+}
+
+// NewPetSet returns a PetSet of pets.
+func NewPetSet(pets []Pet) *PetSet {
+	return &PetSet{pets: pets}
+}
+
+// Pets returns the pets of the set as they are now.
+func (s *PetSet) Pets() []Pet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pets
+}
+
+// Swap replaces the pets of the set with pets.
+func (s *PetSet) Swap(pets []Pet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pets = pets
+}
+
+// Reload replaces the pets of the set with the ones load returns. If load
+// fails, the set keeps the pets it has.
+func (s *PetSet) Reload(load func() ([]Pet, error)) error {
+	pets, err := load()
+	if err != nil {
+		return fmt.Errorf("error in PetSet.Reload: %w", err)
+	}
+	s.Swap(pets)
+	return nil
+}
+
+// reloadOnHangup reloads set with load each time the process receives
+// SIGHUP, until ctx is done, so that a running daemon picks up the changes
+// to its configuration file.
+func reloadOnHangup(ctx context.Context, set *PetSet, load func() ([]Pet, error), warnings io.Writer) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	reloadOn(ctx, hangup, set, load, warnings)
+}
+
+// reloadOn reloads set with load each time a signal is received, until ctx
+// is done. A configuration that fails to load is reported to warnings, and
+// the pets that were running carry on, while one that loads is reported
+// with the pets that were added, changed or removed.
+func reloadOn(ctx context.Context, signals <-chan os.Signal, set *PetSet, load func() ([]Pet, error), warnings io.Writer) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			old := set.Pets()
+			if err := set.Reload(load); err != nil {
+				fmt.Fprintf(warnings, "pet-sounds warning: reload failed, keeping the running pets: %s\n", err)
+				continue
+			}
+			writeReloadChanges(warnings, reloadChanges(old, set.Pets()))
+		}
+	}
+}
+
+// reloadChanges returns the changes from the pets old to the pets current,
+// by name. Pets that were decoded again are changed, while those a
+// DecodeCache kept are the same pets, and so unchanged. Added and changed
+// pets come in the order of current, followed by removed pets in the order
+// of old.
+func reloadChanges(old, current []Pet) []*PetChange {
+	before := map[string]Pet{}
+	for _, p := range old {
+		name, _ := petIdentity(p)
+		before[name] = p
+	}
+	changes, kept := []*PetChange{}, map[string]bool{}
+	for _, p := range current {
+		name, petType := petIdentity(p)
+		kept[name] = true
+		switch o, ok := before[name]; {
+		case !ok:
+			changes = append(changes, &PetChange{Action: changeAdd, Name: name, New: &ManagedPet{Name: name, Type: petType}})
+		case o != p:
+			_, oldType := petIdentity(o)
+			changes = append(changes, &PetChange{
+				Action: changeUpdate, Name: name,
+				Old: &ManagedPet{Name: name, Type: oldType}, New: &ManagedPet{Name: name, Type: petType},
+			})
+		}
+	}
+	for _, p := range old {
+		name, petType := petIdentity(p)
+		if !kept[name] {
+			changes = append(changes, &PetChange{Action: changeRemove, Name: name, Old: &ManagedPet{Name: name, Type: petType}})
+		}
+	}
+	return changes
+}
+
+// writeReloadChanges writes that the configuration was reloaded to w, with
+// changes, one pet per line marked like a plan.
+func writeReloadChanges(w io.Writer, changes []*PetChange) {
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+	}
+	fmt.Fprintf(w, "pet-sounds: reloaded the configuration, %d added, %d changed, %d removed\n",
+		counts[changeAdd], counts[changeUpdate], counts[changeRemove])
+	for _, c := range changes {
+		pet := c.New
+		if pet == nil {
+			pet = c.Old
+		}
+		fmt.Fprintf(w, "  %s %s %q\n", planMarkers[c.Action].marker, pet.Type, pet.Name)
+	}
+}
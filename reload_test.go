@@ -0,0 +1,68 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetSetReload(t *testing.T) {
+	t.Parallel()
+
+	ink := &Cat{Name: "Ink", Sound: "meow"}
+	rex := &Dog{Name: "Rex", Sound: "woof"}
+	set := NewPetSet([]Pet{ink})
+
+	err := set.Reload(func() ([]Pet, error) { return nil, ErrParse })
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.Equal(t, []Pet{ink}, set.Pets())
+
+	err = set.Reload(func() ([]Pet, error) { return []Pet{rex}, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, []Pet{rex}, set.Pets())
+}
+
+func TestReloadOn(t *testing.T) {
+	t.Parallel()
+
+	ink := &Cat{Name: "Ink", Sound: "meow"}
+	rex := &Dog{Name: "Rex", Sound: "woof"}
+	set := NewPetSet([]Pet{ink})
+	results := []struct {
+		pets []Pet
+		err  error
+	}{{nil, errors.New("pets.hcl:1,1-2: Invalid expression")}, {[]Pet{rex}, nil}}
+	load := func() ([]Pet, error) {
+		result := results[0]
+		results = results[1:]
+		return result.pets, result.err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal)
+	warnings := &bytes.Buffer{}
+	done := make(chan struct{})
+	go func() {
+		reloadOn(ctx, signals, set, load, warnings)
+		close(done)
+	}()
+
+	// The channel is unbuffered, so each send waits for the reload before
+	// it to finish.
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGHUP
+	cancel()
+	<-done
+
+	assert.Equal(t, []Pet{rex}, set.Pets())
+	assert.Equal(t, "pet-sounds warning: reload failed, keeping the running pets: "+
+		"error in PetSet.Reload: pets.hcl:1,1-2: Invalid expression\n"+
+		"pet-sounds: reloaded the configuration, 1 added, 0 changed, 1 removed\n"+
+		"  + dog \"Rex\"\n"+
+		"  - cat \"Ink\"\n", warnings.String())
+}
@@ -0,0 +1,41 @@
+package petsounds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// maxRepeat is the most times a pet can repeat its sound each time it Says
+// something, which keeps its line to a sensible length.
+const maxRepeat = 10
+
+// repeated returns the sound a pet with the repeat characteristic repeat
+// says, calling next for each repetition so that a pet with a list of sounds
+// picks one each time. A pet without one says its sound once.
+func repeated(repeat int, next func() string) string {
+	if repeat < 1 {
+		repeat = 1
+	}
+	sounds := make([]string, repeat)
+	for i := range sounds {
+		sounds[i] = next()
+	}
+	return strings.Join(sounds, " ")
+}
+
+// validateRepeat checks that repeat, the repeat characteristic of a pet of
+// petType decoded from body, is at most maxRepeat. Zero, like one, has the
+// pet say its sound once.
+func validateRepeat(body hcl.Body, petType string, repeat int) hcl.Diagnostics {
+	if repeat >= 0 && repeat <= maxRepeat {
+		return nil
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid repeat",
+		Detail:   fmt.Sprintf("The %s repeats its sound %d times, expected from 1 to %d.", petType, repeat, maxRepeat),
+		Subject:  attributeRange(body, "repeat"),
+	}}
+}
@@ -0,0 +1,76 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepeat(t *testing.T) {
+	t.Parallel()
+
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    repeat = 3
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed          = "Dachshund"
+    repeat         = 2
+    sounds         = ["yips", "woofs", "howls"]
+    sound_strategy = "cycle"
+  }
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	for _, p := range config.Pets {
+		p.Say(out)
+		p.Say(out)
+	}
+	// Each repetition picks one of the pet's sounds.
+	assert.Equal(t, "Ink meow meow meow\nInk meow meow meow\n"+
+		"Swinney the Dachshund yips woofs\nSwinney the Dachshund howls yips\n", out.String())
+}
+
+func TestRepeatInvalid(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		repeat string
+		want   string
+	}{
+		{
+			name:   "negative",
+			repeat: "-1",
+			want:   "pets.hcl:4,14-16: Invalid repeat; The cat repeats its sound -1 times, expected from 1 to 10.",
+		},
+		{
+			name:   "too many",
+			repeat: "100",
+			want:   "pets.hcl:4,14-17: Invalid repeat; The cat repeats its sound 100 times, expected from 1 to 10.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := "pet \"Ink\" {\n  type = \"cat\"\n  characteristics {\n    repeat = " + tc.repeat + "\n  }\n}\n"
+			_, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
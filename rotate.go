@@ -0,0 +1,200 @@
+package petsounds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the time a rotated file was rotated at, which is
+// added to its name, as in run.log.2020-06-01T12-00-00.000. Names sort in
+// the order the files were rotated in.
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a file that is written to until it reaches MaxSize
+// bytes, or has been open for MaxAge, when it is renamed with the time it
+// was rotated at and a new file is started. Only the Keep most recent
+// rotated files are kept. The file is opened on the first write, and
+// appended to if it exists. It is safe for concurrent use.
+type RotatingFile struct {
+	// Path is the file written to.
+	Path string
+
+	// MaxSize, if above zero, is the size in bytes the file is rotated at.
+	// A single write larger than it gets a file of its own.
+	MaxSize int64
+
+	// MaxAge, if above zero, is the time after which the file is rotated,
+	// on the next write.
+	MaxAge time.Duration
+
+	// Keep, if above zero, is the number of rotated files kept, the oldest
+	// being removed first.
+	Keep int
+
+	// Clock is the time files are aged and rotated by. Without it, the
+	// system clock is used.
+	Clock Clock
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// Write writes p to the file, rotating it first if it is due.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := clockOrSystem(f.Clock).Now()
+	if f.file != nil && f.due(now, len(p)) {
+		if err := f.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	if f.file == nil {
+		if err := f.open(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("error in RotatingFile writing `%s`: %w", f.Path, err)
+	}
+	return n, nil
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("error in RotatingFile closing `%s`: %w", f.Path, err)
+	}
+	return nil
+}
+
+// due reports whether the file is due to be rotated at now, before n more
+// bytes are written to it.
+func (f *RotatingFile) due(now time.Time, n int) bool {
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(n) > f.MaxSize {
+		return true
+	}
+	return f.MaxAge > 0 && now.Sub(f.opened) >= f.MaxAge
+}
+
+// open opens the file to append to, at now.
+func (f *RotatingFile) open(now time.Time) error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error in RotatingFile opening `%s`: %w", f.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error in RotatingFile opening `%s`: %w", f.Path, err)
+	}
+	f.file, f.size, f.opened = file, info.Size(), now
+	return nil
+}
+
+// rotate closes the file and renames it with the time now, removing the
+// rotated files beyond Keep. The next write opens a new file.
+func (f *RotatingFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("error in RotatingFile closing `%s`: %w", f.Path, err)
+	}
+	f.file = nil
+	rotated := f.Path + "." + now.UTC().Format(rotatedTimeFormat)
+	// Files rotated within the same millisecond are told apart by a count.
+	for i := 1; fileExists(rotated); i++ {
+		rotated = f.Path + "." + now.UTC().Format(rotatedTimeFormat) + "." + strconv.Itoa(i)
+	}
+	if err := os.Rename(f.Path, rotated); err != nil {
+		return fmt.Errorf("error in RotatingFile rotating `%s`: %w", f.Path, err)
+	}
+	return f.prune()
+}
+
+// prune removes the oldest rotated files beyond Keep.
+func (f *RotatingFile) prune() error {
+	if f.Keep <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return fmt.Errorf("error in RotatingFile listing rotated files: %w", err)
+	}
+	rotated := []string{}
+	for _, m := range matches {
+		suffix := strings.TrimPrefix(m, f.Path+".")
+		if len(suffix) < len(rotatedTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(rotatedTimeFormat, suffix[:len(rotatedTimeFormat)]); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	sort.Strings(rotated)
+	for len(rotated) > f.Keep {
+		if err := os.Remove(rotated[0]); err != nil {
+			return fmt.Errorf("error in RotatingFile removing `%s`: %w", rotated[0], err)
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// fileExists reports whether there is a file at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// sizeFlag is a flag of a size in bytes, given as a number of bytes or
+// with a suffix of KB, MB or GB, which are powers of 1024.
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	n, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeFlag(n)
+	return nil
+}
+
+// parseSize parses a size in bytes, such as 512, 64KB, 10MB or 1GB.
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper, multiplier = strings.TrimSuffix(upper, unit.suffix), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(upper), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size `%s`, expected bytes or a size such as 10MB", s)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,122 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rotatedFiles returns the contents of the files in dir, by name.
+func rotatedFiles(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	infos, err := ioutil.ReadDir(dir)
+	if !assert.NoError(t, err) {
+		return files
+	}
+	for _, info := range infos {
+		src, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		assert.NoError(t, err)
+		files[info.Name()] = string(src)
+	}
+	return files
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pet-sounds-rotate")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &fakeClock{now: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)}
+	f := &RotatingFile{Path: filepath.Join(dir, "run.log"), MaxSize: 10, Keep: 2, Clock: clock}
+	for _, line := range []string{"meow\n", "purr\n", "woof\n", "a long howl\n", "yip\n"} {
+		_, err := f.Write([]byte(line))
+		assert.NoError(t, err)
+		clock.now = clock.now.Add(time.Second)
+	}
+	assert.NoError(t, f.Close())
+
+	// The file is rotated before a write would take it past MaxSize, and a
+	// write larger than MaxSize gets a file of its own. The oldest rotated
+	// file, of meow and purr, was removed.
+	assert.Equal(t, map[string]string{
+		"run.log":                         "yip\n",
+		"run.log.2020-06-01T12-00-03.000": "woof\n",
+		"run.log.2020-06-01T12-00-04.000": "a long howl\n",
+	}, rotatedFiles(t, dir))
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pet-sounds-rotate")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.log")
+	// An existing file is appended to.
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte("hiss\n"), 0644)) {
+		return
+	}
+
+	clock := &fakeClock{now: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)}
+	f := &RotatingFile{Path: path, MaxAge: time.Hour, Clock: clock}
+	for _, step := range []time.Duration{0, 30 * time.Minute, 30 * time.Minute, time.Minute} {
+		clock.now = clock.now.Add(step)
+		_, err := f.Write([]byte(clock.now.Format("15:04") + "\n"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+
+	files := rotatedFiles(t, dir)
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"run.log", "run.log.2020-06-01T13-00-00.000"}, names)
+	assert.Equal(t, "hiss\n12:00\n12:30\n", files["run.log.2020-06-01T13-00-00.000"])
+	assert.Equal(t, "13:00\n13:01\n", files["run.log"])
+}
+
+func TestParseSize(t *testing.T) {
+	tcs := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "512", want: 512},
+		{in: "64KB", want: 64 << 10},
+		{in: "10mb", want: 10 << 20},
+		{in: "1 GB", want: 1 << 30},
+		{in: "100B", want: 100},
+		{in: "MB", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "10TB", wantErr: true},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseSize(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
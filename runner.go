@@ -0,0 +1,405 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// The things a pet can do, as they are named in configuration.
+const (
+	eventSay = "say"
+	eventAct = "act"
+)
+
+// Runner executes the Say and Act behaviour of a set of pets. By default pets
+// are run one after another in declaration order, but Parallel can be raised
+// to spread them over a bounded pool of workers.
+type Runner struct {
+	// Out is where every pet writes its output. Writes are serialized by the
+	// Runner, so each line a pet writes reaches Out intact even when many
+	// pets are running at once.
+	Out io.Writer
+
+	// Parallel is the number of workers used to run pets. Values less than
+	// one are treated as one.
+	Parallel int
+
+	// Live, with Parallel above one, writes the lines of pets as they are
+	// written, starting each with the pet's name, instead of in the order
+	// the pets were declared.
+	Live bool
+
+	// Pace is the time to wait before running each pet after the first, so
+	// that their lines can be followed, or heard out with Audio and a
+	// Speaker. With Parallel above one, every worker waits it in turn.
+	Pace time.Duration
+
+	// Audio, if set, plays the sound of each pet through the speakers every
+	// time it Says something.
+	Audio *AudioPlayer
+
+	// Speaker, if set, reads aloud everything a pet Says, in the pet's voice.
+	Speaker Speaker
+
+	// MQTT, if set, publishes everything a pet Says and does to an MQTT
+	// broker.
+	MQTT *MQTTPublisher
+
+	// Notifiers send what pets Say and do to the webhooks of notify blocks.
+	Notifiers []*Notifier
+
+	// Log, if set, writes what pets Say and do to a file of its own.
+	Log *EventLog
+
+	// Style, if set, is the style interactions are written in. Pets are
+	// given their own style with SetStyle.
+	Style *Style
+
+	// Middleware wraps every pet the Runner runs, with the first
+	// middleware outermost. Conditions, audio, speech and hooks see the
+	// pets as they were, without it.
+	Middleware []PetMiddleware
+
+	// Hooks, if set, are called as each pet Says and Acts, and when it
+	// fails.
+	Hooks *Hooks
+
+	// State, if set, remembers what each pet does and the mood it is in.
+	State *StateStore
+
+	// Clock is the time events are recorded at. Without it, the system
+	// clock is used.
+	Clock Clock
+
+	// Rand is the source of the random choices of simulations and
+	// schedulers. Without it, a source seeded with the time the program
+	// started is used.
+	Rand rand.Source
+
+	// Warnings, if set, is where failed pet conditions with warning severity
+	// are reported.
+	Warnings io.Writer
+
+	// Spans, if set, records a span of each time a pet Says or Acts.
+	Spans SpanTracer
+}
+
+// Close closes the Runner's Audio, State, MQTT publisher and Log, and has
+// its Notifiers send their summaries. It returns the first error.
+func (r *Runner) Close() error {
+	var errs []error
+	if r.Audio != nil {
+		errs = append(errs, r.Audio.Close())
+	}
+	errs = append(errs, r.State.Close())
+	if r.MQTT != nil {
+		errs = append(errs, r.MQTT.Close())
+	}
+	for _, n := range r.Notifiers {
+		errs = append(errs, n.Close())
+	}
+	if r.Log != nil {
+		errs = append(errs, r.Log.Close())
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run calls Say and then Act on each pet, returning once every pet has
+// finished. A pet that fails does not stop the others, and the first failure
+// is returned.
+func (r *Runner) Run(pets []Pet) error {
+	return r.each(pets, func(p Pet, w io.Writer) error {
+		if err := r.do(p, w, eventSay); err != nil {
+			return err
+		}
+		return r.do(p, w, eventAct)
+	})
+}
+
+// Interact has each interaction take place, one at a time and in
+// declaration order. Interactions are run after the pets, so the interacting
+// pets have already had their say.
+func (r *Runner) Interact(interactions []*Interaction) {
+	for _, i := range interactions {
+		r.Style.interact(r.Out, i)
+	}
+}
+
+// do has p carry out event, either Saying or Acting. The pet's preconditions
+// are checked first, and if one fails with error severity the pet does
+// nothing. Its postconditions are checked afterwards.
+func (r *Runner) do(p Pet, w io.Writer, event string) error {
+	_, span := startSpan(context.Background(), r.Spans, spanEvent+event, petSpanAttributes(petIdentity(p)))
+	err := r.doEvent(p, w, event)
+	span.End(err)
+	if err != nil {
+		r.Hooks.error(p, err)
+	}
+	return err
+}
+
+// feed feeds p with f, writing that it was fed to w, and then has it Act.
+func (r *Runner) feed(p Pet, f *Feeding, w io.Writer) error {
+	name, petType := petIdentity(p)
+	r.Style.write(w, utterance{
+		pet: name, petType: petType, event: eventFeed, text: f.food(), who: name,
+	})
+	if err := r.State.record(p, eventFeed, f.food(), clockOrSystem(r.Clock).Now()); err != nil {
+		return err
+	}
+	return r.do(p, w, eventAct)
+}
+
+// doEvent is do without the Error hook.
+func (r *Runner) doEvent(p Pet, w io.Writer, event string) error {
+	if err := r.checkConditions(p, phasePrecondition, event); err != nil {
+		return err
+	}
+
+	// Keep a copy of what the pet writes for the Event hook, the state,
+	// MQTT and notifiers.
+	written := &bytes.Buffer{}
+	if (r.Hooks != nil && r.Hooks.Event != nil) || r.State != nil || r.MQTT != nil || len(r.Notifiers) > 0 || r.Log != nil {
+		w = io.MultiWriter(w, written)
+		defer func() { r.Hooks.event(p, event, strings.TrimSpace(written.String())) }()
+	}
+
+	if event == eventSay {
+		r.Hooks.beforeSay(p)
+		if err := r.say(p, w); err != nil {
+			return err
+		}
+	} else {
+		chain(p, r.Middleware).Act(w)
+		r.Hooks.afterAct(p)
+	}
+	if err := r.State.record(p, event, strings.TrimSpace(written.String()), clockOrSystem(r.Clock).Now()); err != nil {
+		return err
+	}
+	if r.MQTT != nil {
+		if err := r.MQTT.Publish(p, event, strings.TrimSpace(written.String())); err != nil {
+			return err
+		}
+	}
+	for _, n := range r.Notifiers {
+		if err := n.Notify(p, event, strings.TrimSpace(written.String())); err != nil {
+			return err
+		}
+	}
+	if r.Log != nil {
+		if err := r.Log.Write(p, event, strings.TrimSpace(written.String()), clockOrSystem(r.Clock).Now()); err != nil {
+			return err
+		}
+	}
+
+	return r.checkConditions(p, phasePostcondition, event)
+}
+
+// checkConditions checks the conditions of p for phase and event, reporting
+// warnings and returning any errors.
+func (r *Runner) checkConditions(p Pet, phase, event string) error {
+	diags := petConditions(p).Check(p, phase, event)
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagWarning && r.Warnings != nil {
+			fmt.Fprintf(r.Warnings, "pet-sounds warning: %s\n", diag.Error())
+		}
+	}
+	if diags.HasErrors() {
+		name, _ := petIdentity(p)
+		return fmt.Errorf("error in Runner checking %s of `%s`: %w", phase, name, diags)
+	}
+	return nil
+}
+
+// say has p Say something, playing its sound if the Runner has Audio and
+// reading it aloud if the Runner has a Speaker.
+func (r *Runner) say(p Pet, w io.Writer) error {
+	// Keep a copy of what the pet said for the Speaker.
+	said := &bytes.Buffer{}
+	chain(p, r.Middleware).Say(io.MultiWriter(w, said))
+
+	if r.Audio != nil {
+		if err := r.Audio.Play(p); err != nil {
+			return err
+		}
+	}
+	if r.Speaker != nil {
+		if err := r.Speaker.Speak(strings.TrimSpace(said.String()), petVoice(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// each calls fn for every pet on the Runner's worker pool, handing it the
+// shared, serialized output. With more than one worker, what each pet writes
+// is held back until the pets declared before it are done, so the output is
+// in declaration order, unless the Runner is Live. It returns the first
+// error returned by fn.
+func (r *Runner) each(pets []Pet, fn func(p Pet, w io.Writer) error) error {
+	pets = enabledPets(pets)
+	out := &syncWriter{w: r.Out}
+
+	workers := r.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pets) {
+		workers = len(pets)
+	}
+	seq := &sequencer{w: out, held: map[int][]byte{}}
+
+	// Feed the pets to the workers over an unbuffered channel, so no more
+	// than Parallel pets are ever in flight.
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	var started int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				p := pets[i]
+				if r.Pace > 0 && atomic.AddInt32(&started, 1) > 1 {
+					time.Sleep(r.Pace)
+				}
+				var w io.Writer = out
+				var held *bytes.Buffer
+				switch {
+				case workers > 1 && r.Live:
+					w = r.livePrefix(p, out)
+				case workers > 1:
+					held = &bytes.Buffer{}
+					w = held
+				}
+				if err := fn(p, w); err != nil {
+					errOnce.Do(func() { firstErr = err })
+				}
+				if held != nil {
+					seq.done(i, held.Bytes())
+				}
+			}
+		}()
+	}
+
+	for i := range pets {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return firstErr
+}
+
+// livePrefix returns the writer p writes to when the Runner is Live, which
+// starts each of its lines with the pet's name so that the lines of pets
+// running at once can be told apart. NDJSON events already name their pet.
+func (r *Runner) livePrefix(p Pet, w io.Writer) io.Writer {
+	if r.Style != nil && r.Style.NDJSON {
+		return w
+	}
+	name, _ := petIdentity(p)
+	return &prefixWriter{w: w, prefix: "[" + name + "] ", start: true}
+}
+
+// sequencer writes what pets running at once wrote to w in the order the
+// pets were declared, as soon as every pet before them is done.
+type sequencer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	next int
+	held map[int][]byte
+}
+
+// done records that the pet at index i is done, having written written.
+func (s *sequencer) done(i int, written []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held[i] = written
+	for {
+		p, ok := s.held[s.next]
+		if !ok {
+			return
+		}
+		s.w.Write(p)
+		delete(s.held, s.next)
+		s.next++
+	}
+}
+
+// prefixWriter starts each line written to it with prefix. Each Write is
+// passed on in a single Write, so it reaches a syncWriter whole.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	// start is whether the next byte written starts a line.
+	start bool
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	var b bytes.Buffer
+	for _, c := range p {
+		if pw.start {
+			b.WriteString(pw.prefix)
+		}
+		b.WriteByte(c)
+		pw.start = c == '\n'
+	}
+	if _, err := pw.w.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// enabledPets returns the pets that are not disabled. Disabled pets are
+// decoded and validated like any other, but never run.
+func enabledPets(pets []Pet) []Pet {
+	enabled := []Pet{}
+	for _, p := range pets {
+		switch pet := unwrapPet(p).(type) {
+		case *Cat:
+			if pet.Disabled {
+				continue
+			}
+		case *Dog:
+			if pet.Disabled {
+				continue
+			}
+		case *CustomPet:
+			if pet.Disabled {
+				continue
+			}
+		}
+		enabled = append(enabled, p)
+	}
+	return enabled
+}
+
+// syncWriter is an io.Writer that allows only one Write at a time to reach
+// the underlying writer. Pets write whole lines per call, so this is enough to
+// keep their output from interleaving mid-line.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
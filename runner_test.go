@@ -0,0 +1,195 @@
+package petsounds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingPet is a Pet that records how many pets are running at the same
+// time, so tests can check the bounds of the worker pool.
+type countingPet struct {
+	name    string
+	mu      *sync.Mutex
+	running *int
+	peak    *int
+}
+
+func (c *countingPet) Say(w io.Writer) {
+	c.mu.Lock()
+	*c.running++
+	if *c.running > *c.peak {
+		*c.peak = *c.running
+	}
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+	fmt.Fprintf(w, "%s says\n", c.name)
+}
+
+func (c *countingPet) Act(w io.Writer) {
+	fmt.Fprintf(w, "%s acts\n", c.name)
+
+	c.mu.Lock()
+	*c.running--
+	c.mu.Unlock()
+}
+
+func TestRunner(t *testing.T) {
+
+	tcs := []struct {
+		name     string
+		parallel int
+		pets     int
+		maxPeak  int
+	}{
+		{
+			name:     "sequential",
+			parallel: 1,
+			pets:     4,
+			maxPeak:  1,
+		},
+		{
+			name:     "zero is sequential",
+			parallel: 0,
+			pets:     3,
+			maxPeak:  1,
+		},
+		{
+			name:     "bounded pool",
+			parallel: 3,
+			pets:     12,
+			maxPeak:  3,
+		},
+		{
+			name:     "more workers than pets",
+			parallel: 8,
+			pets:     2,
+			maxPeak:  2,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var running, peak int
+			pets := []Pet{}
+			want := []string{}
+			for i := 0; i < tc.pets; i++ {
+				name := fmt.Sprintf("pet%d", i)
+				pets = append(pets, &countingPet{name: name, mu: &mu, running: &running, peak: &peak})
+				want = append(want, name+" says", name+" acts")
+			}
+
+			out := &bytes.Buffer{}
+			runner := &Runner{Out: out, Parallel: tc.parallel}
+			runner.Run(pets)
+
+			// However many workers there are, the output is in declaration
+			// order.
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			assert.Equal(t, want, got)
+			assert.LessOrEqual(t, peak, tc.maxPeak)
+		})
+	}
+}
+
+func TestRunnerDisabledPets(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name        string
+		includeDogs string
+		want        string
+	}{
+		{
+			name:        "enabled",
+			includeDogs: "true",
+			want:        "Ink meow\nInk snoozes\nSwinney the mutt barks\nSwinney the mutt plays\n",
+		},
+		{
+			name:        "disabled",
+			includeDogs: "false",
+			want:        "Ink meow\nInk snoozes\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Disabled pets are still decoded, so they are still validated.
+			pets := mustParseEnv(t, `
+pet "Ink" {
+  type = "cat"
+}
+
+pet "Swinney" {
+  type    = "dog"
+  enabled = env.INCLUDE_DOGS == "true"
+}
+`, map[string]string{"INCLUDE_DOGS": tc.includeDogs})
+			assert.Len(t, pets, 2)
+
+			out := &bytes.Buffer{}
+			assert.NoError(t, (&Runner{Out: out}).Run(pets))
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestRunnerPace(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	runner := &Runner{Out: out, Pace: 20 * time.Millisecond}
+	start := time.Now()
+	err := runner.Run([]Pet{
+		&Cat{Name: "Ink", Sound: "meow"},
+		&Cat{Name: "Peanut", Sound: "mew"},
+		&Cat{Name: "Pepper", Sound: "purr"},
+	})
+	assert.NoError(t, err)
+	// There is a pause between each pet and the next.
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 40*time.Millisecond, "ran in %s", elapsed)
+	assert.Equal(t, "Ink meow\nInk snoozes\nPeanut mew\nPeanut snoozes\nPepper purr\nPepper snoozes\n", out.String())
+}
+
+func TestRunnerLive(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"Ink", "Peanut", "Pepper"}
+	pets := []Pet{}
+	for _, name := range names {
+		pets = append(pets, &Cat{Name: name, Sound: "meow"})
+	}
+
+	out := &bytes.Buffer{}
+	runner := &Runner{Out: out, Parallel: 3, Live: true}
+	assert.NoError(t, runner.Run(pets))
+
+	// Lines are written as they happen, so only what each pet wrote is in
+	// order.
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, got, 6)
+	for _, name := range names {
+		lines := []string{}
+		for _, line := range got {
+			if strings.HasPrefix(line, "["+name+"] ") {
+				lines = append(lines, line)
+			}
+		}
+		assert.Equal(t, []string{"[" + name + "] " + name + " meow", "[" + name + "] " + name + " snoozes"}, lines)
+	}
+}
@@ -0,0 +1,175 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// eventFeed is the event of a pet being fed, in NDJSON output.
+const eventFeed = "feed"
+
+// The catch-up policies of a Scheduler, for feeding times that were missed
+// while it was not running, as when the machine was asleep.
+const (
+	// catchUpOnce feeds a pet once for all the feeding times it missed.
+	catchUpOnce = "once"
+	// catchUpAll feeds a pet once for each feeding time it missed.
+	catchUpAll = "all"
+	// catchUpSkip only feeds a pet at feeding times that were not missed.
+	catchUpSkip = "skip"
+)
+
+// Scheduler stays resident, feeding each pet at the times of its feeding
+// blocks. A fed pet then Acts, so its conditions and hooks apply as they do
+// for any other run.
+type Scheduler struct {
+	// Runner runs the pets that are due, so a Scheduler shares its output
+	// and worker pool settings.
+	Runner
+
+	// Jitter, if positive, delays each feeding by a random time up to
+	// Jitter, so that many schedulers started from the same configuration
+	// don't all run at once.
+	Jitter time.Duration
+
+	// CatchUp is what to do about feeding times that were missed, one of
+	// once, all or skip. Empty is once. Missed feeding times are reported
+	// to Warnings.
+	CatchUp string
+
+	// after waits on the Runner's Clock, time.After if nil.
+	after func(d time.Duration) <-chan time.Time
+}
+
+func (s *Scheduler) clock() time.Time {
+	return clockOrSystem(s.Clock).Now()
+}
+
+func (s *Scheduler) wait(d time.Duration) <-chan time.Time {
+	if s.after == nil {
+		return time.After(d)
+	}
+	return s.after(d)
+}
+
+// Run feeds pets at their feeding times until ctx is cancelled. A cancelled
+// context is a clean shutdown rather than an error: pets being fed are
+// allowed to finish and Run returns nil.
+func (s *Scheduler) Run(ctx context.Context, pets []Pet) error {
+	switch s.CatchUp {
+	case "", catchUpOnce, catchUpAll, catchUpSkip:
+	default:
+		return fmt.Errorf(
+			"error in Scheduler.Run: unknown catch-up policy `%s`, expected once, all or skip", s.CatchUp,
+		)
+	}
+
+	// Pets are fed from when the scheduler starts, or, with a State, from
+	// when they were last fed, catching up on the feedings they missed in
+	// between runs.
+	pets = enabledPets(pets)
+	random := newLockedRand(s.Rand)
+	start := s.clock()
+	last := map[Pet]time.Time{}
+	for _, p := range pets {
+		name, _ := petIdentity(p)
+		last[p] = start
+		if fed := s.State.Pet(name).LastFed; !fed.IsZero() && fed.Before(start) {
+			last[p] = fed
+		}
+	}
+
+	for {
+		next := time.Time{}
+		for _, p := range pets {
+			for _, f := range petFeedings(p) {
+				if t := f.Schedule.Next(last[p]); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+					next = t
+				}
+			}
+		}
+		if next.IsZero() {
+			return fmt.Errorf("error in Scheduler.Run: no pet has a feeding time")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.wait(next.Sub(s.clock())):
+		}
+
+		now := s.clock()
+		due := s.due(pets, last, now)
+		for _, p := range pets {
+			last[p] = now
+		}
+		if s.Jitter > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-s.wait(time.Duration(random.Int63n(int64(s.Jitter)))):
+			}
+		}
+
+		err := s.each(pets, func(p Pet, w io.Writer) error {
+			for _, f := range due[p] {
+				if err := s.feed(p, f, w); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error in Scheduler.Run at %s: %w", now.Format(feedingTimeFormat), err)
+		}
+	}
+}
+
+// due returns the feedings of each of pets to carry out for the feeding
+// times after the pet's last feeding time, up to and including now,
+// following the catch-up policy. A feeding appears once for each time the
+// pet is to be fed.
+func (s *Scheduler) due(pets []Pet, last map[Pet]time.Time, now time.Time) map[Pet][]*Feeding {
+	due := map[Pet][]*Feeding{}
+	for _, p := range pets {
+		for _, f := range petFeedings(p) {
+			times := []time.Time{}
+			for t := f.Schedule.Next(last[p]); !t.IsZero() && !t.After(now); t = f.Schedule.Next(t) {
+				times = append(times, t)
+			}
+			if len(times) == 0 {
+				continue
+			}
+
+			// The latest time is on time if it came in the last minute, the
+			// others were missed.
+			missed := len(times) - 1
+			onTime := now.Sub(times[len(times)-1]) < time.Minute
+			if !onTime {
+				missed++
+			}
+
+			feeds := 1
+			switch s.CatchUp {
+			case catchUpAll:
+				feeds = len(times)
+			case catchUpSkip:
+				feeds = 0
+				if onTime {
+					feeds = 1
+				}
+			}
+			if missed > 0 && s.Warnings != nil {
+				name, _ := petIdentity(p)
+				fmt.Fprintf(s.Warnings, "pet-sounds warning: %s missed %d feeding time(s) for %s, feeding %d time(s)\n",
+					name, missed, f.food(), feeds)
+			}
+			for i := 0; i < feeds; i++ {
+				due[p] = append(due[p], f)
+			}
+		}
+	}
+	return due
+}
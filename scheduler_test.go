@@ -0,0 +1,118 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is the clock of a Scheduler under test. Each wait passes the
+// time waited for at once, plus oversleep the first time, as though the
+// machine had been asleep. Once wakes have passed it cancels the
+// scheduler.
+type fakeClock struct {
+	now       time.Time
+	oversleep time.Duration
+	wakes     int
+	cancel    func()
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) after(d time.Duration) <-chan time.Time {
+	if c.wakes == 0 {
+		c.cancel()
+		return nil
+	}
+	c.wakes--
+	if d < 0 {
+		d = 0
+	}
+	c.now = c.now.Add(d + c.oversleep)
+	c.oversleep = 0
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestScheduler(t *testing.T) {
+	tcs := []struct {
+		name      string
+		catchUp   string
+		oversleep time.Duration
+		wakes     int
+		want      string
+		warning   string
+	}{
+		{
+			name:  "on time",
+			wakes: 2,
+			want:  "Ink eats kibble\nInk snoozes\nInk eats kibble\nInk snoozes\n",
+		},
+		{
+			name:      "missed once",
+			oversleep: 25 * time.Hour,
+			wakes:     1,
+			want:      "Ink eats kibble\nInk snoozes\n",
+			warning:   "pet-sounds warning: Ink missed 3 feeding time(s) for kibble, feeding 1 time(s)\n",
+		},
+		{
+			name:      "missed all",
+			catchUp:   catchUpAll,
+			oversleep: 25 * time.Hour,
+			wakes:     1,
+			want:      strings.Repeat("Ink eats kibble\nInk snoozes\n", 3),
+			warning:   "pet-sounds warning: Ink missed 3 feeding time(s) for kibble, feeding 3 time(s)\n",
+		},
+		{
+			name:      "missed skip",
+			catchUp:   catchUpSkip,
+			oversleep: 25 * time.Hour,
+			wakes:     1,
+			warning:   "pet-sounds warning: Ink missed 3 feeding time(s) for kibble, feeding 0 time(s)\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			feedings, err := NewFeedings([]*FeedingHCL{{Schedule: "0 8,18 * * *", Food: "kibble"}})
+			if !assert.NoError(t, err) {
+				return
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			clock := &fakeClock{
+				now: cronTime(t, "2020-08-01 07:59"), oversleep: tc.oversleep, wakes: tc.wakes, cancel: cancel,
+			}
+
+			out, warnings := &bytes.Buffer{}, &bytes.Buffer{}
+			sched := &Scheduler{
+				Runner:  Runner{Out: out, Warnings: warnings, Clock: clock},
+				CatchUp: tc.catchUp,
+				after:   clock.after,
+			}
+			assert.NoError(t, sched.Run(ctx, []Pet{&Cat{Name: "Ink", Feedings: feedings}}))
+			assert.Equal(t, tc.want, out.String())
+			assert.Equal(t, tc.warning, warnings.String())
+		})
+	}
+}
+
+func TestSchedulerErrors(t *testing.T) {
+	t.Parallel()
+
+	err := (&Scheduler{CatchUp: "never"}).Run(context.Background(), nil)
+	assert.EqualError(t, err, "error in Scheduler.Run: unknown catch-up policy `never`, expected once, all or skip")
+
+	err = (&Scheduler{}).Run(context.Background(), []Pet{&Cat{Name: "Ink"}})
+	assert.EqualError(t, err, "error in Scheduler.Run: no pet has a feeding time")
+}
@@ -0,0 +1,189 @@
+package petsounds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+const (
+	// schemaVersionKey is the top-level attribute that declares which version
+	// of the configuration format a file is written in. Files without it are
+	// assumed to be version 1, the original format.
+	schemaVersionKey = "schema_version"
+
+	latestSchemaVersion = 2
+)
+
+// schemaRename renames an attribute, or a block type, that has changed name
+// between two versions of the configuration format.
+type schemaRename struct {
+	// path is the block types leading from the root of the file to the body
+	// that holds the renamed attribute or block.
+	path     []string
+	from, to string
+	block    bool
+}
+
+// schemaMigrations upgrade a configuration file from one version of the
+// format to the next. schemaMigrations[v] upgrades version v to v+1.
+// Version 2 added schema_version itself and renamed nothing, so a file of
+// version 1 reads the same in version 2. The renames of later versions are
+// added here.
+var schemaMigrations = map[int][]schemaRename{}
+
+// deprecations are attributes and blocks that have been renamed within a
+// schema version. Files that use the old names still decode, in any
+// version, with a warning pointing at each use that names the replacement.
+var deprecations = characteristicRenames("noise", "sound")
+
+// characteristicRenames returns the renames of the characteristic from to
+// to in every body that sets characteristics.
+func characteristicRenames(from, to string) []schemaRename {
+	renames := []schemaRename{}
+	for _, path := range [][]string{
+		{"pet", "characteristics"},
+		{"pet_template", "characteristics"},
+		{"household", "pet", "characteristics"},
+		{"defaults", "cat"},
+		{"defaults", "dog"},
+		{"household", "defaults", "cat"},
+		{"household", "defaults", "dog"},
+	} {
+		renames = append(renames, schemaRename{path: path, from: from, to: to})
+	}
+	return renames
+}
+
+// migrateSchema upgrades body, in memory, from the version of the format it
+// declares to the latest one, and renames the deprecated attributes and
+// blocks of the latest version. Each deprecated attribute or block that is
+// migrated produces a warning pointing at it.
+func migrateSchema(body *hclsyntax.Body) hcl.Diagnostics {
+	return applyMigrations(body, schemaMigrations, deprecations)
+}
+
+// applyMigrations is migrateSchema with the migrations between versions and
+// the deprecations of the latest version given.
+func applyMigrations(body *hclsyntax.Body, migrations map[int][]schemaRename, deprecated []schemaRename) hcl.Diagnostics {
+	version, diags := schemaVersion(body)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for ; version < latestSchemaVersion; version++ {
+		for _, rename := range migrations[version] {
+			diags = append(diags, rename.apply(body, rename.path, version+1)...)
+		}
+	}
+	for _, rename := range deprecated {
+		diags = append(diags, rename.apply(body, rename.path, 0)...)
+	}
+	return diags
+}
+
+// schemaVersion returns the version of the format body declares.
+func schemaVersion(body *hclsyntax.Body) (int, hcl.Diagnostics) {
+	attr, ok := body.Attributes[schemaVersionKey]
+	if !ok {
+		return 1, nil
+	}
+
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return 0, diags
+	}
+	var version int
+	if err := gocty.FromCtyValue(val, &version); err != nil || val.Type() != cty.Number {
+		return 0, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid schema version",
+			Detail:   "The schema_version must be a whole number.",
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	if version < 1 || version > latestSchemaVersion {
+		return 0, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported schema version",
+			Detail: fmt.Sprintf(
+				"This version of pet-sounds supports schema versions 1 to %d, got %d.",
+				latestSchemaVersion, version,
+			),
+			Subject: attr.Expr.Range().Ptr(),
+		}}
+	}
+	return version, nil
+}
+
+// apply applies the rename, made in schema version, to every body reached by
+// following path from body. Renames of version 0 are deprecations, made
+// within the latest version.
+func (r schemaRename) apply(body *hclsyntax.Body, path []string, version int) hcl.Diagnostics {
+	if len(path) > 0 {
+		diags := hcl.Diagnostics{}
+		for _, block := range body.Blocks {
+			if block.Type == path[0] {
+				diags = append(diags, r.apply(block.Body, path[1:], version)...)
+			}
+		}
+		return diags
+	}
+
+	where := strings.Join(r.path, " ")
+	if r.block {
+		diags := hcl.Diagnostics{}
+		for _, block := range body.Blocks {
+			if block.Type != r.from {
+				continue
+			}
+			block.Type = r.to
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Deprecated block",
+				Detail:   r.renamed("block", version),
+				Subject:  block.TypeRange.Ptr(),
+			})
+		}
+		return diags
+	}
+
+	attr, ok := body.Attributes[r.from]
+	if !ok {
+		return nil
+	}
+	if _, clash := body.Attributes[r.to]; clash {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Conflicting attributes",
+			Detail: fmt.Sprintf(
+				"The %s attributes `%s` and `%s` are the same attribute under different names; remove `%s`.",
+				where, r.from, r.to, r.from,
+			),
+			Subject: attr.NameRange.Ptr(),
+		}}
+	}
+	delete(body.Attributes, r.from)
+	attr.Name = r.to
+	body.Attributes[r.to] = attr
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagWarning,
+		Summary:  "Deprecated attribute",
+		Detail:   r.renamed("attribute", version),
+		Subject:  attr.NameRange.Ptr(),
+	}}
+}
+
+// renamed describes the rename, of an attribute or a block, made in schema
+// version.
+func (r schemaRename) renamed(what string, version int) string {
+	where := strings.Join(r.path, " ")
+	if version == 0 {
+		return fmt.Sprintf("The %s %s `%s` is deprecated; use `%s` instead.", where, what, r.from, r.to)
+	}
+	return fmt.Sprintf("The %s %s `%s` has been renamed to `%s` in schema version %d.", where, what, r.from, r.to, version)
+}
@@ -0,0 +1,182 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMigrations rename the made up characteristic legacy_sound to sound in
+// version 2, to test migrating files of older versions.
+var testMigrations = map[int][]schemaRename{
+	1: {{path: []string{"pet", "characteristics"}, from: "legacy_sound", to: "sound"}},
+}
+
+func TestMigrateSchema(t *testing.T) {
+
+	tcs := []struct {
+		name          string
+		src           string
+		wantSummaries []string
+		wantErr       bool
+	}{
+		{
+			name: "latest",
+			src: `schema_version = 2
+pet "Ink" {
+  characteristics {
+    sound = "meow"
+  }
+}`,
+		},
+		{
+			name: "implicit version 1",
+			src: `pet "Ink" {
+  characteristics {
+    legacy_sound = "meow"
+  }
+}`,
+			wantSummaries: []string{"Deprecated attribute"},
+		},
+		{
+			name: "conflicting attributes",
+			src: `schema_version = 1
+pet "Ink" {
+  characteristics {
+    legacy_sound = "meow"
+    sound        = "meow"
+  }
+}`,
+			wantSummaries: []string{"Conflicting attributes"},
+			wantErr:       true,
+		},
+		{
+			name: "deprecated attribute",
+			src: `schema_version = 2
+
+pet "Ink" {
+  characteristics {
+    noise = "meow"
+  }
+}
+
+defaults {
+  dog {
+    noise = "woof"
+  }
+}`,
+			wantSummaries: []string{"Deprecated attribute", "Deprecated attribute"},
+		},
+		{
+			name:          "unsupported version",
+			src:           `schema_version = 3`,
+			wantSummaries: []string{"Unsupported schema version"},
+			wantErr:       true,
+		},
+		{
+			name:          "invalid version",
+			src:           `schema_version = "two"`,
+			wantSummaries: []string{"Invalid schema version"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, diags := hclsyntax.ParseConfig([]byte(tc.src), "test.hcl", hcl.InitialPos)
+			if !assert.False(t, diags.HasErrors(), diags.Error()) {
+				return
+			}
+
+			diags = applyMigrations(file.Body.(*hclsyntax.Body), testMigrations, deprecations)
+			summaries := []string{}
+			for _, diag := range diags {
+				summaries = append(summaries, diag.Summary)
+			}
+			assert.ElementsMatch(t, tc.wantSummaries, summaries)
+			assert.Equal(t, tc.wantErr, diags.HasErrors())
+		})
+	}
+}
+
+func TestSchemaRenameBlock(t *testing.T) {
+	src := `pet "Ink" {
+  mood {
+    initial = "sleepy"
+  }
+}`
+	file, diags := hclsyntax.ParseConfig([]byte(src), "test.hcl", hcl.InitialPos)
+	if !assert.False(t, diags.HasErrors(), diags.Error()) {
+		return
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	rename := schemaRename{path: []string{"pet"}, from: "mood", to: "moods", block: true}
+	diags = rename.apply(body, rename.path, 2)
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+		assert.Equal(t, "The pet block `mood` has been renamed to `moods` in schema version 2.", diags[0].Detail)
+		assert.Equal(t, 2, diags[0].Subject.Start.Line)
+	}
+	assert.Equal(t, "moods", body.Blocks[0].Body.Blocks[0].Type)
+}
+
+func TestLoadConfigSchema(t *testing.T) {
+
+	tcs := []struct {
+		name         string
+		filename     string
+		wantWarnings int
+	}{
+		{
+			name:         "version 1",
+			filename:     "testdata/schema_v1.hcl",
+			wantWarnings: 0,
+		},
+		{
+			name:         "version 2",
+			filename:     "testdata/schema_v2.hcl",
+			wantWarnings: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := LoadConfig(tc.filename)
+			if assert.Nil(t, err, "error while parsing input") {
+				assert.Len(t, config.Warnings, tc.wantWarnings)
+				if assert.Len(t, config.Pets, 1) {
+					assert.Equal(t, "testdata/meow.wav", config.Pets[0].(*Cat).AudioFile)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecatedAttribute(t *testing.T) {
+	t.Parallel()
+
+	config, err := DecodeConfig([]byte(`pet "Ink" {
+  type = "cat"
+  characteristics {
+    noise = "purr"
+  }
+}
+`), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "purr", config.Pets[0].(*Cat).Sound)
+	if assert.Len(t, config.Warnings, 1) {
+		assert.Equal(t, "pets.hcl:4,5-10: Deprecated attribute; "+
+			"The pet characteristics attribute `noise` is deprecated; use `sound` instead.", config.Warnings[0].Error())
+	}
+}
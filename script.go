@@ -0,0 +1,171 @@
+package petsounds
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// The limits of a behavior script, for each time it is run, unless its
+// pet_type block sets its own.
+const (
+	defaultScriptMaxSteps = 100000
+	defaultScriptTimeout  = time.Second
+)
+
+// petScript is the behavior script of a pet type, a Starlark program such as
+//   def say(pet):
+//       return pet.name + " squeaks"
+//
+//   def act(pet):
+//       return pet.name + " runs " + str(pet.characteristics["laps"]) + " laps"
+// Its say and act functions are called with the pet each time it Says or
+// Acts, and return the line the pet writes. Scripts are sandboxed: they
+// can't load other files or print, and each run is stopped after maxSteps
+// steps or once timeout has passed.
+type petScript struct {
+	path     string
+	say, act starlark.Callable
+	maxSteps uint64
+	timeout  time.Duration
+}
+
+// loadPetScript runs the script at path, which must define say and act
+// functions, within the limits of maxSteps and timeout.
+func loadPetScript(path string, maxSteps uint64, timeout time.Duration) (*petScript, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &petScript{path: path, maxSteps: maxSteps, timeout: timeout}
+	thread, stop := s.thread()
+	globals, err := starlark.ExecFile(thread, path, src, nil)
+	stop()
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	for _, fn := range []struct {
+		name string
+		to   *starlark.Callable
+	}{{"say", &s.say}, {"act", &s.act}} {
+		callable, ok := globals[fn.name].(starlark.Callable)
+		if !ok {
+			return nil, fmt.Errorf("%s defines no %s function", path, fn.name)
+		}
+		*fn.to = callable
+	}
+	return s, nil
+}
+
+// thread returns a thread to run the script on within its limits, and the
+// function to call once it is done.
+func (s *petScript) thread() (*starlark.Thread, func()) {
+	thread := &starlark.Thread{Name: s.path, Print: func(*starlark.Thread, string) {}}
+	thread.SetMaxExecutionSteps(s.maxSteps)
+	timer := time.AfterFunc(s.timeout, func() {
+		thread.Cancel(fmt.Sprintf("timed out after %s", s.timeout))
+	})
+	return thread, func() {
+		timer.Stop()
+	}
+}
+
+// line calls fn, the script's say or act function, with pet and returns the
+// line it makes.
+func (s *petScript) line(fn starlark.Callable, pet starlark.Value) (string, error) {
+	thread, stop := s.thread()
+	defer stop()
+	val, err := starlark.Call(thread, fn, starlark.Tuple{pet}, nil)
+	if err != nil {
+		return "", scriptError(err)
+	}
+	line, ok := starlark.AsString(val)
+	if !ok {
+		return "", fmt.Errorf("%s: %s returned %s, expected a string", s.path, fn.Name(), val.Type())
+	}
+	return line, nil
+}
+
+// scriptError returns err, an error running a script, with where in the
+// script it happened.
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) && len(evalErr.CallStack) > 0 {
+		return fmt.Errorf("%s: %s", evalErr.CallStack.At(0).Pos, evalErr.Msg)
+	}
+	return err
+}
+
+// starlarkPet returns the pet as the scripts of its type see it: a struct of
+// its name, type, mood, tags and characteristics. Sensitive characteristics
+// are "(sensitive)", so the lines the script makes don't hold their values.
+func starlarkPet(c *CustomPet) starlark.Value {
+	tags := []starlark.Value{}
+	for _, t := range c.Tags {
+		tags = append(tags, starlark.String(t))
+	}
+	names := []string{}
+	for name := range c.characteristics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	characteristics := starlark.NewDict(len(names))
+	for _, name := range names {
+		value := starlarkValue(c.characteristics[name])
+		if c.sensitive[name] {
+			value = starlark.String(sensitiveText)
+		}
+		characteristics.SetKey(starlark.String(name), value)
+	}
+	return starlarkstruct.FromStringDict(starlark.String("pet"), starlark.StringDict{
+		"name":            starlark.String(c.Name),
+		"type":            starlark.String(c.petType),
+		"mood":            starlark.String(string(c.Moods.Mood())),
+		"tags":            starlark.NewList(tags),
+		"characteristics": characteristics,
+	})
+}
+
+// starlarkValue returns v as a Starlark value. Lists, sets and tuples
+// become lists, and maps and objects dicts.
+func starlarkValue(v cty.Value) starlark.Value {
+	if v.IsNull() || !v.IsKnown() {
+		return starlark.None
+	}
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		return starlark.String(v.AsString())
+	case ty == cty.Bool:
+		return starlark.Bool(v.True())
+	case ty == cty.Number:
+		n := v.AsBigFloat()
+		if n.IsInt() {
+			i, _ := n.Int(nil)
+			return starlark.MakeBigInt(i)
+		}
+		f, _ := n.Float64()
+		return starlark.Float(f)
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		elems := []starlark.Value{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			elems = append(elems, starlarkValue(elem))
+		}
+		return starlark.NewList(elems)
+	case ty.IsMapType() || ty.IsObjectType():
+		dict := starlark.NewDict(v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			dict.SetKey(starlark.String(key.AsString()), starlarkValue(elem))
+		}
+		return dict
+	}
+	return starlark.None
+}
@@ -0,0 +1,41 @@
+package petsounds
+
+import (
+	"math/rand"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// seedKey is the attribute that seeds the randomness of a configuration, so
+// that the file alone determines what its pets say:
+//   seed = 42
+const seedKey = "seed"
+
+// seedRandom sets the random source of opts to one seeded with the seed
+// attribute of body, if it has one. A source given in opts.Rand, such as
+// the one of the -seed flag, takes precedence over the file's seed.
+func seedRandom(body *hclsyntax.Body, opts *LoadOptions) hcl.Diagnostics {
+	attr, ok := body.Attributes[seedKey]
+	if !ok || opts.Rand != nil {
+		return nil
+	}
+
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return diags
+	}
+	var seed int64
+	if err := gocty.FromCtyValue(val, &seed); err != nil || val.Type() != cty.Number {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid seed",
+			Detail:   "The seed must be a whole number.",
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	opts.random = newLockedRand(rand.NewSource(seed))
+	return nil
+}
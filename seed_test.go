@@ -0,0 +1,50 @@
+package petsounds
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+
+	src := `seed = 42
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = random("a", "b", "c", "d", "e", "f", "g", "h")
+    sounds = ["mew", "purr", "chirp", "hiss"]
+  }
+}
+`
+	say := func(opts LoadOptions) string {
+		config, err := DecodeConfig([]byte(src), "pets.hcl", opts)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		out := &bytes.Buffer{}
+		cat := config.Pets[0].(*Cat)
+		for i := 0; i < 8; i++ {
+			cat.Say(out)
+		}
+		return cat.Sound + "\n" + out.String()
+	}
+
+	// The file's seed makes every run the same, and a source of the
+	// caller's own replaces it.
+	seeded := say(LoadOptions{})
+	assert.Equal(t, seeded, say(LoadOptions{}))
+	assert.Equal(t, seeded, say(LoadOptions{Rand: rand.NewSource(42)}))
+	assert.NotEqual(t, seeded, say(LoadOptions{Rand: rand.NewSource(7)}))
+}
+
+func TestSeedInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecodeConfig([]byte(`seed = "forty-two"`), "pets.hcl", LoadOptions{})
+	assert.EqualError(t, err, "error in DecodeConfig seeding random: pets.hcl:1,8-19: Invalid seed; The seed must be a whole number.")
+}
@@ -0,0 +1,109 @@
+package petsounds
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// sensitiveText is written in place of sensitive values.
+const sensitiveText = "(sensitive)"
+
+// valueMark is the type of the cty marks pet-sounds puts on values.
+type valueMark string
+
+// sensitiveMark marks the values the sensitive function returns, such as
+// microchip numbers. Marks go wherever the values do as expressions are
+// evaluated, so a characteristic that reads a sensitive value is sensitive
+// too:
+//   sound = "chip ${sensitive(vault("secret/pets", "ink_chip"))}"
+// Pets keep the names of their sensitive characteristics, and write
+// "(sensitive)" in place of them.
+const sensitiveMark = valueMark("sensitive")
+
+// sensitiveFunctions are the functions of the contexts that values marked
+// sensitive can be evaluated in: those of the characteristics, validations
+// and conditions of pets, and the templates of pet types. Elsewhere, as in
+// the blocks decoded into Go structs, which can't hold marks, the sensitive
+// function returns its argument as it is.
+var sensitiveFunctions = map[string]function.Function{
+	"sensitive": newSensitiveFunc(true),
+}
+
+// newSensitiveFunc returns a function that returns its argument, marked as
+// sensitive if mark is set.
+func newSensitiveFunc(mark bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "value", Type: cty.DynamicPseudoType, AllowMarked: true},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[0].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if !mark {
+				return args[0], nil
+			}
+			return args[0].Mark(sensitiveMark), nil
+		},
+	})
+}
+
+// isSensitive returns whether v, or any value in it, is marked sensitive.
+func isSensitive(v cty.Value) bool {
+	_, marks := v.UnmarkDeep()
+	_, ok := marks[sensitiveMark]
+	return ok
+}
+
+// redactDiagnostics hides the values of the sensitive characteristics of p
+// set in body from diags, replacing the detail of each diagnostic about one
+// of them, which can quote the value.
+func redactDiagnostics(diags hcl.Diagnostics, body hcl.Body, p Pet) hcl.Diagnostics {
+	sensitive := fieldsOf(p).sensitive
+	if sensitive == nil {
+		return diags
+	}
+	for name := range *sensitive {
+		rng := attributeRange(body, name)
+		if rng == nil {
+			continue
+		}
+		for _, diag := range diags {
+			if diag.Subject != nil && *diag.Subject == *rng {
+				diag.Detail = fmt.Sprintf("The %s is sensitive, so its value is not shown.", name)
+			}
+		}
+	}
+	return diags
+}
+
+// setSensitive records in the sensitive characteristics of a pet whether the
+// one named name is. Pets with none have a nil map.
+func setSensitive(sensitive *map[string]bool, name string, isSensitive bool) {
+	if sensitive == nil {
+		return
+	}
+	if !isSensitive {
+		delete(*sensitive, name)
+		return
+	}
+	if *sensitive == nil {
+		*sensitive = map[string]bool{}
+	}
+	(*sensitive)[name] = true
+}
+
+// redacted returns s, or "(sensitive)" if it is sensitive, for what pets
+// write about themselves. Pets redact what they write as they write it, so
+// that it is redacted everywhere it goes: the output, the Event hook, MQTT,
+// notifiers, the event log, the state and the dashboard of the serve
+// command.
+func redacted(s string, sensitive bool) string {
+	if sensitive {
+		return sensitiveText
+	}
+	return s
+}
@@ -0,0 +1,193 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensitive(t *testing.T) {
+	t.Parallel()
+
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = sensitive("chip-1234")
+  }
+}
+`
+	trace := &bytes.Buffer{}
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Trace: trace})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "chip-1234", config.Pets[0].(*Cat).Sound)
+	assert.Equal(t, "pet-sounds trace: pets.hcl:4,13-35: Ink.sound = (sensitive)\n", trace.String())
+
+	out := &bytes.Buffer{}
+	config.Pets[0].Say(out)
+	assert.Equal(t, "Ink (sensitive)\n", out.String())
+}
+
+func TestSensitiveFields(t *testing.T) {
+	t.Parallel()
+
+	// Only the characteristics set with sensitive values are redacted, not
+	// the text that happens to contain them.
+	src := `pet "Eve" {
+  type = "dog"
+  characteristics {
+    breed   = "Beagle"
+    sound   = sensitive("e")
+    actions = ["sleeps"]
+  }
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	out, events := &bytes.Buffer{}, []string{}
+	r := &Runner{Out: out, Hooks: &Hooks{Event: func(p Pet, event, line string) {
+		events = append(events, line)
+	}}}
+	if assert.NoError(t, r.Run(config.Pets)) {
+		assert.Equal(t, "Eve the Beagle (sensitive)\nEve the Beagle sleeps\n", out.String())
+		assert.Equal(t, []string{"Eve the Beagle (sensitive)", "Eve the Beagle sleeps"}, events)
+	}
+}
+
+func TestSensitiveReferences(t *testing.T) {
+	t.Parallel()
+
+	// Values read from sensitive characteristics are sensitive too, in
+	// other pets and in validations.
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = sensitive("chip-1234")
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = "${pet.Ink.sound}!"
+  }
+
+  validation {
+    condition     = self.sound == "meow"
+    error_message = "Neko says ${self.sound}."
+  }
+}
+`
+	_, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid pet; (sensitive)")
+		assert.NotContains(t, err.Error(), "chip-1234")
+	}
+}
+
+func TestSensitiveDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    weight = sensitive("4 stone")
+  }
+}
+`
+	_, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "The weight is sensitive, so its value is not shown.")
+		assert.NotContains(t, err.Error(), "stone")
+	}
+}
+
+func TestWriteConfigSensitive(t *testing.T) {
+	t.Parallel()
+
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = sensitive("chip-1234")
+    age   = 7
+  }
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Written configurations keep the characteristic sensitive, and
+	// resolved ones leave the value out.
+	written := &bytes.Buffer{}
+	if assert.NoError(t, WriteConfig(written, config.Pets)) {
+		assert.Contains(t, written.String(), `sound = sensitive("chip-1234")`)
+		again, err := DecodeConfig(written.Bytes(), "written.hcl", LoadOptions{})
+		if assert.NoError(t, err) {
+			out := &bytes.Buffer{}
+			again.Pets[0].Say(out)
+			assert.Equal(t, "Ink (sensitive)\n", out.String())
+		}
+	}
+	resolved := &bytes.Buffer{}
+	if assert.NoError(t, WriteResolvedConfig(resolved, config)) {
+		assert.Contains(t, resolved.String(), `sound = "(sensitive)"`)
+		assert.NotContains(t, resolved.String(), "chip-1234")
+	}
+}
+
+func TestSensitiveDecoder(t *testing.T) {
+	t.Parallel()
+
+	// Decodes with the same Decoder don't share what is sensitive.
+	d := NewDecoder(LoadOptions{})
+	_, err := d.Decode(context.Background(), []byte(`pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = sensitive("meow")
+  }
+}
+`), "secret.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	config, err := d.Decode(context.Background(), []byte(`pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = "meow"
+  }
+}
+`), "plain.hcl")
+	if assert.NoError(t, err) {
+		out := &bytes.Buffer{}
+		config.Pets[0].Say(out)
+		assert.Equal(t, "Neko meow\n", out.String())
+	}
+}
+
+func TestSensitiveFunctionElsewhere(t *testing.T) {
+	t.Parallel()
+
+	// Blocks decoded into Go structs can't hold marks, so sensitive returns
+	// its argument as it is in them.
+	src := `owner "Russell" {
+  phone = sensitive("555-0100")
+}
+
+pet "Ink" {
+  type  = "cat"
+  owner = "Russell"
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if assert.NoError(t, err) && assert.Len(t, config.Owners, 1) {
+		assert.Equal(t, "555-0100", config.Owners[0].Phone)
+	}
+}
@@ -0,0 +1,107 @@
+package petsounds
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// uiHandler serves the web dashboard of the serve command at /ui: a page
+// listing the pets and their characteristics, with a button to make each
+// one Say something. Its assets are embedded in uiFiles, so that the
+// dashboard is served by the pet-sounds binary alone.
+type uiHandler struct {
+	runner *Runner
+	pets   *PetSet
+
+	// mu guards last, the last thing each pet said, by name.
+	mu   sync.Mutex
+	last map[string]string
+}
+
+// newUIHandler returns the web dashboard of the pets of set, run with
+// runner. Each page lists the pets the set has when it is requested.
+func newUIHandler(runner *Runner, set *PetSet) http.Handler {
+	h := &uiHandler{runner: runner, pets: set, last: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ui", h.page)
+	mux.HandleFunc("/ui/say", h.say)
+	mux.HandleFunc("/ui/style.css", func(w http.ResponseWriter, r *http.Request) {
+		style, _ := uiFiles.ReadFile("ui/style.css")
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write(style)
+	})
+	return mux
+}
+
+// uiPet is a pet as the dashboard page shows it.
+type uiPet struct {
+	Name, Type, Last string
+	Characteristics  []string
+}
+
+func (h *uiHandler) page(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	pets := []uiPet{}
+	for _, p := range enabledPets(h.pets.Pets()) {
+		name, petType := petIdentity(p)
+		pet := uiPet{Name: name, Type: petType, Last: h.last[name]}
+		if self, err := selfValue(p); err == nil {
+			for attr, val := range self.AsValueMap() {
+				unmarked, _ := val.UnmarkDeep()
+				if attr == "name" || attr == "type" || unmarked.IsNull() || (unmarked.CanIterateElements() && unmarked.LengthInt() == 0) {
+					continue
+				}
+				// Sensitive characteristics are shown as "(sensitive)".
+				pet.Characteristics = append(pet.Characteristics, attr+" = "+strings.TrimSpace(valueString(val)))
+			}
+			sort.Strings(pet.Characteristics)
+		}
+		pets = append(pets, pet)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := uiTemplate.Execute(w, pets); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// say has the pet named by the pet form value Say something, and sends the
+// browser back to the dashboard.
+func (h *uiHandler) say(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "pets are made to speak with a POST", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.FormValue("pet")
+	for _, p := range enabledPets(h.pets.Pets()) {
+		if n, _ := petIdentity(p); n != name {
+			continue
+		}
+		out := &bytes.Buffer{}
+		if err := h.runner.do(p, out, eventSay); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.mu.Lock()
+		h.last[name] = strings.TrimSpace(out.String())
+		h.mu.Unlock()
+		http.Redirect(w, r, "/ui", http.StatusSeeOther)
+		return
+	}
+	http.Error(w, "no pet named `"+name+"`", http.StatusNotFound)
+}
+
+// uiFiles are the assets of the dashboard: index.html, the template of the
+// page, executed with the []uiPet to list, and style.css, its stylesheet.
+//
+//go:embed ui/index.html ui/style.css
+var uiFiles embed.FS
+
+var uiTemplate = template.Must(template.ParseFS(uiFiles, "ui/index.html"))
@@ -0,0 +1,55 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUIHandler(t *testing.T) {
+	t.Parallel()
+
+	age := 4
+	h := newUIHandler(&Runner{}, NewPetSet([]Pet{
+		&Cat{Name: "Ink", Sound: "meow", Age: &age},
+		&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "woofs"},
+	}))
+
+	get := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body, _ := ioutil.ReadAll(rec.Body)
+		return string(body)
+	}
+	page := get()
+	assert.Contains(t, page, `<h2>Ink <span class="type">cat</span></h2>`)
+	assert.Contains(t, page, `<li><code>age = 4</code></li>`)
+	assert.Contains(t, page, `<li><code>breed = &#34;Dachshund&#34;</code></li>`)
+	assert.NotContains(t, page, `class="last"`)
+
+	say := func(name string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/ui/say", strings.NewReader(url.Values{"pet": {name}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := say("Swinney")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Contains(t, get(), `<p class="last">Swinney the Dachshund woofs</p>`)
+	assert.Equal(t, http.StatusNotFound, say("Swiney").Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui/say?pet=Ink", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui/style.css", nil))
+	assert.Equal(t, "text/css; charset=utf-8", rec.Header().Get("Content-Type"))
+}
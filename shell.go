@@ -0,0 +1,145 @@
+package petsounds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shell reads commands a line at a time and carries them out on a PetSet,
+// for the shell command:
+//   say Ink      Ink Says something
+//   act all      every pet Acts
+//   feed Ink     Ink is fed, and then Acts
+//   pets         list the pets
+//   reload       load the configuration again
+//   help         list the commands
+//   quit         quit
+// Pets Say, Act and are fed through the Runner, so their conditions, hooks
+// and state apply as they do for any other run.
+type Shell struct {
+	// Runner runs the pets, so a Shell shares its hooks and state. What the
+	// pets write, and the replies to commands, go to its Out.
+	Runner
+
+	// Reload, if set, loads the pets for the reload command.
+	Reload func() ([]Pet, error)
+
+	// Prompt, if set, is written before each command is read.
+	Prompt string
+}
+
+// shellHelp lists the commands of a Shell.
+const shellHelp = `say <pet|all>    have a pet Say something
+act <pet|all>    have a pet Act
+feed <pet|all>   feed a pet, which then Acts
+pets             list the pets
+reload           load the configuration again
+help             list the commands
+quit             quit
+`
+
+// Run carries out the commands read from in on the pets of set, until in
+// ends or a quit command. A command that fails is reported to Out, and the
+// shell carries on.
+func (s *Shell) Run(in io.Reader, set *PetSet) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(s.Out, s.Prompt)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := s.command(fields, set); err != nil {
+			fmt.Fprintln(s.Out, err)
+		}
+	}
+}
+
+// command carries out the command fields on the pets of set.
+func (s *Shell) command(fields []string, set *PetSet) error {
+	var event string
+	switch fields[0] {
+	case "help":
+		fmt.Fprint(s.Out, shellHelp)
+		return nil
+	case "pets":
+		for _, p := range enabledPets(set.Pets()) {
+			name, petType := petIdentity(p)
+			fmt.Fprintf(s.Out, "%s (%s)\n", name, petType)
+		}
+		return nil
+	case "reload":
+		if s.Reload == nil {
+			return fmt.Errorf("there is no configuration to reload")
+		}
+		old := set.Pets()
+		if err := set.Reload(s.Reload); err != nil {
+			return err
+		}
+		writeReloadChanges(s.Out, reloadChanges(old, set.Pets()))
+		return nil
+	case "say":
+		event = eventSay
+	case "act":
+		event = eventAct
+	case "feed":
+		event = eventFeed
+	default:
+		return fmt.Errorf("unknown command `%s`, try help", fields[0])
+	}
+	if len(fields) != 2 {
+		return fmt.Errorf("`%s` needs the name of a pet, or all, as in `%s all`", fields[0], fields[0])
+	}
+
+	pets, err := shellPets(enabledPets(set.Pets()), fields[1])
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, p := range pets {
+		if err := s.shellEvent(p, event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// shellEvent has p Say or Act, or feeds it with its first feeding.
+func (s *Shell) shellEvent(p Pet, event string) error {
+	if event != eventFeed {
+		return s.do(p, s.Out, event)
+	}
+	f := &Feeding{}
+	if feedings := petFeedings(p); len(feedings) > 0 {
+		f = feedings[0]
+	}
+	return s.feed(p, f, s.Out)
+}
+
+// shellPets returns the pet of pets named name, or every pet for all.
+func shellPets(pets []Pet, name string) ([]Pet, error) {
+	if name == "all" {
+		return pets, nil
+	}
+	names := []string{}
+	for _, p := range pets {
+		petName, _ := petIdentity(p)
+		if petName == name {
+			return []Pet{p}, nil
+		}
+		names = append(names, petName)
+	}
+	if suggestion := suggest(name, names); suggestion != "" {
+		return nil, fmt.Errorf("there is no pet `%s`, did you mean `%s`?", name, suggestion)
+	}
+	return nil, fmt.Errorf("there is no pet `%s`", name)
+}
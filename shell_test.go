@@ -0,0 +1,54 @@
+package petsounds
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShell(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	reloads := [][]Pet{nil, {&Cat{Name: "Tom", Sound: "mew"}}}
+	shell := &Shell{
+		Runner: Runner{Out: out},
+		Reload: func() ([]Pet, error) {
+			pets := reloads[0]
+			reloads = reloads[1:]
+			if pets == nil {
+				return nil, errors.New("pets.hcl:1,1-2: Invalid expression")
+			}
+			return pets, nil
+		},
+		Prompt: "> ",
+	}
+	set := NewPetSet([]Pet{
+		&Cat{Name: "Ink", Sound: "meow", Feedings: []*Feeding{{Food: "tuna"}}},
+		&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "woofs"},
+	})
+
+	err := shell.Run(strings.NewReader("say Swinney\n\nact all\nfeed Ink\nsay Inc\nsay\nbark all\n"+
+		"reload\nreload\npets\nquit\nsay all\n"), set)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "> Swinney the Dachshund woofs\n"+
+		"> > Ink snoozes\n"+
+		"Swinney the Dachshund plays\n"+
+		"> Ink eats tuna\n"+
+		"Ink snoozes\n"+
+		"> there is no pet `Inc`, did you mean `Ink`?\n"+
+		"> `say` needs the name of a pet, or all, as in `say all`\n"+
+		"> unknown command `bark`, try help\n"+
+		"> error in PetSet.Reload: pets.hcl:1,1-2: Invalid expression\n"+
+		"> pet-sounds: reloaded the configuration, 1 added, 0 changed, 2 removed\n"+
+		"  + cat \"Tom\"\n"+
+		"  - cat \"Ink\"\n"+
+		"  - dog \"Swinney\"\n"+
+		"> Tom (cat)\n"+
+		"> ", out.String())
+}
@@ -0,0 +1,102 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Simulation turns a single pass over the pets into an ongoing one. Once per
+// tick, every pet randomly either Says or Acts. A pet with a nap_duration
+// naps each time it Acts, sitting out the ticks until its nap is over.
+type Simulation struct {
+	// Runner runs each tick, so a Simulation shares its output and worker
+	// pool settings.
+	Runner
+
+	// Ticks is the number of ticks to run before stopping. Zero runs until
+	// the context is cancelled.
+	Ticks int
+
+	// Interval is the time to wait between ticks.
+	Interval time.Duration
+}
+
+// Run ticks through the simulation until Ticks have elapsed or ctx is
+// cancelled. A cancelled context is a clean shutdown rather than an error:
+// the tick in progress is allowed to finish and Run returns nil.
+func (s *Simulation) Run(ctx context.Context, pets []Pet) error {
+	return s.RunSet(ctx, NewPetSet(pets))
+}
+
+// RunSet is Run over the pets of set, which are looked up again every tick,
+// so that the simulation carries on with the pets of a reloaded
+// configuration.
+func (s *Simulation) RunSet(ctx context.Context, set *PetSet) error {
+	if s.Interval <= 0 {
+		return fmt.Errorf("error in Simulation.RunSet: interval must be positive, got %s", s.Interval)
+	}
+
+	random := newLockedRand(s.Rand)
+	sleepers := &naps{until: map[Pet]time.Time{}}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for tick := 0; s.Ticks == 0 || tick < s.Ticks; tick++ {
+		// The first tick happens straight away, every later one waits for
+		// the interval to pass.
+		if tick > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+
+		err := s.each(set.Pets(), func(p Pet, w io.Writer) error {
+			now := clockOrSystem(s.Clock).Now()
+			if sleepers.napping(p, now) {
+				return nil
+			}
+			if random.Intn(2) == 0 {
+				return s.do(p, w, eventSay)
+			}
+			if err := s.do(p, w, eventAct); err != nil {
+				return err
+			}
+			sleepers.start(p, now)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error in Simulation.RunSet on tick %d: %w", tick, err)
+		}
+	}
+	return nil
+}
+
+// naps are when each napping pet of a Simulation wakes up. Pets run
+// concurrently, so naps are locked.
+type naps struct {
+	mu    sync.Mutex
+	until map[Pet]time.Time
+}
+
+// napping returns whether p is still napping at now.
+func (n *naps) napping(p Pet, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return now.Before(n.until[p])
+}
+
+// start has p nap from now for its nap_duration, if it has one.
+func (n *naps) start(p Pet, now time.Time) {
+	d := petNapDuration(p)
+	if d <= 0 {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.until[p] = now.Add(d)
+}
@@ -0,0 +1,79 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// echoPet is a Pet that writes a recognizable line for each call.
+type echoPet struct {
+	name string
+}
+
+func (e *echoPet) Say(w io.Writer) { fmt.Fprintf(w, "%s says\n", e.name) }
+func (e *echoPet) Act(w io.Writer) { fmt.Fprintf(w, "%s acts\n", e.name) }
+
+func TestSimulation(t *testing.T) {
+
+	tcs := []struct {
+		name      string
+		ticks     int
+		cancelled bool
+		wantLines int
+	}{
+		{
+			name:      "fixed ticks",
+			ticks:     3,
+			wantLines: 6,
+		},
+		{
+			name:      "cancelled",
+			ticks:     0,
+			cancelled: true,
+			wantLines: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelled {
+				cancel()
+			}
+
+			out := &bytes.Buffer{}
+			sim := &Simulation{
+				Runner:   Runner{Out: out},
+				Ticks:    tc.ticks,
+				Interval: time.Millisecond,
+			}
+			pets := []Pet{&echoPet{name: "Ink"}, &echoPet{name: "Swinney"}}
+
+			err := sim.Run(ctx, pets)
+			if assert.Nil(t, err) {
+				lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+				assert.Len(t, lines, tc.wantLines)
+				for _, line := range lines {
+					assert.Regexp(t, `^(Ink|Swinney) (says|acts)$`, line)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulationInterval(t *testing.T) {
+	sim := &Simulation{Runner: Runner{Out: &bytes.Buffer{}}, Ticks: 1}
+	err := sim.Run(context.Background(), []Pet{&echoPet{name: "Ink"}})
+	assert.Error(t, err)
+}
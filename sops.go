@@ -0,0 +1,68 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sopsCommand is the sops executable that decrypts configuration files.
+var sopsCommand = "sops"
+
+// isSOPS reports whether src is a file encrypted with SOPS. Encrypted files
+// keep their keys and checksum in a top level sops entry, whether they are
+// YAML files or other files SOPS encrypts whole into a JSON document.
+func isSOPS(src []byte) bool {
+	if !bytes.Contains(src, []byte("sops")) {
+		return false
+	}
+	var doc struct {
+		SOPS struct {
+			MAC string `yaml:"mac"`
+		} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return false
+	}
+	return doc.SOPS.MAC != ""
+}
+
+// decryptSOPS decrypts src, a configuration file written in format and
+// encrypted with SOPS, with the sops command line and the user's keys. SOPS
+// encrypts JSON and YAML values in place, while HCL and TOML files are
+// encrypted whole.
+func decryptSOPS(ctx context.Context, src []byte, format string) ([]byte, error) {
+	store := "binary"
+	switch format {
+	case FormatJSON:
+		store = "json"
+	case FormatYAML:
+		store = "yaml"
+	}
+
+	file, err := ioutil.TempFile("", "pet-sounds-sops")
+	if err != nil {
+		return nil, fmt.Errorf("error in decryptSOPS creating file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(src)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in decryptSOPS writing file: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, sopsCommand, "--decrypt", "--input-type", store, "--output-type", store, file.Name())
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error in decryptSOPS running sops: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,120 @@
+package petsounds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSOPS(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{
+			name:  "hcl",
+			input: `pet "sops" { type = "cat" }`,
+			want:  false,
+		},
+		{
+			name:  "yaml",
+			input: "pet:\n  - name: sops\n    type: cat\n",
+			want:  false,
+		},
+		{
+			name:  "encrypted yaml",
+			input: "pet:\n  - name: ENC[AES256_GCM,data:SW5r,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:bWFj,type:str]\n  version: 3.6.1\n",
+			want:  true,
+		},
+		{
+			name:  "sops without mac",
+			input: "sops:\n  version: 3.6.1\n",
+			want:  false,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, isSOPS([]byte(tc.input)))
+		})
+	}
+
+	src, err := ioutil.ReadFile("testdata/sops.hcl")
+	if assert.Nil(t, err) {
+		assert.True(t, isSOPS(src), "encrypted hcl")
+	}
+}
+
+// fakeSOPS replaces the sops command line with a script that checks it is
+// decrypting a file of the given store type and prints plaintext, until the
+// returned function is called.
+func fakeSOPS(t *testing.T, store, plaintext string) func() {
+	dir, err := ioutil.TempDir("", "pet-sounds-sops-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$3" != "%s" ]; then
+	echo "Failed to get the data key required to decrypt the SOPS file." >&2
+	exit 128
+fi
+cat <<'PLAINTEXT'
+%s
+PLAINTEXT
+`, store, plaintext)
+	path := filepath.Join(dir, "sops")
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	command := sopsCommand
+	sopsCommand = path
+	return func() {
+		sopsCommand = command
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDecryptSOPS(t *testing.T) {
+	src, err := ioutil.ReadFile("testdata/sops.hcl")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	cleanup := fakeSOPS(t, "binary", `pet "Ink" { type = "cat" }`)
+	defer cleanup()
+	config, err := DecodeConfig(src, "testdata/sops.hcl", LoadOptions{})
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{&Cat{Name: "Ink", Sound: "meow"}}, clearDeclRanges(config.Pets))
+	}
+
+	// Decrypting fails without the user's key, and says why.
+	_, err = DecodeConfig(src, "testdata/sops.yaml", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Failed to get the data key")
+	}
+}
+
+func TestDecryptSOPSJSON(t *testing.T) {
+	// SOPS encrypts the values of JSON files in place, like YAML files.
+	src := []byte(`{
+  "pet": {"Ink": {"type": "ENC[AES256_GCM,data:Y2F0,type:str]"}},
+  "sops": {"mac": "ENC[AES256_GCM,data:bWFj,type:str]", "version": "3.6.1"}
+}
+`)
+	cleanup := fakeSOPS(t, "json", `{"pet": {"Ink": {"type": "cat"}}}`)
+	defer cleanup()
+	config, err := DecodeConfig(src, "pets.json", LoadOptions{})
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{&Cat{Name: "Ink", Sound: "meow"}}, clearDeclRanges(config.Pets))
+	}
+}
@@ -0,0 +1,60 @@
+package petsounds
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// The orders SortPets can sort pets in.
+const (
+	sortDeclaration = ""
+	sortName        = "name"
+	sortType        = "type"
+	sortFile        = "file"
+)
+
+// SortPets sorts pets in place by name, by type, or by the file and line
+// they were declared at. Pets that compare equal keep their declaration
+// order, which is also the order of an empty by.
+func SortPets(pets []Pet, by string) error {
+	var less func(a, b Pet) bool
+	switch by {
+	case sortDeclaration:
+		return nil
+	case sortName:
+		less = func(a, b Pet) bool {
+			aName, _ := petIdentity(a)
+			bName, _ := petIdentity(b)
+			return aName < bName
+		}
+	case sortType:
+		less = func(a, b Pet) bool {
+			_, aType := petIdentity(a)
+			_, bType := petIdentity(b)
+			return aType < bType
+		}
+	case sortFile:
+		less = func(a, b Pet) bool {
+			aRange, bRange := declRange(a), declRange(b)
+			if aRange.Filename != bRange.Filename {
+				return aRange.Filename < bRange.Filename
+			}
+			return aRange.Start.Byte < bRange.Start.Byte
+		}
+	default:
+		return fmt.Errorf("unknown sort order `%s`, expected `%s`, `%s` or `%s`", by, sortName, sortType, sortFile)
+	}
+
+	sort.SliceStable(pets, func(i, j int) bool { return less(pets[i], pets[j]) })
+	return nil
+}
+
+// declRange returns where p was declared, if it knows.
+func declRange(p Pet) hcl.Range {
+	if d, ok := p.(interface{ DeclRange() hcl.Range }); ok {
+		return d.DeclRange()
+	}
+	return hcl.Range{}
+}
@@ -0,0 +1,73 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortPets(t *testing.T) {
+	at := func(filename string, byte int) hcl.Range {
+		return hcl.Range{Filename: filename, Start: hcl.Pos{Byte: byte}}
+	}
+
+	tcs := []struct {
+		name    string
+		by      string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "declaration",
+			want: []string{"Swinney", "Ink", "Biscuit", "Neko"},
+		},
+		{
+			name: "name",
+			by:   "name",
+			want: []string{"Biscuit", "Ink", "Neko", "Swinney"},
+		},
+		{
+			name: "type",
+			by:   "type",
+			want: []string{"Ink", "Neko", "Swinney", "Biscuit"},
+		},
+		{
+			name: "file",
+			by:   "file",
+			want: []string{"Biscuit", "Neko", "Ink", "Swinney"},
+		},
+		{
+			name:    "unknown",
+			by:      "age",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pets := []Pet{
+				&Dog{Name: "Swinney", declRange: at("pets.hcl", 40)},
+				&Cat{Name: "Ink", declRange: at("pets.hcl", 0)},
+				&Dog{Name: "Biscuit", declRange: at("module/pets.hcl", 20)},
+				&Cat{Name: "Neko", declRange: at("module/pets.hcl", 60)},
+			}
+			err := SortPets(pets, tc.by)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				got := []string{}
+				for _, p := range pets {
+					name, _ := petIdentity(p)
+					got = append(got, name)
+				}
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
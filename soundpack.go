@@ -0,0 +1,135 @@
+package petsounds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// soundPackKey is the namespace the sounds of a sound pack are in, as in
+// pack.cat.purr.
+const soundPackKey = "pack"
+
+// packContext returns a child of evalContext with the sounds of the sound
+// pack loaded by the sound_pack attribute of body in the pack namespace, or
+// evalContext itself if body has no sound_pack attribute. A sound pack is a
+// file of named sounds for each type of pet, shared between configurations,
+// such as:
+//   cat {
+//     purr  = "prrrr"
+//     chirp = "mrrp?"
+//   }
+//   dog {
+//     woof = "WOOF"
+//   }
+// which characteristics use by key, as pack.cat.purr. Relative paths are
+// relative to the directory of filename, the file body was parsed from.
+func packContext(cache *ParseCache, body *hclsyntax.Body, filename string, evalContext *hcl.EvalContext) (*hcl.EvalContext, hcl.Diagnostics) {
+	attr, ok := body.Attributes["sound_pack"]
+	if !ok {
+		return evalContext, nil
+	}
+	val, diags := attr.Expr.Value(evalContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	val, err := convert.Convert(val, cty.String)
+	if err != nil || val.IsNull() || !val.IsKnown() {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid sound pack",
+			Detail:   "The sound_pack attribute must be the path of a sound pack file.",
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+
+	path := val.AsString()
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(filename), path)
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unreadable sound pack",
+			Detail:   fmt.Sprintf("%s.", err),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	pack, diags := cache.parse(src, path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	sounds, diags := packSounds(pack)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	ctx := evalContext.NewChild()
+	ctx.Variables = map[string]cty.Value{soundPackKey: sounds}
+	return ctx, nil
+}
+
+// packSounds returns the sounds of the sound pack body, as an object of
+// the sounds of each type of pet by key.
+func packSounds(body *hclsyntax.Body) (cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	for name, attr := range body.Attributes {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail:   fmt.Sprintf("A sound pack only has a block for each type of pet, not a %s argument.", name),
+			Subject:  attr.NameRange.Ptr(),
+		})
+	}
+
+	kinds := map[string]cty.Value{}
+	for _, block := range body.Blocks {
+		if _, ok := petKinds[block.Type]; !ok {
+			detail := fmt.Sprintf("A sound pack has a block for each type of pet, and `%s` is not one.", block.Type)
+			if suggestion := suggest(block.Type, petKindNames()); suggestion != "" {
+				detail += fmt.Sprintf(" Did you mean `%s`?", suggestion)
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown pet type",
+				Detail:   detail,
+				Subject:  block.TypeRange.Ptr(),
+			})
+			continue
+		}
+
+		names := []string{}
+		for name := range block.Body.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sounds := map[string]cty.Value{}
+		for _, name := range names {
+			attr := block.Body.Attributes[name]
+			val, valDiags := attr.Expr.Value(nil)
+			diags = append(diags, valDiags...)
+			if valDiags.HasErrors() {
+				continue
+			}
+			if val, err := convert.Convert(val, cty.String); err == nil && !val.IsNull() {
+				sounds[name] = val
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid sound",
+				Detail:   fmt.Sprintf("The sound `%s` of %s must be a string.", name, block.Type),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+		kinds[block.Type] = cty.ObjectVal(sounds)
+	}
+	return cty.ObjectVal(kinds), diags
+}
@@ -0,0 +1,83 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoundPack(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfig("testdata/soundpack.hcl")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Pet{
+			&Cat{Name: "Ink", Sound: "meow", Sounds: []string{"mrow", "mrrp?"}},
+			&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "wuff"},
+		}, clearDeclRanges(config.Pets))
+	}
+}
+
+func TestSoundPackErrors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		pack   string
+		config string
+		want   string
+	}{
+		{
+			name:   "unknown key",
+			pack:   "cat {\n  barn = \"mrow\"\n}\n",
+			config: "sound = pack.cat.bran",
+			want:   "This object does not have an attribute named \"bran\".",
+		},
+		{
+			name:   "unknown type",
+			pack:   "caat {\n  barn = \"mrow\"\n}\n",
+			config: "sound = \"meow\"",
+			want: "pack.hcl:1,1-5: Unknown pet type; " +
+				"A sound pack has a block for each type of pet, and `caat` is not one. Did you mean `cat`?",
+		},
+		{
+			name:   "not a string",
+			pack:   "cat {\n  barn = [\"mrow\"]\n}\n",
+			config: "sound = \"meow\"",
+			want:   "pack.hcl:2,10-18: Invalid sound; The sound `barn` of cat must be a string.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, err := ioutil.TempDir("", "pet-sounds-pack")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer os.RemoveAll(dir)
+			if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pack.hcl"), []byte(tc.pack), 0644)) {
+				return
+			}
+
+			_, err = DecodeConfig([]byte(`
+sound_pack = "pack.hcl"
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    `+tc.config+`
+  }
+}
+`), filepath.Join(dir, "test.hcl"), LoadOptions{})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
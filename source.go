@@ -0,0 +1,495 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// defaultFetchTimeout bounds fetching a configuration file over HTTP.
+	defaultFetchTimeout = 30 * time.Second
+
+	checksumPrefix = "sha256:"
+)
+
+// ConfigSource is somewhere a configuration file can be fetched from.
+type ConfigSource interface {
+	// Fetch returns the contents of the configuration file, giving up when
+	// ctx is done.
+	Fetch(ctx context.Context) ([]byte, error)
+	// Format returns the format of the configuration file, chosen by its
+	// extension.
+	Format() string
+}
+
+// NewConfigSource returns the ConfigSource for name, which is a path to a
+// local file or a URL. HTTP and HTTPS URLs are fetched with an HTTPSource,
+// s3://bucket/key URLs with an S3Source, gs://bucket/object URLs with a
+// GCSSource, and git::<repository>//<path>?ref=<ref> URLs with a GitSource.
+func NewConfigSource(name string) (ConfigSource, error) {
+	switch {
+	case strings.HasPrefix(name, gitPrefix):
+		return parseGitSource(name)
+	case isURL(name):
+		return &HTTPSource{URL: name}, nil
+	case strings.HasPrefix(name, "s3://"), strings.HasPrefix(name, "gs://"):
+		u, err := url.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("error in NewConfigSource parsing `%s`: %w", name, err)
+		}
+		object := strings.TrimPrefix(u.Path, "/")
+		if u.Host == "" || object == "" {
+			return nil, fmt.Errorf("error in NewConfigSource: `%s` needs a bucket and an object", name)
+		}
+		if u.Scheme == "s3" {
+			return &S3Source{Bucket: u.Host, Key: object}, nil
+		}
+		return &GCSSource{Bucket: u.Host, Object: object}, nil
+	}
+	return &FileSource{Path: name}, nil
+}
+
+// FileSource reads a configuration file from the local filesystem. Path can
+// also be a directory of configuration files, which has no contents of its
+// own and is fetched as nil; DecodeConfig reads the files in it.
+type FileSource struct {
+	Path string
+}
+
+func (f *FileSource) Fetch(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("error in FileSource.Fetch: %w", err)
+	}
+	if info, err := os.Stat(f.Path); err == nil && info.IsDir() {
+		return nil, nil
+	}
+	src, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("error in FileSource.Fetch: %w", &sentinelError{err, ErrConfigNotFound})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in FileSource.Fetch: %w", err)
+	}
+	return src, nil
+}
+
+func (f *FileSource) Format() string {
+	return formatOf(f.Path)
+}
+
+// HTTPSource fetches a configuration file from an HTTP or HTTPS URL.
+type HTTPSource struct {
+	URL string
+	// Timeout bounds the whole request. If zero, defaultFetchTimeout is used.
+	Timeout time.Duration
+	// CacheDir, if set, is a directory where fetched files are kept along
+	// with their ETag. A cached file is only fetched again if the server
+	// says it has changed.
+	CacheDir string
+	// Checksum, if set, pins the file to a SHA-256 digest, given as
+	// "sha256:<hex>". Fetching a file with any other digest fails.
+	Checksum string
+	Client   *http.Client
+}
+
+// isURL reports whether name is an HTTP or HTTPS URL.
+func isURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+func (h *HTTPSource) Fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := fetchContext(ctx, h.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error in HTTPSource.Fetch creating request: %w", err)
+	}
+
+	cached, etag := h.cached()
+	if cached != nil && etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error in HTTPSource.Fetch fetching `%s`: %w", h.URL, err)
+	}
+	defer resp.Body.Close()
+
+	var src []byte
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, fmt.Errorf("error in HTTPSource.Fetch: `%s` responded %s without a cached copy", h.URL, resp.Status)
+		}
+		src = cached
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("error in HTTPSource.Fetch: `%s` responded %s: %w", h.URL, resp.Status, ErrConfigNotFound)
+	case http.StatusOK:
+		src, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error in HTTPSource.Fetch reading `%s`: %w", h.URL, err)
+		}
+	default:
+		return nil, fmt.Errorf("error in HTTPSource.Fetch: `%s` responded %s", h.URL, resp.Status)
+	}
+
+	if err := verifyChecksum(src, h.Checksum); err != nil {
+		return nil, fmt.Errorf("error in HTTPSource.Fetch verifying `%s`: %w", h.URL, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := h.cache(src, resp.Header.Get("ETag")); err != nil {
+			return nil, fmt.Errorf("error in HTTPSource.Fetch caching `%s`: %w", h.URL, err)
+		}
+	}
+	return src, nil
+}
+
+func (h *HTTPSource) Format() string {
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return FormatHCL
+	}
+	return formatOf(u.Path)
+}
+
+// cachePath returns the path of the cached copy of the file, without an
+// extension. The path is derived from the URL so every URL is cached apart.
+func (h *HTTPSource) cachePath() string {
+	sum := sha256.Sum256([]byte(h.URL))
+	return filepath.Join(h.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+// cached returns the cached copy of the file and its ETag, or nil if there
+// is no cached copy.
+func (h *HTTPSource) cached() ([]byte, string) {
+	if h.CacheDir == "" {
+		return nil, ""
+	}
+	src, err := ioutil.ReadFile(h.cachePath() + ".body")
+	if err != nil {
+		return nil, ""
+	}
+	etag, err := ioutil.ReadFile(h.cachePath() + ".etag")
+	if err != nil {
+		return nil, ""
+	}
+	return src, string(etag)
+}
+
+// cache keeps a copy of the file, if it has an ETag to check it against
+// later.
+func (h *HTTPSource) cache(src []byte, etag string) error {
+	if h.CacheDir == "" || etag == "" {
+		return nil
+	}
+	if err := os.MkdirAll(h.CacheDir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(h.cachePath()+".body", src, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.cachePath()+".etag", []byte(etag), 0644)
+}
+
+// verifyChecksum checks src against a "sha256:<hex>" checksum. An empty
+// checksum always passes.
+func verifyChecksum(src []byte, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	if !strings.HasPrefix(checksum, checksumPrefix) {
+		return fmt.Errorf("unsupported checksum `%s`, expected %s<hex>", checksum, checksumPrefix)
+	}
+	sum := sha256.Sum256(src)
+	got := hex.EncodeToString(sum[:])
+	if want := strings.ToLower(strings.TrimPrefix(checksum, checksumPrefix)); got != want {
+		return fmt.Errorf("checksum mismatch, expected %s%s but got %s%s", checksumPrefix, want, checksumPrefix, got)
+	}
+	return nil
+}
+
+// S3Source fetches a configuration file from an Amazon S3 bucket, using
+// credentials from the AWS SDK's default chain: the environment, the shared
+// configuration and credentials files, then the instance or task role.
+type S3Source struct {
+	Bucket string
+	Key    string
+	// Region is the bucket's region. If empty, it is looked up.
+	Region string
+	// Endpoint, if set, is used in place of Amazon S3, for S3 compatible
+	// object stores.
+	Endpoint string
+	// Timeout bounds fetching the file. If zero, defaultFetchTimeout is used.
+	Timeout time.Duration
+}
+
+func (s *S3Source) Fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := fetchContext(ctx, s.Timeout)
+	defer cancel()
+
+	config := aws.NewConfig()
+	if s.Endpoint != "" {
+		config = config.WithEndpoint(s.Endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error in S3Source.Fetch creating session: %w", err)
+	}
+
+	region := s.Region
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+	if region == "" {
+		region, err = s3manager.GetBucketRegion(ctx, sess, s.Bucket, "us-east-1")
+		if err != nil {
+			return nil, fmt.Errorf("error in S3Source.Fetch finding region of bucket `%s`: %w", s.Bucket, err)
+		}
+	}
+
+	out, err := s3.New(sess, aws.NewConfig().WithRegion(region)).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		err = &sentinelError{err, ErrConfigNotFound}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in S3Source.Fetch getting `s3://%s/%s`: %w", s.Bucket, s.Key, err)
+	}
+	defer out.Body.Close()
+	src, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error in S3Source.Fetch reading `s3://%s/%s`: %w", s.Bucket, s.Key, err)
+	}
+	return src, nil
+}
+
+func (s *S3Source) Format() string {
+	return formatOf(s.Key)
+}
+
+// GCSSource fetches a configuration file from a Google Cloud Storage bucket,
+// using Google's Application Default Credentials: the environment, the gcloud
+// configuration, then the metadata server.
+type GCSSource struct {
+	Bucket string
+	Object string
+	// Endpoint, if set, is used in place of the Cloud Storage JSON API.
+	Endpoint string
+	// Client, if set, is used in place of a client with the default
+	// credentials.
+	Client *http.Client
+	// Timeout bounds fetching the file. If zero, defaultFetchTimeout is used.
+	Timeout time.Duration
+}
+
+const (
+	gcsEndpoint  = "https://storage.googleapis.com"
+	gcsReadScope = "https://www.googleapis.com/auth/devstorage.read_only"
+)
+
+func (g *GCSSource) Fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := fetchContext(ctx, g.Timeout)
+	defer cancel()
+
+	client := g.Client
+	if client == nil {
+		var err error
+		client, err = google.DefaultClient(ctx, gcsReadScope)
+		if err != nil {
+			return nil, fmt.Errorf("error in GCSSource.Fetch finding credentials: %w", err)
+		}
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = gcsEndpoint
+	}
+
+	object := fmt.Sprintf(
+		"%s/storage/v1/b/%s/o/%s?alt=media",
+		strings.TrimSuffix(endpoint, "/"), url.PathEscape(g.Bucket), url.PathEscape(g.Object),
+	)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, object, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error in GCSSource.Fetch creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error in GCSSource.Fetch getting `gs://%s/%s`: %w", g.Bucket, g.Object, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf(
+			"error in GCSSource.Fetch: `gs://%s/%s` responded %s: %w", g.Bucket, g.Object, resp.Status, ErrConfigNotFound,
+		)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error in GCSSource.Fetch: `gs://%s/%s` responded %s", g.Bucket, g.Object, resp.Status)
+	}
+	src, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error in GCSSource.Fetch reading `gs://%s/%s`: %w", g.Bucket, g.Object, err)
+	}
+	return src, nil
+}
+
+func (g *GCSSource) Format() string {
+	return formatOf(g.Object)
+}
+
+// gitPrefix marks a configuration URL as a git repository.
+const gitPrefix = "git::"
+
+// GitSource reads a configuration file from a git repository at a ref, using
+// the git command line. It is written as a URL like
+//   git::https://github.com/org/pets//prod?ref=v1.2.0
+// where the path after the double slash is a file in the repository, or a
+// directory holding a pets.hcl file, and the ref is a branch, tag or commit.
+type GitSource struct {
+	Repository string
+	Path       string
+	// Ref is the branch, tag or commit to read. If empty, the repository's
+	// default branch is read.
+	Ref string
+	// Timeout bounds fetching the repository. If zero, defaultFetchTimeout
+	// is used.
+	Timeout time.Duration
+}
+
+// parseGitSource parses a git:: configuration URL into a GitSource.
+func parseGitSource(name string) (*GitSource, error) {
+	source := &GitSource{}
+	rest := strings.TrimPrefix(name, gitPrefix)
+	if i := strings.LastIndex(rest, "?"); i >= 0 {
+		query, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("error in NewConfigSource parsing `%s`: %w", name, err)
+		}
+		source.Ref = query.Get("ref")
+		rest = rest[:i]
+	}
+
+	// The path is separated by a double slash after the scheme's own.
+	start := 0
+	if i := strings.Index(rest, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(rest[start:], "//"); i >= 0 {
+		source.Path = rest[start+i+len("//"):]
+		rest = rest[:start+i]
+	}
+	source.Repository = rest
+	if source.Repository == "" {
+		return nil, fmt.Errorf("error in NewConfigSource: `%s` needs a repository", name)
+	}
+	if err := source.checkArgs(); err != nil {
+		return nil, fmt.Errorf("error in NewConfigSource: %w", err)
+	}
+	return source, nil
+}
+
+// checkArgs returns an error if the repository or ref starts with a dash,
+// which git would read as an option, such as --upload-pack, rather than
+// what to fetch, or if the path leaves the repository, with .. or as an
+// absolute path, to read other files.
+func (g *GitSource) checkArgs() error {
+	if strings.HasPrefix(g.Repository, "-") {
+		return fmt.Errorf("invalid repository `%s`, it can't start with -", g.Repository)
+	}
+	if strings.HasPrefix(g.Ref, "-") {
+		return fmt.Errorf("invalid ref `%s`, it can't start with -", g.Ref)
+	}
+	path := filepath.Clean(filepath.FromSlash(g.Path))
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid path `%s`, it is outside the repository", g.Path)
+	}
+	return nil
+}
+
+func (g *GitSource) Fetch(ctx context.Context) ([]byte, error) {
+	if err := g.checkArgs(); err != nil {
+		return nil, fmt.Errorf("error in GitSource.Fetch: %w", err)
+	}
+	ctx, cancel := fetchContext(ctx, g.Timeout)
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "pet-sounds-git")
+	if err != nil {
+		return nil, fmt.Errorf("error in GitSource.Fetch creating directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Fetching a single ref, rather than cloning, works for commits as well
+	// as branches and tags, and only fetches the commit being read.
+	ref := g.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", g.Repository, ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf(
+				"error in GitSource.Fetch running git %s: %w: %s", args[0], err, bytes.TrimSpace(out),
+			)
+		}
+	}
+
+	path := filepath.Join(dir, filepath.Clean(filepath.FromSlash(g.Path)))
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, defaultFileName)
+	}
+	src, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = &sentinelError{err, ErrConfigNotFound}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in GitSource.Fetch reading `%s` at `%s`: %w", g.Path, ref, err)
+	}
+	return src, nil
+}
+
+func (g *GitSource) Format() string {
+	return formatOf(g.Path)
+}
+
+// fetchContext returns a child of ctx for fetching a configuration file
+// within timeout, or within defaultFetchTimeout if timeout is zero.
+func fetchContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = defaultFetchTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,308 @@
+package petsounds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sourceConfig = `pet "Ink" {
+  type = "cat"
+}
+`
+
+func TestHTTPSource(t *testing.T) {
+	sum := sha256.Sum256([]byte(sourceConfig))
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	tcs := []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{
+			name: "unpinned",
+		},
+		{
+			name:     "pinned",
+			checksum: checksum,
+		},
+		{
+			name:     "checksum mismatch",
+			checksum: "sha256:0000",
+			wantErr:  true,
+		},
+		{
+			name:     "unsupported checksum",
+			checksum: "md5:0000",
+			wantErr:  true,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sourceConfig))
+	}))
+	defer server.Close()
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			source := &HTTPSource{URL: server.URL + "/pets.hcl", Checksum: tc.checksum}
+			src, err := source.Fetch(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, sourceConfig, string(src))
+			}
+		})
+	}
+}
+
+func TestHTTPSourceCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	var fetches, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(sourceConfig))
+	}))
+	defer server.Close()
+
+	source := &HTTPSource{URL: server.URL + "/pets.hcl", CacheDir: dir}
+	for i := 0; i < 2; i++ {
+		src, err := source.Fetch(context.Background())
+		if assert.Nil(t, err) {
+			assert.Equal(t, sourceConfig, string(src))
+		}
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+}
+
+func TestHTTPSourceErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.hcl" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	_, err := (&HTTPSource{URL: server.URL + "/missing.hcl"}).Fetch(context.Background())
+	assert.Error(t, err)
+	_, err = (&HTTPSource{URL: server.URL + "/slow.hcl", Timeout: time.Millisecond}).Fetch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestHTTPSourceFormat(t *testing.T) {
+	assert.Equal(t, FormatYAML, (&HTTPSource{URL: "https://example.com/pets.yaml?ref=main"}).Format())
+	assert.Equal(t, FormatHCL, (&HTTPSource{URL: "https://example.com/pets"}).Format())
+}
+
+func TestNewConfigSource(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		input   string
+		want    ConfigSource
+		wantErr bool
+	}{
+		{
+			name:  "file",
+			input: "pets.hcl",
+			want:  &FileSource{Path: "pets.hcl"},
+		},
+		{
+			name:  "https",
+			input: "https://example.com/pets.hcl",
+			want:  &HTTPSource{URL: "https://example.com/pets.hcl"},
+		},
+		{
+			name:  "s3",
+			input: "s3://bucket/prod/pets.yaml",
+			want:  &S3Source{Bucket: "bucket", Key: "prod/pets.yaml"},
+		},
+		{
+			name:  "gcs",
+			input: "gs://bucket/prod/pets.toml",
+			want:  &GCSSource{Bucket: "bucket", Object: "prod/pets.toml"},
+		},
+		{
+			name:  "git",
+			input: "git::https://github.com/org/pets//prod?ref=v1.2.0",
+			want:  &GitSource{Repository: "https://github.com/org/pets", Path: "prod", Ref: "v1.2.0"},
+		},
+		{
+			name:  "git scp",
+			input: "git::git@github.com:org/pets.git//pets.yaml",
+			want:  &GitSource{Repository: "git@github.com:org/pets.git", Path: "pets.yaml"},
+		},
+		{
+			name:    "no object",
+			input:   "gs://bucket",
+			wantErr: true,
+		},
+		{
+			name:    "git repository option",
+			input:   "git::--upload-pack=touch pwned//pets.hcl",
+			wantErr: true,
+		},
+		{
+			name:    "git path outside the repository",
+			input:   "git::https://github.com/org/pets//../../etc/passwd",
+			wantErr: true,
+		},
+		{
+			name:    "git ref option",
+			input:   "git::https://github.com/org/pets//prod?ref=--upload-pack=touch",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewConfigSource(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGCSSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/storage/v1/b/bucket/o/prod%2Fpets.hcl" || r.URL.Query().Get("alt") != "media" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(sourceConfig))
+	}))
+	defer server.Close()
+
+	source := &GCSSource{Bucket: "bucket", Object: "prod/pets.hcl", Endpoint: server.URL, Client: server.Client()}
+	src, err := source.Fetch(context.Background())
+	if assert.Nil(t, err) {
+		assert.Equal(t, sourceConfig, string(src))
+	}
+
+	source.Object = "missing.hcl"
+	_, err = source.Fetch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestS3Source(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bucket/prod/pets.hcl" || r.Header.Get("Authorization") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(sourceConfig))
+	}))
+	defer server.Close()
+
+	for k, v := range map[string]string{
+		"AWS_ACCESS_KEY_ID":           "test",
+		"AWS_SECRET_ACCESS_KEY":       "test",
+		"AWS_CONFIG_FILE":             os.DevNull,
+		"AWS_SHARED_CREDENTIALS_FILE": os.DevNull,
+	} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Setenv(k, v)
+	}
+
+	source := &S3Source{Bucket: "bucket", Key: "prod/pets.hcl", Region: "us-east-1", Endpoint: server.URL}
+	src, err := source.Fetch(context.Background())
+	if assert.Nil(t, err) {
+		assert.Equal(t, sourceConfig, string(src))
+	}
+}
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// The repository has pets.hcl at the tag v1, and a changed pets.hcl on
+	// its default branch.
+	if !assert.Nil(t, os.MkdirAll(filepath.Join(dir, "prod"), 0755)) {
+		return
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	git("init", "--quiet")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "prod", "pets.hcl"), []byte(sourceConfig), 0644))
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "prod", "pets.hcl"), []byte("# changed\n"), 0644))
+	git("commit", "--quiet", "-am", "v2")
+
+	repository := "file://" + filepath.ToSlash(dir)
+	for ref, want := range map[string]string{"v1": sourceConfig, "": "# changed\n"} {
+		source := &GitSource{Repository: repository, Path: "prod", Ref: ref}
+		src, err := source.Fetch(context.Background())
+		if assert.Nil(t, err) {
+			assert.Equal(t, want, string(src))
+		}
+	}
+
+	_, err = (&GitSource{Repository: repository, Path: "prod", Ref: "v9"}).Fetch(context.Background())
+	assert.Error(t, err)
+
+	// A ref that git would take for an option is refused before git runs.
+	_, err = (&GitSource{Repository: repository, Path: "prod", Ref: "--upload-pack=touch pwned"}).Fetch(context.Background())
+	if assert.Error(t, err) {
+		assert.Equal(t, "error in GitSource.Fetch: invalid ref `--upload-pack=touch pwned`, it can't start with -", err.Error())
+	}
+
+	// So is a path that leaves the clone.
+	_, err = (&GitSource{Repository: repository, Path: "prod/../../pets.hcl"}).Fetch(context.Background())
+	if assert.Error(t, err) {
+		assert.Equal(t, "error in GitSource.Fetch: invalid path `prod/../../pets.hcl`, it is outside the repository", err.Error())
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	source := &FileSource{Path: "testdata/basic.yaml"}
+	_, err := source.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, FormatYAML, source.Format())
+}
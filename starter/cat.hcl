@@ -0,0 +1,14 @@
+# Ink is a cat. Without characteristics, cats meow.
+pet "Ink" {
+  type = "cat"
+  tags = ["indoor"]
+
+  characteristics {
+    # Each time Ink says something, one of the sounds is chosen at random.
+    sounds         = ["meow", var.favourite_sound]
+    sound_strategy = "random"
+    age            = 3
+    # env reads the environment, with a default for when it isn't set.
+    actions = [env("INK_ACTION", "snoozes on the keyboard")]
+  }
+}
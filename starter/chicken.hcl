@@ -0,0 +1,4 @@
+# Nugget is a chicken, from the farm pet pack.
+pet "Nugget" {
+  type = "chicken"
+}
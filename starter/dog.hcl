@@ -0,0 +1,17 @@
+# Swinney is a dog, whose breed is picked at random each run.
+pet "Swinney" {
+  type = "dog"
+
+  characteristics {
+    breed      = random("Dachshund", "Corgi", "Beagle")
+    sound      = "barks"
+    actions    = ["waits by the door for the ${var.walk_time} walk"]
+    vaccinated = true
+  }
+
+  feeding {
+    # Feeding times are cron schedules: half past seven every day.
+    schedule = "30 7 * * *"
+    food     = "kibble"
+  }
+}
@@ -0,0 +1,4 @@
+# Billy is a goat, from the farm pet pack.
+pet "Billy" {
+  type = "goat"
+}
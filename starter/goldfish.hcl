@@ -0,0 +1,4 @@
+# Bubbles is a goldfish, from the aquarium pet pack.
+pet "Bubbles" {
+  type = "goldfish"
+}
@@ -0,0 +1,13 @@
+# A starter configuration of pets, written by pet-sounds init. See
+# pet-sounds help for the commands that run its pets, and pet-sounds
+# functions for the functions and variables it can use.
+
+# Variables are set to their defaults, and used in characteristics as
+# var.<name>.
+variable "favourite_sound" {
+  default = "purr"
+}
+
+variable "walk_time" {
+  default = "morning"
+}
@@ -0,0 +1,4 @@
+# Clover is a horse, from the farm pet pack.
+pet "Clover" {
+  type = "horse"
+}
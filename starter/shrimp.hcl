@@ -0,0 +1,4 @@
+# Pinch is a shrimp, from the aquarium pet pack.
+pet "Pinch" {
+  type = "shrimp"
+}
@@ -0,0 +1,168 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// StateHCL is the optional top-level state block, which keeps the state of
+// the pets between runs in a database file, so that they remember their
+// moods, when they were last fed and what they last said and did. It is represented in hcl as:
+//   state {
+//     path = "<file to keep the state in, e.g. pets.db>"
+//   }
+type StateHCL struct {
+	Path string `hcl:"path"`
+}
+
+// stateHistory is the number of events kept in the history of each pet.
+const stateHistory = 100
+
+// PetState is what a StateStore remembers about a pet.
+type PetState struct {
+	// LastFed is when the pet was last fed, zero if it never has been.
+	LastFed time.Time `json:"last_fed,omitempty"`
+	// Mood is the mood the pet was last in.
+	Mood Mood `json:"mood,omitempty"`
+	// Events are the latest things the pet did, oldest first.
+	Events []StateEvent `json:"events,omitempty"`
+}
+
+// StateEvent is one thing a pet did, as remembered by a StateStore.
+type StateEvent struct {
+	Event string    `json:"event"`
+	Text  string    `json:"text,omitempty"`
+	TS    time.Time `json:"ts"`
+}
+
+// stateBucket is the bucket of a state database that the state of each
+// pet is kept in, as JSON, by name.
+var stateBucket = []byte("pets")
+
+// stateOpenTimeout is how long OpenStateStore waits for another process
+// that has the database open to close it.
+const stateOpenTimeout = time.Second
+
+// StateStore keeps the state of pets, by name, in an embedded database,
+// which is written whenever a pet does something. All methods are safe to
+// call on a nil *StateStore, which remembers nothing, and from several
+// goroutines at once.
+type StateStore struct {
+	path string
+	db   *bolt.DB
+}
+
+// OpenStateStore opens the state database at path, creating it if it does
+// not exist yet. Only one process can have it open at a time, so it must be
+// closed with Close.
+func OpenStateStore(path string) (*StateStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: stateOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("error in OpenStateStore opening `%s`: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(stateBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error in OpenStateStore opening `%s`: %w", path, err)
+	}
+	return &StateStore{path: path, db: db}, nil
+}
+
+// Close closes the database.
+func (s *StateStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("error in StateStore closing `%s`: %w", s.path, err)
+	}
+	return nil
+}
+
+// Pet returns the state of the pet named name. A pet whose state can't be
+// read has none.
+func (s *StateStore) Pet(name string) PetState {
+	state := PetState{}
+	if s == nil {
+		return state
+	}
+	s.db.View(func(tx *bolt.Tx) error {
+		return getPetState(tx, name, &state)
+	})
+	return state
+}
+
+// Restore puts pets back in the moods they were last in.
+func (s *StateStore) Restore(pets []Pet) {
+	for _, p := range pets {
+		name, _ := petIdentity(p)
+		petMoods(p).restore(s.Pet(name).Mood)
+	}
+}
+
+// record remembers that the pet p did event at, along with text, the line
+// it wrote, and the mood it is now in. Feeding events also remember when
+// the pet was fed.
+func (s *StateStore) record(p Pet, event, text string, at time.Time) error {
+	if s == nil {
+		return nil
+	}
+	name, _ := petIdentity(p)
+	mood := petMoods(p).Mood()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		state := PetState{}
+		if err := getPetState(tx, name, &state); err != nil {
+			return err
+		}
+		state.Mood = mood
+		if event == eventFeed {
+			state.LastFed = at
+		}
+		state.Events = append(state.Events, StateEvent{Event: event, Text: text, TS: at})
+		if len(state.Events) > stateHistory {
+			state.Events = state.Events[len(state.Events)-stateHistory:]
+		}
+		src, err := json.Marshal(&state)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(stateBucket).Put([]byte(name), src)
+	})
+	if err != nil {
+		return fmt.Errorf("error in StateStore saving `%s`: %w", s.path, err)
+	}
+	return nil
+}
+
+// getPetState reads the state of the pet named name in tx into state,
+// leaving it as it is if the pet has none.
+func getPetState(tx *bolt.Tx, name string, state *PetState) error {
+	src := tx.Bucket(stateBucket).Get([]byte(name))
+	if src == nil {
+		return nil
+	}
+	if err := json.Unmarshal(src, state); err != nil {
+		return fmt.Errorf("reading the state of `%s`: %w", name, err)
+	}
+	return nil
+}
+
+// petMoods returns the moods of p, if it has any.
+func petMoods(p Pet) *MoodMachine {
+	switch pet := unwrapPet(p).(type) {
+	case *Cat:
+		return pet.Moods
+	case *Dog:
+		return pet.Moods
+	case *CustomPet:
+		return pet.Moods
+	}
+	return nil
+}
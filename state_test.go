@@ -0,0 +1,124 @@
+package petsounds
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pets.db")
+
+	config, err := LoadConfig("testdata/state.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &StateHCL{Path: "pets.db"}, config.State)
+
+	store, err := OpenStateStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, PetState{}, store.Pet("Ink"))
+
+	// A sleepy cat wakes up hungry once it has acted.
+	runner := &Runner{Out: &bytes.Buffer{}, State: store}
+	if !assert.NoError(t, runner.Run(config.Pets)) {
+		return
+	}
+	if !assert.NoError(t, runner.Close()) {
+		return
+	}
+
+	reopened, err := OpenStateStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer reopened.Close()
+	ink := reopened.Pet("Ink")
+	assert.Equal(t, MoodHungry, ink.Mood)
+	assert.True(t, ink.LastFed.IsZero())
+	if assert.Len(t, ink.Events, 2) {
+		assert.Equal(t, StateEvent{Event: eventSay, Text: "Ink meow (sleepy)", TS: ink.Events[0].TS}, ink.Events[0])
+		assert.Equal(t, StateEvent{Event: eventAct, Text: "Ink snoozes", TS: ink.Events[1].TS}, ink.Events[1])
+	}
+
+	// The next run starts in the mood the last one finished in.
+	config, err = LoadConfig("testdata/state.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	reopened.Restore(config.Pets)
+	assert.Equal(t, MoodHungry, petMoods(config.Pets[0]).Mood())
+
+	// Only the latest events are kept.
+	for i := 0; i < stateHistory; i++ {
+		assert.NoError(t, reopened.record(config.Pets[0], eventSay, "", time.Now()))
+	}
+	assert.Len(t, reopened.Pet("Ink").Events, stateHistory)
+}
+
+func TestStateStoreInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pets.db")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+
+	_, err = OpenStateStore(path)
+	assert.Error(t, err)
+
+	// A nil store remembers nothing.
+	var store *StateStore
+	assert.Equal(t, PetState{}, store.Pet("Ink"))
+	assert.NoError(t, store.record(&Cat{Name: "Ink"}, eventSay, "", time.Now()))
+	assert.NoError(t, store.Close())
+}
+
+func TestSchedulerState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenStateStore(filepath.Join(dir, "pets.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+	feedings, err := NewFeedings([]*FeedingHCL{{Schedule: "0 8,18 * * *", Food: "kibble"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ink := &Cat{Name: "Ink", Feedings: feedings}
+	assert.NoError(t, store.record(ink, eventFeed, "kibble", cronTime(t, "2020-07-31 08:00")))
+
+	// Ink missed dinner while the scheduler wasn't running, and is fed as
+	// soon as it starts.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &fakeClock{now: cronTime(t, "2020-08-01 07:30"), wakes: 1, cancel: cancel}
+	out, warnings := &bytes.Buffer{}, &bytes.Buffer{}
+	sched := &Scheduler{
+		Runner: Runner{Out: out, Warnings: warnings, State: store, Clock: clock},
+		after:  clock.after,
+	}
+	assert.NoError(t, sched.Run(ctx, []Pet{ink}))
+	assert.Equal(t, "Ink eats kibble\nInk snoozes\n", out.String())
+	assert.Equal(t, "pet-sounds warning: Ink missed 1 feeding time(s) for kibble, feeding 1 time(s)\n", warnings.String())
+	assert.Equal(t, cronTime(t, "2020-08-01 07:30"), store.Pet("Ink").LastFed)
+}
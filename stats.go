@@ -0,0 +1,154 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// Stats summarizes a configuration, for auditing large numbers of pets.
+// Each count is sorted from the most common to the least, and by name
+// among equal counts.
+type Stats struct {
+	Pets   int         `json:"pets"`
+	Types  []StatCount `json:"types"`
+	Breeds []StatCount `json:"breeds"`
+	// Sounds are the most common sounds, counting each sound a pet can
+	// make once.
+	Sounds []StatCount `json:"sounds"`
+	// Files are the number of pet blocks declared in each file, which is
+	// more than one for configurations that use modules.
+	Files []StatCount `json:"files"`
+}
+
+// StatCount is the number of times something was counted.
+type StatCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NewStats counts the pets of config, keeping the top most common sounds,
+// or all of them if top is 0.
+func NewStats(config *Config, top int) *Stats {
+	types, breeds, sounds, files := map[string]int{}, map[string]int{}, map[string]int{}, map[string]int{}
+	for _, p := range config.Pets {
+		_, petType := petIdentity(p)
+		types[petType]++
+		if dog, ok := unwrapPet(p).(*Dog); ok {
+			breeds[redacted(dog.Breed, dog.sensitive["breed"])]++
+		}
+		for _, sound := range petSounds(p) {
+			sounds[sound]++
+		}
+		if filename := declRange(unwrapPet(p)).Filename; filename != "" {
+			files[filename]++
+		}
+	}
+
+	stats := &Stats{
+		Pets: len(config.Pets), Types: statCounts(types), Breeds: statCounts(breeds),
+		Sounds: statCounts(sounds), Files: statCounts(files),
+	}
+	if top > 0 && len(stats.Sounds) > top {
+		stats.Sounds = stats.Sounds[:top]
+	}
+	return stats
+}
+
+// petSounds returns each sound p can make once: its sounds if it has any,
+// or else its single sound.
+func petSounds(p Pet) []string {
+	fields := fieldsOf(p)
+	sound, sounds := fields.sound, fields.sounds
+	if len(sounds) > 0 && fields.isSensitive("sounds") || len(sounds) == 0 && fields.isSensitive("sound") {
+		return []string{sensitiveText}
+	}
+	if len(sounds) == 0 {
+		if sound == "" {
+			return nil
+		}
+		return []string{sound}
+	}
+	unique, seen := []string{}, map[string]bool{}
+	for _, s := range sounds {
+		if !seen[s] {
+			unique, seen[s] = append(unique, s), true
+		}
+	}
+	return unique
+}
+
+// statCounts returns counts sorted from the largest to the smallest, and
+// by name among equal counts.
+func statCounts(counts map[string]int) []StatCount {
+	sorted := []StatCount{}
+	for name, count := range counts {
+		sorted = append(sorted, StatCount{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// WriteStatsTable writes stats to w as a table for each count.
+func WriteStatsTable(w io.Writer, stats *Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "PETS\t%d\n", stats.Pets)
+	for _, section := range []struct {
+		title  string
+		counts []StatCount
+	}{
+		{"TYPE", stats.Types},
+		{"BREED", stats.Breeds},
+		{"SOUND", stats.Sounds},
+		{"FILE", stats.Files},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "\n%s\tCOUNT\n", section.title)
+		for _, c := range section.counts {
+			fmt.Fprintf(tw, "%s\t%d\n", c.Name, c.Count)
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteStatsJSON writes stats to w as an indented JSON object.
+func WriteStatsJSON(w io.Writer, stats *Stats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
+
+// statsCommand writes a summary of the pets of a configuration to stdout,
+// as tables or as JSON.
+func statsCommand(flags *flag.FlagSet) func(args []string) error {
+	loadConfig := configFlags(flags)
+	top := flags.Int("top", 10, "the number of most common sounds to show, 0 shows them all")
+
+	return func(args []string) error {
+		write := WriteStatsTable
+		switch output := flagString(flags, "o"); output {
+		case "", "table":
+		case "json":
+			write = WriteStatsJSON
+		default:
+			return withExitCode(exitUsage, fmt.Errorf("unknown output format `%s`, expected table or json", output))
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		return write(os.Stdout, NewStats(config, *top))
+	}
+}
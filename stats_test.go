@@ -0,0 +1,42 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfig("testdata/module.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	stats := NewStats(config, 2)
+	assert.Equal(t, &Stats{
+		Pets:   5,
+		Types:  []StatCount{{"cat", 3}, {"dog", 2}},
+		Breeds: []StatCount{{"Corgi", 2}},
+		Sounds: []StatCount{{"meow", 3}, {"bark 1", 1}},
+		Files:  []StatCount{{"testdata/modules/barn/pets.hcl", 4}, {"testdata/module.hcl", 1}},
+	}, stats)
+
+	out := &bytes.Buffer{}
+	if assert.NoError(t, WriteStatsTable(out, &Stats{Pets: 2, Types: stats.Types})) {
+		assert.Equal(t, "PETS  2\n\nTYPE  COUNT\ncat   3\ndog   2\n", out.String())
+	}
+	out.Reset()
+	if assert.NoError(t, WriteStatsJSON(out, &Stats{Pets: 1, Types: []StatCount{{"cat", 1}}})) {
+		assert.JSONEq(t, `{"pets":1,"types":[{"name":"cat","count":1}],"breeds":null,"sounds":null,"files":null}`, out.String())
+	}
+}
+
+func TestPetSounds(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"meow"}, petSounds(&Cat{Sound: "meow"}))
+	assert.Equal(t, []string{"woof", "yip"}, petSounds(&Dog{Sound: "bark", Sounds: []string{"woof", "yip", "woof"}}))
+	assert.Nil(t, petSounds(&Dog{}))
+}
@@ -0,0 +1,187 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PetIterator decodes the pets of a configuration one at a time, so that
+// programs that handle each pet on its own don't need to keep every pet of
+// a configuration of tens of thousands of them in memory. The file is
+// still parsed whole, but each pet is only decoded when Next is called,
+// and only the pets others refer to are remembered after that.
+//
+// Pets come in the order they are declared, except that pets that refer to
+// other pets come after them.
+type PetIterator struct {
+	ctx         context.Context
+	opts        LoadOptions
+	petsHCL     *PetsHCL
+	evalContext *hcl.EvalContext
+	warnings    hcl.Diagnostics
+
+	// order is the order the pets are decoded in, by their index in
+	// petsHCL.PetHCLBodies, and next the position in it of the next pet.
+	order []int
+	next  int
+	// referenced are the names of the pets other pets refer to, whose values
+	// are kept in others once they have been decoded.
+	referenced map[string]bool
+	others     map[string]cty.Value
+	// skipped are the pets that failed to decode, with KeepGoing.
+	skipped []*SkippedPet
+
+	// With a DecodeCache, base is what the fingerprints of pets start
+	// from, fingerprints are those of the pets so far by name, and cached
+	// the pets so far by fingerprint, for the cache once every pet has
+	// been decoded.
+	base         []byte
+	fingerprints map[string]string
+	cached       map[string]Pet
+}
+
+// DecodePetIterator is DecodeConfigContext for a PetIterator over the pets
+// of src. Interactions are not decoded, as they need every pet.
+func DecodePetIterator(ctx context.Context, src []byte, filename string, opts LoadOptions) (*PetIterator, error) {
+	opts = opts.withDefaults()
+	petsHCL, evalContext, warnings, err := decodeGenericPets(ctx, src, filename, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Iterate through the generic pets, switch on type, then decode the
+	// hcl.Body into the correct pet type. This allows "polymorphism" in the
+	// pet blocks.
+	if diags := checkOwners(petsHCL.PetHCLBodies, petsHCL.OwnersHCL); diags.HasErrors() {
+		return nil, fmt.Errorf("error in DecodeConfig checking owners: %w", diags)
+	}
+	order, diags := decodeOrder(petsHCL.PetHCLBodies)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error in DecodeConfig resolving pet references: %w", diags)
+	}
+
+	referenced := map[string]bool{}
+	for _, p := range petsHCL.PetHCLBodies {
+		for _, ref := range petReferences(p) {
+			referenced[ref.name] = true
+		}
+	}
+	it := &PetIterator{
+		ctx: ctx, opts: opts, petsHCL: petsHCL, evalContext: evalContext, warnings: warnings,
+		order: order, referenced: referenced, others: map[string]cty.Value{},
+	}
+	if opts.DecodeCache != nil {
+		it.base, it.fingerprints, it.cached = decodeBase(opts), map[string]string{}, map[string]Pet{}
+	}
+	return it, nil
+}
+
+// Warnings are the problems found while loading the file that did not stop
+// it from loading.
+func (it *PetIterator) Warnings() hcl.Diagnostics {
+	return it.warnings
+}
+
+// Next decodes and returns the next pet, or io.EOF once every pet has been
+// returned. With KeepGoing, pets that fail to decode are skipped.
+func (it *PetIterator) Next() (Pet, error) {
+	if it.next >= len(it.order) {
+		if it.cached != nil {
+			it.opts.DecodeCache.replace(it.cached)
+			it.cached = nil
+		}
+		return nil, io.EOF
+	}
+	if err := it.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("error in DecodeConfig: %w", err)
+	}
+	p := it.petsHCL.PetHCLBodies[it.order[it.next]]
+	_, span := startSpan(it.ctx, it.opts.Spans, spanDecode, petSpanAttributes(p.Name, p.Type))
+	pet, err := it.decode(p)
+	span.End(err)
+	if err != nil && it.opts.KeepGoing {
+		it.skipped = append(it.skipped, &SkippedPet{Name: p.Name, Err: err})
+		it.next++
+		return it.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if it.referenced[p.Name] {
+		if it.others[p.Name], err = selfValue(pet); err != nil {
+			return nil, fmt.Errorf("error in DecodeConfig referring to pet `%s`: %w", p.Name, err)
+		}
+	}
+	it.opts.Hooks.decoded(pet)
+	it.next++
+	return pet, nil
+}
+
+// Skipped returns the pets that failed to decode and were skipped, with
+// KeepGoing, so far.
+func (it *PetIterator) Skipped() []*SkippedPet {
+	return it.skipped
+}
+
+// decode decodes p, unless it refers to a pet that was skipped, or the
+// DecodeCache has it already.
+func (it *PetIterator) decode(p *PetHCL) (Pet, error) {
+	refs := []string{}
+	for _, ref := range petReferences(p) {
+		for _, s := range it.skipped {
+			if s.Name == ref.name {
+				return nil, fmt.Errorf(
+					"error in DecodeConfig: pet `%s` refers to pet `%s`, which failed to decode", p.Name, ref.name,
+				)
+			}
+		}
+		refs = append(refs, it.fingerprints[ref.name])
+	}
+	if it.cached == nil {
+		return decodePet(p, it.petsHCL.DefaultsHCL, it.evalContext, it.others, it.opts)
+	}
+
+	fingerprint := petBlockFingerprint(it.base, it.opts.ParseCache, p, refs)
+	pet := it.opts.DecodeCache.get(fingerprint)
+	if pet == nil {
+		var err error
+		if pet, err = decodePet(p, it.petsHCL.DefaultsHCL, it.evalContext, it.others, it.opts); err != nil {
+			return nil, err
+		}
+	}
+	if fingerprint != "" {
+		it.fingerprints[p.Name], it.cached[fingerprint] = fingerprint, pet
+	}
+	return pet, nil
+}
+
+// declared returns the position of the pet Next returned last among the
+// pets in the order they were declared.
+func (it *PetIterator) declared() int {
+	return it.order[it.next-1]
+}
+
+// StreamConfig calls fn with each pet of src as it is decoded by a
+// PetIterator, stopping at the first error fn returns.
+func StreamConfig(ctx context.Context, src []byte, filename string, opts LoadOptions, fn func(Pet) error) error {
+	it, err := DecodePetIterator(ctx, src, filename, opts)
+	if err != nil {
+		return err
+	}
+	for {
+		pet, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(pet); err != nil {
+			return err
+		}
+	}
+}
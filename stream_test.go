@@ -0,0 +1,81 @@
+package petsounds
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const streamTestConfig = `pet "Swinney" {
+  type = "dog"
+  characteristics {
+    sound = "copies ${pet.Ink.sound}"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "meow"
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+}
+`
+
+func TestPetIterator(t *testing.T) {
+	t.Parallel()
+
+	it, err := DecodePetIterator(context.Background(), []byte(streamTestConfig), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	names := []string{}
+	for {
+		pet, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		name, _ := petIdentity(pet)
+		names = append(names, name)
+		if name == "Swinney" {
+			assert.Equal(t, "copies meow", pet.(*Dog).Sound)
+		}
+	}
+	// Swinney refers to Ink, so comes after her.
+	assert.Equal(t, []string{"Ink", "Swinney", "Neko"}, names)
+	// Only the pets others refer to are remembered.
+	assert.Len(t, it.others, 1)
+
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamConfig(t *testing.T) {
+	t.Parallel()
+
+	stop := errors.New("stop")
+	count := 0
+	err := StreamConfig(context.Background(), []byte(streamTestConfig), "pets.hcl", LoadOptions{}, func(p Pet) error {
+		count++
+		if count == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 2, count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = StreamConfig(ctx, []byte(streamTestConfig), "pets.hcl", LoadOptions{}, func(p Pet) error { return nil })
+	assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+}
@@ -0,0 +1,146 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Style changes how pets write their lines. The zero Style, like a nil one,
+// leaves them plain.
+type Style struct {
+	// Emoji prefixes each line with the emoji of the pet's type, and
+	// decorates sounds with the type's emphasis.
+	Emoji bool
+	// Art draws what pets say in a speech bubble, above ASCII art of the
+	// pet, which a pet can choose with its art characteristic.
+	Art bool
+	// NDJSON writes each thing a pet says or does as a JSON object on a line
+	// of its own, for log pipelines, instead of writing its line.
+	NDJSON bool
+	// Clock is the time NDJSON events are stamped with. Without it, the
+	// system clock is used.
+	Clock Clock
+}
+
+// utterance is one thing a pet says or does. Pets feed what they say and
+// do to their Style as utterances, and the Style renders them: text is what
+// was said or done, and who is how the pet is named on its line, like
+// "Swinney the mutt".
+type utterance struct {
+	pet     string
+	petType string
+	event   string
+	text    string
+	who     string
+	mood    Mood
+	art     string
+	volume  string
+}
+
+// styleEvent is an utterance as it is written by NDJSON styles.
+type styleEvent struct {
+	Pet    string    `json:"pet"`
+	Type   string    `json:"type,omitempty"`
+	Event  string    `json:"event"`
+	Text   string    `json:"text"`
+	Mood   Mood      `json:"mood,omitempty"`
+	Volume string    `json:"volume,omitempty"`
+	TS     time.Time `json:"ts"`
+}
+
+// The output formats of the -o flag.
+const (
+	outputText   = "text"
+	outputNDJSON = "ndjson"
+)
+
+// eventInteract is the event of interactions in NDJSON output.
+const eventInteract = "interact"
+
+// display returns the display of pets of petType.
+func display(petType string) petDisplay {
+	if kind, ok := petKinds[petType]; ok {
+		return kind.display
+	}
+	return unknownDisplay
+}
+
+// prefix returns what each line written by a pet of petType starts with.
+func (s *Style) prefix(petType string) string {
+	if s == nil || !s.Emoji {
+		return ""
+	}
+	return display(petType).emoji + " "
+}
+
+// sound returns sound, as said by a pet of petType.
+func (s *Style) sound(petType, sound string) string {
+	if s == nil || !s.Emoji {
+		return sound
+	}
+	return fmt.Sprintf(display(petType).emphasis, sound)
+}
+
+// line returns the line of output u is rendered as. What pets say is
+// spoken at their volume, and decorated with the emphasis of their type.
+func (s *Style) line(u utterance) string {
+	var line string
+	switch u.event {
+	case eventSay:
+		line = s.sound(u.petType, voiced(u.text, u.volume))
+		if u.mood != "" {
+			line += fmt.Sprintf(" (%s)", u.mood)
+		}
+	case eventFeed:
+		line = "eats " + u.text
+	default:
+		line = u.text
+	}
+	// Pets whose lines are whole, as those of pet_type blocks are, leave who
+	// empty.
+	if u.who == "" {
+		return line
+	}
+	return u.who + " " + line
+}
+
+// write writes u to w. What pets say is drawn with the template u.art in
+// art styles, where emoji are left out as they would misalign the bubble.
+func (s *Style) write(w io.Writer, u utterance) {
+	switch {
+	case s != nil && s.NDJSON:
+		// Encode writes the whole object, newline included, at once.
+		json.NewEncoder(w).Encode(styleEvent{
+			Pet: u.pet, Type: u.petType, Event: u.event, Text: u.text, Mood: u.mood, Volume: u.volume,
+			TS: clockOrSystem(s.Clock).Now(),
+		})
+	case s != nil && s.Art && u.event == eventSay:
+		writeArt(w, u.petType, u.art, s.line(u))
+	default:
+		fmt.Fprintf(w, "%s%s\n", s.prefix(u.petType), s.line(u))
+	}
+}
+
+// interact writes the interaction i to w.
+func (s *Style) interact(w io.Writer, i *Interaction) {
+	if s == nil || !s.NDJSON {
+		i.Do(w)
+		return
+	}
+	s.write(w, utterance{pet: i.From, event: eventInteract, text: i.Verb + " " + i.To})
+}
+
+// SetStyle has pets write their lines in s.
+func SetStyle(pets []Pet, s *Style) {
+	Walk(pets, VisitorFuncs{
+		Cat: func(c *Cat) { c.style = s },
+		Dog: func(d *Dog) { d.style = s },
+		Unknown: func(p Pet) {
+			if c, ok := p.(*CustomPet); ok {
+				c.style = s
+			}
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStyle(t *testing.T) {
+	tcs := []struct {
+		name  string
+		style *Style
+		want  string
+	}{
+		{
+			name: "plain",
+			want: "Ink meow\nInk snoozes\nSwinney the Dachshund barks\nSwinney the Dachshund plays\n",
+		},
+		{
+			name:  "zero",
+			style: &Style{},
+			want:  "Ink meow\nInk snoozes\nSwinney the Dachshund barks\nSwinney the Dachshund plays\n",
+		},
+		{
+			name:  "emoji",
+			style: &Style{Emoji: true},
+			want:  "🐱 Ink ~meow~\n🐱 Ink snoozes\n🐶 Swinney the Dachshund barks!\n🐶 Swinney the Dachshund plays\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pets := []Pet{
+				&Cat{Name: "Ink", Sound: defaultCatSound},
+				&Dog{Name: "Swinney", Breed: "Dachshund"},
+			}
+			SetStyle(pets, tc.style)
+			out := &bytes.Buffer{}
+			for _, p := range pets {
+				p.Say(out)
+				p.Act(out)
+			}
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+
+	assert.Equal(t, "🐾 ", (&Style{Emoji: true}).prefix("fish"))
+}
+
+func TestStyleNDJSON(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2020, 8, 1, 12, 0, 0, 0, time.UTC)
+	style := &Style{NDJSON: true, Clock: fixedClock(ts)}
+	pets := []Pet{
+		&Cat{Name: "Ink", Sound: defaultCatSound},
+		&Dog{Name: "Swinney", Breed: "Dachshund"},
+	}
+	SetStyle(pets, style)
+
+	out := &bytes.Buffer{}
+	runner := &Runner{Out: out, Style: style}
+	assert.NoError(t, runner.Run(pets))
+	runner.Interact([]*Interaction{{From: "Swinney", Verb: "chases", To: "Ink"}})
+
+	want := `{"pet":"Ink","type":"cat","event":"say","text":"meow","ts":"2020-08-01T12:00:00Z"}
+{"pet":"Ink","type":"cat","event":"act","text":"snoozes","ts":"2020-08-01T12:00:00Z"}
+{"pet":"Swinney","type":"dog","event":"say","text":"barks","ts":"2020-08-01T12:00:00Z"}
+{"pet":"Swinney","type":"dog","event":"act","text":"plays","ts":"2020-08-01T12:00:00Z"}
+{"pet":"Swinney","event":"interact","text":"chases Ink","ts":"2020-08-01T12:00:00Z"}
+`
+	assert.Equal(t, want, out.String())
+}
@@ -0,0 +1,62 @@
+package petsounds
+
+import (
+	"strings"
+)
+
+// suggest returns the candidate closest to given, ignoring case, for "did you
+// mean" style hints. It returns an empty string when nothing is close enough
+// to be a plausible typo.
+func suggest(given string, candidates []string) string {
+	given = strings.ToLower(given)
+	best, bestDistance := "", 0
+	for _, c := range candidates {
+		d := editDistance(given, strings.ToLower(c))
+		if best == "" || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+
+	// Allow roughly one mistake for every three characters.
+	if best == "" || bestDistance > len(given)/3+1 {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b: the number
+// of single character insertions, deletions and substitutions needed to turn
+// one into the other.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// Only the previous row of the distance matrix is needed to compute the
+	// next one.
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,35 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	tcs := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "cat", b: "cat", want: 0},
+		{a: "caat", b: "cat", want: 1},
+		{a: "dog", b: "cat", want: 3},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "", b: "pug", want: 3},
+	}
+	for _, tc := range tcs {
+		assert.Equal(t, tc.want, editDistance(tc.a, tc.b), "%s -> %s", tc.a, tc.b)
+		assert.Equal(t, tc.want, editDistance(tc.b, tc.a), "%s -> %s", tc.b, tc.a)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"Dachshund", "Pug", "Labrador Retriever"}
+
+	assert.Equal(t, "Dachshund", suggest("dachsund", candidates))
+	assert.Equal(t, "Labrador Retriever", suggest("labrador retreiver", candidates))
+	assert.Equal(t, "Pug", suggest("pugg", candidates))
+	assert.Equal(t, "", suggest("Poodle", candidates))
+	assert.Equal(t, "", suggest("pug", []string{}))
+}
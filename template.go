@@ -0,0 +1,174 @@
+package petsounds
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+const (
+	templateBlockType = "pet_template"
+	extendsKey        = "extends"
+)
+
+// PetTemplateHCL is a pet_template block, which pets extend to share a type,
+// characteristics and anything else a pet block can have:
+//   pet_template "lapdog" {
+//     type = "dog"
+//     characteristics {
+//       breed = "Chihuahua"
+//     }
+//   }
+//
+//   pet "Tiny" {
+//     extends = "lapdog"
+//   }
+// A pet's own attributes take the place of the template's, as they do in
+// override files, and templates can extend other templates.
+type PetTemplateHCL struct {
+	Name string   `hcl:"name,label"`
+	HCL  hcl.Body `hcl:",remain"`
+}
+
+// resolveTemplates replaces the body of every pet block in body that extends
+// a template with the template's body, overridden by the pet's own. The
+// pet_template blocks are removed once they have been used.
+func resolveTemplates(body *hclsyntax.Body) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	templates := map[string]*hclsyntax.Block{}
+	blocks := hclsyntax.Blocks{}
+	for _, block := range body.Blocks {
+		if block.Type != templateBlockType {
+			blocks = append(blocks, block)
+			continue
+		}
+		if len(block.Labels) != 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid pet template",
+				Detail:   "A pet_template block must have a single label, its name.",
+				Subject:  block.DefRange().Ptr(),
+			})
+			continue
+		}
+		if existing, ok := templates[block.Labels[0]]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate pet template",
+				Detail: fmt.Sprintf(
+					"The pet template `%s` was already declared at %s.", block.Labels[0], existing.DefRange(),
+				),
+				Subject: block.DefRange().Ptr(),
+			})
+			continue
+		}
+		templates[block.Labels[0]] = block
+	}
+	body.Blocks = blocks
+
+	r := &templateResolver{templates: templates, resolved: map[string]*hclsyntax.Body{}, resolving: map[string]bool{}}
+	for _, block := range body.Blocks {
+		if block.Type != "pet" {
+			continue
+		}
+		extended, extendDiags := r.extend(block.Body)
+		diags = append(diags, extendDiags...)
+		if extended != nil {
+			block.Body = extended
+		}
+	}
+	return diags
+}
+
+// templateResolver resolves the templates pets extend, each only once.
+type templateResolver struct {
+	templates map[string]*hclsyntax.Block
+	resolved  map[string]*hclsyntax.Body
+	resolving map[string]bool
+}
+
+// extend returns body merged over the template it extends, or body itself if
+// it extends no template.
+func (r *templateResolver) extend(body *hclsyntax.Body) (*hclsyntax.Body, hcl.Diagnostics) {
+	attr, ok := body.Attributes[extendsKey]
+	if !ok {
+		return body, nil
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	var name string
+	if err := gocty.FromCtyValue(val, &name); err != nil || val.Type() != cty.String {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid extends",
+			Detail:   "A pet extends a template by its name, which must be a string.",
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+
+	template, diags := r.template(name, attr.Expr.Range())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	extended := copyBody(template)
+	overrideBody(extended, body)
+	delete(extended.Attributes, extendsKey)
+	extended.SrcRange, extended.EndRange = body.SrcRange, body.EndRange
+	return extended, diags
+}
+
+// template returns the body of the template called name, itself extended
+// from any template it extends. rng is where the template is used.
+func (r *templateResolver) template(name string, rng hcl.Range) (*hclsyntax.Body, hcl.Diagnostics) {
+	if body, ok := r.resolved[name]; ok {
+		return body, nil
+	}
+	block, ok := r.templates[name]
+	if !ok {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown pet template",
+			Detail:   fmt.Sprintf("There is no pet_template block called `%s`.", name),
+			Subject:  rng.Ptr(),
+		}}
+	}
+	if r.resolving[name] {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Pet template cycle",
+			Detail:   fmt.Sprintf("The pet template `%s` extends itself.", name),
+			Subject:  rng.Ptr(),
+		}}
+	}
+
+	r.resolving[name] = true
+	body, diags := r.extend(block.Body)
+	delete(r.resolving, name)
+	if body != nil {
+		r.resolved[name] = body
+	}
+	return body, diags
+}
+
+// copyBody returns a copy of body that can be changed without changing
+// body, sharing only its expressions.
+func copyBody(body *hclsyntax.Body) *hclsyntax.Body {
+	c := *body
+	c.Attributes = hclsyntax.Attributes{}
+	for name, attr := range body.Attributes {
+		a := *attr
+		c.Attributes[name] = &a
+	}
+	c.Blocks = hclsyntax.Blocks{}
+	for _, block := range body.Blocks {
+		b := *block
+		b.Body = copyBody(block.Body)
+		c.Blocks = append(c.Blocks, &b)
+	}
+	return &c
+}
@@ -0,0 +1,30 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPetTemplates(t *testing.T) {
+	config, err := LoadConfig("testdata/template.hcl")
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{
+			&Dog{Name: "Tiny", Breed: "Chihuahua", Sound: "yips", Age: intPtr(3)},
+			&Dog{Name: "Biscuit", Breed: "Pomeranian", Sound: "yips", Age: intPtr(3)},
+			&Dog{
+				Name:    "Pip",
+				Breed:   "Chihuahua",
+				Sound:   "snores",
+				Actions: []string{"naps on a cushion"},
+				Age:     intPtr(3),
+			},
+		}, clearDeclRanges(config.Pets))
+	}
+
+	_, err = LoadConfig("testdata/template_invalid.hcl")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "template_invalid.hcl:6,13-16: Pet template cycle")
+		assert.Contains(t, err.Error(), "and 1 other diagnostic(s)")
+	}
+}
@@ -0,0 +1,15 @@
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed           = "Dachshund"
+    actions         = ["fetches the ball", "digs a hole"]
+    action_strategy = "cycle"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    actions = ["knocks a glass off the table"]
+  }
+}
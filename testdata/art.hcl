@@ -0,0 +1,13 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    art = "loaf"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
@@ -0,0 +1,8 @@
+allow_unknown_breeds = true
+
+pet "Laika" {
+  type = "dog"
+  characteristics {
+    breed = "Space Dog"
+  }
+}
@@ -0,0 +1,23 @@
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed      = "Dachshund"
+    vaccinated = false
+  }
+
+  precondition {
+    condition     = self.vaccinated == true
+    error_message = "${self.name} must be vaccinated before going to the park"
+    on            = "act"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+
+  postcondition {
+    condition     = self.age != null
+    error_message = "${self.name} should have an age"
+    severity      = "warning"
+  }
+}
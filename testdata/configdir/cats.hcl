@@ -0,0 +1,6 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "meow"
+  }
+}
@@ -0,0 +1,5 @@
+interaction {
+  from = "Ink"
+  to   = "Swinney"
+  verb = "hisses at"
+}
@@ -0,0 +1,5 @@
+pet "Ink" {
+  characteristics {
+    sound = "mrrp"
+  }
+}
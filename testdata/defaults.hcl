@@ -0,0 +1,31 @@
+defaults {
+  cat {
+    sound = "purr"
+    age   = 2
+  }
+  dog {
+    breed = "Corgi"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = "mew"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+
+pet "Biscuit" {
+  type = "dog"
+}
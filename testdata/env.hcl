@@ -0,0 +1,7 @@
+pet "Biscuit" {
+  type = "dog"
+  characteristics {
+    breed = env.DOG_BREED
+    sound = env.DOG_SOUND
+  }
+}
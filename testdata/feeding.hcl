@@ -0,0 +1,24 @@
+pet "Ink" {
+  type = "cat"
+
+  feeding {
+    schedule = "0 8,18 * * *"
+    food     = "kibble"
+  }
+
+  feeding {
+    schedule = "30 12 * * sat,sun"
+    food     = "tuna"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+
+  feeding {
+    schedule = "0 7 * * *"
+  }
+}
@@ -0,0 +1,8 @@
+pet "Ink" {
+  type = "cat"
+
+  feeding {
+    schedule = "0 25 * * *"
+    food     = "kibble"
+  }
+}
@@ -0,0 +1,22 @@
+owner "russell" {
+  email = "russell@example.com"
+}
+
+household "home" {
+  pet "Ink" {
+    type  = "cat"
+    owner = "russell"
+    characteristics {
+      sound = "mrrp"
+    }
+  }
+}
+
+pet "Swinney" {
+  type  = "dog"
+  owner = "russell"
+  characteristics {
+    breed = "Dachshund"
+    sound = "copies ${pet["home.Ink"].sound}"
+  }
+}
@@ -0,0 +1,34 @@
+defaults {
+  cat {
+    sound = "purr"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+}
+
+household "smith-family" {
+  defaults {
+    cat {
+      sound = "mrrp"
+    }
+  }
+
+  pet "Ink" {
+    type = "cat"
+  }
+
+  pet "Swinney" {
+    type = "dog"
+    characteristics {
+      breed = "Dachshund"
+    }
+  }
+}
+
+household "jones" {
+  pet "Neko" {
+    type = "cat"
+  }
+}
@@ -0,0 +1,7 @@
+pet "Ink" {
+  type = "cat"
+}
+
+include {
+  path = "./include/dogs.hcl"
+}
@@ -0,0 +1,3 @@
+include {
+  path = "cycle.hcl"
+}
@@ -0,0 +1,12 @@
+schema_version = 2
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+
+include {
+  path = "puppies.yaml"
+}
@@ -0,0 +1,3 @@
+include {
+  path = "invalid_fragment.hcl"
+}
@@ -0,0 +1,2 @@
+pet "Ink" {
+  type = "cat"
@@ -0,0 +1,22 @@
+pet "Ink" {
+  type = "cat"
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+
+interaction {
+  from = "Swinney"
+  to   = "Ink"
+  verb = "chases"
+}
+
+interaction {
+  from = "Ink"
+  to   = "Swinney"
+  verb = "ignores"
+}
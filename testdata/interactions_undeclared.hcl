@@ -0,0 +1,9 @@
+pet "Ink" {
+  type = "cat"
+}
+
+interaction {
+  from = "Swinney"
+  to   = "Ink"
+  verb = "chases"
+}
@@ -0,0 +1,9 @@
+cat {
+  sound = "yarr"
+}
+
+dog {
+  actions = {
+    default = "digs for treasure"
+  }
+}
@@ -0,0 +1,22 @@
+allow_unknown_breeds = false
+
+defaults {
+  cat {
+    sound = "purr"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+}
+
+include {
+  path = "./merge/cats.hcl"
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
@@ -0,0 +1,18 @@
+allow_unknown_breeds = true
+
+defaults {
+  cat {
+    sound = "mrrp"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "hiss"
+  }
+}
+
+pet "Tom" {
+  type = "cat"
+}
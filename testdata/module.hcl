@@ -0,0 +1,20 @@
+variable "breed" {
+  default = "Corgi"
+}
+
+pet "Ink" {
+  type = "cat"
+}
+
+module "barn" {
+  source = "./modules/barn"
+  count  = 2
+  breed  = var.breed
+  sound  = count.index == 0 ? "yip" : "bark"
+}
+
+interaction {
+  from = "Ink"
+  to   = "barn[1].Rex"
+  verb = "hisses at"
+}
@@ -0,0 +1,3 @@
+module "cycle" {
+  source = "./modules/cycle"
+}
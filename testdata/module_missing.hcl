@@ -0,0 +1,4 @@
+module "barn" {
+  source = "./modules/barn"
+  count  = 1
+}
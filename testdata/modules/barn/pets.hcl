@@ -0,0 +1,17 @@
+pet "Rex" {
+  type = "dog"
+  characteristics {
+    breed = var.breed
+    sound = "${var.sound} ${count.index}"
+  }
+}
+
+pet "Tom" {
+  type = "cat"
+}
+
+interaction {
+  from = "Rex"
+  to   = "Tom"
+  verb = "chases"
+}
@@ -0,0 +1,5 @@
+variable "breed" {}
+
+variable "sound" {
+  default = "woof"
+}
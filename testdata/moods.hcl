@@ -0,0 +1,20 @@
+pet "Ink" {
+  type = "cat"
+  moods {
+    initial = "sleepy"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+  moods {
+    transition {
+      from = "playful"
+      to   = "hungry"
+      on   = "say"
+    }
+  }
+}
@@ -0,0 +1,12 @@
+pet "Ink" {
+  characteristics {
+    sound = "mrrp"
+  }
+}
+
+pet "Swinney" {
+  validation {
+    condition     = self.breed == "Dachshund"
+    error_message = "Swinney is a dachshund."
+  }
+}
@@ -0,0 +1,5 @@
+pet "Swinney" {
+  characteristics {
+    sound = "arf"
+  }
+}
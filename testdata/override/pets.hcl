@@ -0,0 +1,19 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "meow"
+    age   = 4
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+
+  validation {
+    condition     = self.breed != ""
+    error_message = "Swinney needs a breed."
+  }
+}
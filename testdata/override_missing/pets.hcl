@@ -0,0 +1,3 @@
+pet "Ink" {
+  type = "cat"
+}
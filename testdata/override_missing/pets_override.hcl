@@ -0,0 +1,3 @@
+pet "Tom" {
+  type = "cat"
+}
@@ -0,0 +1,8 @@
+cat {
+  barn  = "mrow"
+  chirp = "mrrp?"
+}
+
+dog {
+  sheepdog = "wuff"
+}
@@ -0,0 +1,29 @@
+variable "sound" {
+  default = "meow"
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = var.sound
+  }
+}
+
+profile "dev" {
+  pet "Tester" {
+    type = "dog"
+  }
+}
+
+profile "prod" {
+  variables = {
+    sound = "MEOW"
+  }
+
+  pet "Swinney" {
+    type = "dog"
+    characteristics {
+      breed = "Corgi"
+    }
+  }
+}
@@ -0,0 +1,14 @@
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+    sound = "copies ${pet.Ink.name}, then ${pet["Ink"].sound}"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "mrrp"
+  }
+}
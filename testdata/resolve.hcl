@@ -0,0 +1,59 @@
+allow_unknown_breeds = true
+packs = ["farm"]
+
+pet_type "hamster" {
+  say_template = "${name} squeaks"
+  act_template = "${name} runs the wheel"
+}
+
+owner "Russell" {
+  email = "r@example.com"
+}
+
+tts {
+  url = "http://localhost:5002/tts"
+}
+
+state {
+  path = "pets.state"
+}
+
+notify {
+  url    = "http://localhost:9000/hook"
+  events = ["say"]
+}
+
+pet "Ink" {
+  type  = "cat"
+  owner = "Russell"
+  characteristics {
+    weight = "9 lb"
+  }
+  validation {
+    condition     = self.weight < 10
+    error_message = "Ink is too heavy."
+  }
+}
+
+pet "Hammy" {
+  type = "hamster"
+}
+
+pet "Dobbin" {
+  type = "horse"
+}
+
+household "Barn" {
+  pet "Rex" {
+    type = "dog"
+    characteristics {
+      breed = "Corgo"
+    }
+  }
+}
+
+interaction {
+  from = "Ink"
+  to   = "Barn.Rex"
+  verb = "hisses at"
+}
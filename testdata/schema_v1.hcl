@@ -0,0 +1,7 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound      = "meow"
+    audio_file = "testdata/meow.wav"
+  }
+}
@@ -0,0 +1,9 @@
+schema_version = 2
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound      = "meow"
+    audio_file = "testdata/meow.wav"
+  }
+}
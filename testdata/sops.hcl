@@ -0,0 +1,20 @@
+{
+	"data": "ENC[AES256_GCM,data:c2VjcmV0IHBldHM=,iv:aXZpdml2aXZpdml2,tag:dGFndGFndGFndGFn,type:str]",
+	"sops": {
+		"kms": null,
+		"gcp_kms": null,
+		"azure_kv": null,
+		"hc_vault": null,
+		"age": [
+			{
+				"recipient": "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+				"enc": "-----BEGIN AGE ENCRYPTED FILE-----\nYWdl\n-----END AGE ENCRYPTED FILE-----\n"
+			}
+		],
+		"lastmodified": "2020-09-01T12:00:00Z",
+		"mac": "ENC[AES256_GCM,data:bWFjbWFjbWFj,iv:aXZpdml2aXZpdml2,tag:dGFndGFndGFndGFn,type:str]",
+		"pgp": null,
+		"unencrypted_suffix": "_unencrypted",
+		"version": "3.6.1"
+	}
+}
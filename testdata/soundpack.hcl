@@ -0,0 +1,16 @@
+sound_pack = "./packs/farm.hcl"
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sounds = [pack.cat.barn, pack.cat.chirp]
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+    sound = pack.dog.sheepdog
+  }
+}
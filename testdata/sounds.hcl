@@ -0,0 +1,15 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sounds         = ["meow", "mrrp", "hiss"]
+    sound_strategy = "cycle"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+    sound = "woofs"
+  }
+}
@@ -0,0 +1,11 @@
+state {
+  path = "pets.db"
+}
+
+pet "Ink" {
+  type = "cat"
+
+  moods {
+    initial = "sleepy"
+  }
+}
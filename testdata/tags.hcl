@@ -0,0 +1,14 @@
+pet "Ink" {
+  type = "cat"
+  tags = ["indoor", "senior"]
+}
+
+pet "Neko" {
+  type = "cat"
+  tags = ["indoor"]
+}
+
+pet "Swinney" {
+  type = "dog"
+  tags = ["outdoor"]
+}
@@ -0,0 +1,33 @@
+pet_template "lapdog" {
+  type = "dog"
+  characteristics {
+    breed = "Chihuahua"
+    sound = "yips"
+    age   = 3
+  }
+}
+
+pet_template "sleepy_lapdog" {
+  extends = "lapdog"
+  characteristics {
+    actions = ["naps on a cushion"]
+  }
+}
+
+pet "Tiny" {
+  extends = "lapdog"
+}
+
+pet "Biscuit" {
+  extends = "lapdog"
+  characteristics {
+    breed = "Pomeranian"
+  }
+}
+
+pet "Pip" {
+  extends = "sleepy_lapdog"
+  characteristics {
+    sound = "snores"
+  }
+}
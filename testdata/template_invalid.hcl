@@ -0,0 +1,15 @@
+pet_template "a" {
+  extends = "b"
+}
+
+pet_template "b" {
+  extends = "a"
+}
+
+pet "Tiny" {
+  extends = "a"
+}
+
+pet "Rex" {
+  extends = "wolf"
+}
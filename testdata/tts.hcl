@@ -0,0 +1,11 @@
+tts {
+  url   = "http://localhost:5002/api/tts"
+  voice = "en-gb"
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    voice = "en-scottish"
+  }
+}
@@ -0,0 +1,16 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sounds = ["meow", "mrrp"]
+  }
+
+  validation {
+    condition     = length(self.name) > 1
+    error_message = "name too short"
+  }
+
+  validation {
+    condition     = length(self.sounds) > 0 && self.age == null
+    error_message = "${self.name} needs sounds"
+  }
+}
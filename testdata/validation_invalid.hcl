@@ -0,0 +1,8 @@
+pet "X" {
+  type = "dog"
+
+  validation {
+    condition     = length(self.name) > 1
+    error_message = "${self.type} name too short"
+  }
+}
@@ -0,0 +1,7 @@
+pet "Biscuit" {
+  type = "dog"
+  characteristics {
+    breed = vault("kv/pets", "breed")
+    sound = vault("secret/pets", "sound")
+  }
+}
@@ -0,0 +1,33 @@
+pet "Ink" {
+  type = "cat"
+
+  vet_visit {
+    date     = "2019-03-01"
+    reason   = "checkup"
+    next_due = "2020-03-01"
+  }
+
+  vet_visit {
+    date     = "2020-03-01"
+    reason   = "checkup"
+    next_due = "2021-03-01"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+
+  vet_visit {
+    date     = "2020-06-10"
+    reason   = "vaccination"
+    next_due = "2020-08-10"
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+
+  vet_visit {
+    date = "2020-07-01"
+  }
+}
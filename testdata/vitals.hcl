@@ -0,0 +1,9 @@
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    age          = 4
+    weight       = 5.5
+    vaccinated   = true
+    nap_duration = "45m"
+  }
+}
@@ -0,0 +1,8 @@
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed  = "Dachshund"
+    age    = -1
+    weight = 12.5
+  }
+}
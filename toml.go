@@ -0,0 +1,98 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlToHCL translates a TOML pet configuration into native syntax HCL, so
+// that it can be loaded like any other configuration. The TOML document has
+// the same shape as a JSON document given to ConvertToHCL, for example:
+//   [[pet]]
+//   name = "Ink"
+//   type = "cat"
+//   [pet.characteristics]
+//   sound = "${env.CAT_SOUND}"
+// As in HCL JSON, strings are templates and can interpolate expressions.
+func tomlToHCL(src []byte, filename string) ([]byte, error) {
+	doc := map[string]interface{}{}
+	md, err := toml.Decode(string(src), &doc)
+	if err != nil {
+		return nil, fmt.Errorf("error in tomlToHCL parsing `%s`: %w", filename, err)
+	}
+
+	// TOML tables decode into maps, so the order of keys in the document is
+	// recovered from the decoder's metadata. Tables that are only implied by
+	// a nested table's name, like pet in [pet.Ink], come where their first
+	// nested key does.
+	order := map[string]int{}
+	for i, key := range md.Keys() {
+		for j := 1; j <= len(key); j++ {
+			if _, ok := order[key[:j].String()]; !ok {
+				order[key[:j].String()] = i
+			}
+		}
+	}
+
+	out, err := documentHCL(tomlTable(doc, nil, order), filename)
+	if err != nil {
+		return nil, fmt.Errorf("error in tomlToHCL: %w", err)
+	}
+	return out, nil
+}
+
+// tomlTable converts a decoded TOML table at path into the values
+// parseOrderedJSON returns, ordering its keys with order.
+func tomlTable(table map[string]interface{}, path []string, order map[string]int) jsonObject {
+	keys := []string{}
+	for k := range table {
+		keys = append(keys, k)
+	}
+	position := func(k string) int {
+		return order[toml.Key(append(append([]string{}, path...), k)).String()]
+	}
+	sort.Slice(keys, func(i, j int) bool { return position(keys[i]) < position(keys[j]) })
+
+	obj := jsonObject{}
+	for _, k := range keys {
+		obj = append(obj, jsonMember{key: k, value: tomlValue(table[k], append(path, k), order)})
+	}
+	return obj
+}
+
+// tomlValue converts a decoded TOML value at path.
+func tomlValue(v interface{}, path []string, order map[string]int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return tomlTable(v, path, order)
+	case []map[string]interface{}:
+		all := []interface{}{}
+		for _, table := range v {
+			all = append(all, tomlTable(table, path, order))
+		}
+		return all
+	case []interface{}:
+		all := []interface{}{}
+		for _, e := range v {
+			all = append(all, tomlValue(e, path, order))
+		}
+		return all
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10))
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		return v
+	case bool:
+		return v
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
+}
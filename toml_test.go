@@ -0,0 +1,108 @@
+package petsounds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOMLToHCL(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "values",
+			src: `schema_version = 2
+
+[pet.Ink]
+type = "cat"
+
+[pet.Ink.characteristics]
+sounds = ["meow", "mrrp"]
+age = 4
+weight = 5.5
+vaccinated = true
+breed = "${random(\"a\", \"b\")}"
+
+[[pet.Ink.validation]]
+condition = "${self.age > 1}"
+error_message = "too young"
+`,
+			want: `schema_version = 2
+
+pet "Ink" {
+  type = "cat"
+
+  characteristics {
+    sounds     = ["meow", "mrrp"]
+    age        = 4
+    weight     = 5.5
+    vaccinated = true
+    breed      = random("a", "b")
+  }
+
+  validation {
+    condition     = self.age > 1
+    error_message = "too young"
+  }
+}
+`,
+		},
+		{
+			name:    "invalid toml",
+			src:     "[pet",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tomlToHCL([]byte(tc.src), "test.toml")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, string(got))
+			}
+		})
+	}
+}
+
+func TestLoadConfigFormat(t *testing.T) {
+	// A TOML file without a .toml extension needs its format given.
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	src, err := ioutil.ReadFile("testdata/basic.toml")
+	if !assert.Nil(t, err) {
+		return
+	}
+	filename := filepath.Join(dir, "pets.conf")
+	if !assert.Nil(t, ioutil.WriteFile(filename, src, 0644)) {
+		return
+	}
+
+	_, err = LoadConfig(filename)
+	assert.Error(t, err)
+
+	config, err := LoadConfigFormat(filename, FormatTOML)
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Len(t, config.Pets, 2)
+	}
+
+	_, err = LoadConfigFormat(filename, "ini")
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// evalTracer writes what decoding a configuration evaluates, for
+// LoadOptions.Trace. A nil evalTracer traces nothing. It is safe for
+// concurrent use, as modules are parsed concurrently.
+type evalTracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// newEvalTracer returns an evalTracer that writes to w, or nil if w is nil.
+func newEvalTracer(w io.Writer) *evalTracer {
+	if w == nil {
+		return nil
+	}
+	return &evalTracer{w: w}
+}
+
+// printf writes a line of the trace.
+func (t *evalTracer) printf(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.w, "pet-sounds trace: "+format+"\n", args...)
+}
+
+// random traces a call of the random function with args, which drew result.
+func (t *evalTracer) random(args []cty.Value, result cty.Value) {
+	if t == nil {
+		return
+	}
+	values := []string{}
+	for _, arg := range args {
+		values = append(values, valueString(arg))
+	}
+	t.printf("random(%s) = %s", strings.Join(values, ", "), valueString(result))
+}
+
+// characteristics traces the characteristics of the pet name that body set,
+// which were evaluated in ctx and decoded into pet, along with the
+// variables each of them read. The variables a sensitive characteristic
+// read are as sensitive as it is.
+func (t *evalTracer) characteristics(name string, k *petKind, body hcl.Body, ctx *hcl.EvalContext, pet Pet) {
+	if t == nil {
+		return
+	}
+	self, err := selfValue(pet)
+	if err != nil {
+		return
+	}
+	content, _, _ := body.PartialContent(hcldec.ImpliedSchema(k.spec))
+	attrs := []*hcl.Attribute{}
+	for _, attr := range content.Attributes {
+		attrs = append(attrs, attr)
+	}
+	// Attributes are a map, so they are traced in the order they are
+	// written.
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Range.Start.Byte < attrs[j].Range.Start.Byte })
+
+	for _, attr := range attrs {
+		value := self.GetAttr(attr.Name)
+		lines := []string{fmt.Sprintf("%s: %s.%s = %s", attr.Expr.Range(), name, attr.Name, valueString(value))}
+		for _, traversal := range attr.Expr.Variables() {
+			variable, diags := traversal.TraverseAbs(ctx)
+			if diags.HasErrors() {
+				continue
+			}
+			if isSensitive(value) {
+				variable = variable.Mark(sensitiveMark)
+			}
+			lines = append(lines, fmt.Sprintf("  %s = %s", traversalString(traversal), valueString(variable)))
+		}
+		t.printf("%s", strings.Join(lines, "\n"))
+	}
+}
+
+// valueString returns value as it would be written in HCL, or
+// "(sensitive)" if it is sensitive.
+func valueString(value cty.Value) string {
+	if isSensitive(value) {
+		return sensitiveText
+	}
+	if !value.IsWhollyKnown() {
+		return "(unknown)"
+	}
+	return string(hclwrite.TokensForValue(value).Bytes())
+}
+
+// traversalString returns traversal as it is written, as in pet.Neko.sound
+// or env["CAT_SOUND"].
+func traversalString(traversal hcl.Traversal) string {
+	s := traversal.RootName()
+	for _, step := range traversal[1:] {
+		switch step := step.(type) {
+		case hcl.TraverseAttr:
+			s += "." + step.Name
+		case hcl.TraverseIndex:
+			s += "[" + valueString(step.Key) + "]"
+		}
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package petsounds
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceEval(t *testing.T) {
+	t.Parallel()
+
+	src := `defaults {
+  dog {
+    breed = "Corgi"
+  }
+}
+
+pet "Neko" {
+  type = "cat"
+  characteristics {
+    sound = random("mew", "mew")
+    age   = 2
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    sound = "copies ${pet.Neko.sound} in ${env.TRACE_ROOM}"
+  }
+}
+`
+	trace := &bytes.Buffer{}
+	_, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{
+		Env: map[string]string{"TRACE_ROOM": "the hall"}, Rand: rand.NewSource(1), Trace: trace,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, strings.Join([]string{
+		`pet-sounds trace: random("mew", "mew") = "mew"`,
+		`pet-sounds trace: pets.hcl:10,13-33: Neko.sound = "mew"`,
+		`pet-sounds trace: pets.hcl:11,13-14: Neko.age = 2`,
+		`pet-sounds trace: pets.hcl:3,13-20: Swinney.breed = "Corgi"`,
+		`pet-sounds trace: pets.hcl:18,13-60: Swinney.sound = "copies mew in the hall"`,
+		`  pet.Neko.sound = "mew"`,
+		`  env.TRACE_ROOM = "the hall"`,
+		``,
+	}, "\n"), trace.String())
+}
+
+func TestTraceEvalOff(t *testing.T) {
+	t.Parallel()
+
+	var tracer *evalTracer
+	assert.NotPanics(t, func() { tracer.printf("nothing to %s", "trace") })
+	assert.Nil(t, newEvalTracer(nil))
+}
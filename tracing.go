@@ -0,0 +1,51 @@
+package petsounds
+
+import (
+	"context"
+)
+
+// SpanTracer records spans of the work pet-sounds does, so that programs
+// embedding it can see where the time goes in their own tracing stack:
+// parsing a configuration, creating the context it is evaluated in,
+// decoding each pet, and each time a pet Says or Acts. It is small enough
+// to implement over an OpenTelemetry trace.Tracer, and OTLPTracer exports
+// the spans itself.
+type SpanTracer interface {
+	// Start starts a span named name, as a child of the span of ctx if it
+	// has one, and returns ctx with the new span.
+	Start(ctx context.Context, name string, attrs map[string]string) (context.Context, Span)
+}
+
+// Span is a span started by a SpanTracer.
+type Span interface {
+	// End ends the span, as failed if err is not nil.
+	End(err error)
+}
+
+// The names of the spans pet-sounds records. Pets Saying and Acting are
+// recorded as pet-sounds.say and pet-sounds.act.
+const (
+	spanParse   = "pet-sounds.parse"
+	spanContext = "pet-sounds.context"
+	spanDecode  = "pet-sounds.decode"
+	spanEvent   = "pet-sounds."
+)
+
+// startSpan starts a span with t, or a span that records nothing if t is
+// nil.
+func startSpan(ctx context.Context, t SpanTracer, name string, attrs map[string]string) (context.Context, Span) {
+	if t == nil {
+		return ctx, noSpan{}
+	}
+	return t.Start(ctx, name, attrs)
+}
+
+// petSpanAttributes are the attributes of the spans of a pet.
+func petSpanAttributes(name, petType string) map[string]string {
+	return map[string]string{"pet.name": name, "pet.type": petType}
+}
+
+// noSpan is a Span that records nothing.
+type noSpan struct{}
+
+func (noSpan) End(error) {}
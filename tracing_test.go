@@ -0,0 +1,83 @@
+package petsounds
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTracer is a SpanTracer that records the spans that end, as
+// name, attributes and whether they failed.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	attrs  map[string]string
+	failed bool
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, Span) {
+	return ctx, spanFunc(func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.spans = append(r.spans, recordedSpan{name: name, attrs: attrs, failed: err != nil})
+	})
+}
+
+type spanFunc func(err error)
+
+func (f spanFunc) End(err error) {
+	f(err)
+}
+
+func TestDecodeConfigSpans(t *testing.T) {
+	t.Parallel()
+
+	src, err := ioutil.ReadFile("testdata/basic.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	tracer := &recordingTracer{}
+	_, err = DecodeConfig(src, "testdata/basic.hcl", LoadOptions{Spans: tracer})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []recordedSpan{
+		{name: spanParse, attrs: map[string]string{"file": "testdata/basic.hcl", "format": FormatHCL}},
+		{name: spanContext},
+		{name: spanDecode, attrs: map[string]string{"pet.name": "Ink", "pet.type": "cat"}},
+		{name: spanDecode, attrs: map[string]string{"pet.name": "Swinney", "pet.type": "dog"}},
+	}, tracer.spans)
+
+	// A file that doesn't parse ends its span as failed.
+	tracer = &recordingTracer{}
+	_, err = DecodeConfig([]byte(`pet "Ink" {`), "broken.hcl", LoadOptions{Spans: tracer})
+	assert.Error(t, err)
+	if assert.Len(t, tracer.spans, 1) {
+		assert.True(t, tracer.spans[0].failed)
+	}
+}
+
+func TestRunnerSpans(t *testing.T) {
+	t.Parallel()
+
+	tracer := &recordingTracer{}
+	runner := &Runner{Out: ioutil.Discard, Spans: tracer}
+	assert.NoError(t, runner.Run([]Pet{&Cat{Name: "Ink", Sound: "meow"}}))
+	attrs := map[string]string{"pet.name": "Ink", "pet.type": "cat"}
+	assert.Equal(t, []recordedSpan{
+		{name: "pet-sounds.say", attrs: attrs},
+		{name: "pet-sounds.act", attrs: attrs},
+	}, tracer.spans)
+
+	// Without a SpanTracer, nothing is recorded.
+	_, span := startSpan(context.Background(), nil, spanParse, nil)
+	span.End(errors.New("nothing to record it"))
+}
@@ -0,0 +1,144 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// Speaker vocalizes text. Implementations are the pluggable backends of the
+// --tts mode.
+type Speaker interface {
+	// Speak says text aloud in the given voice, returning once it has been
+	// said. An empty voice is the backend's default voice.
+	Speak(text, voice string) error
+}
+
+// TTSHCL is the optional top-level tts block, which configures an HTTP text
+// to speech service instead of the operating system's own. It is represented
+// in hcl as:
+//   tts {
+//     url   = "<service URL>"
+//     voice = "<default voice>"
+//   }
+type TTSHCL struct {
+	URL   string `hcl:"url"`
+	Voice string `hcl:"voice,optional"`
+}
+
+// NewSpeaker returns the Speaker configured by a tts block, or one using the
+// operating system's own text to speech if tts is nil.
+func NewSpeaker(tts *TTSHCL) Speaker {
+	if tts == nil {
+		return &CommandSpeaker{}
+	}
+	return &HTTPSpeaker{
+		URL:          tts.URL,
+		DefaultVoice: tts.Voice,
+		Player:       &AudioPlayer{},
+	}
+}
+
+// CommandSpeaker speaks using the operating system's command line text to
+// speech tool: say on macOS, PowerShell on Windows, and espeak-ng or espeak
+// elsewhere.
+type CommandSpeaker struct{}
+
+func (c *CommandSpeaker) Speak(text, voice string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		args := []string{}
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		cmd = exec.Command("say", append(args, text)...)
+	case "windows":
+		// The text and voice are handed to the script through the
+		// environment, so they never need quoting for PowerShell.
+		script := "Add-Type -AssemblyName System.Speech; " +
+			"$s = New-Object System.Speech.Synthesis.SpeechSynthesizer; " +
+			"if ($env:PET_SOUNDS_VOICE) { $s.SelectVoice($env:PET_SOUNDS_VOICE) }; " +
+			"$s.Speak($env:PET_SOUNDS_TEXT)"
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", script)
+		cmd.Env = append(os.Environ(), "PET_SOUNDS_TEXT="+text, "PET_SOUNDS_VOICE="+voice)
+	default:
+		espeak := "espeak-ng"
+		if _, err := exec.LookPath(espeak); err != nil {
+			espeak = "espeak"
+		}
+		args := []string{}
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		cmd = exec.Command(espeak, append(args, text)...)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error in CommandSpeaker.Speak: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// HTTPSpeaker speaks using an HTTP text to speech service. It POSTs a JSON
+// object with "text" and "voice" fields to URL, and plays the audio file the
+// service responds with.
+type HTTPSpeaker struct {
+	URL          string
+	DefaultVoice string
+	Player       *AudioPlayer
+	Client       *http.Client
+}
+
+func (h *HTTPSpeaker) Speak(text, voice string) error {
+	if voice == "" {
+		voice = h.DefaultVoice
+	}
+	body, err := json.Marshal(map[string]string{"text": text, "voice": voice})
+	if err != nil {
+		return fmt.Errorf("error in HTTPSpeaker.Speak encoding request: %w", err)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error in HTTPSpeaker.Speak calling `%s`: %w", h.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error in HTTPSpeaker.Speak: `%s` responded %s", h.URL, resp.Status)
+	}
+
+	// The audio is written to a temporary file so it can be handed to the
+	// AudioPlayer like any other pet sound.
+	audio, err := ioutil.TempFile("", "pet-sounds-tts")
+	if err != nil {
+		return fmt.Errorf("error in HTTPSpeaker.Speak creating audio file: %w", err)
+	}
+	defer os.Remove(audio.Name())
+	_, err = audio.ReadFrom(resp.Body)
+	audio.Close()
+	if err != nil {
+		return fmt.Errorf("error in HTTPSpeaker.Speak reading audio: %w", err)
+	}
+
+	player := h.Player
+	if player == nil {
+		player = &AudioPlayer{}
+	}
+	return player.PlayFile(audio.Name())
+}
+
+// petVoice returns the voice characteristic of p, if it has one.
+func petVoice(p Pet) string {
+	return fieldsOf(p).voice
+}
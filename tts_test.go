@@ -0,0 +1,96 @@
+package petsounds
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSpeaker is a Speaker that remembers everything it was asked to
+// say.
+type recordingSpeaker struct {
+	mu     sync.Mutex
+	spoken []string
+}
+
+func (r *recordingSpeaker) Speak(text, voice string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spoken = append(r.spoken, voice+": "+text)
+	return nil
+}
+
+func TestRunnerSpeaker(t *testing.T) {
+	speaker := &recordingSpeaker{}
+	runner := &Runner{Out: &bytes.Buffer{}, Speaker: speaker}
+	err := runner.Run([]Pet{
+		&Cat{Name: "Ink", Sound: "meow", Voice: "en-scottish"},
+		&Dog{Name: "Swinney", Breed: "Dachshund"},
+	})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{
+			"en-scottish: Ink meow",
+			": Swinney the Dachshund barks",
+		}, speaker.spoken)
+	}
+}
+
+func TestNewSpeaker(t *testing.T) {
+	config, err := LoadConfig("testdata/tts.hcl")
+	if !assert.Nil(t, err, "error while parsing input") {
+		return
+	}
+	assert.Equal(t, &TTSHCL{URL: "http://localhost:5002/api/tts", Voice: "en-gb"}, config.TTS)
+
+	speaker, ok := NewSpeaker(config.TTS).(*HTTPSpeaker)
+	if assert.True(t, ok) {
+		assert.Equal(t, "http://localhost:5002/api/tts", speaker.URL)
+		assert.Equal(t, "en-gb", speaker.DefaultVoice)
+	}
+	assert.IsType(t, &CommandSpeaker{}, NewSpeaker(nil))
+}
+
+func TestHTTPSpeaker(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake audio player is a shell script")
+	}
+
+	var request map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte("RIFF audio"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	record := filepath.Join(dir, "played")
+
+	// The fake player keeps a copy of the audio it was asked to play.
+	speaker := &HTTPSpeaker{
+		URL:          server.URL,
+		DefaultVoice: "en-gb",
+		Player:       &AudioPlayer{Command: []string{"sh", "-c", `cp "$1" "$0"`, record}},
+	}
+
+	if assert.Nil(t, speaker.Speak("Ink meow", "")) {
+		assert.Equal(t, map[string]string{"text": "Ink meow", "voice": "en-gb"}, request)
+		played, err := ioutil.ReadFile(record)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "RIFF audio", string(played))
+		}
+	}
+}
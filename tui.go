@@ -0,0 +1,201 @@
+package petsounds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// clearScreen moves the cursor to the top left of a terminal and clears it.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// The keys of a Dashboard that aren't a single printable character.
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyCtrlC = "\x03"
+)
+
+// dashboardRefresh is the time between the redraws of a Dashboard when no
+// key is pressed, unless it sets its own.
+const dashboardRefresh = time.Second
+
+// Dashboard is an interactive view of the pets, for the tui command. It
+// shows each pet's mood and the last thing it said or did, and is driven a
+// key at a time, from a terminal in raw mode:
+//   up, down, j, k   select a pet
+//   s                the selected pet Says something
+//   a                the selected pet Acts
+//   q, ctrl-c        quit
+// It is redrawn after each key, and every Refresh, so that moods that
+// change over time are shown as they do. Pets Say and Act through the
+// Runner, so their conditions, hooks and state apply as they do for any
+// other run, and the last thing each said or did comes from its Event
+// hook.
+type Dashboard struct {
+	// Runner runs the pets, so a Dashboard shares its hooks and state. The
+	// dashboard is drawn to its Out.
+	Runner
+
+	// Refresh is the time between redraws when no key is pressed. Without
+	// it, the dashboard is redrawn every second.
+	Refresh time.Duration
+}
+
+// Run draws the dashboard of pets and carries out the keys read from in,
+// until in ends or q or ctrl-c is pressed.
+func (d *Dashboard) Run(in io.Reader, pets []Pet) error {
+	pets = enabledPets(pets)
+	last := map[Pet]string{}
+	runner := d.Runner
+	runner.Hooks = dashboardHooks(runner.Hooks, last)
+
+	keys, errs, done := make(chan string), make(chan error, 1), make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(keys)
+		errs <- readKeys(bufio.NewReader(in), keys, done)
+	}()
+	refresh := d.Refresh
+	if refresh <= 0 {
+		refresh = dashboardRefresh
+	}
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	selected, status := 0, ""
+	for {
+		d.draw(pets, last, selected, status)
+		var key string
+		select {
+		case <-ticker.C:
+			continue
+		case k, ok := <-keys:
+			if !ok {
+				return <-errs
+			}
+			key = k
+		}
+
+		status = ""
+		var event string
+		switch key {
+		case "q", keyCtrlC:
+			return nil
+		case keyUp, "k":
+			if selected > 0 {
+				selected--
+			}
+			continue
+		case keyDown, "j":
+			if selected < len(pets)-1 {
+				selected++
+			}
+			continue
+		case "s":
+			event = eventSay
+		case "a":
+			event = eventAct
+		default:
+			continue
+		}
+		if len(pets) == 0 {
+			status = "there are no pets"
+			continue
+		}
+		if err := runner.do(pets[selected], ioutil.Discard, event); err != nil {
+			status = err.Error()
+		}
+	}
+}
+
+// dashboardHooks returns h, which may be nil, with an Event hook that
+// records the line of each event in last after calling the Event hook of
+// h.
+func dashboardHooks(h *Hooks, last map[Pet]string) *Hooks {
+	hooks := &Hooks{}
+	if h != nil {
+		*hooks = *h
+	}
+	next := hooks.Event
+	hooks.Event = func(p Pet, event, line string) {
+		if next != nil {
+			next(p, event, line)
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			last[p] = line
+		}
+	}
+	return hooks
+}
+
+// readKeys sends the keys read from r to keys until r ends, or done is
+// closed. The escape sequences of the arrow keys are sent as keyUp and
+// keyDown, and other escape sequences are dropped.
+func readKeys(r *bufio.Reader, keys chan<- string, done <-chan struct{}) error {
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key := string(b)
+		if b == '\x1b' {
+			// A terminal sends the whole sequence of a key at once, so an
+			// escape with nothing after it is the escape key itself.
+			if r.Buffered() < 2 {
+				continue
+			}
+			seq := make([]byte, 2)
+			if _, err := io.ReadFull(r, seq); err != nil {
+				return err
+			}
+			switch string(seq) {
+			case "[A":
+				key = keyUp
+			case "[B":
+				key = keyDown
+			default:
+				continue
+			}
+		}
+		select {
+		case keys <- key:
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// draw clears the terminal and writes the dashboard to the Runner's Out,
+// with the selected pet marked. A terminal in raw mode doesn't return the
+// cursor to the start of a line on a newline, so lines end with \r\n.
+func (d *Dashboard) draw(pets []Pet, last map[Pet]string, selected int, status string) {
+	b := &strings.Builder{}
+	tw := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, " \tNAME\tTYPE\tMOOD\tLAST")
+	for i, p := range pets {
+		marker := " "
+		if i == selected {
+			marker = ">"
+		}
+		name, petType := petIdentity(p)
+		mood := string(petMoods(p).Mood())
+		if mood == "" {
+			mood = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", marker, name, petType, mood, last[p])
+	}
+	tw.Flush()
+	if status != "" {
+		fmt.Fprintf(b, "\n%s\n", status)
+	}
+	fmt.Fprint(b, "\nup/down or j/k to select, s to say, a to act, q to quit\n")
+	fmt.Fprint(d.Out, clearScreen+strings.ReplaceAll(b.String(), "\n", "\r\n"))
+}
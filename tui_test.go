@@ -0,0 +1,66 @@
+package petsounds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	said := []string{}
+	d := &Dashboard{Runner: Runner{Out: out, Hooks: &Hooks{BeforeSay: func(p Pet) {
+		name, _ := petIdentity(p)
+		said = append(said, name)
+	}}}}
+	pets := []Pet{
+		&Cat{Name: "Ink", Sound: "meow"},
+		&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "woofs"},
+	}
+
+	// Down arrow, say, k, act, j twice, the second past the last pet, x,
+	// quit, and a key that is never read.
+	err := d.Run(strings.NewReader("\x1b[Bskajjxqs"), pets)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"Swinney"}, said)
+
+	screens := dashboardScreens(out.String())
+	if assert.Len(t, screens, 6) {
+		assert.Equal(t, "   NAME     TYPE  MOOD  LAST\r\n"+
+			">  Ink      cat   -     \r\n"+
+			"   Swinney  dog   -     \r\n\r\n"+
+			"up/down or j/k to select, s to say, a to act, q to quit\r\n", screens[0])
+		assert.Contains(t, screens[1], ">  Swinney  dog   -     \r\n")
+		assert.Contains(t, screens[2], ">  Swinney  dog   -     Swinney the Dachshund woofs\r\n")
+		assert.Contains(t, screens[3], ">  Ink      cat   -     \r\n")
+		assert.Contains(t, screens[4], ">  Ink      cat   -     Ink snoozes\r\n")
+		assert.Contains(t, screens[5], ">  Swinney  dog   -     Swinney the Dachshund woofs\r\n")
+	}
+}
+
+func TestDashboardEOF(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	d := &Dashboard{Runner: Runner{Out: out}}
+	assert.NoError(t, d.Run(strings.NewReader("s"), nil))
+	assert.Contains(t, out.String(), "there are no pets")
+}
+
+// dashboardScreens returns the screens a Dashboard drew to out, leaving out
+// those that are the same as the one before, as redraws are.
+func dashboardScreens(out string) []string {
+	screens := []string{}
+	for _, s := range strings.Split(out, clearScreen)[1:] {
+		if len(screens) == 0 || screens[len(screens)-1] != s {
+			screens = append(screens, s)
+		}
+	}
+	return screens
+}
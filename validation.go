@@ -0,0 +1,185 @@
+package petsounds
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// ValidationHCL is a user-defined check on a decoded pet. Each validation is
+// represented in hcl, inside a pet block, as:
+//   validation {
+//     condition     = <boolean expression>
+//     error_message = "<why the pet is invalid>"
+//   }
+// Both expressions are left undecoded until the pet has been decoded, so they
+// can refer to the pet as `self`, e.g. `length(self.name) > 1`.
+type ValidationHCL struct {
+	Condition    hcl.Expression `hcl:"condition"`
+	ErrorMessage hcl.Expression `hcl:"error_message"`
+}
+
+// checkValidations evaluates validations against the decoded pet p. Each
+// validation whose condition is false produces an error diagnostic pointing
+// at the condition.
+func checkValidations(validations []*ValidationHCL, p Pet, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	if len(validations) == 0 {
+		return nil
+	}
+
+	ctx, diags := selfContext(p, evalContext)
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, v := range validations {
+		diags = append(diags, evalCondition(
+			"validation", v.Condition, v.ErrorMessage, hcl.DiagError, "Invalid pet", ctx,
+		)...)
+	}
+	return diags
+}
+
+// selfContext returns a child of evalContext in which p is available as
+// `self`, and values can be marked sensitive.
+func selfContext(p Pet, evalContext *hcl.EvalContext) (*hcl.EvalContext, hcl.Diagnostics) {
+	self, err := selfValue(p)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid pet",
+			Detail:   fmt.Sprintf("Could not build `self`: %s.", err),
+		}}
+	}
+	ctx := evalContext.NewChild()
+	ctx.Variables = map[string]cty.Value{"self": self}
+	ctx.Functions = sensitiveFunctions
+	return ctx, nil
+}
+
+// headerContext returns a child of evalContext in which the name and type
+// of the pet being decoded are available as `self`, for its
+// characteristics to refer to, and values can be marked sensitive. The rest
+// of the pet is not decoded yet.
+func headerContext(name, petType string, evalContext *hcl.EvalContext) *hcl.EvalContext {
+	ctx := evalContext.NewChild()
+	ctx.Variables = map[string]cty.Value{
+		"self": cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal(name),
+			"type": cty.StringVal(petType),
+		}),
+	}
+	ctx.Functions = sensitiveFunctions
+	return ctx
+}
+
+// evalCondition evaluates condition in ctx. If it is false, it returns a
+// diagnostic with the given severity and summary, detailed by errorMessage
+// and pointing at the condition. block names the kind of block the
+// expressions come from, for diagnostics about the expressions themselves.
+func evalCondition(
+	block string, condition, errorMessage hcl.Expression,
+	severity hcl.DiagnosticSeverity, summary string, ctx *hcl.EvalContext,
+) hcl.Diagnostics {
+	result, diags := condition.Value(ctx)
+	if diags.HasErrors() {
+		return diags
+	}
+	result, _ = result.UnmarkDeep()
+	if result.IsNull() || !result.IsKnown() || !result.Type().Equals(cty.Bool) {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Invalid %s condition", block),
+			Detail:   fmt.Sprintf("The condition of a %s must be true or false.", block),
+			Subject:  condition.Range().Ptr(),
+		})
+	}
+	if result.True() {
+		return diags
+	}
+
+	message, msgDiags := errorMessage.Value(ctx)
+	diags = append(diags, msgDiags...)
+	if msgDiags.HasErrors() {
+		return diags
+	}
+	sensitive := isSensitive(message)
+	message, _ = message.UnmarkDeep()
+	if message.IsNull() || !message.IsKnown() || !message.Type().Equals(cty.String) {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Invalid %s error message", block),
+			Detail:   fmt.Sprintf("The error_message of a %s must be a string.", block),
+			Subject:  errorMessage.Range().Ptr(),
+		})
+	}
+	// A message that reads a sensitive value is sensitive as a whole.
+	detail := message.AsString()
+	if sensitive {
+		detail = sensitiveText
+	}
+	return append(diags, &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  condition.Range().Ptr(),
+	})
+}
+
+// selfValue returns the object validations see as `self`. It holds the pet's
+// name and type, along with every characteristic the pet's type decodes, named
+// as they are in hcl. Unset optional characteristics are null.
+func selfValue(p Pet) (cty.Value, error) {
+	name, petType := petIdentity(p)
+	attrs := map[string]cty.Value{
+		"name": cty.StringVal(name),
+		"type": cty.StringVal(petType),
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(p))
+	if v.Kind() != reflect.Struct {
+		return cty.ObjectVal(attrs), nil
+	}
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag.Get("hcl")
+		attr := strings.Split(tag, ",")[0]
+		if attr == "" {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Type() == durationType {
+			attrs[attr] = durationValue(time.Duration(field.Int()))
+			continue
+		}
+		ty, err := gocty.ImpliedType(field.Interface())
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("characteristic `%s`: %w", attr, err)
+		}
+		// A list that was never set reads better as empty than as null, as
+		// it can then be passed straight to functions like length.
+		if field.Kind() == reflect.Slice && field.Len() == 0 {
+			attrs[attr] = cty.ListValEmpty(ty.ElementType())
+			continue
+		}
+		val, err := gocty.ToCtyValue(field.Interface(), ty)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("characteristic `%s`: %w", attr, err)
+		}
+		attrs[attr] = val
+	}
+	// Sensitive characteristics stay sensitive in what is evaluated from
+	// them.
+	if sensitive := fieldsOf(p).sensitive; sensitive != nil {
+		for attr := range *sensitive {
+			if val, ok := attrs[attr]; ok {
+				attrs[attr] = val.Mark(sensitiveMark)
+			}
+		}
+	}
+	return cty.ObjectVal(attrs), nil
+}
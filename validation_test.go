@@ -0,0 +1,130 @@
+package petsounds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSelfValue(t *testing.T) {
+	got, err := selfValue(&Cat{Name: "Ink", Sound: "meow", Age: intPtr(4)})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, cty.StringVal("Ink"), got.GetAttr("name"))
+	assert.Equal(t, cty.StringVal("cat"), got.GetAttr("type"))
+	assert.Equal(t, cty.StringVal("meow"), got.GetAttr("sound"))
+	assert.Equal(t, cty.ListValEmpty(cty.String), got.GetAttr("sounds"))
+	assert.True(t, got.GetAttr("age").RawEquals(cty.NumberIntVal(4)))
+	assert.True(t, got.GetAttr("weight").IsNull())
+}
+
+func TestCheckValidations(t *testing.T) {
+	evalContext, err := createContext(context.Background(), LoadOptions{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	expr := func(src string) hcl.Expression {
+		e, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags)
+		}
+		return e
+	}
+
+	tcs := []struct {
+		name      string
+		condition string
+		message   string
+		want      string
+	}{
+		{
+			name:      "passes",
+			condition: `length(self.name) > 1`,
+			message:   `"name too short"`,
+		},
+		{
+			name:      "fails",
+			condition: `self.breed != "mutt"`,
+			message:   `"${self.name} needs a breed"`,
+			want:      "Invalid pet; Swinney needs a breed",
+		},
+		{
+			name:      "not a boolean",
+			condition: `self.name`,
+			message:   `"unused"`,
+			want:      "Invalid validation condition; The condition of a validation must be true or false.",
+		},
+		{
+			name:      "unknown attribute",
+			condition: `self.microchip == "123"`,
+			message:   `"unused"`,
+			want:      "Unsupported attribute; This object does not have an attribute named \"microchip\".",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			validations := []*ValidationHCL{{
+				Condition:    expr(tc.condition),
+				ErrorMessage: expr(tc.message),
+			}}
+			dog := &Dog{Name: "Swinney", Breed: "mutt"}
+
+			diags := checkValidations(validations, dog, evalContext)
+			if tc.want == "" {
+				assert.False(t, diags.HasErrors(), diags.Error())
+			} else if assert.Len(t, diags, 1) {
+				assert.Equal(t, tc.want, diags[0].Summary+"; "+diags[0].Detail)
+			}
+		})
+	}
+}
+
+func TestValidationConfig(t *testing.T) {
+	_, err := LoadConfig("testdata/validation.hcl")
+	assert.Nil(t, err)
+
+	_, err = LoadConfig("testdata/validation_invalid.hcl")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "validation_invalid.hcl:5,21-42: Invalid pet; dog name too short")
+	}
+}
+
+func TestSelfInCharacteristics(t *testing.T) {
+	t.Parallel()
+
+	pets := mustParse(t, `
+defaults {
+  dog {
+    sound = "${self.name} woofs"
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "${self.name} the ${self.type} says meow"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = "Dachshund"
+  }
+}
+`)
+	assert.Equal(t, []Pet{
+		&Cat{Name: "Ink", Sound: "Ink the cat says meow"},
+		&Dog{Name: "Swinney", Breed: "Dachshund", Sound: "Swinney woofs"},
+	}, clearDeclRanges(pets))
+}
@@ -0,0 +1,178 @@
+package petsounds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const defaultVaultAddress = "https://127.0.0.1:8200"
+
+// VaultClient reads secrets from HashiCorp Vault for the vault function,
+// which lets a configuration keep values like microchip IDs out of the file:
+//   microchip = vault("secret/pets", "ink_microchip")
+// Both version 1 and version 2 key/value secrets engines are supported.
+// Each secret is only read once.
+type VaultClient struct {
+	Address   string
+	Token     string
+	Namespace string
+	Client    *http.Client
+
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+}
+
+// NewVaultClient returns a VaultClient configured like the vault command
+// line: from VAULT_ADDR, VAULT_TOKEN, or the token helper's ~/.vault-token
+// file, and VAULT_NAMESPACE.
+func NewVaultClient() (*VaultClient, error) {
+	v := &VaultClient{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+	if v.Address == "" {
+		v.Address = defaultVaultAddress
+	}
+	if v.Token == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("error in NewVaultClient finding token: %w", err)
+		}
+		token, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+		if err != nil {
+			return nil, fmt.Errorf("error in NewVaultClient: no VAULT_TOKEN and no token file: %w", err)
+		}
+		v.Token = strings.TrimSpace(string(token))
+	}
+	return v, nil
+}
+
+// Read returns the field key of the secret at path.
+func (v *VaultClient) Read(ctx context.Context, path, key string) (string, error) {
+	secret, err := v.secret(ctx, strings.Trim(path, "/"))
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret[key]
+	if !ok {
+		return "", fmt.Errorf("secret `%s` has no key `%s`", path, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// secret returns the data of the secret at path, reading it from Vault the
+// first time it is asked for.
+func (v *VaultClient) secret(ctx context.Context, path string) (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if secret, ok := v.secrets[path]; ok {
+		return secret, nil
+	}
+
+	// Version 2 secrets live under data/ in their mount, which the vault
+	// command line adds for you, so it is tried when the path is not found.
+	secret, err := v.get(ctx, path)
+	if err == errVaultNotFound {
+		if parts := strings.SplitN(path, "/", 2); len(parts) == 2 {
+			secret, err = v.get(ctx, parts[0]+"/data/"+parts[1])
+		}
+	}
+	if err == errVaultNotFound {
+		return nil, fmt.Errorf("secret `%s` not found", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if v.secrets == nil {
+		v.secrets = map[string]map[string]interface{}{}
+	}
+	v.secrets[path] = secret
+	return secret, nil
+}
+
+var errVaultNotFound = fmt.Errorf("not found")
+
+// get reads the secret at path from the Vault HTTP API.
+func (v *VaultClient) get(ctx context.Context, path string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(v.Address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errVaultNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading `%s`, Vault responded %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding `%s`: %w", path, err)
+	}
+
+	// A version 2 secret nests its data alongside its metadata.
+	data, hasData := body.Data["data"].(map[string]interface{})
+	if _, hasMetadata := body.Data["metadata"]; hasData && hasMetadata {
+		return data, nil
+	}
+	return body.Data, nil
+}
+
+// vaultFunc returns the vault function for a configuration, which reads
+// secrets within ctx. If v is nil, reading secrets is disabled and the
+// function always fails.
+func vaultFunc(ctx context.Context, v *VaultClient) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+			{Name: "key", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if v == nil {
+				return cty.NilVal, fmt.Errorf("reading secrets from Vault is disabled, enable it with -vault")
+			}
+			value, err := v.Read(ctx, args[0].AsString(), args[1].AsString())
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.StringVal(value), nil
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package petsounds
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// vaultServer serves a version 1 secret at kv/pets and a version 2 secret at
+// secret/pets, counting the requests for each.
+func vaultServer(t *testing.T, requests map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/pets":
+			fmt.Fprint(w, `{"data": {"breed": "Corgi", "age": 3}}`)
+		case "/v1/secret/data/pets":
+			fmt.Fprint(w, `{"data": {"data": {"sound": "yip"}, "metadata": {"version": 2}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVaultClient(t *testing.T) {
+	requests := map[string]int{}
+	srv := vaultServer(t, requests)
+	defer srv.Close()
+	v := &VaultClient{Address: srv.URL, Token: "s.token"}
+
+	tcs := []struct {
+		name    string
+		path    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "version 1", path: "kv/pets", key: "breed", want: "Corgi"},
+		{name: "version 1 number", path: "/kv/pets", key: "age", want: "3"},
+		{name: "version 2", path: "secret/pets", key: "sound", want: "yip"},
+		{name: "version 2 data path", path: "secret/data/pets", key: "sound", want: "yip"},
+		{name: "missing key", path: "kv/pets", key: "sound", wantErr: true},
+		{name: "missing secret", path: "kv/birds", key: "sound", wantErr: true},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := v.Read(context.Background(), tc.path, tc.key)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+
+	// Secrets are only read once.
+	assert.Equal(t, 1, requests["/v1/kv/pets"])
+
+	_, err := (&VaultClient{Address: srv.URL, Token: "s.wrong"}).Read(context.Background(), "kv/pets", "breed")
+	assert.Error(t, err)
+}
+
+func TestVaultFunction(t *testing.T) {
+	srv := vaultServer(t, map[string]int{})
+	defer srv.Close()
+	src, err := ioutil.ReadFile("testdata/vault.hcl")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	opts := LoadOptions{Vault: &VaultClient{Address: srv.URL, Token: "s.token"}}
+	config, err := DecodeConfig(src, "testdata/vault.hcl", opts)
+	if assert.Nil(t, err, "error while parsing input") {
+		assert.Equal(t, []Pet{&Dog{Name: "Biscuit", Breed: "Corgi", Sound: "yip"}}, clearDeclRanges(config.Pets))
+	}
+
+	// Without a client, the vault function is disabled.
+	_, err = DecodeConfig(src, "testdata/vault.hcl", LoadOptions{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "-vault")
+	}
+}
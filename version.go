@@ -0,0 +1,99 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// The version, commit and build date of pet-sounds, set when building a
+// release with
+//   go build -ldflags "-X $pkg.version=v1.2.0 -X $pkg.commit=... -X $pkg.date=..." ./cmd/pet-sounds
+// where $pkg is github.com/russellrollins/pet-sounds, the package they are
+// declared in.
+// Without them, they are read from the build information of the binary.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// BuildInfo describes the build of pet-sounds that is running.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// buildInfo returns the BuildInfo of this build, from its ldflags and the
+// build information the go command embeds in the binary.
+func buildInfo() BuildInfo {
+	build, _ := debug.ReadBuildInfo()
+	return buildInfoFrom(build)
+}
+
+// buildInfoFrom returns the BuildInfo of a build with the build information
+// build, which may be nil. The version, commit and date set with ldflags
+// are preferred. Otherwise, the version is that of the module that was
+// installed with go install, or "dev", and the commit and date are those of
+// the version control checkout the binary was built in, as recorded by go
+// build.
+func buildInfoFrom(build *debug.BuildInfo) BuildInfo {
+	info := BuildInfo{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if build != nil {
+		if info.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		settings := map[string]string{}
+		for _, s := range build.Settings {
+			settings[s.Key] = s.Value
+		}
+		if info.Commit == "" && settings["vcs.revision"] != "" {
+			info.Commit = settings["vcs.revision"]
+			if settings["vcs.modified"] == "true" {
+				info.Commit += " (modified)"
+			}
+		}
+		if info.Date == "" {
+			info.Date = settings["vcs.time"]
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// versionCommand prints the version of pet-sounds, and its commit and build
+// date when they are known.
+func versionCommand(flags *flag.FlagSet) func(args []string) error {
+	asJSON := flags.Bool("json", false, "print the version as JSON")
+
+	return func(args []string) error {
+		return writeVersion(os.Stdout, buildInfo(), *asJSON)
+	}
+}
+
+// writeVersion writes info to w, as JSON if asJSON is set.
+func writeVersion(w io.Writer, info BuildInfo, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	fmt.Fprintf(w, "pet-sounds %s\n", info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(w, "commit: %s\n", info.Commit)
+	}
+	if info.Date != "" {
+		fmt.Fprintf(w, "built: %s\n", info.Date)
+	}
+	_, err := fmt.Fprintf(w, "go: %s\n", info.GoVersion)
+	return err
+}
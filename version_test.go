@@ -0,0 +1,105 @@
+package petsounds
+
+import (
+	"bytes"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteVersion(t *testing.T) {
+	tcs := []struct {
+		name   string
+		info   BuildInfo
+		asJSON bool
+		want   string
+	}{
+		{
+			name: "dev",
+			info: BuildInfo{Version: "dev", GoVersion: "go1.14"},
+			want: "pet-sounds dev\ngo: go1.14\n",
+		},
+		{
+			name: "release",
+			info: BuildInfo{Version: "v1.2.0", Commit: "abc123", Date: "2020-08-01", GoVersion: "go1.14"},
+			want: "pet-sounds v1.2.0\ncommit: abc123\nbuilt: 2020-08-01\ngo: go1.14\n",
+		},
+		{
+			name:   "json",
+			info:   BuildInfo{Version: "v1.2.0", Commit: "abc123", GoVersion: "go1.14"},
+			asJSON: true,
+			want:   "{\n  \"version\": \"v1.2.0\",\n  \"commit\": \"abc123\",\n  \"go_version\": \"go1.14\"\n}\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			if assert.Nil(t, writeVersion(out, tc.info, tc.asJSON)) {
+				assert.Equal(t, tc.want, out.String())
+			}
+		})
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	// Tests are not built with ldflags or from an installed module.
+	assert.Equal(t, "dev", buildInfo().Version)
+}
+
+func TestBuildInfoFrom(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		build *debug.BuildInfo
+		want  BuildInfo
+	}{
+		{
+			name: "none",
+			want: BuildInfo{Version: "dev"},
+		},
+		{
+			name:  "devel",
+			build: &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}},
+			want:  BuildInfo{Version: "dev"},
+		},
+		{
+			name:  "installed",
+			build: &debug.BuildInfo{Main: debug.Module{Version: "v1.2.0"}},
+			want:  BuildInfo{Version: "v1.2.0"},
+		},
+		{
+			name: "vcs",
+			build: &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.time", Value: "2020-08-01T12:00:00Z"},
+			}},
+			want: BuildInfo{Version: "dev", Commit: "abc123", Date: "2020-08-01T12:00:00Z"},
+		},
+		{
+			name: "vcs modified",
+			build: &debug.BuildInfo{Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.modified", Value: "true"},
+			}},
+			want: BuildInfo{Version: "dev", Commit: "abc123 (modified)"},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := buildInfoFrom(tc.build)
+			got.GoVersion = ""
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
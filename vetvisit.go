@@ -0,0 +1,130 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// VetVisitHCL is a vet_visit block of a pet, a record of one visit to the
+// vet. It is represented in hcl, inside a pet block, as:
+//   vet_visit {
+//     date     = "<YYYY-MM-DD>"
+//     reason   = "<why the pet went, e.g. checkup>"
+//     next_due = "<YYYY-MM-DD of the next visit, optional>"
+//   }
+type VetVisitHCL struct {
+	Date    string `hcl:"date"`
+	Reason  string `hcl:"reason,optional"`
+	NextDue string `hcl:"next_due,optional"`
+}
+
+// VetVisit is a validated vet_visit block. NextDue is zero when no next
+// visit is due.
+type VetVisit struct {
+	Date    time.Time
+	Reason  string
+	NextDue time.Time
+}
+
+// vetDateFormat is how the dates of vet visits are written.
+const vetDateFormat = "2006-01-02"
+
+// NewVetVisits checks the vet_visit blocks of a pet, returning nil if there
+// are none.
+func NewVetVisits(visits []*VetVisitHCL) ([]*VetVisit, error) {
+	if len(visits) == 0 {
+		return nil, nil
+	}
+
+	all := []*VetVisit{}
+	for _, v := range visits {
+		date, err := time.Parse(vetDateFormat, v.Date)
+		if err != nil {
+			return nil, fmt.Errorf("error in NewVetVisits: invalid date `%s`, expected YYYY-MM-DD", v.Date)
+		}
+		visit := &VetVisit{Date: date, Reason: v.Reason}
+		if v.NextDue != "" {
+			if visit.NextDue, err = time.Parse(vetDateFormat, v.NextDue); err != nil {
+				return nil, fmt.Errorf("error in NewVetVisits: invalid next_due `%s`, expected YYYY-MM-DD", v.NextDue)
+			}
+			if visit.NextDue.Before(date) {
+				return nil, fmt.Errorf("error in NewVetVisits: next_due `%s` is before the visit on `%s`", v.NextDue, v.Date)
+			}
+		}
+		all = append(all, visit)
+	}
+	return all, nil
+}
+
+// hcl returns the vet_visit block v was decoded from.
+func (v *VetVisit) hcl() *VetVisitHCL {
+	visit := &VetVisitHCL{Date: v.Date.Format(vetDateFormat), Reason: v.Reason}
+	if !v.NextDue.IsZero() {
+		visit.NextDue = v.NextDue.Format(vetDateFormat)
+	}
+	return visit
+}
+
+// reason returns why a pet went to the vet.
+func (v *VetVisit) reason() string {
+	if v.Reason == "" {
+		return "vet visit"
+	}
+	return v.Reason
+}
+
+// petVetVisits returns the vet visits of p, if it has any.
+func petVetVisits(p Pet) []*VetVisit {
+	return fieldsOf(p).vetVisits
+}
+
+// reminder is the next vet visit a pet is due for.
+type reminder struct {
+	pet   string
+	visit *VetVisit
+}
+
+// writeReminders writes the vet visits of pets that are overdue as of
+// today, or due within the days after it, soonest first. A pet is due for
+// the next visit of its latest one, as a later visit replaces the plans
+// made at the ones before it. The birthdays of pets within the days after
+// today follow.
+func writeReminders(w io.Writer, pets []Pet, today time.Time, within int) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	reminders := []reminder{}
+	for _, p := range pets {
+		var latest *VetVisit
+		for _, v := range petVetVisits(p) {
+			if latest == nil || !v.Date.Before(latest.Date) {
+				latest = v
+			}
+		}
+		if latest == nil || latest.NextDue.IsZero() || latest.NextDue.After(today.AddDate(0, 0, within)) {
+			continue
+		}
+		name, _ := petIdentity(p)
+		reminders = append(reminders, reminder{pet: name, visit: latest})
+	}
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].visit.NextDue.Before(reminders[j].visit.NextDue)
+	})
+
+	for _, r := range reminders {
+		due := r.visit.NextDue.Format(reminderDateFormat)
+		days := int(r.visit.NextDue.Sub(today).Hours() / 24)
+		switch {
+		case days < 0:
+			fmt.Fprintf(w, "%s is overdue for a %s, due %s (%d days ago)\n", r.pet, r.visit.reason(), due, -days)
+		case days == 0:
+			fmt.Fprintf(w, "%s is due for a %s today\n", r.pet, r.visit.reason())
+		default:
+			fmt.Fprintf(w, "%s is due for a %s on %s (in %d days)\n", r.pet, r.visit.reason(), due, days)
+		}
+	}
+	writeBirthdays(w, pets, today, within)
+}
+
+// reminderDateFormat is how reminders write the dates visits are due.
+const reminderDateFormat = "Mon Jan 2 2006"
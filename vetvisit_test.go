@@ -0,0 +1,97 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReminders(t *testing.T) {
+	pets, err := ReadConfig("testdata/vet.hcl")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tcs := []struct {
+		name   string
+		today  string
+		within int
+		want   string
+	}{
+		{
+			name:   "nothing due",
+			today:  "2020-07-01",
+			within: 30,
+		},
+		{
+			name:   "upcoming",
+			today:  "2020-07-20",
+			within: 30,
+			want:   "Swinney is due for a vaccination on Mon Aug 10 2020 (in 21 days)\n",
+		},
+		{
+			name:   "today",
+			today:  "2020-08-10",
+			within: 0,
+			want:   "Swinney is due for a vaccination today\n",
+		},
+		{
+			name:   "overdue",
+			today:  "2021-03-11",
+			within: 30,
+			want: "Swinney is overdue for a vaccination, due Mon Aug 10 2020 (213 days ago)\n" +
+				"Ink is overdue for a checkup, due Mon Mar 1 2021 (10 days ago)\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			today, err := time.Parse(vetDateFormat, tc.today)
+			if !assert.NoError(t, err) {
+				return
+			}
+			out := &bytes.Buffer{}
+			writeReminders(out, pets, today, tc.within)
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestNewVetVisitsInvalid(t *testing.T) {
+	tcs := []struct {
+		name   string
+		visits []*VetVisitHCL
+		want   string
+	}{
+		{
+			name:   "date",
+			visits: []*VetVisitHCL{{Date: "03/01/2020"}},
+			want:   "error in NewVetVisits: invalid date `03/01/2020`, expected YYYY-MM-DD",
+		},
+		{
+			name:   "next due",
+			visits: []*VetVisitHCL{{Date: "2020-03-01", NextDue: "soon"}},
+			want:   "error in NewVetVisits: invalid next_due `soon`, expected YYYY-MM-DD",
+		},
+		{
+			name:   "next due before date",
+			visits: []*VetVisitHCL{{Date: "2020-03-01", NextDue: "2019-03-01"}},
+			want:   "error in NewVetVisits: next_due `2019-03-01` is before the visit on `2020-03-01`",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewVetVisits(tc.visits)
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// birthdateLayout is how the birthdate characteristic is written, as in
+// "2019-06-01".
+const birthdateLayout = "2006-01-02"
+
+// vitalLimits are the upper bounds of the age (in years), weight (in
+// kilograms) and length (in centimetres) characteristics of a pet type.
+// Anything above them is far more likely to be a typo than a record
+// breaking pet.
+var vitalLimits = map[string]struct {
+	maxAge    int
+	maxWeight float64
+	maxLength float64
+}{
+	"cat": {maxAge: 30, maxWeight: 25, maxLength: 125},
+	"dog": {maxAge: 30, maxWeight: 100, maxLength: 250},
+}
+
+// validateVitals checks the age, weight and length characteristics of a pet
+// of type petType, any of which may be unset. The returned diagnostics
+// point at the offending attribute in body, the pet's characteristics
+// block.
+func validateVitals(body hcl.Body, petType string, age *int, weight, length *float64) hcl.Diagnostics {
+	limits := vitalLimits[petType]
+	diags := hcl.Diagnostics{}
+
+	if age != nil && (*age < 0 || *age > limits.maxAge) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid age",
+			Detail: fmt.Sprintf(
+				"A %s's age must be between 0 and %d years, got %d.", petType, limits.maxAge, *age,
+			),
+			Subject: attributeRange(body, "age"),
+		})
+	}
+	if weight != nil && (*weight <= 0 || *weight > limits.maxWeight) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid weight",
+			Detail: fmt.Sprintf(
+				"A %s's weight must be more than 0 and at most %g kg, got %g.", petType, limits.maxWeight, *weight,
+			),
+			Subject: attributeRange(body, "weight"),
+		})
+	}
+	if length != nil && (*length <= 0 || *length > limits.maxLength) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid length",
+			Detail: fmt.Sprintf(
+				"A %s's length must be more than 0 and at most %g cm, got %g.", petType, limits.maxLength, *length,
+			),
+			Subject: attributeRange(body, "length"),
+		})
+	}
+	return diags
+}
+
+// attributeRange returns the source range of the value of the attribute
+// called name in body, or nil if body has no such attribute.
+func attributeRange(body hcl.Body, name string) *hcl.Range {
+	if body == nil {
+		return nil
+	}
+	content, _, _ := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: name}},
+	})
+	attr, ok := content.Attributes[name]
+	if !ok {
+		return nil
+	}
+	rng := attr.Expr.Range()
+	return &rng
+}
+
+// ageOn returns the age in whole years, on now, of a pet born on birthdate.
+func ageOn(birthdate, now time.Time) int {
+	age := now.Year() - birthdate.Year()
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		age--
+	}
+	return age
+}
+
+// setBirthdateAge sets the age of pet from its birthdate characteristic, as
+// of now, so that its age is always current. A pet can set both only if they
+// agree, as a configuration written with -resolve does. The returned
+// diagnostics point at the offending attribute in body, the pet's
+// characteristics block.
+func setBirthdateAge(pet Pet, body hcl.Body, now time.Time) hcl.Diagnostics {
+	fields := fieldsOf(pet)
+	birthdate, age := fields.birthdate, fields.age
+	if birthdate == "" {
+		return nil
+	}
+
+	born, err := time.ParseInLocation(birthdateLayout, birthdate, now.Location())
+	if err != nil {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid birthdate",
+			Detail:   fmt.Sprintf("A birthdate is a date written as YYYY-MM-DD, got %q.", birthdate),
+			Subject:  attributeRange(body, "birthdate"),
+		}}
+	}
+	if born.After(now) {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid birthdate",
+			Detail:   fmt.Sprintf("The birthdate %s is in the future.", birthdate),
+			Subject:  attributeRange(body, "birthdate"),
+		}}
+	}
+	computed := ageOn(born, now)
+	if *age != nil && **age != computed {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Conflicting age",
+			Detail:   fmt.Sprintf("The age %d does not match the birthdate %s, which makes the pet %d.", **age, birthdate, computed),
+			Subject:  attributeRange(body, "age"),
+		}}
+	}
+	*age = &computed
+	return nil
+}
+
+// petBirthdate returns the birthdate characteristic of a pet, which is
+// empty for pets without one.
+func petBirthdate(p Pet) string {
+	return fieldsOf(p).birthdate
+}
+
+// writeBirthdays writes the birthdays of pets from today to within days
+// after it, soonest first, with the age each pet turns.
+func writeBirthdays(w io.Writer, pets []Pet, today time.Time, within int) {
+	type birthday struct {
+		pet string
+		on  time.Time
+		age int
+	}
+	birthdays := []birthday{}
+	for _, p := range pets {
+		born, err := time.Parse(birthdateLayout, petBirthdate(p))
+		if err != nil {
+			continue
+		}
+		on := time.Date(today.Year(), born.Month(), born.Day(), 0, 0, 0, 0, time.UTC)
+		if on.Before(today) {
+			on = on.AddDate(1, 0, 0)
+		}
+		if on.After(today.AddDate(0, 0, within)) {
+			continue
+		}
+		name, _ := petIdentity(p)
+		birthdays = append(birthdays, birthday{pet: name, on: on, age: on.Year() - born.Year()})
+	}
+	sort.SliceStable(birthdays, func(i, j int) bool { return birthdays[i].on.Before(birthdays[j].on) })
+
+	for _, b := range birthdays {
+		days := int(b.on.Sub(today).Hours() / 24)
+		if days == 0 {
+			fmt.Fprintf(w, "%s turns %d today\n", b.pet, b.age)
+			continue
+		}
+		fmt.Fprintf(w, "%s turns %d on %s (in %d days)\n", b.pet, b.age, b.on.Format(reminderDateFormat), days)
+	}
+}
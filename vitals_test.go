@@ -0,0 +1,172 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateVitals(t *testing.T) {
+	src := []byte("age = -1\nweight = 140\nlength = 300\n")
+	file, diags := hclsyntax.ParseConfig(src, "vitals.hcl", hcl.InitialPos)
+	if !assert.False(t, diags.HasErrors()) {
+		return
+	}
+
+	tcs := []struct {
+		name     string
+		petType  string
+		age      *int
+		weight   *float64
+		length   *float64
+		want     []string
+		wantLine []int
+	}{
+		{
+			name:    "unset",
+			petType: "cat",
+		},
+		{
+			name:    "valid",
+			petType: "dog",
+			age:     intPtr(12),
+			weight:  float64Ptr(40),
+		},
+		{
+			name:     "negative age",
+			petType:  "cat",
+			age:      intPtr(-1),
+			want:     []string{"Invalid age"},
+			wantLine: []int{1},
+		},
+		{
+			name:     "heavy cat",
+			petType:  "cat",
+			age:      intPtr(3),
+			weight:   float64Ptr(140),
+			want:     []string{"Invalid weight"},
+			wantLine: []int{2},
+		},
+		{
+			name:     "zero weight",
+			petType:  "dog",
+			weight:   float64Ptr(0),
+			want:     []string{"Invalid weight"},
+			wantLine: []int{2},
+		},
+		{
+			name:     "long cat",
+			petType:  "cat",
+			length:   float64Ptr(300),
+			want:     []string{"Invalid length"},
+			wantLine: []int{3},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := validateVitals(file.Body, tc.petType, tc.age, tc.weight, tc.length)
+			summaries, lines := []string{}, []int{}
+			for _, d := range got {
+				summaries = append(summaries, d.Summary)
+				lines = append(lines, d.Subject.Start.Line)
+			}
+			if tc.want == nil {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tc.want, summaries)
+				assert.Equal(t, tc.wantLine, lines)
+			}
+		})
+	}
+}
+
+func TestValidateVitalsConfig(t *testing.T) {
+	_, err := LoadConfig("testdata/vitals_invalid.hcl")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "vitals_invalid.hcl:5,14-16: Invalid age")
+	}
+}
+
+func TestBirthdate(t *testing.T) {
+	t.Parallel()
+
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    birthdate = "2019-06-01"
+  }
+  validation {
+    condition     = self.age >= 1
+    error_message = "Ink is too young."
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    birthdate = "2020-07-10"
+    age       = 0
+  }
+}
+`
+	now := fixedClock(time.Date(2021, 5, 31, 12, 0, 0, 0, time.UTC))
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Clock: now})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, *config.Pets[0].(*Cat).Age)
+	assert.Equal(t, 0, *config.Pets[1].(*Dog).Age)
+
+	// On Swinney's first birthday, its age no longer matches.
+	now = fixedClock(time.Date(2021, 7, 10, 12, 0, 0, 0, time.UTC))
+	_, err = DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Clock: now})
+	assert.EqualError(t, err, "error in DecodeConfig validating dog `Swinney`: pets.hcl:16,17-18: Conflicting age; "+
+		"The age 0 does not match the birthdate 2020-07-10, which makes the pet 1.")
+
+	out := &bytes.Buffer{}
+	writeBirthdays(out, config.Pets, time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC), 45)
+	assert.Equal(t, "Ink turns 2 on Tue Jun 1 2021 (in 1 days)\nSwinney turns 1 on Sat Jul 10 2021 (in 40 days)\n", out.String())
+}
+
+func TestBirthdateInvalid(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name      string
+		birthdate string
+		want      string
+	}{
+		{
+			name:      "not a date",
+			birthdate: "June 1st",
+			want:      "pets.hcl:4,17-27: Invalid birthdate; A birthdate is a date written as YYYY-MM-DD, got \"June 1st\".",
+		},
+		{
+			name:      "future",
+			birthdate: "2030-01-01",
+			want:      "pets.hcl:4,17-29: Invalid birthdate; The birthdate 2030-01-01 is in the future.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := "pet \"Ink\" {\n  type = \"cat\"\n  characteristics {\n    birthdate = \"" + tc.birthdate + "\"\n  }\n}\n"
+			now := fixedClock(time.Date(2021, 5, 31, 12, 0, 0, 0, time.UTC))
+			_, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Clock: now})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
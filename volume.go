@@ -0,0 +1,44 @@
+package petsounds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// The volumes a pet can say things at with its volume characteristic. A pet
+// without one speaks normally.
+const (
+	volumeLoud  = "loud"
+	volumeQuiet = "quiet"
+)
+
+// voiced returns text as it is said at volume: shouted in capitals by loud
+// pets, and whispered in parentheses by quiet ones.
+func voiced(text, volume string) string {
+	switch volume {
+	case volumeLoud:
+		return strings.ToUpper(strings.TrimRight(text, "!")) + "!!"
+	case volumeQuiet:
+		return "(" + strings.ToLower(text) + ")"
+	default:
+		return text
+	}
+}
+
+// validateVolume checks that volume, the volume characteristic of a pet of
+// petType decoded from body, is loud or quiet.
+func validateVolume(body hcl.Body, petType, volume string) hcl.Diagnostics {
+	if volume == "" || volume == volumeLoud || volume == volumeQuiet {
+		return nil
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid volume",
+		Detail: fmt.Sprintf(
+			"The %s has the unknown volume `%s`, expected %s or %s.", petType, volume, volumeLoud, volumeQuiet,
+		),
+		Subject: attributeRange(body, "volume"),
+	}}
+}
@@ -0,0 +1,67 @@
+package petsounds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoiced(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		volume string
+		want   string
+	}{
+		{name: "normal", volume: "", want: "Meow!"},
+		{name: "loud", volume: volumeLoud, want: "MEOW!!"},
+		{name: "quiet", volume: volumeQuiet, want: "(meow!)"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, voiced("Meow!", tc.volume))
+		})
+	}
+}
+
+func TestVolumeConfig(t *testing.T) {
+	t.Parallel()
+
+	src := `pet "Ink" {
+  type = "cat"
+  characteristics {
+    volume = "quiet"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed  = "Dachshund"
+    volume = "loud"
+  }
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&Runner{Out: out}).Run(config.Pets))
+	// Only what the pets say is affected, not what they do.
+	assert.Equal(t, "Ink (meow)\nInk snoozes\nSwinney the Dachshund BARKS!!\nSwinney the Dachshund plays\n", out.String())
+
+	out.Reset()
+	SetStyle(config.Pets, &Style{Emoji: true})
+	config.Pets[1].Say(out)
+	assert.Equal(t, "🐶 Swinney the Dachshund BARKS!!!\n", out.String())
+
+	_, err = DecodeConfig([]byte("pet \"Ink\" {\n  type = \"cat\"\n  characteristics {\n    volume = \"deafening\"\n  }\n}\n"), "pets.hcl", LoadOptions{})
+	assert.EqualError(t, err, "error in DecodeConfig validating cat `Ink`: pets.hcl:4,14-25: Invalid volume; "+
+		"The cat has the unknown volume `deafening`, expected loud or quiet.")
+}
@@ -0,0 +1,48 @@
+package petsounds
+
+// Visitor has a method for each type of pet, which Walk calls for the pets
+// of that type. VisitUnknown is called for pets of any other type, such as
+// pets that tools define themselves.
+type Visitor interface {
+	VisitCat(c *Cat)
+	VisitDog(d *Dog)
+	VisitUnknown(p Pet)
+}
+
+// Walk calls the method of v for the type of each of pets, in order.
+func Walk(pets []Pet, v Visitor) {
+	for _, p := range pets {
+		if a, ok := p.(petAccessor); ok {
+			a.visit(v)
+		} else {
+			v.VisitUnknown(p)
+		}
+	}
+}
+
+// VisitorFuncs is a Visitor that calls its functions, so a tool only has to
+// handle the types of pet it is interested in. Pets whose function is nil
+// are skipped.
+type VisitorFuncs struct {
+	Cat     func(c *Cat)
+	Dog     func(d *Dog)
+	Unknown func(p Pet)
+}
+
+func (f VisitorFuncs) VisitCat(c *Cat) {
+	if f.Cat != nil {
+		f.Cat(c)
+	}
+}
+
+func (f VisitorFuncs) VisitDog(d *Dog) {
+	if f.Dog != nil {
+		f.Dog(d)
+	}
+}
+
+func (f VisitorFuncs) VisitUnknown(p Pet) {
+	if f.Unknown != nil {
+		f.Unknown(p)
+	}
+}
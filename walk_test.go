@@ -0,0 +1,41 @@
+package petsounds
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingVisitor records the pets it visits.
+type recordingVisitor struct {
+	visited []string
+}
+
+func (r *recordingVisitor) VisitCat(c *Cat) {
+	r.visited = append(r.visited, "cat "+c.Name)
+}
+
+func (r *recordingVisitor) VisitDog(d *Dog) {
+	r.visited = append(r.visited, "dog "+d.Name)
+}
+
+func (r *recordingVisitor) VisitUnknown(p Pet) {
+	r.visited = append(r.visited, fmt.Sprintf("unknown %T", p))
+}
+
+func TestWalk(t *testing.T) {
+	pets := []Pet{
+		&Cat{Name: "Ink"},
+		&countingPet{name: "Goldie"},
+		&Dog{Name: "Swinney"},
+	}
+
+	v := &recordingVisitor{}
+	Walk(pets, v)
+	assert.Equal(t, []string{"cat Ink", "unknown *petsounds.countingPet", "dog Swinney"}, v.visited)
+
+	dogs := []string{}
+	Walk(pets, VisitorFuncs{Dog: func(d *Dog) { dogs = append(dogs, d.Name) }})
+	assert.Equal(t, []string{"Swinney"}, dogs)
+}
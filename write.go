@@ -0,0 +1,369 @@
+package petsounds
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// WriteConfig encodes pets as a pet configuration file and writes it to w.
+// Reading the file back with ReadConfig gives the same pets.
+//
+// Characteristics that are not set are left out, and sensitive ones are
+// written with the sensitive function. Precondition and postcondition
+// expressions are written as they were in the file they were loaded from,
+// so conditions built from expressions that were not parsed from a file
+// cannot be written. Only pets are written, so the owner blocks
+// of pets with an owner have to be added to the file for it to be read
+// back, as WriteResolvedConfig does.
+func WriteConfig(w io.Writer, pets []Pet) error {
+	file := hclwrite.NewFile()
+	enc := &configEncoder{sources: map[string][]byte{}}
+	if err := enc.pets(file.Body(), pets); err != nil {
+		return fmt.Errorf("error in WriteConfig: %w", err)
+	}
+
+	if _, err := w.Write(hclwrite.Format(file.Bytes())); err != nil {
+		return fmt.Errorf("error in WriteConfig writing configuration: %w", err)
+	}
+	return nil
+}
+
+// WriteResolvedConfig writes the whole of config as a pet configuration
+// file, as the -resolve flag does: its pets as WriteConfig writes them,
+// along with the blocks and attributes the pets need to be read back, such
+// as the owner blocks of their owners, and those of the settings for how
+// they are run. Variables, defaults, templates, modules and profiles have
+// already been applied to the pets, so they are left out. Sensitive
+// characteristics are written as "(sensitive)", so the file does not hold
+// their values.
+func WriteResolvedConfig(w io.Writer, config *Config) error {
+	file := hclwrite.NewFile()
+	body := file.Body()
+	enc := &configEncoder{sources: map[string][]byte{}, redact: true}
+
+	if config.AllowUnknownBreeds {
+		body.SetAttributeValue("allow_unknown_breeds", cty.True)
+	}
+	if len(config.Packs) > 0 {
+		vals := []cty.Value{}
+		for _, pack := range config.Packs {
+			vals = append(vals, cty.StringVal(pack))
+		}
+		body.SetAttributeValue(packsKey, cty.ListVal(vals))
+	}
+
+	type block struct {
+		blockType string
+		labels    []string
+		value     interface{}
+	}
+	blocks := []block{}
+	for _, t := range config.PetTypes {
+		blocks = append(blocks, block{"pet_type", []string{t.Name}, t})
+	}
+	for _, o := range config.Owners {
+		blocks = append(blocks, block{"owner", []string{o.Name}, o})
+	}
+	if config.TTS != nil {
+		blocks = append(blocks, block{"tts", nil, config.TTS})
+	}
+	if config.State != nil {
+		blocks = append(blocks, block{"state", nil, config.State})
+	}
+	if config.Broker != nil {
+		blocks = append(blocks, block{"broker", nil, config.Broker})
+	}
+	for _, n := range config.Notify {
+		blocks = append(blocks, block{"notify", nil, n})
+	}
+	for _, b := range blocks {
+		if len(body.Attributes()) > 0 || len(body.Blocks()) > 0 {
+			body.AppendNewline()
+		}
+		if err := enc.body(body.AppendNewBlock(b.blockType, b.labels).Body(), reflect.ValueOf(b.value)); err != nil {
+			return fmt.Errorf("error in WriteResolvedConfig: %s block: %w", b.blockType, err)
+		}
+	}
+
+	if len(config.Pets) > 0 && (len(body.Attributes()) > 0 || len(body.Blocks()) > 0) {
+		body.AppendNewline()
+	}
+	if err := enc.pets(body, config.Pets); err != nil {
+		return fmt.Errorf("error in WriteResolvedConfig: %w", err)
+	}
+
+	for _, i := range config.Interactions {
+		body.AppendNewline()
+		interaction := &InteractionHCL{From: i.From, To: i.To, Verb: i.Verb}
+		if err := enc.body(body.AppendNewBlock("interaction", nil).Body(), reflect.ValueOf(interaction)); err != nil {
+			return fmt.Errorf("error in WriteResolvedConfig: interaction block: %w", err)
+		}
+	}
+
+	if _, err := w.Write(hclwrite.Format(file.Bytes())); err != nil {
+		return fmt.Errorf("error in WriteResolvedConfig writing configuration: %w", err)
+	}
+	return nil
+}
+
+// configEncoder encodes pets into hclwrite bodies. It caches the files that
+// condition expressions are read back from.
+type configEncoder struct {
+	sources map[string][]byte
+	// redact writes sensitive characteristics as "(sensitive)", rather than
+	// with the sensitive function.
+	redact bool
+}
+
+// pets appends a pet block for each of pets to body. The pets of a
+// household are written in a household block where the first of them was.
+func (e *configEncoder) pets(body *hclwrite.Body, pets []Pet) error {
+	households := map[string]*hclwrite.Body{}
+	for i, p := range pets {
+		petBody := body
+		if h := petHousehold(p); h != "" {
+			if households[h] == nil {
+				if i > 0 {
+					body.AppendNewline()
+				}
+				households[h] = body.AppendNewBlock("household", []string{h}).Body()
+			} else {
+				households[h].AppendNewline()
+			}
+			petBody = households[h]
+		} else if i > 0 {
+			body.AppendNewline()
+		}
+		if err := e.pet(petBody, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pet appends a pet block for p to body.
+func (e *configEncoder) pet(body *hclwrite.Body, p Pet) error {
+	a, ok := p.(petAccessor)
+	if !ok {
+		return fmt.Errorf("cannot write pet of unknown type %T", p)
+	}
+	fields := a.fields()
+	moods, conditions, feedings := fields.moods, fields.conditions, fields.feedings
+
+	name, petType := petIdentity(p)
+	if h := petHousehold(p); h != "" {
+		name = strings.TrimPrefix(name, h+".")
+	}
+	block := body.AppendNewBlock("pet", []string{name}).Body()
+	block.SetAttributeValue("type", cty.StringVal(petType))
+	if fields.disabled {
+		block.SetAttributeValue("enabled", cty.False)
+	}
+	if owner := petOwner(p); owner != "" {
+		block.SetAttributeValue("owner", cty.StringVal(owner))
+	}
+	if language := petLanguage(p); language != "" {
+		block.SetAttributeValue("language", cty.StringVal(language))
+	}
+	if tags := petTags(p); len(tags) > 0 {
+		vals := []cty.Value{}
+		for _, tag := range tags {
+			vals = append(vals, cty.StringVal(tag))
+		}
+		block.SetAttributeValue("tags", cty.ListVal(vals))
+	}
+
+	characteristics := hclwrite.NewBlock("characteristics", nil)
+	if c, ok := p.(*CustomPet); ok {
+		// The characteristics of a CustomPet are whatever attributes its
+		// script reads, kept as they were decoded.
+		names := []string{}
+		for name := range c.characteristics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			characteristics.Body().SetAttributeValue(name, c.characteristics[name])
+		}
+	} else if err := e.body(characteristics.Body(), reflect.ValueOf(p)); err != nil {
+		return fmt.Errorf("pet `%s`: %w", name, err)
+	}
+	e.sensitive(characteristics.Body(), fields)
+	if len(characteristics.Body().Attributes()) > 0 {
+		block.AppendNewline()
+		block.AppendBlock(characteristics)
+	}
+
+	if moods != nil {
+		block.AppendNewline()
+		if err := e.body(block.AppendNewBlock("moods", nil).Body(), reflect.ValueOf(moods.hcl())); err != nil {
+			return fmt.Errorf("pet `%s`: %w", name, err)
+		}
+	}
+
+	if fields.validations != nil {
+		for _, v := range *fields.validations {
+			block.AppendNewline()
+			if err := e.body(block.AppendNewBlock("validation", nil).Body(), reflect.ValueOf(v)); err != nil {
+				return fmt.Errorf("pet `%s`: %w", name, err)
+			}
+		}
+	}
+
+	if conditions != nil {
+		for _, phase := range []struct {
+			blockType  string
+			conditions []*ConditionHCL
+		}{
+			{phasePrecondition, conditions.pre},
+			{phasePostcondition, conditions.post},
+		} {
+			for _, c := range phase.conditions {
+				block.AppendNewline()
+				if err := e.body(block.AppendNewBlock(phase.blockType, nil).Body(), reflect.ValueOf(c)); err != nil {
+					return fmt.Errorf("pet `%s`: %w", name, err)
+				}
+			}
+		}
+	}
+
+	for _, f := range feedings {
+		block.AppendNewline()
+		if err := e.body(block.AppendNewBlock("feeding", nil).Body(), reflect.ValueOf(f.hcl())); err != nil {
+			return fmt.Errorf("pet `%s`: %w", name, err)
+		}
+	}
+
+	for _, v := range petVetVisits(p) {
+		block.AppendNewline()
+		if err := e.body(block.AppendNewBlock("vet_visit", nil).Body(), reflect.ValueOf(v.hcl())); err != nil {
+			return fmt.Errorf("pet `%s`: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// sensitive writes the sensitive characteristics of the pet with fields,
+// already written into body, with the sensitive function, so they are read
+// back sensitive, or as "(sensitive)" if the encoder redacts them.
+func (e *configEncoder) sensitive(body *hclwrite.Body, fields petFields) {
+	if fields.sensitive == nil {
+		return
+	}
+	for name := range *fields.sensitive {
+		attr := body.GetAttribute(name)
+		if attr == nil {
+			continue
+		}
+		if e.redact {
+			body.SetAttributeValue(name, cty.StringVal(sensitiveText))
+			continue
+		}
+		tokens := hclwrite.Tokens{
+			{Type: hclsyntax.TokenIdent, Bytes: []byte("sensitive")},
+			{Type: hclsyntax.TokenOParen, Bytes: []byte("(")},
+		}
+		tokens = attr.Expr().BuildTokens(tokens)
+		body.SetAttributeRaw(name, append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCParen, Bytes: []byte(")")}))
+	}
+}
+
+// body writes the hcl tagged fields of the struct v into body. Attributes
+// with zero values are left out, as are labels, which are written with the
+// block. Measurements are rounded, as converting them from other units
+// leaves digits that were never written.
+func (e *configEncoder) body(body *hclwrite.Body, v reflect.Value) error {
+	v = reflect.Indirect(v)
+	for i := 0; i < v.NumField(); i++ {
+		tag := strings.Split(v.Type().Field(i).Tag.Get("hcl"), ",")
+		field := v.Field(i)
+		if tag[0] == "" || field.IsZero() || len(tag) > 1 && tag[1] == "label" {
+			continue
+		}
+
+		if len(tag) > 1 && tag[1] == "block" {
+			blocks := []reflect.Value{field}
+			if field.Kind() == reflect.Slice {
+				blocks = blocks[:0]
+				for j := 0; j < field.Len(); j++ {
+					blocks = append(blocks, field.Index(j))
+				}
+			}
+			for _, b := range blocks {
+				if err := e.body(body.AppendNewBlock(tag[0], nil).Body(), b); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if expr, ok := field.Interface().(hcl.Expression); ok {
+			// Optional attributes that were left out are decoded as
+			// expressions with nothing in their range.
+			if rng := expr.Range(); rng.Start.Byte == rng.End.Byte {
+				continue
+			}
+			tokens, err := e.expression(expr)
+			if err != nil {
+				return fmt.Errorf("attribute `%s`: %w", tag[0], err)
+			}
+			body.SetAttributeRaw(tag[0], tokens)
+			continue
+		}
+
+		if field.Type() == durationType {
+			body.SetAttributeValue(tag[0], durationValue(time.Duration(field.Int())))
+			continue
+		}
+
+		value := reflect.Indirect(field).Interface()
+		if f, ok := value.(float64); ok && measuredAttrs[tag[0]] != nil {
+			value = roundMeasurement(f)
+		}
+		ty, err := gocty.ImpliedType(value)
+		if err != nil {
+			return fmt.Errorf("attribute `%s`: %w", tag[0], err)
+		}
+		val, err := gocty.ToCtyValue(value, ty)
+		if err != nil {
+			return fmt.Errorf("attribute `%s`: %w", tag[0], err)
+		}
+		body.SetAttributeValue(tag[0], val)
+	}
+	return nil
+}
+
+// expression returns the tokens of expr, read back from the file it was
+// parsed from.
+func (e *configEncoder) expression(expr hcl.Expression) (hclwrite.Tokens, error) {
+	rng := expr.Range()
+	src, ok := e.sources[rng.Filename]
+	if !ok {
+		var err error
+		src, err = ioutil.ReadFile(rng.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading expression source: %w", err)
+		}
+		e.sources[rng.Filename] = src
+	}
+	if rng.End.Byte > len(src) {
+		return nil, fmt.Errorf("expression source `%s` has changed", rng.Filename)
+	}
+
+	file, diags := hclwrite.ParseConfig(append([]byte("expr = "), rng.SliceBytes(src)...), rng.Filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing expression source: %w", diags)
+	}
+	return file.Body().GetAttribute("expr").Expr().BuildTokens(nil), nil
+}
@@ -0,0 +1,245 @@
+package petsounds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestWriteConfig(t *testing.T) {
+	moods, err := NewMoodMachine(&MoodsHCL{Initial: "sleepy"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	pets := []Pet{
+		&Cat{Name: "Ink", Sound: "meow", Sounds: []string{"meow", "mrrp"}, Age: intPtr(7), Moods: moods, Tags: []string{"indoor"}},
+		&Dog{Name: "Swinney", Breed: "Dachshund", Vaccinated: boolPtr(false), Disabled: true},
+	}
+
+	out := &bytes.Buffer{}
+	if assert.Nil(t, WriteConfig(out, pets)) {
+		assert.Equal(t, `pet "Ink" {
+  type = "cat"
+  tags = ["indoor"]
+
+  characteristics {
+    sound  = "meow"
+    sounds = ["meow", "mrrp"]
+    age    = 7
+  }
+
+  moods {
+    initial = "sleepy"
+  }
+}
+
+pet "Swinney" {
+  type    = "dog"
+  enabled = false
+
+  characteristics {
+    breed      = "Dachshund"
+    vaccinated = false
+  }
+}
+`, out.String())
+	}
+}
+
+func TestWriteConfigCustomPet(t *testing.T) {
+	pets := []Pet{
+		&CustomPet{Name: "Hammy", petType: "hamster", Tags: []string{"small"}},
+		&CustomPet{Name: "Nibbles", petType: "runner", Disabled: true, characteristics: map[string]cty.Value{
+			"laps":  cty.NumberIntVal(3),
+			"wheel": cty.StringVal("red"),
+		}},
+	}
+
+	out := &bytes.Buffer{}
+	if assert.Nil(t, WriteConfig(out, pets)) {
+		assert.Equal(t, `pet "Hammy" {
+  type = "hamster"
+  tags = ["small"]
+}
+
+pet "Nibbles" {
+  type    = "runner"
+  enabled = false
+
+  characteristics {
+    laps  = 3
+    wheel = "red"
+  }
+}
+`, out.String())
+	}
+}
+
+func TestWriteConfigUnknownPet(t *testing.T) {
+	err := WriteConfig(&bytes.Buffer{}, []Pet{&echoPet{name: "Ink"}})
+	assert.Error(t, err)
+}
+
+// TestWriteConfigRoundTrip checks that writing pets loaded from testdata
+// and loading them again gives the same pets, which are written the same way
+// again.
+func TestWriteConfigRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for _, filename := range []string{
+		"testdata/basic.hcl",
+		"testdata/sounds.hcl",
+		"testdata/vitals.hcl",
+		"testdata/moods.hcl",
+		"testdata/conditions.hcl",
+		"testdata/feeding.hcl",
+		"testdata/vet.hcl",
+		"testdata/household.hcl",
+		"testdata/tags.hcl",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			want, err := ReadConfig(filename)
+			if !assert.Nil(t, err, "error while parsing input") {
+				return
+			}
+			out := &bytes.Buffer{}
+			if !assert.Nil(t, WriteConfig(out, want)) {
+				return
+			}
+			written := filepath.Join(dir, filepath.Base(filename))
+			if !assert.Nil(t, ioutil.WriteFile(written, out.Bytes(), 0644)) {
+				return
+			}
+
+			got, err := ReadConfig(written)
+			if assert.Nil(t, err, "error while parsing written config") && assert.Len(t, got, len(want)) {
+				for i := range want {
+					assert.True(t, comparablePet(t, want[i]).RawEquals(comparablePet(t, got[i])))
+				}
+				again := &bytes.Buffer{}
+				if assert.Nil(t, WriteConfig(again, got)) {
+					assert.Equal(t, out.String(), again.String())
+				}
+			}
+		})
+	}
+}
+
+// comparablePet returns the name, type and characteristics of p, leaving out
+// the state that differs between two loads of the same configuration.
+func comparablePet(t *testing.T, p Pet) cty.Value {
+	self, err := selfValue(p)
+	assert.Nil(t, err)
+	return self
+}
+
+func TestWriteConfigResolved(t *testing.T) {
+	t.Parallel()
+
+	src := `variable "breed" {
+  default = "Corgi"
+}
+
+defaults {
+  cat {
+    age = 2
+  }
+}
+
+pet "Ink" {
+  type = "cat"
+  characteristics {
+    sound = "${env.RESOLVE_SOUND}s ${random("lazily")}"
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+  characteristics {
+    breed = var.breed
+    sound = "copies ${pet.Ink.sound}"
+  }
+}
+`
+	config, err := DecodeConfig([]byte(src), "pets.hcl", LoadOptions{Env: map[string]string{"RESOLVE_SOUND": "meow"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, WriteConfig(out, config.Pets)) {
+		return
+	}
+	assert.Equal(t, `pet "Ink" {
+  type = "cat"
+
+  characteristics {
+    sound = "meows lazily"
+    age   = 2
+  }
+}
+
+pet "Swinney" {
+  type = "dog"
+
+  characteristics {
+    breed = "Corgi"
+    sound = "copies meows lazily"
+  }
+}
+`, out.String())
+}
+
+// TestWriteResolvedConfig checks that a whole configuration written with
+// WriteResolvedConfig loads again, and is written the same way again.
+func TestWriteResolvedConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pet-sounds-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	config, err := LoadConfig("testdata/resolve.hcl")
+	if !assert.Nil(t, err, "error while parsing input") {
+		return
+	}
+	out := &bytes.Buffer{}
+	if !assert.Nil(t, WriteResolvedConfig(out, config)) {
+		return
+	}
+	for _, want := range []string{
+		`packs                = ["farm"]`,
+		`pet_type "hamster" {`,
+		`owner "Russell" {`,
+		`tts {`,
+		`state {`,
+		`notify {`,
+		`    weight = 4.08233133`,
+		`  validation {`,
+		`interaction {`,
+	} {
+		assert.Contains(t, out.String(), want)
+	}
+
+	written := filepath.Join(dir, "resolve.hcl")
+	if !assert.Nil(t, ioutil.WriteFile(written, out.Bytes(), 0644)) {
+		return
+	}
+	got, err := LoadConfig(written)
+	if assert.Nil(t, err, "error while parsing written config") {
+		assert.Len(t, got.Pets, len(config.Pets))
+		assert.Equal(t, config.Interactions, got.Interactions)
+		again := &bytes.Buffer{}
+		if assert.Nil(t, WriteResolvedConfig(again, got)) {
+			assert.Equal(t, out.String(), again.String())
+		}
+	}
+}
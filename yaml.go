@@ -0,0 +1,88 @@
+package petsounds
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlToHCL translates a YAML pet configuration into native syntax HCL, so
+// that it can be loaded like any other configuration. The YAML document has
+// the same shape as a JSON document given to ConvertToHCL, for example:
+//   pet:
+//     - name: Ink
+//       type: cat
+//       characteristics:
+//         sound: ${env.CAT_SOUND}
+// As in HCL JSON, strings are templates and can interpolate expressions.
+func yamlToHCL(src []byte, filename string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, fmt.Errorf("error in yamlToHCL parsing `%s`: %w", filename, err)
+	}
+	if len(doc.Content) == 0 {
+		return []byte{}, nil
+	}
+
+	value, err := yamlValue(doc.Content[0])
+	if err != nil {
+		return nil, fmt.Errorf("error in yamlToHCL: %w", err)
+	}
+	obj, ok := value.(jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("error in yamlToHCL: `%s` is not a YAML mapping", filename)
+	}
+	out, err := documentHCL(obj, filename)
+	if err != nil {
+		return nil, fmt.Errorf("error in yamlToHCL: %w", err)
+	}
+	return out, nil
+}
+
+// yamlValue converts a YAML node into the values parseOrderedJSON returns.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		obj := jsonObject{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, jsonMember{key: node.Content[i].Value, value: value})
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		all := []interface{}{}
+		for _, n := range node.Content {
+			value, err := yamlValue(n)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, value)
+		}
+		return all, nil
+	}
+
+	switch node.Tag {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		err := node.Decode(&b)
+		return b, err
+	case "!!int":
+		var i int64
+		err := node.Decode(&i)
+		return json.Number(strconv.FormatInt(i, 10)), err
+	case "!!float":
+		var f float64
+		err := node.Decode(&f)
+		return json.Number(strconv.FormatFloat(f, 'f', -1, 64)), err
+	}
+	return node.Value, nil
+}
@@ -0,0 +1,92 @@
+package petsounds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYAMLToHCL(t *testing.T) {
+
+	tcs := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "values",
+			src: `pet:
+  Ink:
+    type: cat
+    characteristics: &ink
+      sounds: [meow, "mrrp"]
+      age: 4
+      weight: 5.5
+      vaccinated: true
+      voice: ~
+      breed: "${random(\"a\", \"b\")}"
+  Shadow:
+    type: cat
+    characteristics: *ink
+`,
+			want: `pet "Ink" {
+  type = "cat"
+
+  characteristics {
+    sounds     = ["meow", "mrrp"]
+    age        = 4
+    weight     = 5.5
+    vaccinated = true
+    voice      = null
+    breed      = random("a", "b")
+  }
+}
+
+pet "Shadow" {
+  type = "cat"
+
+  characteristics {
+    sounds     = ["meow", "mrrp"]
+    age        = 4
+    weight     = 5.5
+    vaccinated = true
+    voice      = null
+    breed      = random("a", "b")
+  }
+}
+`,
+		},
+		{
+			name: "empty",
+			src:  "",
+			want: "",
+		},
+		{
+			name:    "not a mapping",
+			src:     "- Ink\n- Swinney\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			src:     "pet: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yamlToHCL([]byte(tc.src), "test.yaml")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.want, string(got))
+			}
+		})
+	}
+}